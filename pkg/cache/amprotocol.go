@@ -19,9 +19,12 @@
 package cache
 
 import (
+	"time"
+
 	v1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
 )
 
 type AddApplicationRequest struct {
@@ -42,6 +45,9 @@ type ApplicationMetadata struct {
 	OwnerReferences            []metav1.OwnerReference
 	SchedulingPolicyParameters *SchedulingPolicyParameters
 	CreationTime               int64
+	AppGroup                   string // application group the application is gang scheduled with
+	AppGroupSize               int    // number of applications in the group, 0 if not known
+	Partition                  string // core partition the application is scheduled in
 }
 
 type TaskGroup struct {
@@ -54,6 +60,35 @@ type TaskGroup struct {
 	Tolerations               []v1.Toleration
 	Affinity                  *v1.Affinity
 	TopologySpreadConstraints []v1.TopologySpreadConstraint
+	// optional overrides of the application placeholder timeout and gang scheduling style for this group
+	PlaceholderTimeoutInSeconds int64  `json:",omitempty"`
+	GangSchedulingStyle         string `json:",omitempty"`
+	// optional number or percentage of MinMember that is enough to start the group after the placeholder timeout
+	MinReady *intstr.IntOrString `json:",omitempty"`
+	// optional node label key: all members of the group are placed on nodes with the same value for the label
+	TopologyKey string `json:",omitempty"`
+	// optional priority and runtime class of the placeholders, they should match the real pods of the group
+	PriorityClassName string `json:",omitempty"`
+	RuntimeClassName  string `json:",omitempty"`
+	// pod overhead of the runtime class, resolved when the application is added
+	Overhead v1.ResourceList `json:"-"`
+}
+
+// podResource returns the resources one member of the task group uses on a node, including the pod overhead
+func (tg TaskGroup) podResource() map[string]resource.Quantity {
+	if len(tg.Overhead) == 0 {
+		return tg.MinResource
+	}
+	podResource := make(map[string]resource.Quantity, len(tg.MinResource)+len(tg.Overhead))
+	for name, value := range tg.MinResource {
+		podResource[name] = value
+	}
+	for name, value := range tg.Overhead {
+		total := podResource[string(name)]
+		total.Add(value)
+		podResource[string(name)] = total
+	}
+	return podResource
 }
 
 type TaskMetadata struct {
@@ -67,6 +102,7 @@ type TaskMetadata struct {
 type SchedulingPolicyParameters struct {
 	placeholderTimeout  int64
 	gangSchedulingStyle string
+	maxRuntime          time.Duration
 }
 
 func NewSchedulingPolicyParameters(placeholderTimeout int64, gangSchedulingStyle string) *SchedulingPolicyParameters {
@@ -81,3 +117,8 @@ func (spp *SchedulingPolicyParameters) GetPlaceholderTimeout() int64 {
 func (spp *SchedulingPolicyParameters) GetGangSchedulingStyle() string {
 	return spp.gangSchedulingStyle
 }
+
+// GetMaxRuntime returns the maximum runtime requested on the pod, 0 if not set
+func (spp *SchedulingPolicyParameters) GetMaxRuntime() time.Duration {
+	return spp.maxRuntime
+}
@@ -0,0 +1,153 @@
+/*
+ Licensed to the Apache Software Foundation (ASF) under one
+ or more contributor license agreements.  See the NOTICE file
+ distributed with this work for additional information
+ regarding copyright ownership.  The ASF licenses this file
+ to you under the Apache License, Version 2.0 (the
+ "License"); you may not use this file except in compliance
+ with the License.  You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package cache
+
+import (
+	"fmt"
+
+	"go.uber.org/zap"
+
+	"github.com/apache/yunikorn-k8shim/pkg/common/constants"
+	"github.com/apache/yunikorn-k8shim/pkg/dispatcher"
+	"github.com/apache/yunikorn-k8shim/pkg/locking"
+	"github.com/apache/yunikorn-k8shim/pkg/log"
+)
+
+// ApplicationGroup gang schedules several applications together. Each member reserves its own placeholders,
+// members with a complete reservation are held in Reserving until every member is complete and are then
+// released to Running at once. A timeout of one member is applied to all members.
+// The group only dispatches events for its members, it never takes the lock of a member.
+type ApplicationGroup struct {
+	name     string
+	size     int             // expected number of members, 0 means the members that have joined
+	members  map[string]bool // member application ID to reservation complete
+	released bool            // members have been released to Running or the group timed out
+	locking.Mutex
+}
+
+func newApplicationGroup(name string, size int) *ApplicationGroup {
+	return &ApplicationGroup{
+		name:    name,
+		size:    size,
+		members: make(map[string]bool),
+	}
+}
+
+func (g *ApplicationGroup) join(appID string, size int) {
+	g.Lock()
+	defer g.Unlock()
+	if _, ok := g.members[appID]; !ok {
+		g.members[appID] = false
+	}
+	// members could be created with different sizes: wait for the largest group
+	g.size = max(g.size, size)
+}
+
+// leave removes the member, it returns true if the group has no members left
+func (g *ApplicationGroup) leave(appID string) bool {
+	g.Lock()
+	defer g.Unlock()
+	delete(g.members, appID)
+	return len(g.members) == 0
+}
+
+// memberReady records that the reservation of the member is complete. It returns true if the members have been
+// released to Running, false if the member has to wait for the other members.
+func (g *ApplicationGroup) memberReady(appID string) bool {
+	g.Lock()
+	defer g.Unlock()
+	if g.released {
+		return true
+	}
+	g.members[appID] = true
+	if len(g.members) < g.size {
+		log.Log(log.ShimCacheApplication).Info("application group waiting for members to be created",
+			zap.String("appGroup", g.name),
+			zap.Int("members", len(g.members)),
+			zap.Int("size", g.size))
+		return false
+	}
+	for _, ready := range g.members {
+		if !ready {
+			return false
+		}
+	}
+	log.Log(log.ShimCacheApplication).Info("application group reservation complete, releasing all members",
+		zap.String("appGroup", g.name),
+		zap.Int("members", len(g.members)))
+	g.released = true
+	for memberID := range g.members {
+		dispatcher.Dispatch(NewRunApplicationEvent(memberID))
+	}
+	return true
+}
+
+// memberTimedOut applies the gang style of a member that timed out to all other members: a Hard timeout fails all
+// members, a Soft timeout runs all members without waiting for the rest of the group. Placeholders of the other
+// members that are not replaced are released by the core placeholder timeout.
+func (g *ApplicationGroup) memberTimedOut(appID string, hard bool) {
+	g.Lock()
+	defer g.Unlock()
+	if g.released {
+		return
+	}
+	g.released = true
+	log.Log(log.ShimCacheApplication).Info("application group member timed out, applying the timeout to all members",
+		zap.String("appGroup", g.name),
+		zap.String("appID", appID),
+		zap.Bool("hard", hard))
+	for memberID := range g.members {
+		if memberID == appID {
+			continue
+		}
+		if hard {
+			dispatcher.Dispatch(NewFailApplicationEvent(memberID,
+				fmt.Sprintf("%s: application group %s member %s timed out", constants.ApplicationInsufficientResourcesFailure, g.name, appID)))
+		} else {
+			dispatcher.Dispatch(NewRunApplicationEvent(memberID))
+		}
+	}
+}
+
+// joinAppGroup adds the application to its application group, creating the group when the first member joins.
+// The context lock must be held.
+func (ctx *Context) joinAppGroup(app *Application, name string, size int) {
+	if name == "" {
+		return
+	}
+	group, ok := ctx.appGroups[name]
+	if !ok {
+		group = newApplicationGroup(name, size)
+		ctx.appGroups[name] = group
+	}
+	group.join(app.applicationID, size)
+	app.appGroup = group
+}
+
+// leaveAppGroup removes the application from its application group, the group is removed with its last member.
+// The context lock must be held.
+func (ctx *Context) leaveAppGroup(app *Application) {
+	group := app.getAppGroup()
+	if group == nil {
+		return
+	}
+	if group.leave(app.applicationID) {
+		delete(ctx.appGroups, group.name)
+	}
+}
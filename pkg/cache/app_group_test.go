@@ -0,0 +1,201 @@
+/*
+ Licensed to the Apache Software Foundation (ASF) under one
+ or more contributor license agreements.  See the NOTICE file
+ distributed with this work for additional information
+ regarding copyright ownership.  The ASF licenses this file
+ to you under the Apache License, Version 2.0 (the
+ "License"); you may not use this file except in compliance
+ with the License.  You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package cache
+
+import (
+	"testing"
+	"time"
+
+	"gotest.tools/v3/assert"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	apis "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/apache/yunikorn-k8shim/pkg/common/constants"
+	"github.com/apache/yunikorn-k8shim/pkg/dispatcher"
+)
+
+func newAppGroupTestContext(t *testing.T) *Context {
+	context, apiProvider := initContextAndAPIProviderForTest()
+	dispatcher.RegisterEventHandler("TestAppHandler", dispatcher.EventTypeApp, context.ApplicationEventHandler())
+	dispatcher.Start()
+	t.Cleanup(func() {
+		dispatcher.UnregisterAllEventHandlers()
+		dispatcher.Stop()
+	})
+	apiProvider.MockDeleteFn(func(_ *v1.Pod) error {
+		return nil
+	})
+	NewPlaceholderManager(apiProvider.GetAPIs())
+	return context
+}
+
+func addAppGroupMember(context *Context, id, group string, size int) *Application {
+	app := context.AddApplication(&AddApplicationRequest{
+		Metadata: ApplicationMetadata{
+			ApplicationID: id,
+			QueueName:     "root.a",
+			User:          "testuser",
+			TaskGroups: []TaskGroup{
+				{Name: "tg", MinMember: 1, MinResource: map[string]resource.Quantity{"cpu": resource.MustParse("1")}},
+			},
+			AppGroup:     group,
+			AppGroupSize: size,
+		},
+	})
+	app.sm.SetState(ApplicationStates().Reserving)
+	return app
+}
+
+func TestAppGroupMembership(t *testing.T) {
+	context, _ := initContextAndAPIProviderForTest()
+	app1 := addAppGroupMember(context, "app-1", "group", 0)
+	app2 := addAppGroupMember(context, "app-2", "group", 3)
+	app3 := addAppGroupMember(context, "app-3", "", 0)
+	assert.Assert(t, app3.getAppGroup() == nil, "application without group annotation should not join a group")
+	group := app1.getAppGroup()
+	assert.Assert(t, group != nil)
+	assert.Equal(t, group, app2.getAppGroup())
+	assert.Equal(t, len(group.members), 2)
+	assert.Equal(t, group.size, 3, "largest size of the members expected")
+
+	context.RemoveApplication("app-1")
+	assert.Equal(t, len(group.members), 1)
+	assert.Equal(t, len(context.appGroups), 1)
+	context.RemoveApplication("app-2")
+	assert.Equal(t, len(context.appGroups), 0, "group should be removed with the last member")
+}
+
+func TestAppGroupReservationComplete(t *testing.T) {
+	context := newAppGroupTestContext(t)
+	app1 := addAppGroupMember(context, "app-1", "group", 0)
+	app2 := addAppGroupMember(context, "app-2", "group", 0)
+	addTaskGroupPlaceholder(context, app1, "ph-1", "tg", TaskStates().Bound, time.Now())
+	addTaskGroupPlaceholder(context, app2, "ph-2", "tg", TaskStates().Bound, time.Now())
+
+	app1.lock.Lock()
+	app1.checkReservationComplete()
+	app1.lock.Unlock()
+	time.Sleep(100 * time.Millisecond)
+	assert.Equal(t, app1.GetApplicationState(), ApplicationStates().Reserving, "member should wait for the group")
+
+	app2.lock.Lock()
+	app2.checkReservationComplete()
+	app2.lock.Unlock()
+	assertAppState(t, app1, ApplicationStates().Running, 3*time.Second)
+	assertAppState(t, app2, ApplicationStates().Running, 3*time.Second)
+}
+
+func TestAppGroupWaitForSize(t *testing.T) {
+	context := newAppGroupTestContext(t)
+	app1 := addAppGroupMember(context, "app-1", "group", 2)
+	addTaskGroupPlaceholder(context, app1, "ph-1", "tg", TaskStates().Bound, time.Now())
+
+	app1.lock.Lock()
+	app1.checkReservationComplete()
+	app1.lock.Unlock()
+	time.Sleep(100 * time.Millisecond)
+	assert.Equal(t, app1.GetApplicationState(), ApplicationStates().Reserving, "member should wait for the missing member")
+
+	app2 := addAppGroupMember(context, "app-2", "group", 2)
+	addTaskGroupPlaceholder(context, app2, "ph-2", "tg", TaskStates().Bound, time.Now())
+	app2.lock.Lock()
+	app2.checkReservationComplete()
+	app2.lock.Unlock()
+	assertAppState(t, app1, ApplicationStates().Running, 3*time.Second)
+	assertAppState(t, app2, ApplicationStates().Running, 3*time.Second)
+}
+
+func TestAppGroupTimeout(t *testing.T) {
+	t.Run("hard", func(t *testing.T) {
+		context := newAppGroupTestContext(t)
+		app1 := addAppGroupMember(context, "app-1", "group", 0)
+		app2 := addAppGroupMember(context, "app-2", "group", 0)
+		dispatcher.Dispatch(NewFailApplicationEvent(app1.applicationID, constants.ApplicationInsufficientResourcesFailure))
+		assertAppState(t, app1, ApplicationStates().Failing, 3*time.Second)
+		assertAppState(t, app2, ApplicationStates().Failing, 3*time.Second)
+	})
+	t.Run("soft", func(t *testing.T) {
+		context := newAppGroupTestContext(t)
+		app1 := addAppGroupMember(context, "app-1", "group", 0)
+		app2 := addAppGroupMember(context, "app-2", "group", 0)
+		dispatcher.Dispatch(NewResumingApplicationEvent(app1.applicationID))
+		assertAppState(t, app1, ApplicationStates().Resuming, 3*time.Second)
+		assertAppState(t, app2, ApplicationStates().Running, 3*time.Second)
+		// the timeout is applied once: a later ready member does not change the outcome
+		assert.Assert(t, app1.getAppGroup().memberReady(app1.applicationID))
+	})
+}
+
+func TestAppGroupMemberWithoutTaskGroupsTimeout(t *testing.T) {
+	addMember := func(context *Context, style string) *Application {
+		app := context.AddApplication(&AddApplicationRequest{
+			Metadata: ApplicationMetadata{
+				ApplicationID:              "app-no-tg",
+				QueueName:                  "root.a",
+				User:                       "testuser",
+				AppGroup:                   "group",
+				SchedulingPolicyParameters: NewSchedulingPolicyParameters(60, style),
+			},
+		})
+		app.sm.SetState(ApplicationStates().Reserving)
+		app.lock.Lock()
+		app.startAppGroupTimer()
+		assert.Assert(t, app.appGroupTimer != nil, "member without task groups should wait with a timeout")
+		app.lock.Unlock()
+		return app
+	}
+	t.Run("hard", func(t *testing.T) {
+		context := newAppGroupTestContext(t)
+		app1 := addMember(context, constants.SchedulingPolicyStyleParamValues["Hard"])
+		app2 := addAppGroupMember(context, "app-2", "group", 0)
+		app1.handleAppGroupTimeout()
+		assertAppState(t, app1, ApplicationStates().Failed, 3*time.Second)
+		assertAppState(t, app2, ApplicationStates().Failing, 3*time.Second)
+	})
+	t.Run("soft", func(t *testing.T) {
+		context := newAppGroupTestContext(t)
+		app1 := addMember(context, constants.SchedulingPolicyStyleParamValues["Soft"])
+		app2 := addAppGroupMember(context, "app-2", "group", 0)
+		app1.handleAppGroupTimeout()
+		assertAppState(t, app1, ApplicationStates().Running, 3*time.Second)
+		assertAppState(t, app2, ApplicationStates().Running, 3*time.Second)
+		assert.Assert(t, app1.appGroupTimer == nil, "timer should be cleared")
+	})
+}
+
+func TestGetAppGroup(t *testing.T) {
+	pod := &v1.Pod{ObjectMeta: apis.ObjectMeta{Name: "pod", Namespace: "default"}}
+	name, size := getAppGroup(pod)
+	assert.Equal(t, name, "")
+	assert.Equal(t, size, 0)
+
+	pod.Annotations = map[string]string{constants.AnnotationAppGroup: "group"}
+	name, size = getAppGroup(pod)
+	assert.Equal(t, name, "group")
+	assert.Equal(t, size, 0)
+
+	pod.Annotations[constants.AnnotationAppGroupSize] = "3"
+	_, size = getAppGroup(pod)
+	assert.Equal(t, size, 3)
+
+	pod.Annotations[constants.AnnotationAppGroupSize] = "-1"
+	_, size = getAppGroup(pod)
+	assert.Equal(t, size, 0, "invalid size should be ignored")
+}
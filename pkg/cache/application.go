@@ -22,16 +22,20 @@ import (
 	"context"
 	"fmt"
 	"sort"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/looplab/fsm"
 	"go.uber.org/zap"
 	v1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 
+	schedulercache "github.com/apache/yunikorn-k8shim/pkg/cache/external"
 	"github.com/apache/yunikorn-k8shim/pkg/common"
 	"github.com/apache/yunikorn-k8shim/pkg/common/constants"
 	"github.com/apache/yunikorn-k8shim/pkg/common/events"
+	"github.com/apache/yunikorn-k8shim/pkg/common/utils"
 	"github.com/apache/yunikorn-k8shim/pkg/conf"
 	"github.com/apache/yunikorn-k8shim/pkg/dispatcher"
 	"github.com/apache/yunikorn-k8shim/pkg/locking"
@@ -58,11 +62,41 @@ type Application struct {
 	placeholderAsk             *si.Resource // total placeholder request for the app (all task groups)
 	placeholderTimeoutInSec    int64
 	schedulingStyle            string
-	originatingTask            *Task // Original Pod which creates the requests
+	originatingTask            *Task         // Original Pod which creates the requests
+	maxRuntime                 time.Duration // maximum runtime after the app reaches Running, 0 means no limit
+	maxRuntimeFixed            bool          // max runtime set on the pod or namespace, the queue default does not apply
+	resourceAccountingPolicy   string        // container resources counted for the pods of the app
+	resourceAccountingFixed    bool          // policy set on the namespace, the queue policy does not apply
+	deadlineTimer              *time.Timer
+	runningSince               time.Time // time the application reached Running, the max runtime is measured from it
+	deadline                   deadlineState
+	removedFromCore            bool                           // app removed from the core by the shim, the shim completes the failure
+	suspendedFromState         string                         // state to return to when a suspended app is resumed
+	deferredEvents             []events.ApplicationEvent      // events received while suspended, replayed on resume
+	previousQueue              string                         // queue before a move, set until the core accepts the app in the new queue
+	queueMoveReverting         bool                           // the in-flight move returns the app to its previous queue
+	placeholderCreateAttempts  int                            // failed gang creation attempts using the Retry policy
+	placeholderCreateLock      locking.Mutex                  // serialises the placeholder creation of the application
+	taskGroupTimers            map[string]*time.Timer         // placeholder timeouts of task groups with their own policy
+	timedOutTaskGroups         map[string]bool                // task groups no longer waited for after their placeholder timeout
+	schedulerCache             *schedulercache.SchedulerCache // node view used to pick the topology domain of task groups
+	taskGroupDomains           map[string]string              // topology domain each topology aware task group is pinned to
+	triedDomains               map[string]map[string]bool     // topology domains that timed out for each task group
+	appGroup                   *ApplicationGroup              // applications gang scheduled together with this application
+	appGroupTimer              *time.Timer                    // wait for the application group of a member without task groups
 }
 
 const transitionErr = "no transition"
 
+// deadlineState tracks an application that exceeded its max runtime
+type deadlineState int
+
+const (
+	deadlineNotExceeded deadlineState = iota
+	deadlineExceeded                  // timer fired, the application is being failed by the shim
+	deadlineTerminated                // pods are terminated and the application is removed from the core
+)
+
 func (app *Application) String() string {
 	return fmt.Sprintf("applicationID: %s, queue: %s, partition: %s,"+
 		" totalNumOfTasks: %d, currentState: %s",
@@ -168,7 +202,7 @@ func (app *Application) setTaskGroups(taskGroups []TaskGroup) {
 	defer app.lock.Unlock()
 	app.taskGroups = taskGroups
 	for _, taskGroup := range app.taskGroups {
-		app.placeholderAsk = common.Add(app.placeholderAsk, common.GetTGResource(taskGroup.MinResource, int64(taskGroup.MinMember)))
+		app.placeholderAsk = common.Add(app.placeholderAsk, common.GetTGResource(taskGroup.podResource(), int64(taskGroup.MinMember)))
 	}
 }
 
@@ -184,6 +218,12 @@ func (app *Application) getTaskGroups() []TaskGroup {
 	return app.taskGroups
 }
 
+func (app *Application) getAppGroup() *ApplicationGroup {
+	app.lock.RLock()
+	defer app.lock.RUnlock()
+	return app.appGroup
+}
+
 func (app *Application) setPlaceholderOwnerReferences(ref []metav1.OwnerReference) {
 	app.lock.Lock()
 	defer app.lock.Unlock()
@@ -202,6 +242,29 @@ func (app *Application) setSchedulingStyle(schedulingStyle string) {
 	app.schedulingStyle = schedulingStyle
 }
 
+func (app *Application) setMaxRuntime(maxRuntime time.Duration) {
+	app.lock.Lock()
+	defer app.lock.Unlock()
+	app.maxRuntime = maxRuntime
+}
+
+func (app *Application) getMaxRuntime() time.Duration {
+	app.lock.RLock()
+	defer app.lock.RUnlock()
+	return app.maxRuntime
+}
+
+// getPodResource returns the resource of a pod of the application using the resource accounting policy of the app
+func (app *Application) getPodResource(pod *v1.Pod) *si.Resource {
+	if app == nil {
+		return common.GetPodResource(pod)
+	}
+	app.lock.RLock()
+	policy := app.resourceAccountingPolicy
+	app.lock.RUnlock()
+	return common.GetPodResourceForPolicy(pod, policy)
+}
+
 func (app *Application) setOriginatingTask(task *Task) {
 	app.lock.Lock()
 	defer app.lock.Unlock()
@@ -344,6 +407,7 @@ func (app *Application) TriggerAppSubmission() error {
 // do nothing more than just triggering the state transition.
 // return true if the app needs scheduling or false if not
 func (app *Application) Schedule() bool {
+	app.updateSuspension()
 	switch app.GetApplicationState() {
 	case ApplicationStates().New:
 		ev := NewSubmitApplicationEvent(app.GetApplicationID())
@@ -387,6 +451,76 @@ func (app *Application) Schedule() bool {
 	return true
 }
 
+// updateSuspension suspends or resumes the application based on the suspended annotation of its pods.
+func (app *Application) updateSuspension() {
+	var ev events.ApplicationEvent
+	suspendRequested := app.isSuspendRequested()
+	if suspendRequested && app.canHandle(NewSimpleApplicationEvent(app.applicationID, SuspendApplication)) {
+		ev = NewSimpleApplicationEvent(app.applicationID, SuspendApplication)
+	} else if !suspendRequested && app.GetApplicationState() == ApplicationStates().Suspended {
+		ev = NewResumeApplicationEvent(app.applicationID, app.getSuspendedFromState())
+	}
+	if ev == nil {
+		return
+	}
+	if err := app.handle(ev); err != nil {
+		log.Log(log.ShimCacheApplication).Warn("failed to update application suspension",
+			zap.String("appID", app.applicationID),
+			zap.String("event", ev.GetEvent()),
+			zap.Error(err))
+	}
+}
+
+// isSuspendRequested returns true if any of the non-terminated pods of the application
+// carries the suspended annotation set to true.
+func (app *Application) isSuspendRequested() bool {
+	app.lock.RLock()
+	defer app.lock.RUnlock()
+	for _, task := range app.taskMap {
+		if task.isTerminated() {
+			continue
+		}
+		if value := utils.GetPodAnnotationValue(task.GetTaskPod(), constants.AnnotationSuspended); value != "" {
+			if suspended, err := strconv.ParseBool(value); err == nil && suspended {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// deferEvent records an event from the core that cannot be handled while the application is suspended, the events
+// are replayed when the application is resumed. The placeholder timeout keeps running in the core while the
+// application is suspended: the release of timed out placeholders is deferred, the placeholder pods are only removed
+// after the resume. Returns true if the event was deferred.
+func (app *Application) deferEvent(ev events.ApplicationEvent) bool {
+	app.lock.Lock()
+	defer app.lock.Unlock()
+	if app.sm.Current() != ApplicationStates().Suspended {
+		return false
+	}
+	switch ev.GetEvent() {
+	case RunApplication.String(), CompleteApplication.String(), AppTaskCompleted.String(), ResumingApplication.String():
+	case ReleaseAppAllocation.String():
+		if re, ok := ev.(ReleaseAppAllocationEvent); !ok || re.terminationType != si.TerminationType_name[int32(si.TerminationType_TIMEOUT)] {
+			return false
+		}
+	default:
+		return false
+	}
+	log.Log(log.ShimCacheApplication).Info("application is suspended, event deferred until resume",
+		zap.String("appID", app.applicationID),
+		zap.String("event", ev.GetEvent()))
+	app.deferredEvents = append(app.deferredEvents, ev)
+	return true
+}
+
+func (app *Application) getSuspendedFromState() string {
+	app.lock.RLock()
+	defer app.lock.RUnlock()
+	return app.suspendedFromState
+}
+
 func (app *Application) scheduleTasks(taskScheduleCondition func(t *Task) bool) {
 	for _, task := range app.GetNewTasks() {
 		if taskScheduleCondition(task) {
@@ -420,25 +554,7 @@ func (app *Application) handleSubmitApplicationEvent() error {
 		zap.Stringer("app", app),
 		zap.String("clusterID", conf.GetSchedulerConf().ClusterID))
 
-	if err := app.schedulerAPI.UpdateApplication(
-		&si.ApplicationRequest{
-			New: []*si.AddApplicationRequest{
-				{
-					ApplicationID: app.applicationID,
-					QueueName:     app.queue,
-					PartitionName: app.partition,
-					Ugi: &si.UserGroupInformation{
-						User:   app.user,
-						Groups: app.groups,
-					},
-					Tags:                         app.tags,
-					PlaceholderAsk:               app.placeholderAsk,
-					ExecutionTimeoutMilliSeconds: app.placeholderTimeoutInSec * 1000,
-					GangSchedulingStyle:          app.schedulingStyle,
-				},
-			},
-			RmID: conf.GetSchedulerConf().ClusterID,
-		}); err != nil {
+	if err := app.schedulerAPI.UpdateApplication(app.newApplicationRequest()); err != nil {
 		// submission failed
 		log.Log(log.ShimCacheApplication).Warn("failed to submit new app request to core", zap.Error(err))
 		dispatcher.Dispatch(NewFailApplicationEvent(app.applicationID, err.Error()))
@@ -447,9 +563,31 @@ func (app *Application) handleSubmitApplicationEvent() error {
 	return nil
 }
 
+// newApplicationRequest creates the request to add the application to the core, must be called with the app lock held.
+func (app *Application) newApplicationRequest() *si.ApplicationRequest {
+	return &si.ApplicationRequest{
+		New: []*si.AddApplicationRequest{
+			{
+				ApplicationID: app.applicationID,
+				QueueName:     app.queue,
+				PartitionName: app.partition,
+				Ugi: &si.UserGroupInformation{
+					User:   app.user,
+					Groups: app.groups,
+				},
+				Tags:                         app.tags,
+				PlaceholderAsk:               app.placeholderAsk,
+				ExecutionTimeoutMilliSeconds: app.getCorePlaceholderTimeout() * 1000,
+				GangSchedulingStyle:          app.schedulingStyle,
+			},
+		},
+		RmID: conf.GetSchedulerConf().ClusterID,
+	}
+}
+
 func (app *Application) skipReservationStage() bool {
-	// no task groups defined, skip reservation
-	if len(app.taskGroups) == 0 {
+	// no task groups defined, skip reservation unless the application has to wait for its application group
+	if len(app.taskGroups) == 0 && app.appGroup == nil {
 		log.Log(log.ShimCacheApplication).Debug("Skip reservation stage: no task groups defined",
 			zap.String("appID", app.applicationID))
 		return true
@@ -497,12 +635,190 @@ func (app *Application) postAppAccepted() {
 // onResuming triggered when entering the resuming state which is triggered by the time out of the gang placeholders
 // if SOFT gang scheduling is configured.
 func (app *Application) onResuming() {
+	if app.appGroup != nil {
+		app.appGroup.memberTimedOut(app.applicationID, false)
+	}
 	if app.originatingTask != nil {
 		events.GetRecorder().Eventf(app.originatingTask.GetTaskPod().DeepCopy(), nil, v1.EventTypeWarning, "GangScheduling",
 			"GangSchedulingFailed", "Application %s resuming as non-gang application (SOFT)", app.applicationID)
 	}
 }
 
+// onRunning triggered when entering the running state.
+// The time the application first reaches Running is recorded, or restored from the running-since annotation of the
+// application pods after a scheduler restart. If a max runtime is set for the application the deadline timer is
+// started, measured from that time, and the time is persisted on an application pod.
+func (app *Application) onRunning() {
+	if app.runningSince.IsZero() {
+		app.runningSince = app.getAnnotatedRunningSince()
+	}
+	if app.maxRuntime <= 0 || app.deadlineTimer != nil {
+		return
+	}
+	app.persistRunningSince()
+	deadline := app.runningSince.Add(app.maxRuntime)
+	remaining := time.Until(deadline)
+	log.Log(log.ShimCacheApplication).Info("application deadline set",
+		zap.String("appID", app.applicationID),
+		zap.Duration("maxRuntime", app.maxRuntime),
+		zap.Time("deadline", deadline))
+	maxRuntime := app.maxRuntime
+	var timer *time.Timer
+	timer = time.AfterFunc(remaining, func() {
+		app.lock.Lock()
+		// the timer was stopped or replaced while this call was waiting for the lock
+		if app.deadlineTimer != timer {
+			app.lock.Unlock()
+			return
+		}
+		app.deadlineTimer = nil
+		app.deadline = deadlineExceeded
+		app.lock.Unlock()
+		log.Log(log.ShimCacheApplication).Info("application exceeded max runtime",
+			zap.String("appID", app.applicationID),
+			zap.Duration("maxRuntime", maxRuntime))
+		dispatcher.Dispatch(NewFailApplicationEvent(app.applicationID,
+			fmt.Sprintf("%s: application exceeded its maximum runtime of %s", constants.ApplicationDeadlineExceededFailure, maxRuntime)))
+	})
+	app.deadlineTimer = timer
+}
+
+// getAnnotatedRunningSince returns the earliest running-since annotation of the application pods, or the current time
+// if none of the pods has a valid annotation. Must be called with the app lock held.
+func (app *Application) getAnnotatedRunningSince() time.Time {
+	var runningSince time.Time
+	for _, task := range app.taskMap {
+		if task.placeholder {
+			continue
+		}
+		value, ok := task.GetTaskPod().Annotations[constants.AnnotationRunningSince]
+		if !ok {
+			continue
+		}
+		since, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			log.Log(log.ShimCacheApplication).Warn("ignoring invalid running-since annotation",
+				zap.String("appID", app.applicationID),
+				zap.String("taskID", task.taskID),
+				zap.String("value", value))
+			continue
+		}
+		if runningSince.IsZero() || since.Before(runningSince) {
+			runningSince = since
+		}
+	}
+	if runningSince.IsZero() {
+		return time.Now()
+	}
+	return runningSince
+}
+
+// persistRunningSince sets the running-since annotation on the originating pod, or on the first application pod if
+// the originating pod is not known. Nothing is updated if an application pod already has the annotation.
+// The pod update is asynchronous, must be called with the app lock held.
+func (app *Application) persistRunningSince() {
+	var target *Task
+	for _, task := range app.taskMap {
+		if task.placeholder {
+			continue
+		}
+		if _, ok := task.GetTaskPod().Annotations[constants.AnnotationRunningSince]; ok {
+			return
+		}
+		if target == nil || task == app.originatingTask {
+			target = task
+		}
+	}
+	if target == nil || target.context == nil {
+		return
+	}
+	value := app.runningSince.Format(time.RFC3339)
+	pod := target.GetTaskPod().DeepCopy()
+	go func() {
+		if _, err := target.UpdateTaskPod(pod, func(pod *v1.Pod) {
+			if pod.Annotations == nil {
+				pod.Annotations = make(map[string]string)
+			}
+			pod.Annotations[constants.AnnotationRunningSince] = value
+		}); err != nil {
+			log.Log(log.ShimCacheApplication).Warn("failed to persist the running-since annotation",
+				zap.String("appID", app.applicationID),
+				zap.String("taskID", target.taskID),
+				zap.Error(err))
+		}
+	}()
+}
+
+// stopDeadlineTimer stops the max runtime timer if it is running, must be called with the app lock held.
+func (app *Application) stopDeadlineTimer() {
+	if app.deadlineTimer != nil {
+		app.deadlineTimer.Stop()
+		app.deadlineTimer = nil
+	}
+}
+
+// stopTimers stops the timers of an application that is removed from the context.
+func (app *Application) stopTimers() {
+	app.lock.Lock()
+	defer app.lock.Unlock()
+	app.stopDeadlineTimer()
+	app.stopTaskGroupTimers()
+}
+
+// setPlacedQueue is called when the core accepts the application, with the queue known to the shim, and when the core
+// reports the queue it placed the application in. The max runtime defaults to the value of the queue the application
+// is placed in, unless it is set on the pod or the namespace.
+func (app *Application) setPlacedQueue(queue string) {
+	app.lock.Lock()
+	defer app.lock.Unlock()
+	// the queue of an in-flight move is finalised when the core accepts or rejects the application
+	if app.previousQueue == "" {
+		app.queue = queue
+	}
+	if app.maxRuntimeFixed {
+		return
+	}
+	maxRuntime := conf.GetSchedulerConf().GetQueueMaxRuntime(queue)
+	if maxRuntime == app.maxRuntime {
+		return
+	}
+	log.Log(log.ShimCacheApplication).Info("application max runtime set from queue",
+		zap.String("appID", app.applicationID),
+		zap.String("queue", queue),
+		zap.Duration("maxRuntime", maxRuntime))
+	app.maxRuntime = maxRuntime
+	app.stopDeadlineTimer()
+	if app.sm.Current() == ApplicationStates().Running {
+		app.onRunning()
+	}
+}
+
+// setPlacedQueueResourceAccounting is called when the core accepts or places the application in a queue. The resource
+// accounting policy is set to the policy of the queue, unless it is set on the namespace, and the resources of the
+// tasks are recalculated. The tasks known to the core are updated.
+func (app *Application) setPlacedQueueResourceAccounting(queue string) {
+	app.lock.Lock()
+	policy := conf.GetSchedulerConf().GetResourceAccountingPolicy(queue)
+	if app.resourceAccountingFixed || policy == app.resourceAccountingPolicy {
+		app.lock.Unlock()
+		return
+	}
+	log.Log(log.ShimCacheApplication).Info("application resource accounting policy set from queue",
+		zap.String("appID", app.applicationID),
+		zap.String("queue", queue),
+		zap.String("policy", policy))
+	app.resourceAccountingPolicy = policy
+	tasks := make([]*Task, 0, len(app.taskMap))
+	for _, task := range app.taskMap {
+		tasks = append(tasks, task)
+	}
+	app.lock.Unlock()
+	// the task lock is taken before the application lock when the resource is calculated
+	for _, task := range tasks {
+		task.SetTaskPod(task.GetTaskPod())
+	}
+}
+
 // onReserving triggered when entering the reserving state.
 // During normal operation this creates all the placeholders. During recovery this call could cause the application
 // in the shim and core to progress to the next state.
@@ -518,21 +834,111 @@ func (app *Application) onReserving() {
 			"CreatingPlaceholders", "Application %s creating placeholders", app.applicationID)
 	}
 
-	go func() {
-		// while doing reserving
-		if err := getPlaceholderManager().createAppPlaceholders(app); err != nil {
-			// creating placeholder failed
-			// put the app into recycling queue and turn the app to running state
-			getPlaceholderManager().cleanUp(app)
-			ev := NewRunApplicationEvent(app.applicationID)
-			dispatcher.Dispatch(ev)
-			// failed at least one placeholder creation progress as a normal application
-			if app.originatingTask != nil {
-				events.GetRecorder().Eventf(app.originatingTask.GetTaskPod().DeepCopy(), nil, v1.EventTypeWarning, "GangScheduling",
-					"PlaceholderCreateFailed", "Application %s fall back to normal scheduling", app.applicationID)
+	app.selectTaskGroupDomains()
+	app.startTaskGroupTimers()
+	go app.createPlaceholders()
+	// a member of an application group without task groups has nothing to reserve
+	if len(app.taskGroups) == 0 {
+		app.startAppGroupTimer()
+		app.checkReservationComplete()
+	}
+}
+
+// startAppGroupTimer limits the time a member without task groups waits for its application group. The member has
+// no placeholders, the core placeholder timeout never fires for it.
+func (app *Application) startAppGroupTimer() {
+	if app.appGroup == nil || app.appGroupTimer != nil {
+		return
+	}
+	timeout := defaultPlaceholderTimeout
+	if app.placeholderTimeoutInSec > 0 {
+		timeout = time.Duration(app.placeholderTimeoutInSec) * time.Second
+	}
+	app.appGroupTimer = time.AfterFunc(timeout, app.handleAppGroupTimeout)
+}
+
+// handleAppGroupTimeout is called when a member without task groups is still waiting for its application group.
+// Using the Hard style the member fails, which fails the group. Using the Soft style all members start.
+func (app *Application) handleAppGroupTimeout() {
+	app.lock.Lock()
+	defer app.lock.Unlock()
+	if app.sm.Current() != ApplicationStates().Reserving || app.appGroupTimer == nil {
+		return
+	}
+	app.appGroupTimer = nil
+	log.Log(log.ShimCacheApplication).Info("application group wait timed out",
+		zap.String("appID", app.applicationID),
+		zap.String("appGroup", app.appGroup.name),
+		zap.String("style", app.schedulingStyle))
+	if app.originatingTask != nil {
+		events.GetRecorder().Eventf(app.originatingTask.GetTaskPod().DeepCopy(), nil, v1.EventTypeWarning, "GangScheduling",
+			"ApplicationGroupTimeOut", "Application %s timed out waiting for application group %s (%s style)",
+			app.applicationID, app.appGroup.name, app.schedulingStyle)
+	}
+	if app.schedulingStyle == constants.SchedulingPolicyStyleParamValues["Hard"] {
+		// the core does not know about the application group, it would keep the failed app
+		app.removeFromCore()
+		dispatcher.Dispatch(NewFailApplicationEvent(app.applicationID,
+			fmt.Sprintf("%s: application group %s timed out", constants.ApplicationInsufficientResourcesFailure, app.appGroup.name)))
+		return
+	}
+	app.appGroup.memberTimedOut(app.applicationID, false)
+	dispatcher.Dispatch(NewRunApplicationEvent(app.applicationID))
+}
+
+// createPlaceholders creates the placeholders for the application while it is reserving.
+// If the gang could not be created the configured failure policy decides what happens to the application.
+func (app *Application) createPlaceholders() {
+	err := getPlaceholderManager().createAppPlaceholders(app)
+	if err == nil {
+		return
+	}
+	// creating placeholder failed: the placeholders created in this attempt have been rolled back,
+	// remove any placeholder that was created before
+	getPlaceholderManager().cleanUp(app)
+	createConf := conf.GetSchedulerConf().PlaceholderCreate
+	switch createConf.FailurePolicy {
+	case conf.PlaceholderCreatePolicyFail:
+		app.failPlaceholderCreate(err)
+	case conf.PlaceholderCreatePolicyRetry:
+		app.lock.Lock()
+		app.placeholderCreateAttempts++
+		attempts := app.placeholderCreateAttempts
+		app.lock.Unlock()
+		if attempts >= createConf.MaxAttempts {
+			app.failPlaceholderCreate(err)
+			return
+		}
+		if app.originatingTask != nil {
+			events.GetRecorder().Eventf(app.originatingTask.GetTaskPod().DeepCopy(), nil, v1.EventTypeWarning, "GangScheduling",
+				constants.PlaceholderCreateFailure, "Application %s failed to create placeholders (attempt %d of %d), retrying in %s: %v",
+				app.applicationID, attempts, createConf.MaxAttempts, createConf.RetryInterval, err)
+		}
+		time.AfterFunc(createConf.RetryInterval, func() {
+			// the application could have progressed or failed in the meantime
+			if app.GetApplicationState() == ApplicationStates().Reserving {
+				app.createPlaceholders()
 			}
+		})
+	default:
+		// put the app into recycling queue and turn the app to running state
+		ev := NewRunApplicationEvent(app.applicationID)
+		dispatcher.Dispatch(ev)
+		// failed at least one placeholder creation progress as a normal application
+		if app.originatingTask != nil {
+			events.GetRecorder().Eventf(app.originatingTask.GetTaskPod().DeepCopy(), nil, v1.EventTypeWarning, "GangScheduling",
+				constants.PlaceholderCreateFailure, "Application %s fall back to normal scheduling", app.applicationID)
 		}
-	}()
+	}
+}
+
+// failPlaceholderCreate fails the application after the gang could not be created
+func (app *Application) failPlaceholderCreate(err error) {
+	if app.originatingTask != nil {
+		events.GetRecorder().Eventf(app.originatingTask.GetTaskPod().DeepCopy(), nil, v1.EventTypeWarning, "GangScheduling",
+			constants.PlaceholderCreateFailure, "Application %s failed: placeholders could not be created: %v", app.applicationID, err)
+	}
+	dispatcher.Dispatch(NewFailApplicationEvent(app.applicationID, fmt.Sprintf("%s: %v", constants.PlaceholderCreateFailure, err)))
 }
 
 // onReservationStateChange is called when there is an add or a release of a placeholder
@@ -542,9 +948,17 @@ func (app *Application) onReservationStateChange() {
 		events.GetRecorder().Eventf(app.originatingTask.GetTaskPod().DeepCopy(), nil, v1.EventTypeNormal, "GangScheduling",
 			"PlaceholderAllocated", "Application %s placeholder has been allocated.", app.applicationID)
 	}
+	app.checkReservationComplete()
+}
+
+// checkReservationComplete moves the application to running when all task groups have their placeholders allocated.
+// Task groups that timed out using the Soft style, or that reached minReady, are no longer waited for.
+func (app *Application) checkReservationComplete() {
 	desireCounts := make(map[string]int32, len(app.taskGroups))
 	for _, tg := range app.taskGroups {
-		desireCounts[tg.Name] = tg.MinMember
+		if !app.timedOutTaskGroups[tg.Name] {
+			desireCounts[tg.Name] = tg.MinMember
+		}
 	}
 
 	for _, t := range app.getTasks(TaskStates().Bound) {
@@ -552,7 +966,7 @@ func (app *Application) onReservationStateChange() {
 			taskGroupName := t.GetTaskGroupName()
 			if _, ok := desireCounts[taskGroupName]; ok {
 				desireCounts[taskGroupName]--
-			} else {
+			} else if !app.timedOutTaskGroups[taskGroupName] {
 				log.Log(log.ShimCacheApplication).Debug("placeholder taskGroupName set on pod is unknown for application",
 					zap.String("application", app.applicationID),
 					zap.String("podName", t.GetTaskPod().Name),
@@ -568,6 +982,15 @@ func (app *Application) onReservationStateChange() {
 		}
 	}
 
+	// members of an application group are released to running together by the group
+	if app.appGroup != nil {
+		if !app.appGroup.memberReady(app.applicationID) && app.originatingTask != nil {
+			events.GetRecorder().Eventf(app.originatingTask.GetTaskPod().DeepCopy(), nil, v1.EventTypeNormal, "GangScheduling",
+				"WaitingForApplicationGroup", "Application %s all placeholders are allocated, waiting for application group %s.",
+				app.applicationID, app.appGroup.name)
+		}
+		return
+	}
 	if app.originatingTask != nil {
 		// Now that all placeholders has been allocated, send a final conclusion message
 		events.GetRecorder().Eventf(app.originatingTask.GetTaskPod().DeepCopy(), nil, v1.EventTypeNormal, "GangScheduling",
@@ -576,6 +999,232 @@ func (app *Application) onReservationStateChange() {
 	dispatcher.Dispatch(NewRunApplicationEvent(app.applicationID))
 }
 
+// hasTaskGroupPolicies returns true if any task group overrides the placeholder timeout or gang scheduling style
+// of the application. Without overrides the placeholder timeout is handled by the core only.
+func (app *Application) hasTaskGroupPolicies() bool {
+	for _, tg := range app.taskGroups {
+		if tg.PlaceholderTimeoutInSeconds > 0 || tg.GangSchedulingStyle != "" || tg.MinReady != nil || tg.TopologyKey != "" {
+			return true
+		}
+	}
+	return false
+}
+
+// getTaskGroupTimeout returns the placeholder timeout of the task group: the task group setting,
+// the application setting or the core default in that order.
+func (app *Application) getTaskGroupTimeout(tg TaskGroup) time.Duration {
+	if tg.PlaceholderTimeoutInSeconds > 0 {
+		return time.Duration(tg.PlaceholderTimeoutInSeconds) * time.Second
+	}
+	if app.placeholderTimeoutInSec > 0 {
+		return time.Duration(app.placeholderTimeoutInSec) * time.Second
+	}
+	return defaultPlaceholderTimeout
+}
+
+// getTaskGroupStyle returns the gang scheduling style of the task group, falling back to the application style
+func (app *Application) getTaskGroupStyle(tg TaskGroup) string {
+	if tg.GangSchedulingStyle != "" {
+		return tg.GangSchedulingStyle
+	}
+	return app.schedulingStyle
+}
+
+// getCorePlaceholderTimeout returns the placeholder timeout in seconds that is passed to the core.
+// When task groups have their own policy the shim enforces the timeout of each group, the core timeout
+// is raised past the longest group timeout so it only acts as a backstop: placeholders kept for a partial
+// start must be replaced before the core releases them. A topology aware group can use its timeout once per domain.
+func (app *Application) getCorePlaceholderTimeout() int64 {
+	if !app.hasTaskGroupPolicies() {
+		return app.placeholderTimeoutInSec
+	}
+	timeout := app.placeholderTimeoutInSec
+	for _, tg := range app.taskGroups {
+		groupTimeout := app.getTaskGroupTimeout(tg)
+		if tg.TopologyKey != "" {
+			groupTimeout *= maxTopologyDomainAttempts
+		}
+		if groupTimeout := int64((groupTimeout + corePlaceholderTimeoutMargin) / time.Second); groupTimeout > timeout {
+			timeout = groupTimeout
+		}
+	}
+	return timeout
+}
+
+// startTaskGroupTimers starts the placeholder timeout of each task group if any group has its own policy.
+// The timeout is measured from the creation of the oldest placeholder of the group, which means the timers
+// are rebuilt from the placeholders during recovery.
+func (app *Application) startTaskGroupTimers() {
+	if !app.hasTaskGroupPolicies() || app.taskGroupTimers != nil {
+		return
+	}
+	start := make(map[string]time.Time, len(app.taskGroups))
+	for _, ph := range app.getPlaceHolderTasks() {
+		created := ph.GetTaskPod().CreationTimestamp.Time
+		name := ph.GetTaskGroupName()
+		if first, ok := start[name]; !created.IsZero() && (!ok || created.Before(first)) {
+			start[name] = created
+		}
+	}
+	now := time.Now()
+	app.taskGroupTimers = make(map[string]*time.Timer, len(app.taskGroups))
+	for _, tg := range app.taskGroups {
+		if app.timedOutTaskGroups[tg.Name] {
+			continue
+		}
+		first, ok := start[tg.Name]
+		if !ok {
+			first = now
+		}
+		remaining := max(app.getTaskGroupTimeout(tg)-now.Sub(first), 0)
+		name := tg.Name
+		app.taskGroupTimers[name] = time.AfterFunc(remaining, func() {
+			app.handleTaskGroupTimeout(name)
+		})
+	}
+}
+
+// startPartialTaskGroup starts a task group that reached minReady at its placeholder timeout.
+// The bound placeholders are kept for the real pods, the unbound placeholders are released.
+func (app *Application) startPartialTaskGroup(taskGroup *TaskGroup, bound int32, placeholders []*Task) {
+	log.Log(log.ShimCacheApplication).Info("task group placeholders timed out, starting with the placeholders allocated",
+		zap.String("appID", app.applicationID),
+		zap.String("taskGroup", taskGroup.Name),
+		zap.Int32("bound", bound),
+		zap.Int32("minMember", taskGroup.MinMember))
+	if app.originatingTask != nil {
+		events.GetRecorder().Eventf(app.originatingTask.GetTaskPod().DeepCopy(), nil, v1.EventTypeNormal, "GangScheduling",
+			"TaskGroupPartiallyReady", "Application %s task group %s placeholders timed out, starting with %d of %d allocated",
+			app.applicationID, taskGroup.Name, bound, taskGroup.MinMember)
+	}
+	if app.timedOutTaskGroups == nil {
+		app.timedOutTaskGroups = make(map[string]bool)
+	}
+	app.timedOutTaskGroups[taskGroup.Name] = true
+	unbound := make([]*Task, 0)
+	for _, ph := range placeholders {
+		if ph.GetTaskState() != TaskStates().Bound {
+			unbound = append(unbound, ph)
+		}
+	}
+	// deleting the pods is done outside the lock as each delete is an API call
+	go getPlaceholderManager().deletePlaceholders(unbound)
+	app.checkReservationComplete()
+}
+
+func (app *Application) stopTaskGroupTimers() {
+	for _, timer := range app.taskGroupTimers {
+		timer.Stop()
+	}
+	app.taskGroupTimers = nil
+	if app.appGroupTimer != nil {
+		app.appGroupTimer.Stop()
+		app.appGroupTimer = nil
+	}
+}
+
+// handleTaskGroupTimeout is called when the placeholder timeout of a task group expires while the application
+// is still reserving. Using the Hard style the application fails. Using the Soft style the placeholders of the
+// group are released and the application no longer waits for the group.
+func (app *Application) handleTaskGroupTimeout(taskGroupName string) {
+	app.lock.Lock()
+	defer app.lock.Unlock()
+	if app.sm.Current() != ApplicationStates().Reserving || app.taskGroupTimers == nil {
+		return
+	}
+	var taskGroup *TaskGroup
+	for i := range app.taskGroups {
+		if app.taskGroups[i].Name == taskGroupName {
+			taskGroup = &app.taskGroups[i]
+		}
+	}
+	if taskGroup == nil {
+		return
+	}
+	bound := int32(0)
+	placeholders := make([]*Task, 0)
+	for _, ph := range app.getPlaceHolderTasks() {
+		if ph.GetTaskGroupName() != taskGroupName || ph.isTerminated() {
+			continue
+		}
+		placeholders = append(placeholders, ph)
+		if ph.GetTaskState() == TaskStates().Bound {
+			bound++
+		}
+	}
+	if bound >= taskGroup.MinMember {
+		return
+	}
+	if minReady := getMinReady(*taskGroup); bound >= minReady {
+		app.startPartialTaskGroup(taskGroup, bound, placeholders)
+		return
+	}
+	if taskGroup.TopologyKey != "" && app.retryTaskGroupDomain(taskGroup, placeholders) {
+		return
+	}
+	style := app.getTaskGroupStyle(*taskGroup)
+	log.Log(log.ShimCacheApplication).Info("task group placeholders timed out",
+		zap.String("appID", app.applicationID),
+		zap.String("taskGroup", taskGroupName),
+		zap.String("style", style),
+		zap.Int32("bound", bound),
+		zap.Int32("minMember", taskGroup.MinMember))
+	if app.originatingTask != nil {
+		events.GetRecorder().Eventf(app.originatingTask.GetTaskPod().DeepCopy(), nil, v1.EventTypeWarning, "GangScheduling",
+			"TaskGroupTimeOut", "Application %s task group %s placeholders timed out with %d of %d allocated (%s style)",
+			app.applicationID, taskGroupName, bound, taskGroup.MinMember, style)
+	}
+	if style == constants.SchedulingPolicyStyleParamValues["Hard"] {
+		// the core does not know about task group timeouts, it would keep the asks and placeholders of the failed app
+		app.removeFromCore()
+		dispatcher.Dispatch(NewFailApplicationEvent(app.applicationID,
+			fmt.Sprintf("%s: task group %s placeholders timed out", constants.ApplicationInsufficientResourcesFailure, taskGroupName)))
+		return
+	}
+	if app.timedOutTaskGroups == nil {
+		app.timedOutTaskGroups = make(map[string]bool)
+	}
+	app.timedOutTaskGroups[taskGroupName] = true
+	// deleting the pods is done outside the lock as each delete is an API call
+	go getPlaceholderManager().deletePlaceholders(placeholders)
+	app.checkReservationComplete()
+}
+
+// handleSuspendApplicationEvent withdraws all outstanding asks from the core, tasks which are already allocated
+// are left untouched. The withdrawn tasks are moved back to New and are submitted again when the app is resumed.
+func (app *Application) handleSuspendApplicationEvent(fromState string) {
+	app.suspendedFromState = fromState
+	log.Log(log.ShimCacheApplication).Info("suspending application",
+		zap.String("appID", app.applicationID),
+		zap.String("fromState", fromState))
+	for _, task := range app.taskMap {
+		state := task.GetTaskState()
+		if state == TaskStates().Pending || state == TaskStates().Scheduling {
+			dispatcher.Dispatch(NewSimpleTaskEvent(app.applicationID, task.taskID, WithdrawTask))
+		}
+	}
+	if app.originatingTask != nil {
+		events.GetRecorder().Eventf(app.originatingTask.GetTaskPod().DeepCopy(), nil, v1.EventTypeNormal, "ApplicationSuspended",
+			"ApplicationSuspended", "Application %s is suspended, outstanding requests are withdrawn", app.applicationID)
+	}
+}
+
+// handleResumeApplicationEvent is called when a suspended app goes back to the state it was suspended from.
+func (app *Application) handleResumeApplicationEvent() {
+	log.Log(log.ShimCacheApplication).Info("resuming application",
+		zap.String("appID", app.applicationID),
+		zap.String("toState", app.suspendedFromState))
+	app.suspendedFromState = ""
+	for _, ev := range app.deferredEvents {
+		dispatcher.Dispatch(ev)
+	}
+	app.deferredEvents = nil
+	if app.originatingTask != nil {
+		events.GetRecorder().Eventf(app.originatingTask.GetTaskPod().DeepCopy(), nil, v1.EventTypeNormal, "ApplicationResumed",
+			"ApplicationResumed", "Application %s is resumed", app.applicationID)
+	}
+}
+
 func (app *Application) handleRejectApplicationEvent(reason string) {
 	log.Log(log.ShimCacheApplication).Info("app is rejected by scheduler", zap.String("appID", app.applicationID))
 	// for rejected apps, we directly move them to failed state
@@ -584,6 +1233,7 @@ func (app *Application) handleRejectApplicationEvent(reason string) {
 }
 
 func (app *Application) handleCompleteApplicationEvent() {
+	app.stopDeadlineTimer()
 	go func() {
 		getPlaceholderManager().cleanUp(app)
 	}()
@@ -606,17 +1256,37 @@ func failTaskPodWithReasonAndMsg(task *Task, reason string, msg string) {
 }
 
 func (app *Application) handleFailApplicationEvent(errMsg string) {
+	app.stopDeadlineTimer()
 	go func() {
 		getPlaceholderManager().cleanUp(app)
 	}()
 	log.Log(log.ShimCacheApplication).Info("failApplication reason", zap.String("applicationID", app.applicationID), zap.String("errMsg", errMsg))
+	switch app.deadline {
+	case deadlineExceeded:
+		app.terminateOnDeadline(errMsg)
+		return
+	case deadlineTerminated:
+		// pods were already terminated when the application started failing
+		return
+	}
+	if app.removedFromCore {
+		// the core no longer tracks the application and will not fail it: move to Failed from the shim
+		if app.sm.Current() == ApplicationStates().Failed {
+			return
+		}
+		dispatcher.Dispatch(NewFailApplicationEvent(app.applicationID, errMsg))
+	}
 	// unallocated task states include New, Pending and Scheduling
 	unalloc := app.getTasks(TaskStates().New)
 	unalloc = append(unalloc, app.getTasks(TaskStates().Pending)...)
 	unalloc = append(unalloc, app.getTasks(TaskStates().Scheduling)...)
 
 	timeout := strings.Contains(errMsg, constants.ApplicationInsufficientResourcesFailure)
+	if timeout && app.appGroup != nil {
+		app.appGroup.memberTimedOut(app.applicationID, true)
+	}
 	rejected := strings.Contains(errMsg, constants.ApplicationRejectedFailure)
+	placeholderFailed := strings.Contains(errMsg, constants.PlaceholderCreateFailure)
 	// publish pod level event to unallocated pods
 	for _, task := range unalloc {
 		// Only need to fail the non-placeholder pod(s)
@@ -625,13 +1295,66 @@ func (app *Application) handleFailApplicationEvent(errMsg string) {
 		} else if rejected {
 			errMsgArr := strings.Split(errMsg, ":")
 			failTaskPodWithReasonAndMsg(task, constants.ApplicationRejectedFailure, errMsgArr[1])
+		} else if placeholderFailed {
+			failTaskPodWithReasonAndMsg(task, constants.PlaceholderCreateFailure, "Gang scheduling placeholders could not be created")
 		}
 		events.GetRecorder().Eventf(task.GetTaskPod().DeepCopy(), nil, v1.EventTypeWarning, "ApplicationFailed", "ApplicationFailed",
 			"Application %s scheduling failed, reason: %s", app.applicationID, errMsg)
 	}
 }
 
-func (app *Application) handleReleaseAppAllocationEvent(taskID string, terminationType string) {
+// terminateOnDeadline fails the unallocated pods and deletes the allocated pods of an application that exceeded its
+// max runtime. Allocated pods are deleted using their own termination grace period.
+// The core does not know about the deadline: the application is removed from the core, which releases its asks and
+// allocations, and the shim moves the application to Failed itself.
+func (app *Application) terminateOnDeadline(errMsg string) {
+	app.deadline = deadlineTerminated
+	app.removeFromCore()
+	dispatcher.Dispatch(NewFailApplicationEvent(app.applicationID, errMsg))
+	if app.originatingTask != nil {
+		events.GetRecorder().Eventf(app.originatingTask.GetTaskPod().DeepCopy(), nil, v1.EventTypeWarning, constants.ApplicationDeadlineExceededFailure,
+			constants.ApplicationDeadlineExceededFailure, "Application %s exceeded its maximum runtime of %s, terminating pods", app.applicationID, app.maxRuntime)
+	}
+	toDelete := make([]*Task, 0)
+	for _, task := range app.taskMap {
+		if task.isTerminated() || task.placeholder {
+			continue
+		}
+		switch task.GetTaskState() {
+		case TaskStates().New, TaskStates().Pending, TaskStates().Scheduling:
+			failTaskPodWithReasonAndMsg(task, constants.ApplicationDeadlineExceededFailure, "Application exceeded its maximum runtime")
+		default:
+			toDelete = append(toDelete, task)
+		}
+		events.GetRecorder().Eventf(task.GetTaskPod().DeepCopy(), nil, v1.EventTypeWarning, "ApplicationFailed", constants.ApplicationDeadlineExceededFailure,
+			"Application %s scheduling failed, reason: %s", app.applicationID, errMsg)
+	}
+	// deleting the pods is done outside the state machine callback as each delete is an API call
+	go func() {
+		for _, task := range toDelete {
+			if err := task.DeleteTaskPod(); err != nil {
+				log.Log(log.ShimCacheApplication).Warn("failed to delete pod of application that exceeded its deadline",
+					zap.String("appID", app.applicationID),
+					zap.String("taskID", task.taskID),
+					zap.Error(err))
+			}
+		}
+	}()
+}
+
+// removeFromCore removes the application from the core, which releases all its asks and allocations. Used when the
+// shim fails an application on its own, the core only learns about failures it detects itself.
+func (app *Application) removeFromCore() {
+	app.removedFromCore = true
+	if err := app.schedulerAPI.UpdateApplication(
+		common.CreateUpdateRequestForRemoveApplication(app.applicationID, app.partition)); err != nil {
+		log.Log(log.ShimCacheApplication).Warn("failed to remove application from the core",
+			zap.String("appID", app.applicationID),
+			zap.Error(err))
+	}
+}
+
+func (app *Application) handleReleaseAppAllocationEvent(taskID string, terminationType string, message string) {
 	log.Log(log.ShimCacheApplication).Info("try to release pod from application",
 		zap.String("appID", app.applicationID),
 		zap.String("taskID", taskID),
@@ -639,6 +1362,16 @@ func (app *Application) handleReleaseAppAllocationEvent(taskID string, terminati
 
 	if task, ok := app.taskMap[taskID]; ok {
 		task.setTaskTerminationType(terminationType)
+		if terminationType == si.TerminationType_name[int32(si.TerminationType_PREEMPTED_BY_SCHEDULER)] {
+			// evicting the pod is done outside the state machine callback as the eviction might be retried until
+			// the preemption eviction timeout, one minute by default, before the pod is deleted
+			go func() {
+				if err := task.PreemptTaskPod(getPreemptorAllocationKey(message)); err != nil {
+					log.Log(log.ShimCacheApplication).Error("failed to release preempted allocation from application", zap.Error(err))
+				}
+			}()
+			return
+		}
 		err := task.DeleteTaskPod()
 		if err != nil {
 			log.Log(log.ShimCacheApplication).Error("failed to release allocation from application", zap.Error(err))
@@ -681,6 +1414,12 @@ func (app *Application) SetPlaceholderTimeout(timeout int64) {
 	app.placeholderTimeoutInSec = timeout
 }
 
+func (app *Application) getPlaceholderTimeout() time.Duration {
+	app.lock.RLock()
+	defer app.lock.RUnlock()
+	return time.Duration(app.placeholderTimeoutInSec) * time.Second
+}
+
 func (app *Application) removeCompletedTasks() {
 	app.lock.Lock()
 	defer app.lock.Unlock()
@@ -690,3 +1429,166 @@ func (app *Application) removeCompletedTasks() {
 		}
 	}
 }
+
+// getAllocatedResource returns the sum of the resources of all allocated and bound tasks, including placeholders.
+func (app *Application) getAllocatedResource() *si.Resource {
+	app.lock.RLock()
+	defer app.lock.RUnlock()
+	allocated := common.NewResourceBuilder().Build()
+	for _, task := range app.taskMap {
+		state := task.GetTaskState()
+		if state == TaskStates().Allocated || state == TaskStates().Bound {
+			allocated = common.Add(allocated, task.getTaskResource())
+		}
+	}
+	return allocated
+}
+
+// moveQueue moves the application to a different queue. The core does not support moving an application, so the
+// application is removed from the core and added back in the new queue. The asks and the allocations of the tasks
+// are registered again afterwards. The move is finalised when the core accepts the application, if the core rejects
+// it the application is added back to the previous queue.
+func (app *Application) moveQueue(targetQueue string) error {
+	app.lock.Lock()
+	defer app.lock.Unlock()
+	state := app.sm.Current()
+	if state != ApplicationStates().Accepted && state != ApplicationStates().Running && state != ApplicationStates().Suspended {
+		return fmt.Errorf("application %s cannot be moved in state %s", app.applicationID, state)
+	}
+	if app.previousQueue != "" {
+		return fmt.Errorf("application %s is already being moved from queue %s", app.applicationID, app.previousQueue)
+	}
+	for _, task := range app.taskMap {
+		if task.GetTaskState() == TaskStates().Allocated {
+			return fmt.Errorf("application %s has pods being bound, retry the move later", app.applicationID)
+		}
+	}
+
+	log.Log(log.ShimCacheApplication).Info("moving application to a different queue",
+		zap.String("appID", app.applicationID),
+		zap.String("fromQueue", app.queue),
+		zap.String("toQueue", targetQueue))
+	if err := app.schedulerAPI.UpdateApplication(&si.ApplicationRequest{
+		Remove: []*si.RemoveApplicationRequest{
+			{
+				ApplicationID: app.applicationID,
+				PartitionName: app.partition,
+			},
+		},
+		RmID: conf.GetSchedulerConf().ClusterID,
+	}); err != nil {
+		return fmt.Errorf("failed to remove application %s from queue %s: %w", app.applicationID, app.queue, err)
+	}
+	app.previousQueue = app.queue
+	app.queue = targetQueue
+	app.queueMoveReverting = false
+	app.resubmitToCore()
+	return nil
+}
+
+// isQueueMoving returns true if the application is removed from the core and added back in a different queue.
+func (app *Application) isQueueMoving() bool {
+	app.lock.RLock()
+	defer app.lock.RUnlock()
+	return app.previousQueue != ""
+}
+
+// getQueueMove returns the queues of an in-flight queue move and whether the move returns the application to its
+// previous queue. The from queue is empty if no move is in progress.
+func (app *Application) getQueueMove() (string, string, bool) {
+	app.lock.RLock()
+	defer app.lock.RUnlock()
+	return app.previousQueue, app.queue, app.queueMoveReverting
+}
+
+// confirmQueueMove is called when the core accepts the application, finalises an in-flight queue move.
+func (app *Application) confirmQueueMove() {
+	app.lock.Lock()
+	defer app.lock.Unlock()
+	if app.previousQueue == "" {
+		return
+	}
+	if app.queueMoveReverting {
+		log.Log(log.ShimCacheApplication).Info("application returned to its previous queue",
+			zap.String("appID", app.applicationID),
+			zap.String("queue", app.queue))
+		app.previousQueue = ""
+		app.queueMoveReverting = false
+		return
+	}
+	log.Log(log.ShimCacheApplication).Info("application moved to a different queue",
+		zap.String("appID", app.applicationID),
+		zap.String("fromQueue", app.previousQueue),
+		zap.String("toQueue", app.queue))
+	if app.originatingTask != nil {
+		events.GetRecorder().Eventf(app.originatingTask.GetTaskPod().DeepCopy(), nil, v1.EventTypeNormal, "QueueMoved",
+			"QueueMoved", "Application %s moved from queue %s to queue %s", app.applicationID, app.previousQueue, app.queue)
+	}
+	app.previousQueue = ""
+}
+
+// revertQueueMove is called when the core rejects the application. If a queue move is in-flight the application is
+// added back to the previous queue and true is returned, false is returned if no move was in progress.
+func (app *Application) revertQueueMove(reason string) bool {
+	app.lock.Lock()
+	defer app.lock.Unlock()
+	if app.previousQueue == "" {
+		return false
+	}
+	log.Log(log.ShimCacheApplication).Warn("queue move rejected by the core, reverting",
+		zap.String("appID", app.applicationID),
+		zap.String("fromQueue", app.previousQueue),
+		zap.String("toQueue", app.queue),
+		zap.String("reason", reason))
+	if app.originatingTask != nil {
+		events.GetRecorder().Eventf(app.originatingTask.GetTaskPod().DeepCopy(), nil, v1.EventTypeWarning, "QueueMoveRejected",
+			"QueueMoveRejected", "Application %s cannot be moved to queue %s: %s", app.applicationID, app.queue, reason)
+	}
+	app.queue = app.previousQueue
+	app.previousQueue = ""
+	app.queueMoveReverting = false
+	app.resubmitToCore()
+	return true
+}
+
+// abortQueueMove is called when the core accepted the application in the new queue but the queue is over its limits.
+// The application is moved back: it is removed from the core and added back to its previous queue. The bound pods
+// keep running while the move back is in-flight.
+func (app *Application) abortQueueMove(reason string) {
+	app.lock.Lock()
+	defer app.lock.Unlock()
+	if app.previousQueue == "" || app.queueMoveReverting {
+		return
+	}
+	log.Log(log.ShimCacheApplication).Warn("queue move exceeds the queue limits, moving back",
+		zap.String("appID", app.applicationID),
+		zap.String("fromQueue", app.previousQueue),
+		zap.String("toQueue", app.queue),
+		zap.String("reason", reason))
+	if app.originatingTask != nil {
+		events.GetRecorder().Eventf(app.originatingTask.GetTaskPod().DeepCopy(), nil, v1.EventTypeWarning, "QueueMoveRejected",
+			"QueueMoveRejected", "Application %s cannot be moved to queue %s: %s", app.applicationID, app.queue, reason)
+	}
+	if err := app.schedulerAPI.UpdateApplication(
+		common.CreateUpdateRequestForRemoveApplication(app.applicationID, app.partition)); err != nil {
+		// the application stays in the new queue
+		log.Log(log.ShimCacheApplication).Warn("failed to remove application from core", zap.Error(err))
+		app.previousQueue = ""
+		return
+	}
+	app.queue, app.previousQueue = app.previousQueue, app.queue
+	app.queueMoveReverting = true
+	app.resubmitToCore()
+}
+
+// resubmitToCore adds the application to the core and registers the asks and allocations of its tasks again.
+// Must be called with the app lock held.
+func (app *Application) resubmitToCore() {
+	if err := app.schedulerAPI.UpdateApplication(app.newApplicationRequest()); err != nil {
+		log.Log(log.ShimCacheApplication).Warn("failed to add application to core", zap.Error(err))
+		return
+	}
+	for _, task := range app.taskMap {
+		task.resubmitToCore()
+	}
+}
@@ -20,6 +20,7 @@ package cache
 
 import (
 	"context"
+	"fmt"
 	"sync"
 
 	"github.com/looplab/fsm"
@@ -51,10 +52,12 @@ const (
 	ReleaseAppAllocation
 	ResumingApplication
 	AppTaskCompleted
+	SuspendApplication
+	ResumeApplication
 )
 
 func (ae ApplicationEventType) String() string {
-	return [...]string{"SubmitApplication", "AcceptApplication", "TryReserve", "UpdateReservation", "RunApplication", "RejectApplication", "CompleteApplication", "FailApplication", "KillApplication", "KilledApplication", "ReleaseAppAllocation", "ResumingApplication", "AppTaskCompleted"}[ae]
+	return [...]string{"SubmitApplication", "AcceptApplication", "TryReserve", "UpdateReservation", "RunApplication", "RejectApplication", "CompleteApplication", "FailApplication", "KillApplication", "KilledApplication", "ReleaseAppAllocation", "ResumingApplication", "AppTaskCompleted", "SuspendApplication", "ResumeApplication"}[ae]
 }
 
 // ------------------------
@@ -267,14 +270,16 @@ type ReleaseAppAllocationEvent struct {
 	applicationID   string
 	allocationKey   string
 	terminationType string
+	message         string
 	event           ApplicationEventType
 }
 
-func NewReleaseAppAllocationEvent(appID string, allocTermination si.TerminationType, allocationKey string) ReleaseAppAllocationEvent {
+func NewReleaseAppAllocationEvent(appID string, allocTermination si.TerminationType, allocationKey, message string) ReleaseAppAllocationEvent {
 	return ReleaseAppAllocationEvent{
 		applicationID:   appID,
 		allocationKey:   allocationKey,
 		terminationType: si.TerminationType_name[int32(allocTermination)],
+		message:         message,
 		event:           ReleaseAppAllocation,
 	}
 }
@@ -284,9 +289,10 @@ func (re ReleaseAppAllocationEvent) GetApplicationID() string {
 }
 
 func (re ReleaseAppAllocationEvent) GetArgs() []interface{} {
-	args := make([]interface{}, 2)
+	args := make([]interface{}, 3)
 	args[0] = re.allocationKey
 	args[1] = re.terminationType
+	args[2] = re.message
 	return args
 }
 
@@ -321,6 +327,40 @@ func (re ResumingApplicationEvent) GetApplicationID() string {
 	return re.applicationID
 }
 
+// ------------------------
+// Resume suspended application
+// ------------------------
+type ResumeApplicationEvent struct {
+	applicationID string
+	state         string
+}
+
+// NewResumeApplicationEvent creates the event that moves a suspended application back to the state it was
+// suspended from.
+func NewResumeApplicationEvent(appID string, state string) ResumeApplicationEvent {
+	return ResumeApplicationEvent{
+		applicationID: appID,
+		state:         state,
+	}
+}
+
+func (re ResumeApplicationEvent) GetEvent() string {
+	return resumeEventName(re.state)
+}
+
+func (re ResumeApplicationEvent) GetArgs() []interface{} {
+	return nil
+}
+
+func (re ResumeApplicationEvent) GetApplicationID() string {
+	return re.applicationID
+}
+
+// resumeEventName returns the name of the transition from Suspended back to the given state
+func resumeEventName(state string) string {
+	return fmt.Sprintf("%sTo%s", ResumeApplication, state)
+}
+
 // ----------------------------------
 // Application states
 // ----------------------------------
@@ -339,6 +379,7 @@ type AStates struct {
 	Failing   string
 	Failed    string
 	Resuming  string
+	Suspended string
 }
 
 func ApplicationStates() *AStates {
@@ -356,6 +397,7 @@ func ApplicationStates() *AStates {
 			Failed:    "Failed",
 			Failing:   "Failing",
 			Resuming:  "Resuming",
+			Suspended: "Suspended",
 		}
 	})
 	return storeApplicationStates
@@ -415,6 +457,36 @@ func newAppState() *fsm.FSM { //nolint:funlen
 				Src:  []string{states.Resuming},
 				Dst:  states.Resuming,
 			},
+			{
+				Name: ReleaseAppAllocation.String(),
+				Src:  []string{states.Suspended},
+				Dst:  states.Suspended,
+			},
+			{
+				Name: SuspendApplication.String(),
+				Src:  []string{states.Accepted, states.Reserving, states.Running, states.Resuming},
+				Dst:  states.Suspended,
+			},
+			{
+				Name: resumeEventName(states.Accepted),
+				Src:  []string{states.Suspended},
+				Dst:  states.Accepted,
+			},
+			{
+				Name: resumeEventName(states.Reserving),
+				Src:  []string{states.Suspended},
+				Dst:  states.Reserving,
+			},
+			{
+				Name: resumeEventName(states.Running),
+				Src:  []string{states.Suspended},
+				Dst:  states.Running,
+			},
+			{
+				Name: resumeEventName(states.Resuming),
+				Src:  []string{states.Suspended},
+				Dst:  states.Resuming,
+			},
 			{
 				Name: CompleteApplication.String(),
 				Src:  []string{states.Running},
@@ -427,7 +499,7 @@ func newAppState() *fsm.FSM { //nolint:funlen
 			},
 			{
 				Name: FailApplication.String(),
-				Src:  []string{states.Submitted, states.Accepted, states.Running, states.Reserving},
+				Src:  []string{states.Submitted, states.Accepted, states.Running, states.Reserving, states.Suspended},
 				Dst:  states.Failing,
 			},
 			{
@@ -437,7 +509,7 @@ func newAppState() *fsm.FSM { //nolint:funlen
 			},
 			{
 				Name: KillApplication.String(),
-				Src:  []string{states.Accepted, states.Running, states.Reserving},
+				Src:  []string{states.Accepted, states.Running, states.Reserving, states.Suspended},
 				Dst:  states.Killing,
 			},
 			{
@@ -457,12 +529,42 @@ func newAppState() *fsm.FSM { //nolint:funlen
 			},
 			states.Reserving: func(_ context.Context, event *fsm.Event) {
 				app := event.Args[0].(*Application) //nolint:errcheck
+				// placeholders were already created before the app was suspended
+				if event.Src == states.Suspended {
+					app.startTaskGroupTimers()
+					app.onReservationStateChange()
+					return
+				}
 				app.onReserving()
 			},
+			leaveState(states.Reserving): func(_ context.Context, event *fsm.Event) {
+				app := event.Args[0].(*Application) //nolint:errcheck
+				app.stopTaskGroupTimers()
+			},
+			states.Running: func(_ context.Context, event *fsm.Event) {
+				app := event.Args[0].(*Application) //nolint:errcheck
+				app.onRunning()
+			},
 			states.Resuming: func(_ context.Context, event *fsm.Event) {
 				app := event.Args[0].(*Application) //nolint:errcheck
+				if event.Src == states.Suspended {
+					return
+				}
 				app.onResuming()
 			},
+			SuspendApplication.String(): func(_ context.Context, event *fsm.Event) {
+				app := event.Args[0].(*Application) //nolint:errcheck
+				app.handleSuspendApplicationEvent(event.Src)
+			},
+			leaveState(states.Suspended): func(_ context.Context, event *fsm.Event) {
+				app := event.Args[0].(*Application) //nolint:errcheck
+				if event.Event == resumeEventName(event.Dst) {
+					app.handleResumeApplicationEvent()
+					return
+				}
+				// failed or killed while suspended
+				app.deferredEvents = nil
+			},
 			SubmitApplication.String(): func(_ context.Context, event *fsm.Event) {
 				app := event.Args[0].(*Application) //nolint:errcheck
 				event.Err = app.handleSubmitApplicationEvent()
@@ -482,6 +584,10 @@ func newAppState() *fsm.FSM { //nolint:funlen
 				app := event.Args[0].(*Application) //nolint:errcheck
 				app.handleCompleteApplicationEvent()
 			},
+			KillApplication.String(): func(_ context.Context, event *fsm.Event) {
+				app := event.Args[0].(*Application) //nolint:errcheck
+				app.stopDeadlineTimer()
+			},
 			FailApplication.String(): func(_ context.Context, event *fsm.Event) {
 				app := event.Args[0].(*Application) //nolint:errcheck
 				eventArgs := make([]string, 1)
@@ -499,7 +605,7 @@ func newAppState() *fsm.FSM { //nolint:funlen
 			},
 			ReleaseAppAllocation.String(): func(_ context.Context, event *fsm.Event) {
 				app := event.Args[0].(*Application) //nolint:errcheck
-				eventArgs := make([]string, 2)
+				eventArgs := make([]string, 3)
 				generic := event.Args[1].([]interface{}) //nolint:errcheck
 				if err := events.GetEventArgsAsStrings(eventArgs, generic); err != nil {
 					log.Log(log.ShimFSM).Error("fail to parse event arg", zap.Error(err))
@@ -507,7 +613,8 @@ func newAppState() *fsm.FSM { //nolint:funlen
 				}
 				taskID := eventArgs[0]
 				terminationType := eventArgs[1]
-				app.handleReleaseAppAllocationEvent(taskID, terminationType)
+				message := eventArgs[2]
+				app.handleReleaseAppAllocationEvent(taskID, terminationType, message)
 			},
 			AppTaskCompleted.String(): func(_ context.Context, event *fsm.Event) {
 				app := event.Args[0].(*Application) //nolint:errcheck
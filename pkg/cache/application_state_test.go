@@ -683,7 +683,7 @@ func TestNewReleaseAppAllocationEvent(t *testing.T) {
 	}
 
 	for _, tt := range tests {
-		instance := NewReleaseAppAllocationEvent(tt.appID, tt.terminationType, tt.allocationKey)
+		instance := NewReleaseAppAllocationEvent(tt.appID, tt.terminationType, tt.allocationKey, "")
 		t.Run(tt.name, func(t *testing.T) {
 			if instance.applicationID != tt.wantID || instance.allocationKey != tt.wantAllocationKey || instance.terminationType != tt.wantType || instance.event != tt.wantEvent {
 				t.Errorf("want %s %s %s %s, got %s %s %s %s",
@@ -705,7 +705,7 @@ func TestReleaseAppAllocationEventGetEvent(t *testing.T) {
 	}
 
 	for _, tt := range tests {
-		instance := NewReleaseAppAllocationEvent(tt.appID, tt.terminationType, tt.allocationKey)
+		instance := NewReleaseAppAllocationEvent(tt.appID, tt.terminationType, tt.allocationKey, "")
 		event := instance.GetEvent()
 		t.Run(tt.name, func(t *testing.T) {
 			if event != tt.wantEvent.String() {
@@ -724,11 +724,11 @@ func TestReleaseAppAllocationEventGetArgs(t *testing.T) {
 		castOk               []bool
 		wantArg              []string
 	}{
-		{TestArgsName, "testAppId001", "testTaskId001", si.TerminationType_TIMEOUT, 2, []bool{true, true}, []string{"testTaskId001", "TIMEOUT"}},
+		{TestArgsName, "testAppId001", "testTaskId001", si.TerminationType_TIMEOUT, 3, []bool{true, true, true}, []string{"testTaskId001", "TIMEOUT", ""}},
 	}
 
 	for _, tt := range tests {
-		instance := NewReleaseAppAllocationEvent(tt.appID, tt.terminationType, tt.allocationKey)
+		instance := NewReleaseAppAllocationEvent(tt.appID, tt.terminationType, tt.allocationKey, "")
 		args := instance.GetArgs()
 		t.Run(tt.name, func(t *testing.T) {
 			if len(args) != tt.wantLen {
@@ -759,7 +759,7 @@ func TestReleaseAppAllocationEventGetApplicationID(t *testing.T) {
 	}
 
 	for _, tt := range tests {
-		instance := NewReleaseAppAllocationEvent(tt.appID, tt.terminationType, tt.allocationKey)
+		instance := NewReleaseAppAllocationEvent(tt.appID, tt.terminationType, tt.allocationKey, "")
 		appID := instance.GetApplicationID()
 		t.Run(tt.name, func(t *testing.T) {
 			if appID != tt.wantID {
@@ -22,6 +22,7 @@ import (
 	"fmt"
 	"sort"
 	"strings"
+	"sync/atomic"
 	"testing"
 	"time"
 
@@ -31,6 +32,7 @@ import (
 	v1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/resource"
 	apis "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
 	k8sEvents "k8s.io/client-go/tools/events"
 
 	"github.com/apache/yunikorn-k8shim/pkg/client"
@@ -38,6 +40,7 @@ import (
 	"github.com/apache/yunikorn-k8shim/pkg/common/constants"
 	"github.com/apache/yunikorn-k8shim/pkg/common/events"
 	"github.com/apache/yunikorn-k8shim/pkg/common/utils"
+	"github.com/apache/yunikorn-k8shim/pkg/conf"
 	"github.com/apache/yunikorn-k8shim/pkg/dispatcher"
 	"github.com/apache/yunikorn-k8shim/pkg/locking"
 	"github.com/apache/yunikorn-scheduler-interface/lib/go/api"
@@ -308,6 +311,199 @@ func TestSetUnallocatedPodsToFailedWhenFailApplication(t *testing.T) {
 	assert.Equal(t, newPod3.Status.Reason, constants.ApplicationInsufficientResourcesFailure, 3*time.Second)
 }
 
+func TestApplicationDeadlineExceeded(t *testing.T) {
+	context := initContextForTest()
+	dispatcher.RegisterEventHandler("TestAppHandler", dispatcher.EventTypeApp, context.ApplicationEventHandler())
+	dispatcher.Start()
+	defer dispatcher.Stop()
+
+	mockedAPIProvider := client.NewMockedAPIProvider(false)
+	mgr := NewPlaceholderManager(mockedAPIProvider.GetAPIs())
+	mgr.Start()
+	defer mgr.Stop()
+
+	deletedPods := newThreadSafePodsMap()
+	mockClient := mockedAPIProvider.GetAPIs().KubeClient
+	mockedAPIProvider.MockDeleteFn(func(pod *v1.Pod) error {
+		deletedPods.add(pod)
+		return nil
+	})
+	context.apiProvider.GetAPIs().KubeClient = mockClient
+	events.SetRecorder(events.NewMockedRecorder())
+	defer events.SetRecorder(events.NewMockedRecorder())
+
+	pendingPod, err := mockClient.Create(&v1.Pod{
+		ObjectMeta: apis.ObjectMeta{
+			Name: "pod-pending",
+			UID:  "UID-00001",
+		},
+	})
+	assert.NilError(t, err)
+	runningPod, err := mockClient.Create(&v1.Pod{
+		ObjectMeta: apis.ObjectMeta{
+			Name: "pod-running",
+			UID:  "UID-00002",
+		},
+	})
+	assert.NilError(t, err)
+
+	removed := atomic.Bool{}
+	schedulerAPI := newMockSchedulerAPI()
+	schedulerAPI.UpdateApplicationFn = func(request *si.ApplicationRequest) error {
+		if len(request.Remove) == 1 && request.Remove[0].ApplicationID == appID {
+			removed.Store(true)
+		}
+		return nil
+	}
+	app := NewApplication(appID, "root.abc", "testuser", testGroups, map[string]string{}, schedulerAPI)
+	context.applications[appID] = app
+	app.setMaxRuntime(200 * time.Millisecond)
+	task1 := NewTask("task01", app, context, pendingPod)
+	task2 := NewTask("task02", app, context, runningPod)
+	task1.sm.SetState(TaskStates().Pending)
+	task2.sm.SetState(TaskStates().Bound)
+	app.addTask(task1)
+	app.addTask(task2)
+	app.SetState(ApplicationStates().Accepted)
+
+	err = app.handle(NewRunApplicationEvent(app.applicationID))
+	assert.NilError(t, err)
+	assertAppState(t, app, ApplicationStates().Running, 3*time.Second)
+	assert.Assert(t, app.deadlineTimer != nil, "deadline timer was not started")
+
+	// timer fires, the application is removed from the core and failed by the shim
+	assertAppState(t, app, ApplicationStates().Failed, 3*time.Second)
+	assert.Assert(t, removed.Load(), "application was not removed from the core")
+	err = utils.WaitForCondition(func() bool {
+		return deletedPods.count() == 1
+	}, 10*time.Millisecond, 3*time.Second)
+	assert.NilError(t, err, "allocated pod was not deleted")
+	newPod, err := mockClient.Get(pendingPod.Namespace, pendingPod.Name)
+	assert.NilError(t, err)
+	assert.Equal(t, newPod.Status.Phase, v1.PodFailed)
+	assert.Equal(t, newPod.Status.Reason, constants.ApplicationDeadlineExceededFailure)
+	assert.Assert(t, app.deadlineTimer == nil, "deadline timer was not stopped")
+}
+
+func TestApplicationDeadlineNotSet(t *testing.T) {
+	app := NewApplication(appID, "root.abc", "testuser", testGroups, map[string]string{}, newMockSchedulerAPI())
+	app.SetState(ApplicationStates().Accepted)
+	err := app.handle(NewRunApplicationEvent(app.applicationID))
+	assert.NilError(t, err)
+	assert.Assert(t, app.deadlineTimer == nil, "deadline timer should not be started without max runtime")
+
+	// timer is stopped when the app completes
+	app.setMaxRuntime(time.Hour)
+	app.SetState(ApplicationStates().Accepted)
+	err = app.handle(NewRunApplicationEvent(app.applicationID))
+	assert.NilError(t, err)
+	assert.Assert(t, app.deadlineTimer != nil, "deadline timer was not started")
+	NewPlaceholderManager(client.NewMockedAPIProvider(false).GetAPIs())
+	err = app.handle(NewSimpleApplicationEvent(app.applicationID, CompleteApplication))
+	assert.NilError(t, err)
+	assert.Assert(t, app.deadlineTimer == nil, "deadline timer was not stopped")
+}
+
+func TestApplicationDeadlineStopped(t *testing.T) {
+	context := initContextForTest()
+	app := NewApplication(appID, "root.abc", "testuser", testGroups, map[string]string{}, newMockSchedulerAPI())
+	app.setMaxRuntime(time.Hour)
+	app.SetState(ApplicationStates().Accepted)
+	err := app.handle(NewRunApplicationEvent(app.applicationID))
+	assert.NilError(t, err)
+	assert.Assert(t, app.deadlineTimer != nil, "deadline timer was not started")
+	err = app.handle(NewSimpleApplicationEvent(app.applicationID, KillApplication))
+	assert.NilError(t, err)
+	assert.Assert(t, app.deadlineTimer == nil, "deadline timer was not stopped on kill")
+
+	// removing the application from the context stops the timer
+	app.SetState(ApplicationStates().Accepted)
+	err = app.handle(NewRunApplicationEvent(app.applicationID))
+	assert.NilError(t, err)
+	assert.Assert(t, app.deadlineTimer != nil, "deadline timer was not started")
+	context.applications[appID] = app
+	context.RemoveApplication(appID)
+	assert.Assert(t, app.deadlineTimer == nil, "deadline timer was not stopped on remove")
+}
+
+func TestApplicationDeadlineRunningSince(t *testing.T) {
+	context := initContextForTest()
+	mockClient := context.apiProvider.GetAPIs().KubeClient
+
+	// the pod start time is not used, the runtime is measured from the time the app reaches Running
+	startTime := apis.NewTime(time.Now().Add(-time.Hour))
+	pod := &v1.Pod{
+		ObjectMeta: apis.ObjectMeta{
+			Name: "pod-running",
+			UID:  "UID-00001",
+		},
+		Status: v1.PodStatus{StartTime: &startTime},
+	}
+	app := NewApplication(appID, "root.abc", "testuser", testGroups, map[string]string{}, newMockSchedulerAPI())
+	task := NewTask("task01", app, context, pod)
+	app.addTask(task)
+	app.setMaxRuntime(time.Hour)
+	app.SetState(ApplicationStates().Accepted)
+	before := time.Now()
+	err := app.handle(NewRunApplicationEvent(app.applicationID))
+	assert.NilError(t, err)
+	assert.Assert(t, app.deadlineTimer != nil, "deadline timer was not started")
+	assert.Assert(t, !app.runningSince.Before(before), "running time not measured from entering Running")
+	app.stopTimers()
+
+	// the running time is persisted on the pod
+	err = utils.WaitForCondition(func() bool {
+		updated, getErr := mockClient.Get(pod.Namespace, pod.Name)
+		return getErr == nil && updated.Annotations[constants.AnnotationRunningSince] == app.runningSince.Format(time.RFC3339)
+	}, 10*time.Millisecond, time.Second)
+	assert.NilError(t, err, "running-since annotation was not persisted")
+
+	// after a restart the running time is restored from the annotation
+	runningSince := time.Now().Add(-30 * time.Minute).Truncate(time.Second)
+	pod = pod.DeepCopy()
+	pod.Annotations = map[string]string{constants.AnnotationRunningSince: runningSince.Format(time.RFC3339)}
+	app = NewApplication(appID, "root.abc", "testuser", testGroups, map[string]string{}, newMockSchedulerAPI())
+	app.addTask(NewTask("task01", app, context, pod))
+	app.setMaxRuntime(time.Hour)
+	app.SetState(ApplicationStates().Accepted)
+	err = app.handle(NewRunApplicationEvent(app.applicationID))
+	assert.NilError(t, err)
+	assert.Assert(t, app.deadlineTimer != nil, "deadline timer was not started")
+	assert.Assert(t, app.runningSince.Equal(runningSince), "running time not restored from the annotation")
+	app.stopTimers()
+}
+
+func TestApplicationPlacedQueueMaxRuntime(t *testing.T) {
+	err := conf.UpdateConfigMaps([]*v1.ConfigMap{nil, {Data: map[string]string{
+		conf.CMSvcQueueMaxRuntimePrefix + "root.placed": "1h",
+	}}}, true)
+	assert.NilError(t, err, "failed to update configmap")
+	t.Cleanup(func() {
+		err = conf.UpdateConfigMaps([]*v1.ConfigMap{nil, nil}, true)
+		assert.NilError(t, err, "failed to reset configmap")
+	})
+
+	app := NewApplication(appID, "", "testuser", testGroups, map[string]string{}, newMockSchedulerAPI())
+	app.SetState(ApplicationStates().Accepted)
+	err = app.handle(NewRunApplicationEvent(app.applicationID))
+	assert.NilError(t, err)
+	assert.Assert(t, app.deadlineTimer == nil, "deadline timer should not be started without max runtime")
+
+	// the queue default applies once the core placed the application
+	app.setPlacedQueue("root.placed")
+	assert.Equal(t, app.GetQueue(), "root.placed")
+	assert.Equal(t, app.getMaxRuntime(), time.Hour)
+	assert.Assert(t, app.deadlineTimer != nil, "deadline timer was not started")
+	app.stopTimers()
+
+	// a max runtime set on the pod or namespace is not replaced
+	app = NewApplication(appID, "", "testuser", testGroups, map[string]string{}, newMockSchedulerAPI())
+	app.maxRuntime = time.Minute
+	app.maxRuntimeFixed = true
+	app.setPlacedQueue("root.placed")
+	assert.Equal(t, app.getMaxRuntime(), time.Minute)
+}
+
 func TestSetUnallocatedPodsToFailedWhenRejectApplication(t *testing.T) {
 	context := initContextForTest()
 	dispatcher.RegisterEventHandler("TestAppHandler", dispatcher.EventTypeApp, context.ApplicationEventHandler())
@@ -434,7 +630,7 @@ func TestReleaseAppAllocation(t *testing.T) {
 	app.addTask(task)
 	task.allocationKey = task.taskID
 	// app must be running states
-	err := app.handle(NewReleaseAppAllocationEvent(appID, si.TerminationType_TIMEOUT, task.taskID))
+	err := app.handle(NewReleaseAppAllocationEvent(appID, si.TerminationType_TIMEOUT, task.taskID, ""))
 	if err == nil {
 		// this should give an error
 		t.Error("expecting error got 'nil'")
@@ -442,7 +638,7 @@ func TestReleaseAppAllocation(t *testing.T) {
 	// set app states to running, let event can be trigger
 	app.SetState(ApplicationStates().Running)
 	assertAppState(t, app, ApplicationStates().Running, 3*time.Second)
-	err = app.handle(NewReleaseAppAllocationEvent(appID, si.TerminationType_TIMEOUT, task.taskID))
+	err = app.handle(NewReleaseAppAllocationEvent(appID, si.TerminationType_TIMEOUT, task.taskID, ""))
 	assert.NilError(t, err)
 	// after handle release event the states of app must be running
 	assertAppState(t, app, ApplicationStates().Running, 3*time.Second)
@@ -997,7 +1193,7 @@ func TestReleaseAppAllocationInFailingState(t *testing.T) {
 	task.allocationKey = task.taskID
 
 	// app must be running states
-	err := app.handle(NewReleaseAppAllocationEvent(appID, si.TerminationType_TIMEOUT, task.taskID))
+	err := app.handle(NewReleaseAppAllocationEvent(appID, si.TerminationType_TIMEOUT, task.taskID, ""))
 	if err == nil {
 		// this should give an error
 		t.Error("expecting error got 'nil'")
@@ -1005,12 +1201,12 @@ func TestReleaseAppAllocationInFailingState(t *testing.T) {
 	// set app states to running, let event can be trigger
 	app.SetState(ApplicationStates().Running)
 	assertAppState(t, app, ApplicationStates().Running, 3*time.Second)
-	err = app.handle(NewReleaseAppAllocationEvent(appID, si.TerminationType_TIMEOUT, task.taskID))
+	err = app.handle(NewReleaseAppAllocationEvent(appID, si.TerminationType_TIMEOUT, task.taskID, ""))
 	assert.NilError(t, err)
 	// after handle release event the states of app must be running
 	assertAppState(t, app, ApplicationStates().Running, 3*time.Second)
 	app.SetState(ApplicationStates().Failing)
-	err = app.handle(NewReleaseAppAllocationEvent(appID, si.TerminationType_TIMEOUT, task.taskID))
+	err = app.handle(NewReleaseAppAllocationEvent(appID, si.TerminationType_TIMEOUT, task.taskID, ""))
 	assert.NilError(t, err)
 	// after handle release event the states of app must be failing
 	assertAppState(t, app, ApplicationStates().Failing, 3*time.Second)
@@ -1081,6 +1277,143 @@ func TestResumingStateTransitions(t *testing.T) {
 	assertAppState(t, app, ApplicationStates().Running, 3*time.Second)
 }
 
+func TestSuspendAndResumeApplication(t *testing.T) {
+	context, mockedAPIProvider := initContextAndAPIProviderForTest()
+	dispatcher.RegisterEventHandler("TestTaskHandler", dispatcher.EventTypeTask, context.TaskEventHandler())
+	dispatcher.Start()
+	defer dispatcher.UnregisterAllEventHandlers()
+	defer dispatcher.Stop()
+
+	app := NewApplication(appID, "root.abc", "testuser", testGroups, map[string]string{}, mockedAPIProvider.GetAPIs().SchedulerAPI)
+	context.addApplicationToContext(app)
+	suspendedPod := &v1.Pod{
+		ObjectMeta: apis.ObjectMeta{
+			Name:        "pod-pending",
+			UID:         "UID-00001",
+			Annotations: map[string]string{constants.AnnotationSuspended: "true"},
+		},
+	}
+	runningPod := &v1.Pod{
+		ObjectMeta: apis.ObjectMeta{
+			Name: "pod-running",
+			UID:  "UID-00002",
+		},
+	}
+	task1 := NewTask("task01", app, context, suspendedPod)
+	task2 := NewTask("task02", app, context, runningPod)
+	task1.sm.SetState(TaskStates().Scheduling)
+	task2.sm.SetState(TaskStates().Bound)
+	app.addTask(task1)
+	app.addTask(task2)
+	app.SetState(ApplicationStates().Running)
+
+	// the annotation suspends the app and withdraws the ask, the bound task is untouched
+	assert.Assert(t, !app.Schedule(), "suspended app should not need scheduling")
+	assert.Equal(t, app.GetApplicationState(), ApplicationStates().Suspended)
+	assert.Equal(t, app.getSuspendedFromState(), ApplicationStates().Running)
+	err := utils.WaitForCondition(func() bool {
+		return task1.GetTaskState() == TaskStates().New
+	}, 10*time.Millisecond, 3*time.Second)
+	assert.NilError(t, err, "task was not withdrawn")
+	assert.Equal(t, mockedAPIProvider.GetSchedulerAPIUpdateAllocationCount(), int32(1))
+	assert.Equal(t, task2.GetTaskState(), TaskStates().Bound)
+
+	// new tasks are not submitted while suspended
+	assert.Assert(t, !app.Schedule(), "suspended app should not need scheduling")
+	assert.Equal(t, task1.GetTaskState(), TaskStates().New)
+
+	// removing the annotation resumes the app in the state it was suspended from
+	task1.SetTaskPod(runningPod.DeepCopy())
+	app.Schedule()
+	assert.Equal(t, app.GetApplicationState(), ApplicationStates().Running)
+	assert.Equal(t, app.getSuspendedFromState(), "")
+	assert.Assert(t, task1.GetTaskState() != TaskStates().New, "task was not submitted after resume")
+}
+
+func TestResumeSuspendedReservingApplication(t *testing.T) {
+	app := NewApplication(appID, "root.abc", "testuser", testGroups, map[string]string{}, newMockSchedulerAPI())
+	app.setTaskGroups([]TaskGroup{{Name: "tg-1", MinMember: 1}})
+	app.SetState(ApplicationStates().Reserving)
+
+	err := app.handle(NewSimpleApplicationEvent(app.applicationID, SuspendApplication))
+	assert.NilError(t, err)
+	assert.Equal(t, app.GetApplicationState(), ApplicationStates().Suspended)
+	// events that are only valid in the Reserving state are ignored while suspended
+	assert.Assert(t, !app.canHandle(NewUpdateApplicationReservationEvent(app.applicationID)))
+	assert.Assert(t, !app.canHandle(NewRunApplicationEvent(app.applicationID)))
+
+	err = app.handle(NewResumeApplicationEvent(app.applicationID, app.getSuspendedFromState()))
+	assert.NilError(t, err)
+	assert.Equal(t, app.GetApplicationState(), ApplicationStates().Reserving)
+
+	// an app can be failed while suspended
+	err = app.handle(NewSimpleApplicationEvent(app.applicationID, SuspendApplication))
+	assert.NilError(t, err)
+	assert.Assert(t, app.canHandle(NewFailApplicationEvent(app.applicationID, "failed")))
+}
+
+func TestSuspendedApplicationDeferredEvents(t *testing.T) {
+	context := initContextForTest()
+	dispatcher.RegisterEventHandler("TestAppHandler", dispatcher.EventTypeApp, context.ApplicationEventHandler())
+	dispatcher.Start()
+	defer dispatcher.UnregisterAllEventHandlers()
+	defer dispatcher.Stop()
+
+	app := NewApplication(appID, "root.abc", "testuser", testGroups, map[string]string{}, newMockSchedulerAPI())
+	app.setTaskGroups([]TaskGroup{{Name: "tg-1", MinMember: 1}})
+	context.addApplicationToContext(app)
+	app.SetState(ApplicationStates().Reserving)
+	err := app.handle(NewSimpleApplicationEvent(app.applicationID, SuspendApplication))
+	assert.NilError(t, err)
+
+	// the core runs the app while it is suspended
+	dispatcher.Dispatch(NewRunApplicationEvent(app.applicationID))
+	err = utils.WaitForCondition(func() bool {
+		app.lock.RLock()
+		defer app.lock.RUnlock()
+		return len(app.deferredEvents) == 1
+	}, 10*time.Millisecond, 3*time.Second)
+	assert.NilError(t, err, "event was not deferred")
+	assert.Equal(t, app.GetApplicationState(), ApplicationStates().Suspended)
+
+	// the deferred event is replayed after the resume
+	err = app.handle(NewResumeApplicationEvent(app.applicationID, app.getSuspendedFromState()))
+	assert.NilError(t, err)
+	assertAppState(t, app, ApplicationStates().Running, 3*time.Second)
+
+	// events that do not come from the core are not deferred
+	app.SetState(ApplicationStates().Suspended)
+	assert.Assert(t, !app.deferEvent(NewUpdateApplicationReservationEvent(app.applicationID)))
+}
+
+func TestSuspendedApplicationPlaceholderTimeout(t *testing.T) {
+	context := initContextForTest()
+	requests := make([]*si.ApplicationRequest, 0)
+	schedulerAPI := newMockSchedulerAPI()
+	schedulerAPI.UpdateApplicationFn = func(request *si.ApplicationRequest) error {
+		requests = append(requests, request)
+		return nil
+	}
+	app := NewApplication(appID, "root.abc", "testuser", testGroups, map[string]string{}, schedulerAPI)
+	app.setTaskGroups([]TaskGroup{{Name: "tg-1", MinMember: 1}})
+	app.SetPlaceholderTimeout(60)
+	placeholder := NewTaskPlaceholder("ph-01", app, context, &v1.Pod{})
+	placeholder.sm.SetState(TaskStates().Bound)
+	app.addTask(placeholder)
+	app.SetState(ApplicationStates().Reserving)
+	err := app.handle(NewSimpleApplicationEvent(app.applicationID, SuspendApplication))
+	assert.NilError(t, err)
+	assert.Equal(t, len(requests), 0, "suspended application must not be registered again")
+
+	// the release of a timed out placeholder is deferred until the resume, other releases are not
+	timeout := NewReleaseAppAllocationEvent(app.applicationID, si.TerminationType_TIMEOUT, "ph-01", "timeout")
+	assert.Assert(t, app.deferEvent(timeout), "placeholder timeout not deferred")
+	preempted := NewReleaseAppAllocationEvent(app.applicationID, si.TerminationType_PREEMPTED_BY_SCHEDULER, "ph-01", "preempted")
+	assert.Assert(t, !app.deferEvent(preempted), "preemption must not be deferred")
+	assert.Equal(t, len(app.deferredEvents), 1)
+	assert.Equal(t, placeholder.GetTaskState(), TaskStates().Bound)
+}
+
 func TestGetPlaceholderTasks(t *testing.T) {
 	context := initContextForTest()
 	app := NewApplication(appID, "root.a", "testuser", testGroups, map[string]string{}, newMockSchedulerAPI())
@@ -1180,7 +1513,7 @@ func TestPlaceholderTimeoutEvents(t *testing.T) {
 	// set app states to running, let event can be trigger
 	app.SetState(ApplicationStates().Running)
 	assertAppState(t, app, ApplicationStates().Running, 3*time.Second)
-	err := app.handle(NewReleaseAppAllocationEvent(appID, si.TerminationType_TIMEOUT, allocationKey))
+	err := app.handle(NewReleaseAppAllocationEvent(appID, si.TerminationType_TIMEOUT, allocationKey, ""))
 	assert.NilError(t, err)
 	// after handle release event the states of app must be running
 	assertAppState(t, app, ApplicationStates().Running, 3*time.Second)
@@ -1333,3 +1666,228 @@ func (ctx *Context) addApplicationToContext(app *Application) {
 	defer ctx.lock.Unlock()
 	ctx.applications[app.applicationID] = app
 }
+
+func TestPlaceholderCreateFailurePolicy(t *testing.T) {
+	context := initContextForTest()
+	dispatcher.RegisterEventHandler("TestAppHandler", dispatcher.EventTypeApp, context.ApplicationEventHandler())
+	dispatcher.Start()
+	defer dispatcher.UnregisterAllEventHandlers()
+	defer dispatcher.Stop()
+	defer func() {
+		err := conf.UpdateConfigMaps([]*v1.ConfigMap{nil, nil}, true)
+		assert.NilError(t, err, "failed to reset configmap")
+	}()
+
+	// the first gang creation fails on the last placeholder, the next one succeeds
+	createdPods := newThreadSafePodsMap()
+	var calls atomic.Int32
+	mockedAPIProvider := client.NewMockedAPIProvider(false)
+	mockedAPIProvider.MockCreateFn(func(pod *v1.Pod) (*v1.Pod, error) {
+		if calls.Add(1) == 30 {
+			return nil, fmt.Errorf("create failed")
+		}
+		createdPods.add(pod)
+		return pod, nil
+	})
+	mgr := NewPlaceholderManager(mockedAPIProvider.GetAPIs())
+	mgr.setRetryBackoff(time.Millisecond)
+
+	// Retry: the gang is rolled back and recreated
+	err := conf.UpdateConfigMaps([]*v1.ConfigMap{nil, {Data: map[string]string{
+		conf.CMSvcPlaceholderCreateFailPolicy:  conf.PlaceholderCreatePolicyRetry,
+		conf.CMSvcPlaceholderCreateRetryDelay:  "10ms",
+		conf.CMSvcPlaceholderCreateParallelism: "1",
+	}}}, true)
+	assert.NilError(t, err, "failed to set configmap")
+	app := createAppWIthTaskGroupForTest()
+	context.addApplicationToContext(app)
+	app.SetState(ApplicationStates().Reserving)
+	app.createPlaceholders()
+	err = utils.WaitForCondition(func() bool {
+		return createdPods.count() == 59
+	}, 5*time.Millisecond, time.Second)
+	assert.NilError(t, err, "placeholders should have been recreated")
+	assert.Equal(t, app.GetApplicationState(), ApplicationStates().Reserving)
+	assert.Equal(t, app.placeholderCreateAttempts, 1)
+
+	// Fail: the gang is rolled back and the application fails
+	err = conf.UpdateConfigMaps([]*v1.ConfigMap{nil, {Data: map[string]string{
+		conf.CMSvcPlaceholderCreateFailPolicy: conf.PlaceholderCreatePolicyFail,
+	}}}, true)
+	assert.NilError(t, err, "failed to set configmap")
+	calls.Store(0)
+	app = createAppWIthTaskGroupForTest()
+	app.applicationID = "app02"
+	context.addApplicationToContext(app)
+	app.SetState(ApplicationStates().Reserving)
+	app.createPlaceholders()
+	assertAppState(t, app, ApplicationStates().Failing, 3*time.Second)
+}
+
+func newTaskGroupTestApp(t *testing.T) (*Context, *Application, *threadSafePodsMap) {
+	context, apiProvider := initContextAndAPIProviderForTest()
+	dispatcher.RegisterEventHandler("TestAppHandler", dispatcher.EventTypeApp, context.ApplicationEventHandler())
+	dispatcher.Start()
+	t.Cleanup(func() {
+		dispatcher.UnregisterAllEventHandlers()
+		dispatcher.Stop()
+	})
+	deleted := newThreadSafePodsMap()
+	apiProvider.MockDeleteFn(func(pod *v1.Pod) error {
+		deleted.add(pod)
+		return nil
+	})
+	NewPlaceholderManager(apiProvider.GetAPIs())
+
+	app := NewApplication(appID, "root.a", "testuser", testGroups, map[string]string{}, newMockSchedulerAPI())
+	app.setTaskGroups([]TaskGroup{
+		{Name: "ps", MinMember: 1, MinResource: map[string]resource.Quantity{"cpu": resource.MustParse("1")}, GangSchedulingStyle: "Hard"},
+		{Name: "worker", MinMember: 2, MinResource: map[string]resource.Quantity{"cpu": resource.MustParse("1")}, PlaceholderTimeoutInSeconds: 60},
+	})
+	app.SetPlaceholderTimeout(120)
+	context.addApplicationToContext(app)
+	return context, app, deleted
+}
+
+func addTaskGroupPlaceholder(context *Context, app *Application, name, taskGroup string, state string, created time.Time) *Task {
+	pod := newPodHelper(name, "default", name, "", app.applicationID, v1.PodPending)
+	pod.CreationTimestamp = apis.NewTime(created)
+	task := NewFromTaskMeta(name, app, context, TaskMetadata{
+		ApplicationID: app.applicationID,
+		TaskID:        name,
+		Pod:           pod,
+		Placeholder:   true,
+		TaskGroupName: taskGroup,
+	}, false)
+	task.sm.SetState(state)
+	app.addTask(task)
+	return task
+}
+
+func TestTaskGroupPlaceholderTimeoutPolicy(t *testing.T) {
+	app := NewApplication(appID, "root.a", "testuser", testGroups, map[string]string{}, newMockSchedulerAPI())
+	app.setTaskGroups([]TaskGroup{{Name: "a", MinMember: 1}, {Name: "b", MinMember: 1}})
+	app.SetPlaceholderTimeout(120)
+	assert.Assert(t, !app.hasTaskGroupPolicies())
+	assert.Equal(t, app.getCorePlaceholderTimeout(), int64(120))
+	app.startTaskGroupTimers()
+	assert.Assert(t, app.taskGroupTimers == nil, "no timers expected without task group policies")
+
+	app.setTaskGroups([]TaskGroup{{Name: "a", MinMember: 1, PlaceholderTimeoutInSeconds: 300}, {Name: "b", MinMember: 1, GangSchedulingStyle: "Hard"}})
+	assert.Assert(t, app.hasTaskGroupPolicies())
+	assert.Equal(t, app.getTaskGroupTimeout(app.taskGroups[0]), 300*time.Second)
+	assert.Equal(t, app.getTaskGroupTimeout(app.taskGroups[1]), 120*time.Second)
+	assert.Equal(t, app.getTaskGroupStyle(app.taskGroups[0]), constants.SchedulingPolicyStyleParamDefault)
+	assert.Equal(t, app.getTaskGroupStyle(app.taskGroups[1]), "Hard")
+	// the core timeout is a backstop past the longest group timeout
+	assert.Equal(t, app.getCorePlaceholderTimeout(), int64((300*time.Second+corePlaceholderTimeoutMargin)/time.Second))
+	app.SetPlaceholderTimeout(0)
+	assert.Equal(t, app.getTaskGroupTimeout(app.taskGroups[1]), defaultPlaceholderTimeout)
+	assert.Equal(t, app.getCorePlaceholderTimeout(), int64((defaultPlaceholderTimeout+corePlaceholderTimeoutMargin)/time.Second))
+}
+
+func TestTaskGroupTimeoutSoft(t *testing.T) {
+	context, app, deleted := newTaskGroupTestApp(t)
+	app.SetState(ApplicationStates().Reserving)
+	addTaskGroupPlaceholder(context, app, "ph-ps", "ps", TaskStates().Bound, time.Now())
+	addTaskGroupPlaceholder(context, app, "ph-worker-1", "worker", TaskStates().Bound, time.Now())
+	// created before a restart: the timeout has already expired
+	addTaskGroupPlaceholder(context, app, "ph-worker-2", "worker", TaskStates().Scheduling, time.Now().Add(-time.Hour))
+
+	app.lock.Lock()
+	app.startTaskGroupTimers()
+	app.lock.Unlock()
+	// the worker group times out immediately, its placeholders are released and the gang is complete
+	assertAppState(t, app, ApplicationStates().Running, 3*time.Second)
+	err := utils.WaitForCondition(func() bool {
+		return deleted.count() == 2
+	}, 10*time.Millisecond, time.Second)
+	assert.NilError(t, err, "worker placeholders should have been deleted")
+	app.lock.RLock()
+	assert.Assert(t, app.timedOutTaskGroups["worker"], "worker group should have timed out")
+	assert.Assert(t, !app.timedOutTaskGroups["ps"], "ps group should not have timed out")
+	app.lock.RUnlock()
+}
+
+func TestTaskGroupTimeoutHard(t *testing.T) {
+	context, app, deleted := newTaskGroupTestApp(t)
+	removed := atomic.Bool{}
+	schedulerAPI := newMockSchedulerAPI()
+	schedulerAPI.UpdateApplicationFn = func(request *si.ApplicationRequest) error {
+		if len(request.Remove) == 1 && request.Remove[0].ApplicationID == appID {
+			removed.Store(true)
+		}
+		return nil
+	}
+	app.schedulerAPI = schedulerAPI
+	app.SetState(ApplicationStates().Reserving)
+	addTaskGroupPlaceholder(context, app, "ph-ps", "ps", TaskStates().Scheduling, time.Now())
+	addTaskGroupPlaceholder(context, app, "ph-worker-1", "worker", TaskStates().Bound, time.Now())
+	addTaskGroupPlaceholder(context, app, "ph-worker-2", "worker", TaskStates().Bound, time.Now())
+
+	app.lock.Lock()
+	app.startTaskGroupTimers()
+	assert.Equal(t, len(app.taskGroupTimers), 2)
+	app.lock.Unlock()
+
+	// a complete group does not time out
+	app.handleTaskGroupTimeout("worker")
+	assert.Equal(t, app.GetApplicationState(), ApplicationStates().Reserving)
+	assert.Equal(t, len(app.timedOutTaskGroups), 0)
+
+	// an incomplete Hard group removes the application from the core and fails it
+	app.handleTaskGroupTimeout("ps")
+	assertAppState(t, app, ApplicationStates().Failed, 3*time.Second)
+	assert.Assert(t, removed.Load(), "application was not removed from the core")
+	assert.Assert(t, app.taskGroupTimers == nil, "timers should be stopped when leaving Reserving")
+	assert.Equal(t, len(app.timedOutTaskGroups), 0)
+	err := utils.WaitForCondition(func() bool {
+		return deleted.count() == 3
+	}, 10*time.Millisecond, time.Second)
+	assert.NilError(t, err, "all placeholders should be cleaned up")
+}
+
+func TestTaskGroupTimeoutMinReady(t *testing.T) {
+	minReady := intstr.FromString("50%")
+	setup := func(t *testing.T, bound int) (*Application, *threadSafePodsMap) {
+		context, app, deleted := newTaskGroupTestApp(t)
+		app.setTaskGroups([]TaskGroup{
+			{Name: "ps", MinMember: 1, MinResource: map[string]resource.Quantity{"cpu": resource.MustParse("1")}},
+			{Name: "worker", MinMember: 4, MinResource: map[string]resource.Quantity{"cpu": resource.MustParse("1")}, GangSchedulingStyle: "Hard", MinReady: &minReady},
+		})
+		app.SetState(ApplicationStates().Reserving)
+		addTaskGroupPlaceholder(context, app, "ph-ps", "ps", TaskStates().Bound, time.Now())
+		for i := 1; i <= 4; i++ {
+			state := TaskStates().Scheduling
+			if i <= bound {
+				state = TaskStates().Bound
+			}
+			addTaskGroupPlaceholder(context, app, fmt.Sprintf("ph-worker-%d", i), "worker", state, time.Now())
+		}
+		app.lock.Lock()
+		app.startTaskGroupTimers()
+		app.lock.Unlock()
+		return app, deleted
+	}
+
+	t.Run("below minReady", func(t *testing.T) {
+		app, _ := setup(t, 1)
+		// the style of the group applies
+		app.handleTaskGroupTimeout("worker")
+		assertAppState(t, app, ApplicationStates().Failed, 3*time.Second)
+	})
+
+	t.Run("minReady reached", func(t *testing.T) {
+		app, deleted := setup(t, 2)
+		// the group starts with the bound placeholders, only the unbound ones are released
+		app.handleTaskGroupTimeout("worker")
+		assertAppState(t, app, ApplicationStates().Running, 3*time.Second)
+		err := utils.WaitForCondition(func() bool {
+			return deleted.count() == 2
+		}, 10*time.Millisecond, time.Second)
+		assert.NilError(t, err, "unbound worker placeholders should have been deleted")
+		deleted.RLock()
+		defer deleted.RUnlock()
+		assert.Assert(t, deleted.pods["ph-worker-3"] != nil && deleted.pods["ph-worker-4"] != nil, "only unbound placeholders should be deleted")
+	})
+}
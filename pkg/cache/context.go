@@ -23,12 +23,15 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"maps"
 	"reflect"
+	"slices"
 	"sort"
 	"strconv"
 	"strings"
 	"sync"
 	"sync/atomic"
+	"time"
 
 	"go.uber.org/zap"
 	v1 "k8s.io/api/core/v1"
@@ -40,6 +43,7 @@ import (
 	"k8s.io/kubernetes/pkg/scheduler/framework"
 	"k8s.io/kubernetes/pkg/scheduler/framework/plugins/volumebinding"
 
+	"github.com/apache/yunikorn-core/pkg/common/resources"
 	schedulercache "github.com/apache/yunikorn-k8shim/pkg/cache/external"
 	"github.com/apache/yunikorn-k8shim/pkg/client"
 	"github.com/apache/yunikorn-k8shim/pkg/common"
@@ -66,9 +70,12 @@ var (
 // context maintains scheduling state, like apps and apps' tasks.
 type Context struct {
 	applications   map[string]*Application        // apps
+	appGroups      map[string]*ApplicationGroup   // application groups by name
 	schedulerCache *schedulercache.SchedulerCache // external cache
 	apiProvider    client.APIProvider             // apis to interact with api-server, scheduler-core, etc
 	predManager    predicates.PredicateManager    // K8s predicates
+	predHandle     framework.Handle               // framework handle used to (re)build the predicate manager
+	predConf       schedulerconf.PredicatesConf   // predicate configuration the predicate manager was built from
 	pluginMode     bool                           // true if we are configured as a scheduler plugin
 	namespace      string                         // yunikorn namespace
 	configMaps     []*v1.ConfigMap                // cached yunikorn configmaps
@@ -76,6 +83,8 @@ type Context struct {
 	txnID          atomic.Uint64                  // transaction ID counter
 	klogger        klog.Logger
 	podActivator   atomic.Value
+	pdbRejections  *pdbRejections               // nodes rejected for preemption because of PodDisruptionBudgets
+	nodeAttributes map[string]map[string]string // attributes of each registered node as sent to the core
 }
 
 // NewContext create a new context for the scheduler using a default (empty) configuration
@@ -92,12 +101,15 @@ func NewContextWithBootstrapConfigMaps(apis client.APIProvider, bootstrapConfigM
 	// nodecontroller needs the cache
 	// predictor need the cache, volumebinder and informers
 	ctx := &Context{
-		applications: make(map[string]*Application),
-		apiProvider:  apis,
-		namespace:    schedulerconf.GetSchedulerConf().Namespace,
-		configMaps:   bootstrapConfigMaps,
-		lock:         &locking.RWMutex{},
-		klogger:      klog.NewKlogr(),
+		applications:   make(map[string]*Application),
+		appGroups:      make(map[string]*ApplicationGroup),
+		apiProvider:    apis,
+		namespace:      schedulerconf.GetSchedulerConf().Namespace,
+		configMaps:     bootstrapConfigMaps,
+		lock:           &locking.RWMutex{},
+		klogger:        klog.NewKlogr(),
+		pdbRejections:  newPDBRejections(),
+		nodeAttributes: make(map[string]map[string]string),
 	}
 
 	// create the cache
@@ -107,7 +119,9 @@ func NewContextWithBootstrapConfigMaps(apis client.APIProvider, bootstrapConfigM
 	sharedLister := support.NewSharedLister(ctx.schedulerCache)
 	clientSet := apis.GetAPIs().KubeClient.GetClientSet()
 	informerFactory := apis.GetAPIs().InformerFactory
-	ctx.predManager = predicates.NewPredicateManager(support.NewFrameworkHandle(sharedLister, informerFactory, clientSet))
+	ctx.predHandle = support.NewFrameworkHandle(sharedLister, informerFactory, clientSet)
+	ctx.predConf = schedulerconf.GetSchedulerConf().GetPredicatesConf()
+	ctx.predManager = predicates.NewPredicateManager(ctx.predHandle)
 
 	return ctx
 }
@@ -215,7 +229,8 @@ func (ctx *Context) updateNodeInternal(node *v1.Node, register bool) {
 			if applicationID == "" {
 				ctx.updateForeignPod(pod)
 			} else {
-				ctx.updateYuniKornPod(applicationID, nil, pod)
+				// adopted pods are already assigned to the node, there is no gang to infer
+				ctx.updateYuniKornPod(applicationID, nil, pod, nil)
 			}
 		}
 
@@ -225,9 +240,17 @@ func (ctx *Context) updateNodeInternal(node *v1.Node, register bool) {
 		}
 	} else {
 		// existing node
-		prevCapacity := common.GetNodeResource(&prevNode.Status)
-		newCapacity := common.GetNodeResource(&node.Status)
+		// the core only takes the attributes of a node at registration and ignores them in an update: label
+		// changes are not propagated, the node keeps the attributes it was registered with until it is removed
+		partition := ctx.getNodePartition(node.Name)
+		if !maps.Equal(getNodeAttributes(prevNode, partition), getNodeAttributes(node, partition)) {
+			log.Log(log.ShimContext).Info("Node attributes changed, the scheduler keeps the registered attributes",
+				zap.String("nodeName", node.Name),
+				zap.Any("registered", ctx.nodeAttributes[node.Name]))
+		}
 
+		prevCapacity := common.GetNodeCapacity(prevNode)
+		newCapacity := common.GetNodeCapacity(node)
 		if !common.Equals(prevCapacity, newCapacity) {
 			// update capacity
 			if err := ctx.updateNodeResources(node, newCapacity); err != nil {
@@ -236,6 +259,75 @@ func (ctx *Context) updateNodeInternal(node *v1.Node, register bool) {
 				log.Log(log.ShimContext).Warn("Failed to update cached node capacity", zap.String("nodeName", node.Name))
 			}
 		}
+
+		// cordon, readiness and taint changes switch the node between schedulable and unschedulable in the core
+		drainTaintKeys := schedulerconf.GetSchedulerConf().GetNodeDrainTaintKeys()
+		ctx.updateNodeSchedulable(node, utils.GetNodeUnschedulableReason(prevNode, drainTaintKeys),
+			utils.GetNodeUnschedulableReason(node, drainTaintKeys))
+	}
+}
+
+// getNodeCapacities returns the capacity of all registered nodes as sent to the core.
+// Must be called while holding the context lock.
+func (ctx *Context) getNodeCapacities() map[string]*si.Resource {
+	capacities := make(map[string]*si.Resource, len(ctx.nodeAttributes))
+	for _, nodeInfo := range ctx.schedulerCache.GetNodesInfo() {
+		node := nodeInfo.Node()
+		if _, ok := ctx.nodeAttributes[node.Name]; ok {
+			capacities[node.Name] = common.GetNodeCapacity(node)
+		}
+	}
+	return capacities
+}
+
+// updateNodesCapacity sends the capacity of the registered nodes to the core if it changed after a configuration
+// update. Must be called while holding the context lock.
+func (ctx *Context) updateNodesCapacity(prevCapacities map[string]*si.Resource) {
+	for _, nodeInfo := range ctx.schedulerCache.GetNodesInfo() {
+		node := nodeInfo.Node()
+		prevCapacity, ok := prevCapacities[node.Name]
+		if !ok {
+			continue
+		}
+		newCapacity := common.GetNodeCapacity(node)
+		if common.Equals(prevCapacity, newCapacity) {
+			continue
+		}
+		if err := ctx.updateNodeResources(node, newCapacity); err != nil {
+			log.Log(log.ShimContext).Warn("Failed to update node capacity", zap.String("nodeName", node.Name), zap.Error(err))
+		}
+	}
+}
+
+// updateNodeSchedulable drains or restores the node in the core when it becomes unschedulable or schedulable.
+// A change of the reason for a node that stays unschedulable is only published as an event.
+func (ctx *Context) updateNodeSchedulable(node *v1.Node, prevReason, newReason string) {
+	switch {
+	case prevReason == newReason:
+		return
+	case prevReason == "":
+		ctx.drainNode(node, newReason)
+	case newReason == "":
+		ctx.restoreNode(node)
+	default:
+		events.GetRecorder().Eventf(node.DeepCopy(), nil, v1.EventTypeNormal, "NodeUnschedulable", "NodeUnschedulable",
+			fmt.Sprintf("node %s is unschedulable in the scheduler: %s", node.Name, newReason))
+	}
+}
+
+// updateNodesSchedulable re-evaluates all registered nodes after the drain taint keys changed.
+// Must be called while holding the context lock.
+func (ctx *Context) updateNodesSchedulable(prevDrainTaintKeys, newDrainTaintKeys []string) {
+	if slices.Equal(prevDrainTaintKeys, newDrainTaintKeys) {
+		return
+	}
+	for _, nodeInfo := range ctx.schedulerCache.GetNodesInfo() {
+		node := nodeInfo.Node()
+		if _, ok := ctx.nodeAttributes[node.Name]; !ok {
+			continue
+		}
+		ctx.updateNodeSchedulable(node, utils.GetNodeUnschedulableReason(node, prevDrainTaintKeys),
+			utils.GetNodeUnschedulableReason(node, newDrainTaintKeys))
 	}
 }
 
@@ -287,6 +379,7 @@ func (ctx *Context) deleteNodeInternal(node *v1.Node) {
 	if err := ctx.decommissionNode(node); err != nil {
 		log.Log(log.ShimContext).Warn("Unable to decommission node", zap.Error(err))
 	}
+	delete(ctx.nodeAttributes, node.Name)
 
 	// post the event
 	events.GetRecorder().Eventf(node.DeepCopy(), nil, v1.EventTypeNormal, "NodeDeleted", "NodeDeleted",
@@ -298,13 +391,15 @@ func (ctx *Context) AddPod(obj interface{}) {
 }
 
 func (ctx *Context) UpdatePod(oldObj, newObj interface{}) {
-	ctx.lock.Lock()
-	defer ctx.lock.Unlock()
 	pod, err := utils.Convert2Pod(newObj)
 	if err != nil {
 		log.Log(log.ShimContext).Error("failed to update pod", zap.Error(err))
 		return
 	}
+	// reading the workload can call the API server, it is done before locking the context
+	inferred := ctx.inferNewApplicationTaskGroups(pod)
+	ctx.lock.Lock()
+	defer ctx.lock.Unlock()
 	var oldPod *v1.Pod
 	if oldObj != nil {
 		oldPod, err = utils.Convert2Pod(oldObj)
@@ -317,18 +412,25 @@ func (ctx *Context) UpdatePod(oldObj, newObj interface{}) {
 	if applicationID == "" {
 		ctx.updateForeignPod(pod)
 	} else {
-		ctx.updateYuniKornPod(applicationID, oldPod, pod)
+		ctx.updateYuniKornPod(applicationID, oldPod, pod, inferred)
 	}
 }
 
-func (ctx *Context) updateYuniKornPod(appID string, oldPod, pod *v1.Pod) {
+// updateYuniKornPod updates the task of the pod, the application is created with the inferred task groups if it
+// does not exist yet.
+func (ctx *Context) updateYuniKornPod(appID string, oldPod, pod *v1.Pod, inferred []TaskGroup) {
 	taskID := string(pod.UID)
 	app := ctx.getApplication(appID)
 	if app != nil {
 		if task := app.GetTask(taskID); task != nil {
-			task.SetTaskPod(pod)
+			if err := ctx.checkPodResize(app, task, pod); err != nil {
+				ctx.rejectPodResize(app, task, oldPod, pod, err)
+			} else {
+				task.SetTaskPod(pod)
+			}
 		}
 	}
+	ctx.updateApplicationQueue(app, oldPod, pod)
 
 	// treat terminated pods like a remove
 	if utils.IsPodTerminated(pod) {
@@ -352,11 +454,61 @@ func (ctx *Context) updateYuniKornPod(appID string, oldPod, pod *v1.Pod) {
 	// always call UpdatePod() first to make sure the pod instance is the latest in the cache
 	if ctx.schedulerCache.UpdatePod(pod) && !hasGates {
 		// pod was accepted; ensure the application and task objects have been created
-		ctx.ensureAppAndTaskCreated(pod, app)
+		ctx.ensureAppAndTaskCreated(pod, app, inferred)
 	}
 }
 
-func (ctx *Context) ensureAppAndTaskCreated(pod *v1.Pod, app *Application) {
+// checkPodResize checks an in-place resize of a bound pod against the maximum resources of the queue of the
+// application. The usage of the queues is based on the applications known to the shim. Growth that does not fit
+// returns an error, a resize of a pod in a queue that is not in the configuration is not checked.
+// Must be called with the context lock held.
+func (ctx *Context) checkPodResize(app *Application, task *Task, pod *v1.Pod) error {
+	if task.GetTaskState() != TaskStates().Bound {
+		return nil
+	}
+	delta := common.Sub(app.getPodResource(pod), task.getTaskResource())
+	grown := false
+	for _, quantity := range delta.GetResources() {
+		if quantity.GetValue() > 0 {
+			grown = true
+			break
+		}
+	}
+	if !grown {
+		return nil
+	}
+	queuePath, err := ctx.getQueueConfigPath(app.partition, normalizeQueuePath(app.GetQueue()))
+	if err != nil {
+		log.Log(log.ShimContext).Debug("unable to check pod resize against queue limits",
+			zap.String("podName", pod.Name),
+			zap.Error(err))
+		return nil
+	}
+	path := ""
+	for _, queueConf := range queuePath {
+		path = joinQueuePath(path, queueConf.Name)
+		if err = ctx.checkQueueMaxResource(queueConf, path, resources.NewResourceFromProto(delta)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// rejectPodResize keeps the allocation of a pod with a resize that exceeds the queue limits, the core is not updated.
+// The PodResizePending event is only published when the resize is requested, not for every update of the pod.
+func (ctx *Context) rejectPodResize(app *Application, task *Task, oldPod, pod *v1.Pod, err error) {
+	task.setTaskPodKeepResource(pod)
+	if oldPod != nil && common.Equals(app.getPodResource(oldPod), app.getPodResource(pod)) {
+		return
+	}
+	log.Log(log.ShimContext).Info("pod resize exceeds queue maximum",
+		zap.String("podName", pod.Name),
+		zap.Error(err))
+	events.GetRecorder().Eventf(pod.DeepCopy(), nil, v1.EventTypeWarning, constants.PodStatusPodResizePending,
+		constants.PodStatusPodResizePending, "Resize of %s exceeds the queue limits, the allocation is not changed: %v", task.alias, err)
+}
+
+func (ctx *Context) ensureAppAndTaskCreated(pod *v1.Pod, app *Application, inferred []TaskGroup) {
 	// add app if it doesn't already exist
 	if app == nil {
 		// get app metadata
@@ -367,6 +519,12 @@ func (ctx *Context) ensureAppAndTaskCreated(pod *v1.Pod, app *Application) {
 				zap.String("name", pod.Name))
 			return
 		}
+		if len(appMeta.TaskGroups) == 0 {
+			ctx.resolveTaskGroupsRef(pod, &appMeta)
+		}
+		if len(appMeta.TaskGroups) == 0 {
+			appMeta.TaskGroups = inferred
+		}
 		app = ctx.addApplication(&AddApplicationRequest{
 			Metadata: appMeta,
 		})
@@ -380,6 +538,9 @@ func (ctx *Context) ensureAppAndTaskCreated(pod *v1.Pod, app *Application) {
 			zap.String("name", pod.Name))
 		return
 	}
+	if taskMeta.TaskGroupName == "" && !taskMeta.Placeholder && len(app.getTaskGroups()) > 0 {
+		taskMeta.TaskGroupName = ctx.getInferredTaskGroupName(pod, app)
+	}
 
 	// add task if it doesn't already exist
 	if task := app.GetTask(string(pod.UID)); task == nil {
@@ -408,7 +569,8 @@ func (ctx *Context) updateForeignPod(pod *v1.Pod) {
 				zap.String("podName", pod.Name),
 				zap.String("podStatusBefore", podStatusBefore),
 				zap.String("podStatusCurrent", string(pod.Status.Phase)))
-			allocReq := common.CreateAllocationForForeignPod(pod)
+			allocReq := common.CreateAllocationForForeignPod(pod, ctx.getNodePartition(pod.Spec.NodeName),
+				ctx.getResourceAccountingPolicy(pod.Namespace, ""))
 			if err := ctx.apiProvider.GetAPIs().SchedulerAPI.UpdateAllocation(allocReq); err != nil {
 				log.Log(log.ShimContext).Error("failed to add foreign allocation to the core",
 					zap.Error(err))
@@ -437,7 +599,7 @@ func (ctx *Context) updateForeignPod(pod *v1.Pod) {
 			// this means pod is terminated
 			// remove from the scheduler cache and create release request to remove foreign allocation from the core
 			ctx.schedulerCache.RemovePod(pod)
-			releaseReq := common.CreateReleaseRequestForForeignPod(string(pod.UID), constants.DefaultPartition)
+			releaseReq := common.CreateReleaseRequestForForeignPod(string(pod.UID), ctx.getNodePartition(pod.Spec.NodeName))
 			if err := ctx.apiProvider.GetAPIs().SchedulerAPI.UpdateAllocation(releaseReq); err != nil {
 				log.Log(log.ShimContext).Error("failed to remove foreign allocation from the core",
 					zap.Error(err))
@@ -486,12 +648,13 @@ func (ctx *Context) deleteYuniKornPod(pod *v1.Pod) {
 
 	log.Log(log.ShimContext).Debug("removing pod from cache", zap.String("podName", pod.Name))
 	ctx.schedulerCache.RemovePod(pod)
+	ctx.pdbRejections.remove(string(pod.UID))
 }
 
 func (ctx *Context) deleteForeignPod(pod *v1.Pod) {
 	ctx.lock.Lock()
 	defer ctx.lock.Unlock()
-	releaseReq := common.CreateReleaseRequestForForeignPod(string(pod.UID), constants.DefaultPartition)
+	releaseReq := common.CreateReleaseRequestForForeignPod(string(pod.UID), ctx.getNodePartition(pod.Spec.NodeName))
 	if err := ctx.apiProvider.GetAPIs().SchedulerAPI.UpdateAllocation(releaseReq); err != nil {
 		log.Log(log.ShimContext).Error("failed to remove foreign allocation from the core",
 			zap.Error(err))
@@ -648,16 +811,50 @@ func (ctx *Context) setConfigMap(index int, configMap *v1.ConfigMap) map[string]
 	ctx.lock.Lock()
 	defer ctx.lock.Unlock()
 	ctx.configMaps[index] = configMap
+	prevDrainTaintKeys := schedulerconf.GetSchedulerConf().GetNodeDrainTaintKeys()
+	prevCapacities := ctx.getNodeCapacities()
 	err := schedulerconf.UpdateConfigMaps(ctx.configMaps, false)
 	if err != nil {
 		log.Log(log.ShimContext).Error("Unable to update configmap, ignoring changes", zap.Error(err))
 		return nil
 	}
+	ctx.updatePredicateManager()
+	ctx.updateNodesSchedulable(prevDrainTaintKeys, schedulerconf.GetSchedulerConf().GetNodeDrainTaintKeys())
+	ctx.updateNodesCapacity(prevCapacities)
 	return schedulerconf.FlattenConfigMaps(ctx.configMaps)
 }
 
+// updatePredicateManager rebuilds the predicate manager if the predicate configuration has changed. The current
+// predicate manager is kept if the new configuration cannot be applied.
+// In plugin mode the events the plugin registers with the scheduler framework are fixed at startup and depend on the
+// allocation plugins: changes to the allocation and out-of-tree plugin lists require a restart.
+// Must be called while holding the context lock.
+func (ctx *Context) updatePredicateManager() {
+	predConf := schedulerconf.GetSchedulerConf().GetPredicatesConf()
+	if utils.IsPluginMode() && (predConf.Allocation != ctx.predConf.Allocation || predConf.Plugins != ctx.predConf.Plugins) {
+		log.Log(log.ShimContext).Warn("ignoring predicate plugin change in plugin mode (restart required to update)",
+			zap.String("allocation", predConf.Allocation),
+			zap.String("plugins", predConf.Plugins))
+		predConf.Allocation = ctx.predConf.Allocation
+		predConf.Plugins = ctx.predConf.Plugins
+	}
+	if predConf.Equal(ctx.predConf) {
+		return
+	}
+	predManager, err := predicates.NewPredicateManagerFromConfig(ctx.predHandle, predConf)
+	if err != nil {
+		log.Log(log.ShimContext).Error("Unable to apply predicate configuration, keeping current predicates", zap.Error(err))
+		return
+	}
+	log.Log(log.ShimContext).Info("predicate configuration updated")
+	ctx.predManager = predManager
+	ctx.predConf = predConf
+}
+
 // EventsToRegister returns the Kubernetes events that should be watched for updates which may effect predicate processing
 func (ctx *Context) EventsToRegister(queueingHintFn framework.QueueingHintFn) []framework.ClusterEventWithHint {
+	ctx.lock.RLock()
+	defer ctx.lock.RUnlock()
 	return ctx.predManager.EventsToRegister(queueingHintFn)
 }
 
@@ -701,9 +898,11 @@ func (ctx *Context) IsPodFitNodeViaPreemption(name, node string, allocations []s
 				victims[index] = victim
 			}
 
-			// check predicates for a match
+			// check predicates for a match, all victims up to the index are preempted
 			if index := ctx.predManager.PreemptionPredicates(pod, targetNode, victims, startIndex); index != -1 {
-				return index, true
+				if ctx.checkPreemptionPDBs(pod, node, victims[:index+1]) {
+					return index, true
+				}
 			}
 		}
 	}
@@ -997,7 +1196,10 @@ func (ctx *Context) addApplication(request *AddApplicationRequest) *Application
 		request.Metadata.Groups,
 		request.Metadata.Tags,
 		ctx.apiProvider.GetAPIs().SchedulerAPI)
-	app.setTaskGroups(request.Metadata.TaskGroups)
+	if request.Metadata.Partition != "" {
+		app.partition = request.Metadata.Partition
+	}
+	app.setTaskGroups(ctx.resolveTaskGroupOverheads(request.Metadata.TaskGroups))
 	app.setTaskGroupsDefinition(request.Metadata.Tags[constants.AnnotationTaskGroups])
 	app.setSchedulingParamsDefinition(request.Metadata.Tags[constants.AnnotationSchedulingPolicyParam])
 	if request.Metadata.CreationTime != 0 {
@@ -1007,6 +1209,22 @@ func (ctx *Context) addApplication(request *AddApplicationRequest) *Application
 		app.SetPlaceholderTimeout(request.Metadata.SchedulingPolicyParameters.GetPlaceholderTimeout())
 		app.setSchedulingStyle(request.Metadata.SchedulingPolicyParameters.GetGangSchedulingStyle())
 	}
+	if maxRuntime := ctx.getMaxRuntime(request); maxRuntime > 0 {
+		app.maxRuntime = maxRuntime
+		app.maxRuntimeFixed = true
+	} else {
+		// placement rules can pick a different queue, the default is set again once the core placed the app
+		app.maxRuntime = schedulerconf.GetSchedulerConf().GetQueueMaxRuntime(request.Metadata.QueueName)
+	}
+	if policy := ctx.getNamespaceResourceAccountingPolicy(request.Metadata.Tags[constants.AppTagNamespace]); policy != "" {
+		app.resourceAccountingPolicy = policy
+		app.resourceAccountingFixed = true
+	} else {
+		// placement rules can pick a different queue, the policy is set again once the core placed the app
+		app.resourceAccountingPolicy = schedulerconf.GetSchedulerConf().GetResourceAccountingPolicy(request.Metadata.QueueName)
+	}
+	app.schedulerCache = ctx.schedulerCache
+	ctx.joinAppGroup(app, request.Metadata.AppGroup, request.Metadata.AppGroupSize)
 	app.setPlaceholderOwnerReferences(request.Metadata.OwnerReferences)
 
 	// add into cache
@@ -1017,6 +1235,115 @@ func (ctx *Context) addApplication(request *AddApplicationRequest) *Application
 	return app
 }
 
+// getMaxRuntime returns the max runtime set for the application in order of precedence: the pod annotation or
+// scheduling policy parameter and the namespace annotation. Returns 0 if the queue default applies.
+func (ctx *Context) getMaxRuntime(request *AddApplicationRequest) time.Duration {
+	if params := request.Metadata.SchedulingPolicyParameters; params != nil && params.GetMaxRuntime() > 0 {
+		return params.GetMaxRuntime()
+	}
+	if ns, ok := request.Metadata.Tags[constants.AppTagNamespace]; ok {
+		if namespaceObj := ctx.getNamespaceObject(ns); namespaceObj != nil {
+			if maxRuntime := utils.GetNamespaceMaxRuntimeFromAnnotation(namespaceObj); maxRuntime > 0 {
+				return maxRuntime
+			}
+		}
+	}
+	return 0
+}
+
+// getResourceAccountingPolicy returns the resource accounting policy for pods in the namespace and queue in order
+// of precedence: the namespace annotation, the queue and the global policy.
+func (ctx *Context) getResourceAccountingPolicy(namespace, queue string) string {
+	if policy := ctx.getNamespaceResourceAccountingPolicy(namespace); policy != "" {
+		return policy
+	}
+	return schedulerconf.GetSchedulerConf().GetResourceAccountingPolicy(queue)
+}
+
+// getNamespaceResourceAccountingPolicy returns the resource accounting policy set on the namespace, an empty string
+// if it is not set
+func (ctx *Context) getNamespaceResourceAccountingPolicy(namespace string) string {
+	if namespaceObj := ctx.getNamespaceObject(namespace); namespaceObj != nil {
+		return utils.GetNamespaceResourceAccountingPolicyFromAnnotation(namespaceObj)
+	}
+	return ""
+}
+
+// resolveTaskGroupsRef sets the task groups from the ConfigMap referenced by the pod. The inline task groups
+// annotation takes precedence: placeholders carry the resolved definition inline, which keeps recovery working after
+// the ConfigMap changed or was removed. The reference is only resolved when the application is created.
+func (ctx *Context) resolveTaskGroupsRef(pod *v1.Pod, appMeta *ApplicationMetadata) {
+	informer := ctx.apiProvider.GetAPIs().TaskGroupConfigMapInformer
+	if informer == nil || schedulerconf.GetSchedulerConf().DisableGangScheduling ||
+		utils.GetPodAnnotationValue(pod, constants.AnnotationTaskGroups) != "" {
+		return
+	}
+	taskGroups, definition, err := GetTaskGroupsFromReference(pod, informer.Lister())
+	if err != nil {
+		log.Log(log.ShimContext).Error("unable to get referenced taskGroups for pod",
+			zap.String("namespace", pod.Namespace),
+			zap.String("name", pod.Name),
+			zap.Error(err))
+		events.GetRecorder().Eventf(pod, nil, v1.EventTypeWarning, "GangScheduling", "TaskGroupsError",
+			"unable to get taskGroups for pod, reason: %s", err.Error())
+		return
+	}
+	if len(taskGroups) == 0 {
+		return
+	}
+	appMeta.TaskGroups = taskGroups
+	appMeta.Tags[constants.AnnotationTaskGroups] = definition
+}
+
+// resolveTaskGroupOverheads returns a copy of the task groups with the pod overhead of their runtime class set.
+// The placeholders and the placeholder ask of the application then include the overhead the real pods have.
+func (ctx *Context) resolveTaskGroupOverheads(taskGroups []TaskGroup) []TaskGroup {
+	informer := ctx.apiProvider.GetAPIs().RuntimeClassInformer
+	if informer == nil || len(taskGroups) == 0 {
+		return taskGroups
+	}
+	resolved := make([]TaskGroup, len(taskGroups))
+	copy(resolved, taskGroups)
+	for i := range resolved {
+		if resolved[i].RuntimeClassName == "" {
+			continue
+		}
+		runtimeClass, err := informer.Lister().Get(resolved[i].RuntimeClassName)
+		if err != nil {
+			log.Log(log.ShimContext).Warn("runtime class of task group not found, placeholders have no overhead",
+				zap.String("taskGroup", resolved[i].Name),
+				zap.String("runtimeClass", resolved[i].RuntimeClassName),
+				zap.Error(err))
+			continue
+		}
+		if runtimeClass.Overhead != nil {
+			resolved[i].Overhead = runtimeClass.Overhead.PodFixed
+		}
+	}
+	return resolved
+}
+
+// getTaskPendingTimeout returns how long the pod may wait for an allocation, 0 means no timeout.
+// The pod annotation takes precedence over the namespace annotation, which takes precedence over the global setting.
+func (ctx *Context) getTaskPendingTimeout(pod *v1.Pod) time.Duration {
+	if value := utils.GetPodAnnotationValue(pod, constants.AnnotationTaskPendingTimeout); value != "" {
+		timeout, err := utils.ParseDurationOrSeconds(value)
+		if err == nil {
+			return timeout
+		}
+		log.Log(log.ShimContext).Warn("invalid task pending timeout annotation on pod",
+			zap.String("podName", pod.Name),
+			zap.String("value", value),
+			zap.Error(err))
+	}
+	if namespaceObj := ctx.getNamespaceObject(pod.Namespace); namespaceObj != nil {
+		if timeout := utils.GetNamespaceTaskPendingTimeoutFromAnnotation(namespaceObj); timeout > 0 {
+			return timeout
+		}
+	}
+	return schedulerconf.GetSchedulerConf().GetTaskPendingTimeout()
+}
+
 func (ctx *Context) IsPreemptSelfAllowed(priorityClassName string) bool {
 	priorityClass := ctx.schedulerCache.GetPriorityClass(priorityClassName)
 	if priorityClass == nil {
@@ -1050,6 +1377,8 @@ func (ctx *Context) RemoveApplication(appID string) {
 		log.Log(log.ShimContext).Debug("Attempted to remove non-existent application", zap.String("appID", appID))
 		return
 	}
+	ctx.leaveAppGroup(ctx.applications[appID])
+	ctx.applications[appID].stopTimers()
 	delete(ctx.applications, appID)
 }
 
@@ -1183,6 +1512,15 @@ func (ctx *Context) PublishEvents(eventRecords []*si.EventRecord) {
 				}
 				events.GetRecorder().Eventf(node.DeepCopy(), nil,
 					v1.EventTypeNormal, "Informational", "Informational", record.Message)
+			case si.EventRecord_QUEUE:
+				// the core reports the queue an application is placed in, the queue is not known to the
+				// shim when placement rules pick it
+				if record.EventChangeType == si.EventRecord_ADD && record.EventChangeDetail == si.EventRecord_QUEUE_APP {
+					if app := ctx.GetApplication(record.ReferenceID); app != nil {
+						app.setPlacedQueue(record.ObjectID)
+						app.setPlacedQueueResourceAccounting(record.ObjectID)
+					}
+				}
 			}
 		}
 	}
@@ -1218,7 +1556,7 @@ func (ctx *Context) HandleContainerStateUpdate(request *si.UpdateContainerSchedu
 		case si.UpdateContainerSchedulingStateRequest_SKIPPED:
 			// auto-scaler scans pods whose pod condition is PodScheduled=false && reason=Unschedulable
 			// if the pod is skipped because the queue quota has been exceeded, we do not trigger the auto-scaling
-			task.SetTaskSchedulingState(TaskSchedSkipped)
+			task.setSchedulingStateAndReason(TaskSchedSkipped, request.Reason)
 			ctx.schedulerCache.NotifyTaskSchedulerAction(task.taskID)
 			if ctx.updatePodCondition(task,
 				&v1.PodCondition{
@@ -1232,7 +1570,7 @@ func (ctx *Context) HandleContainerStateUpdate(request *si.UpdateContainerSchedu
 					"Task %s is skipped from scheduling because the queue quota has been exceed", task.alias)
 			}
 		case si.UpdateContainerSchedulingStateRequest_FAILED:
-			task.SetTaskSchedulingState(TaskSchedFailed)
+			task.setSchedulingStateAndReason(TaskSchedFailed, request.Reason)
 			ctx.schedulerCache.NotifyTaskSchedulerAction(task.taskID)
 			// set pod condition to Unschedulable in order to trigger auto-scaling
 			if ctx.updatePodCondition(task,
@@ -1264,6 +1602,9 @@ func (ctx *Context) ApplicationEventHandler() func(obj interface{}) {
 				return
 			}
 
+			if app.deferEvent(event) {
+				return
+			}
 			if app.canHandle(event) {
 				if err := app.handle(event); err != nil {
 					log.Log(log.ShimContext).Error("failed to handle application event",
@@ -1342,8 +1683,10 @@ func (ctx *Context) LoadConfigMaps() ([]*v1.ConfigMap, error) {
 func (ctx *Context) GetStateDump() (string, error) {
 	log.Log(log.ShimContext).Info("State dump requested")
 
+	cacheDao := ctx.schedulerCache.GetSchedulerCacheDao()
 	dump := map[string]interface{}{
-		"cache": ctx.schedulerCache.GetSchedulerCacheDao(),
+		"cache":      cacheDao,
+		"partitions": ctx.getPartitionsDao(cacheDao.Nodes),
 	}
 
 	bytes, err := json.Marshal(dump)
@@ -1353,6 +1696,37 @@ func (ctx *Context) GetStateDump() (string, error) {
 	return string(bytes), nil
 }
 
+// partitionDao lists the nodes and applications the shim has mapped to a core partition
+type partitionDao struct {
+	Nodes        []string `json:"nodes,omitempty"`
+	Applications []string `json:"applications,omitempty"`
+}
+
+func (ctx *Context) getPartitionsDao(nodes map[string]schedulercache.NodeDao) map[string]*partitionDao {
+	partitions := make(map[string]*partitionDao)
+	getPartition := func(name string) *partitionDao {
+		if _, ok := partitions[name]; !ok {
+			partitions[name] = &partitionDao{}
+		}
+		return partitions[name]
+	}
+	ctx.lock.RLock()
+	for name := range nodes {
+		partition := getPartition(ctx.getNodePartition(name))
+		partition.Nodes = append(partition.Nodes, name)
+	}
+	for appID, app := range ctx.applications {
+		partition := getPartition(app.partition)
+		partition.Applications = append(partition.Applications, appID)
+	}
+	ctx.lock.RUnlock()
+	for _, partition := range partitions {
+		sort.Strings(partition.Nodes)
+		sort.Strings(partition.Applications)
+	}
+	return partitions
+}
+
 func isPublishableNodeEvent(event *si.EventRecord) bool {
 	// we only send node added & removed event
 	if event.Type == si.EventRecord_NODE &&
@@ -1518,19 +1892,24 @@ func (ctx *Context) RegisterNodes(nodes []*v1.Node) ([]*v1.Node, error) {
 func (ctx *Context) registerNodes(nodes []*v1.Node) ([]*v1.Node, error) {
 	nodesToRegister := make([]*si.NodeInfo, 0)
 	pendingNodes := make(map[string]*v1.Node)
+	attributes := make(map[string]map[string]string)
 
 	// Generate a NodeInfo object for each node and add to the registration request
 	for _, node := range nodes {
-		log.Log(log.ShimContext).Info("Registering node", zap.String("name", node.Name))
-		nodeStatus := node.Status
+		// a node registered again keeps its partition
+		partition, ok := ctx.nodeAttributes[node.Name][siCommon.NodePartition]
+		if !ok {
+			partition = schedulerconf.GetSchedulerConf().GetNodePartition(node.Labels)
+		}
+		attributes[node.Name] = getNodeAttributes(node, partition)
+		log.Log(log.ShimContext).Info("Registering node",
+			zap.String("name", node.Name),
+			zap.String("partition", partition))
 		nodesToRegister = append(nodesToRegister, &si.NodeInfo{
-			NodeID: node.Name,
-			Action: si.NodeInfo_CREATE_DRAIN,
-			Attributes: map[string]string{
-				constants.DefaultNodeAttributeHostNameKey: node.Name,
-				constants.DefaultNodeAttributeRackNameKey: constants.DefaultRackName,
-			},
-			SchedulableResource: common.GetNodeResource(&nodeStatus),
+			NodeID:              node.Name,
+			Action:              si.NodeInfo_CREATE_DRAIN,
+			Attributes:          maps.Clone(attributes[node.Name]), // the core updates the partition in the request
+			SchedulableResource: common.GetNodeCapacity(node),
 		})
 		pendingNodes[node.Name] = node
 	}
@@ -1542,6 +1921,8 @@ func (ctx *Context) registerNodes(nodes []*v1.Node) ([]*v1.Node, error) {
 	}
 
 	for _, node := range acceptedNodes {
+		// the node stays in this partition until it is removed, label changes do not move it
+		ctx.nodeAttributes[node.Name] = attributes[node.Name]
 		// post a successful event to the node
 		events.GetRecorder().Eventf(node.DeepCopy(), nil, v1.EventTypeNormal, "NodeAccepted", "NodeAccepted",
 			fmt.Sprintf("node %s is accepted by the scheduler", node.Name))
@@ -1607,16 +1988,60 @@ func (ctx *Context) registerNodesInternal(nodesToRegister []*si.NodeInfo, pendin
 	return acceptedNodes, rejectedNodes, nil
 }
 
+// getTaskAliasAndQueue returns the alias and the queue of the task with the given allocation key
+func (ctx *Context) getTaskAliasAndQueue(allocationKey string) (string, string, bool) {
+	if allocationKey == "" {
+		return "", "", false
+	}
+	pod := ctx.schedulerCache.GetPod(allocationKey)
+	if pod == nil {
+		return "", "", false
+	}
+	taskMeta, ok := getTaskMetadata(pod)
+	if !ok {
+		return "", "", false
+	}
+	app := ctx.GetApplication(taskMeta.ApplicationID)
+	if app == nil {
+		return "", "", false
+	}
+	return fmt.Sprintf("%s/%s", pod.Namespace, pod.Name), app.GetQueue(), true
+}
+
+// getNodePartition returns the partition of a node known to the cache, the default partition if it is not known
+func (ctx *Context) getNodePartition(nodeName string) string {
+	if partition, ok := ctx.nodeAttributes[nodeName][siCommon.NodePartition]; ok {
+		return partition
+	}
+	return constants.DefaultPartition
+}
+
 func (ctx *Context) decommissionNode(node *v1.Node) error {
-	request := common.CreateUpdateRequestForDeleteOrRestoreNode(node.Name, si.NodeInfo_DECOMISSION)
+	request := common.CreateUpdateRequestForDeleteOrRestoreNode(node.Name, ctx.getNodePartition(node.Name), si.NodeInfo_DECOMISSION)
 	return ctx.apiProvider.GetAPIs().SchedulerAPI.UpdateNode(request)
 }
 
 func (ctx *Context) updateNodeResources(node *v1.Node, capacity *si.Resource) error {
-	request := common.CreateUpdateRequestForUpdatedNode(node.Name, capacity)
+	request := common.CreateUpdateRequestForUpdatedNode(node.Name, ctx.getNodePartition(node.Name), capacity)
 	return ctx.apiProvider.GetAPIs().SchedulerAPI.UpdateNode(request)
 }
 
+// getNodeAttributes returns the attributes of the node that are sent to the core: the host name, the
+// partition and the topology attributes mapped from the node labels. The rack defaults to the default rack.
+// The attributes are only sent at registration: the core does not support changing them, label changes made
+// after the node is registered are not seen by the core until the node is removed and added again.
+func getNodeAttributes(node *v1.Node, partition string) map[string]string {
+	attributes := map[string]string{
+		constants.DefaultNodeAttributeHostNameKey: node.Name,
+		constants.DefaultNodeAttributeRackNameKey: constants.DefaultRackName,
+	}
+	for key, value := range schedulerconf.GetSchedulerConf().GetNodeAttributes(node.Labels) {
+		attributes[key] = value
+	}
+	attributes[siCommon.NodePartition] = partition
+	return attributes
+}
+
 func (ctx *Context) enableNode(node *v1.Node) error {
 	return ctx.enableNodes([]*v1.Node{node})
 }
@@ -1626,11 +2051,22 @@ func (ctx *Context) enableNodes(nodes []*v1.Node) error {
 
 	// Generate a NodeInfo object for each node and add to the enablement request
 	for _, node := range nodes {
+		// unschedulable nodes stay drained until the condition clears
+		if reason := utils.GetNodeUnschedulableReason(node, schedulerconf.GetSchedulerConf().GetNodeDrainTaintKeys()); reason != "" {
+			log.Log(log.ShimContext).Info("Not enabling unschedulable node",
+				zap.String("name", node.Name),
+				zap.String("reason", reason))
+			events.GetRecorder().Eventf(node.DeepCopy(), nil, v1.EventTypeNormal, "NodeUnschedulable", "NodeUnschedulable",
+				fmt.Sprintf("node %s is unschedulable in the scheduler: %s", node.Name, reason))
+			continue
+		}
 		log.Log(log.ShimContext).Info("Enabling node", zap.String("name", node.Name))
 		nodesToEnable = append(nodesToEnable, &si.NodeInfo{
-			NodeID:     node.Name,
-			Action:     si.NodeInfo_DRAIN_TO_SCHEDULABLE,
-			Attributes: map[string]string{},
+			NodeID: node.Name,
+			Action: si.NodeInfo_DRAIN_TO_SCHEDULABLE,
+			Attributes: map[string]string{
+				siCommon.NodePartition: ctx.getNodePartition(node.Name),
+			},
 		})
 	}
 
@@ -1645,6 +2081,34 @@ func (ctx *Context) enableNodes(nodes []*v1.Node) error {
 	return nil
 }
 
+// drainNode stops the core from scheduling new allocations on the node, existing allocations are not affected
+func (ctx *Context) drainNode(node *v1.Node, reason string) {
+	log.Log(log.ShimContext).Info("Marking node unschedulable",
+		zap.String("nodeName", node.Name),
+		zap.String("reason", reason))
+	request := common.CreateUpdateRequestForDeleteOrRestoreNode(node.Name,
+		ctx.getNodePartition(node.Name), si.NodeInfo_DRAIN_NODE)
+	if err := ctx.apiProvider.GetAPIs().SchedulerAPI.UpdateNode(request); err != nil {
+		log.Log(log.ShimContext).Warn("Failed to mark node unschedulable", zap.Error(err))
+		return
+	}
+	events.GetRecorder().Eventf(node.DeepCopy(), nil, v1.EventTypeNormal, "NodeUnschedulable", "NodeUnschedulable",
+		fmt.Sprintf("node %s is unschedulable in the scheduler: %s", node.Name, reason))
+}
+
+// restoreNode allows the core to schedule on a node that was drained by drainNode
+func (ctx *Context) restoreNode(node *v1.Node) {
+	log.Log(log.ShimContext).Info("Marking node schedulable", zap.String("nodeName", node.Name))
+	request := common.CreateUpdateRequestForDeleteOrRestoreNode(node.Name,
+		ctx.getNodePartition(node.Name), si.NodeInfo_DRAIN_TO_SCHEDULABLE)
+	if err := ctx.apiProvider.GetAPIs().SchedulerAPI.UpdateNode(request); err != nil {
+		log.Log(log.ShimContext).Warn("Failed to mark node schedulable", zap.Error(err))
+		return
+	}
+	events.GetRecorder().Eventf(node.DeepCopy(), nil, v1.EventTypeNormal, "NodeSchedulable", "NodeSchedulable",
+		fmt.Sprintf("node %s is schedulable in the scheduler", node.Name))
+}
+
 func (ctx *Context) finalizeNodes(existingNodes []*v1.Node) error {
 	// list all nodes via the informer
 	nodes, err := ctx.apiProvider.GetAPIs().NodeInformer.Lister().List(labels.Everything())
@@ -1739,7 +2203,7 @@ func (ctx *Context) finalizePods(existingPods []*v1.Pod) error {
 }
 
 // for a given pod, return an allocation if found
-func getExistingAllocation(pod *v1.Pod) *si.Allocation {
+func (ctx *Context) getExistingAllocation(pod *v1.Pod) *si.Allocation {
 	// skip terminated pods
 	if utils.IsPodTerminated(pod) {
 		return nil
@@ -1758,12 +2222,12 @@ func getExistingAllocation(pod *v1.Pod) *si.Allocation {
 		return &si.Allocation{
 			AllocationKey:    string(pod.UID),
 			AllocationTags:   meta.Tags,
-			ResourcePerAlloc: common.GetPodResource(pod),
+			ResourcePerAlloc: common.GetPodResourceForPolicy(pod, ctx.getResourceAccountingPolicy(pod.Namespace, meta.QueueName)),
 			NodeID:           pod.Spec.NodeName,
 			ApplicationID:    meta.ApplicationID,
 			Placeholder:      placeholder,
 			TaskGroupName:    taskGroupName,
-			PartitionName:    constants.DefaultPartition,
+			PartitionName:    meta.Partition,
 		}
 	}
 	return nil
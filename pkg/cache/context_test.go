@@ -28,6 +28,7 @@ import (
 
 	"gotest.tools/v3/assert"
 	v1 "k8s.io/api/core/v1"
+	nodev1 "k8s.io/api/node/v1"
 	schedulingv1 "k8s.io/api/scheduling/v1"
 	"k8s.io/apimachinery/pkg/api/resource"
 	apis "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -41,6 +42,7 @@ import (
 	"github.com/apache/yunikorn-k8shim/pkg/common/events"
 	"github.com/apache/yunikorn-k8shim/pkg/common/test"
 	"github.com/apache/yunikorn-k8shim/pkg/common/utils"
+	schedulerconf "github.com/apache/yunikorn-k8shim/pkg/conf"
 	"github.com/apache/yunikorn-k8shim/pkg/dispatcher"
 	"github.com/apache/yunikorn-k8shim/pkg/log"
 	siCommon "github.com/apache/yunikorn-scheduler-interface/lib/go/common"
@@ -212,6 +214,151 @@ func TestUpdateNodes(t *testing.T) {
 	ctx.updateNode(&oldNode, &newNode)
 }
 
+func TestUpdateNodeLabels(t *testing.T) {
+	ctx, apiProvider := initContextAndAPIProviderForTest()
+	dispatcher.Start()
+	defer dispatcher.UnregisterAllEventHandlers()
+	defer dispatcher.Stop()
+	var nodeRequests []*si.NodeRequest
+	apiProvider.MockSchedulerAPIUpdateNodeFn(func(request *si.NodeRequest) error {
+		nodeRequests = append(nodeRequests, request)
+		for _, node := range request.Nodes {
+			if node.Action == si.NodeInfo_CREATE_DRAIN {
+				dispatcher.Dispatch(CachedSchedulerNodeEvent{NodeID: node.NodeID, Event: NodeAccepted})
+			}
+		}
+		return nil
+	})
+	var allocRequests []*si.AllocationRequest
+	apiProvider.MockSchedulerAPIUpdateAllocationFn(func(request *si.AllocationRequest) error {
+		allocRequests = append(allocRequests, request)
+		return nil
+	})
+
+	oldNode := &v1.Node{
+		ObjectMeta: apis.ObjectMeta{
+			Name:   Host1,
+			Labels: map[string]string{v1.LabelTopologyZone: "zone-a"},
+		},
+	}
+	ctx.addNode(oldNode)
+	assert.Equal(t, nodeRequests[0].Nodes[0].Action, si.NodeInfo_CREATE_DRAIN)
+	nodeInfo := nodeRequests[0].Nodes[0]
+	assert.Equal(t, nodeInfo.Attributes[constants.NodeAttributeZoneKey], "zone-a")
+	assert.Equal(t, nodeInfo.Attributes[constants.DefaultNodeAttributeRackNameKey], constants.DefaultRackName)
+	assert.Equal(t, nodeInfo.Attributes[siCommon.NodePartition], constants.DefaultPartition)
+	foreignPod := newPodHelper("foreign", "default", "uid-foreign", Host1, "", v1.PodRunning)
+	foreignPod.Spec.SchedulerName = "default-scheduler"
+	ctx.AddPod(foreignPod)
+	nodeRequests = nil
+	allocRequests = nil
+
+	// no changes: nothing is sent to the core
+	ctx.updateNode(oldNode, oldNode.DeepCopy())
+	assert.Equal(t, len(nodeRequests), 0)
+
+	// label change: the core ignores attributes in an update, nothing is sent and the node is left alone
+	newNode := oldNode.DeepCopy()
+	newNode.Labels[v1.LabelTopologyZone] = "zone-b"
+	ctx.updateNode(oldNode, newNode)
+	assert.Equal(t, len(nodeRequests), 0)
+	assert.Equal(t, len(allocRequests), 0)
+	assert.Equal(t, ctx.nodeAttributes[Host1][constants.NodeAttributeZoneKey], "zone-a")
+
+	// a capacity change only sends the capacity
+	resizedNode := newNode.DeepCopy()
+	resizedNode.Status.Allocatable = v1.ResourceList{v1.ResourceCPU: resource.MustParse("2")}
+	ctx.updateNode(newNode, resizedNode)
+	assert.Equal(t, len(nodeRequests), 1)
+	nodeInfo = nodeRequests[0].Nodes[0]
+	assert.Equal(t, nodeInfo.Action, si.NodeInfo_UPDATE)
+	assert.Assert(t, nodeInfo.SchedulableResource != nil, "capacity should be sent")
+	assert.DeepEqual(t, nodeInfo.Attributes, map[string]string{siCommon.NodePartition: constants.DefaultPartition})
+}
+
+func TestUpdateNodeSchedulable(t *testing.T) {
+	t.Cleanup(func() {
+		err := schedulerconf.UpdateConfigMaps([]*v1.ConfigMap{nil, nil}, true)
+		assert.NilError(t, err, "UpdateConfigMap failed")
+	})
+	ctx, apiProvider := initContextAndAPIProviderForTest()
+	var actions []si.NodeInfo_ActionFromRM
+	apiProvider.MockSchedulerAPIUpdateNodeFn(func(request *si.NodeRequest) error {
+		for _, node := range request.Nodes {
+			actions = append(actions, node.Action)
+		}
+		return nil
+	})
+	eventCount := 0
+	mr := events.NewMockedRecorder()
+	mr.OnEventf = func() {
+		eventCount++
+	}
+	events.SetRecorder(mr)
+	defer events.SetRecorder(events.NewMockedRecorder())
+
+	node := &v1.Node{
+		ObjectMeta: apis.ObjectMeta{Name: Host1},
+		Status: v1.NodeStatus{
+			Conditions: []v1.NodeCondition{{Type: v1.NodeReady, Status: v1.ConditionTrue}},
+		},
+	}
+	ctx.updateNodeInternal(node, false)
+	ctx.nodeAttributes[Host1] = getNodeAttributes(node, constants.DefaultPartition)
+	assert.DeepEqual(t, actions, []si.NodeInfo_ActionFromRM{si.NodeInfo_DRAIN_TO_SCHEDULABLE})
+
+	// cordon drains the node, uncordon restores it
+	actions = nil
+	cordoned := node.DeepCopy()
+	cordoned.Spec.Unschedulable = true
+	ctx.updateNodeInternal(cordoned, false)
+	assert.DeepEqual(t, actions, []si.NodeInfo_ActionFromRM{si.NodeInfo_DRAIN_NODE})
+	assert.Equal(t, eventCount, 1)
+	// going NotReady while cordoned does not change the state in the core
+	notReady := cordoned.DeepCopy()
+	notReady.Status.Conditions[0].Status = v1.ConditionFalse
+	ctx.updateNodeInternal(notReady, false)
+	assert.DeepEqual(t, actions, []si.NodeInfo_ActionFromRM{si.NodeInfo_DRAIN_NODE})
+	assert.Equal(t, eventCount, 1)
+	// uncordon while NotReady keeps the node drained, the new reason is published
+	notReady = notReady.DeepCopy()
+	notReady.Spec.Unschedulable = false
+	ctx.updateNodeInternal(notReady, false)
+	assert.DeepEqual(t, actions, []si.NodeInfo_ActionFromRM{si.NodeInfo_DRAIN_NODE})
+	assert.Equal(t, eventCount, 2)
+	ctx.updateNodeInternal(node.DeepCopy(), false)
+	assert.DeepEqual(t, actions, []si.NodeInfo_ActionFromRM{si.NodeInfo_DRAIN_NODE, si.NodeInfo_DRAIN_TO_SCHEDULABLE})
+
+	// only node condition taints drain the node by default
+	actions = nil
+	tainted := node.DeepCopy()
+	tainted.Spec.Taints = []v1.Taint{{Key: "maintenance", Effect: v1.TaintEffectNoSchedule}}
+	ctx.updateNodeInternal(tainted, false)
+	assert.Equal(t, len(actions), 0)
+	conditionTainted := tainted.DeepCopy()
+	conditionTainted.Spec.Taints = append(conditionTainted.Spec.Taints, v1.Taint{Key: v1.TaintNodeDiskPressure, Effect: v1.TaintEffectNoSchedule})
+	ctx.updateNodeInternal(conditionTainted, false)
+	assert.DeepEqual(t, actions, []si.NodeInfo_ActionFromRM{si.NodeInfo_DRAIN_NODE})
+	ctx.updateNodeInternal(tainted, false)
+	assert.DeepEqual(t, actions, []si.NodeInfo_ActionFromRM{si.NodeInfo_DRAIN_NODE, si.NodeInfo_DRAIN_TO_SCHEDULABLE})
+
+	// a reload of the drain taint keys re-evaluates the registered nodes
+	actions = nil
+	flat := ctx.setConfigMap(1, &v1.ConfigMap{Data: map[string]string{schedulerconf.CMSvcNodeDrainTaintKeys: "maintenance"}})
+	assert.Assert(t, flat != nil, "config map update failed")
+	assert.DeepEqual(t, actions, []si.NodeInfo_ActionFromRM{si.NodeInfo_DRAIN_NODE})
+	flat = ctx.setConfigMap(1, &v1.ConfigMap{Data: map[string]string{}})
+	assert.Assert(t, flat != nil, "config map update failed")
+	assert.DeepEqual(t, actions, []si.NodeInfo_ActionFromRM{si.NodeInfo_DRAIN_NODE, si.NodeInfo_DRAIN_TO_SCHEDULABLE})
+
+	// a new node that is unschedulable is not enabled
+	actions = nil
+	newNode := conditionTainted.DeepCopy()
+	newNode.Name = Host2
+	ctx.updateNodeInternal(newNode, false)
+	assert.Equal(t, len(actions), 0)
+}
+
 func TestDeleteNodes(t *testing.T) {
 	ctx, apiProvider := initContextAndAPIProviderForTest()
 	dispatcher.Start()
@@ -1126,6 +1273,172 @@ func TestGetTask(t *testing.T) {
 	assert.Assert(t, task == nil)
 }
 
+func TestSetConfigMapPredicates(t *testing.T) {
+	t.Cleanup(func() {
+		err := schedulerconf.UpdateConfigMaps([]*v1.ConfigMap{nil, nil}, true)
+		assert.NilError(t, err, "UpdateConfigMap failed")
+	})
+	context := initContextForTest()
+	predManager := context.predManager
+	assert.Equal(t, context.predConf.Reservation, schedulerconf.DefaultPredicatesReservation)
+
+	// no predicate changes: predicate manager is not rebuilt
+	flat := context.setConfigMap(1, &v1.ConfigMap{Data: map[string]string{schedulerconf.CMSvcPolicyGroup: "test"}})
+	assert.Assert(t, flat != nil, "config map update failed")
+	assert.Assert(t, predManager == context.predManager, "predicate manager should not have been replaced")
+
+	// changed predicates: predicate manager is rebuilt
+	flat = context.setConfigMap(1, &v1.ConfigMap{Data: map[string]string{schedulerconf.CMSvcPredicatesReservation: "NodeName"}})
+	assert.Assert(t, flat != nil, "config map update failed")
+	assert.Assert(t, predManager != context.predManager, "predicate manager should have been replaced")
+	assert.Equal(t, context.predConf.Reservation, "NodeName")
+
+	// unknown plugin: the whole update is rejected and the current predicate manager is kept
+	predManager = context.predManager
+	flat = context.setConfigMap(1, &v1.ConfigMap{Data: map[string]string{
+		schedulerconf.CMSvcPredicatesReservation: "Unknown",
+		schedulerconf.CMSvcPolicyGroup:           "rejected",
+	}})
+	assert.Assert(t, flat == nil, "config map update should have been rejected")
+	assert.Assert(t, predManager == context.predManager, "predicate manager should not have been replaced")
+	assert.Equal(t, context.predConf.Reservation, "NodeName")
+	assert.Equal(t, schedulerconf.GetSchedulerConf().GetPredicatesConf().Reservation, "NodeName")
+	assert.Assert(t, schedulerconf.GetSchedulerConf().PolicyGroup != "rejected", "other settings should not have been applied")
+
+	// plugin mode: the allocation plugins are fixed, the other predicate settings are applied
+	utils.SetPluginMode(true)
+	defer utils.SetPluginMode(false)
+	flat = context.setConfigMap(1, &v1.ConfigMap{Data: map[string]string{
+		schedulerconf.CMSvcPredicatesReservation: "NodeName,NodePorts",
+		schedulerconf.CMSvcPredicatesAllocation:  "NodeName",
+	}})
+	assert.Assert(t, flat != nil, "config map update failed")
+	assert.Assert(t, predManager != context.predManager, "predicate manager should have been replaced")
+	assert.Equal(t, context.predConf.Reservation, "NodeName,NodePorts")
+	assert.Equal(t, context.predConf.Allocation, schedulerconf.DefaultPredicatesAllocation)
+}
+
+func TestSetConfigMapNodeCapacity(t *testing.T) {
+	t.Cleanup(func() {
+		err := schedulerconf.UpdateConfigMaps([]*v1.ConfigMap{nil, nil}, true)
+		assert.NilError(t, err, "UpdateConfigMap failed")
+	})
+	context, apiProvider := initContextAndAPIProviderForTest()
+	var nodeRequests []*si.NodeRequest
+	apiProvider.MockSchedulerAPIUpdateNodeFn(func(request *si.NodeRequest) error {
+		nodeRequests = append(nodeRequests, request)
+		return nil
+	})
+	node := &v1.Node{
+		ObjectMeta: apis.ObjectMeta{Name: Host1, Labels: map[string]string{"pool": "dev"}},
+		Status: v1.NodeStatus{
+			Allocatable: v1.ResourceList{v1.ResourceCPU: resource.MustParse("4")},
+			Conditions:  []v1.NodeCondition{{Type: v1.NodeReady, Status: v1.ConditionTrue}},
+		},
+	}
+	context.updateNodeInternal(node, false)
+	context.nodeAttributes[Host1] = getNodeAttributes(node, constants.DefaultPartition)
+	nodeRequests = nil
+
+	// an unrelated change does not send the capacity
+	flat := context.setConfigMap(1, &v1.ConfigMap{Data: map[string]string{schedulerconf.CMSvcPlaceholderGCDryRun: "false"}})
+	assert.Assert(t, flat != nil, "config map update failed")
+	assert.Equal(t, len(nodeRequests), 0)
+
+	// a changed adjustment sends the new capacity of the matching nodes
+	flat = context.setConfigMap(1, &v1.ConfigMap{Data: map[string]string{
+		schedulerconf.CMSvcNodeCapacityAdjustmentPrefix + "dev": `{"nodeSelector": "pool=dev", "reserved": {"cpu": "1"}}`,
+	}})
+	assert.Assert(t, flat != nil, "config map update failed")
+	assert.Equal(t, len(nodeRequests), 1)
+	nodeInfo := nodeRequests[0].Nodes[0]
+	assert.Equal(t, nodeInfo.Action, si.NodeInfo_UPDATE)
+	assert.Equal(t, nodeInfo.SchedulableResource.Resources[siCommon.CPU].GetValue(), int64(3000))
+}
+
+func TestUpdateYuniKornPodResize(t *testing.T) {
+	recorder := k8sEvents.NewFakeRecorder(1024)
+	events.SetRecorder(recorder)
+	defer events.SetRecorder(events.NewMockedRecorder())
+	context, apiProvider := initContextAndAPIProviderForTest()
+	context.configMaps = []*v1.ConfigMap{nil, {Data: map[string]string{"queues.yaml": queueMoveConfig}}}
+
+	app := NewApplication(appID1, "root.d", testUser, testGroups, map[string]string{}, apiProvider.GetAPIs().SchedulerAPI)
+	context.addApplicationToContext(app)
+	pod := newPodHelper("pod-resize", "default", taskUID1, fakeNodeName, appID1, v1.PodRunning)
+	pod.Spec.Containers = []v1.Container{{
+		Name: "container-01",
+		Resources: v1.ResourceRequirements{
+			Requests: v1.ResourceList{v1.ResourceMemory: resource.MustParse("50M")},
+		},
+	}}
+	task := NewTask(taskUID1, app, context, pod)
+	task.sm.SetState(TaskStates().Bound)
+	app.addTask(task)
+
+	// resize within the queue max: no event, allocation updated in the core
+	resized := pod.DeepCopy()
+	resized.Spec.Containers[0].Resources.Requests[v1.ResourceMemory] = resource.MustParse("80M")
+	context.updateYuniKornPod(appID1, pod, resized, nil)
+	assert.Equal(t, len(recorder.Events), 0)
+	assert.Equal(t, apiProvider.GetSchedulerAPIUpdateAllocationCount(), int32(1))
+	assert.Equal(t, task.getTaskResource().Resources[siCommon.Memory].GetValue(), int64(80000000))
+
+	// resize over the queue max: the allocation is not changed and an event is published
+	overMax := resized.DeepCopy()
+	overMax.Spec.Containers[0].Resources.Requests[v1.ResourceMemory] = resource.MustParse("200M")
+	context.updateYuniKornPod(appID1, resized, overMax, nil)
+	assert.Equal(t, apiProvider.GetSchedulerAPIUpdateAllocationCount(), int32(1))
+	assert.Equal(t, task.getTaskResource().Resources[siCommon.Memory].GetValue(), int64(80000000))
+	assert.Equal(t, task.GetTaskPod(), overMax)
+	select {
+	case event := <-recorder.Events:
+		assert.Assert(t, strings.Contains(event, constants.PodStatusPodResizePending), event)
+	default:
+		t.Fatal("expected PodResizePending event")
+	}
+
+	// other updates of the pod do not publish the event again
+	updated := overMax.DeepCopy()
+	updated.Labels = map[string]string{"updated": "true"}
+	context.updateYuniKornPod(appID1, overMax, updated, nil)
+	assert.Equal(t, len(recorder.Events), 0)
+	assert.Equal(t, apiProvider.GetSchedulerAPIUpdateAllocationCount(), int32(1))
+
+	// shrinking is always accepted
+	context.updateYuniKornPod(appID1, updated, pod, nil)
+	assert.Equal(t, len(recorder.Events), 0)
+	assert.Equal(t, apiProvider.GetSchedulerAPIUpdateAllocationCount(), int32(2))
+	assert.Equal(t, task.getTaskResource().Resources[siCommon.Memory].GetValue(), int64(50000000))
+}
+
+func TestSetTaskPodUpdatesCoreOnlyForSubmittedTasks(t *testing.T) {
+	context, apiProvider := initContextAndAPIProviderForTest()
+	app := NewApplication(appID1, "root.a", testUser, testGroups, map[string]string{}, apiProvider.GetAPIs().SchedulerAPI)
+	pod := newPodHelper("pod-resize", "default", taskUID1, "", appID1, v1.PodPending)
+	task := NewTask(taskUID1, app, context, pod)
+	resized := pod.DeepCopy()
+	resized.Spec.Containers = []v1.Container{{
+		Name: "container-01",
+		Resources: v1.ResourceRequirements{
+			Requests: v1.ResourceList{v1.ResourceMemory: resource.MustParse("50M")},
+		},
+	}}
+
+	// a new task is not known to the core yet
+	task.SetTaskPod(resized)
+	assert.Equal(t, apiProvider.GetSchedulerAPIUpdateAllocationCount(), int32(0))
+
+	// a completed task must not be added back
+	task.sm.SetState(TaskStates().Completed)
+	task.SetTaskPod(pod)
+	assert.Equal(t, apiProvider.GetSchedulerAPIUpdateAllocationCount(), int32(0))
+
+	task.sm.SetState(TaskStates().Scheduling)
+	task.SetTaskPod(resized)
+	assert.Equal(t, apiProvider.GetSchedulerAPIUpdateAllocationCount(), int32(1))
+}
+
 func TestNodeEventFailsPublishingWithoutNode(t *testing.T) {
 	recorder := k8sEvents.NewFakeRecorder(1024)
 	events.SetRecorder(recorder)
@@ -1386,7 +1699,242 @@ func TestPublishEventsCorrectly(t *testing.T) {
 	assert.NilError(t, err, "event should have been emitted")
 }
 
+func TestPublishEventsApplicationPlaced(t *testing.T) {
+	context := initContextForTest()
+	app := context.AddApplication(&AddApplicationRequest{
+		Metadata: ApplicationMetadata{
+			ApplicationID: appID1,
+			User:          testUser,
+		},
+	})
+	assert.Equal(t, app.GetQueue(), "")
+
+	context.PublishEvents([]*si.EventRecord{
+		{
+			Type:              si.EventRecord_QUEUE,
+			ObjectID:          "root.other",
+			ReferenceID:       appID2,
+			EventChangeType:   si.EventRecord_ADD,
+			EventChangeDetail: si.EventRecord_QUEUE_APP,
+		},
+		{
+			Type:              si.EventRecord_QUEUE,
+			ObjectID:          "root.placed",
+			ReferenceID:       appID1,
+			EventChangeType:   si.EventRecord_ADD,
+			EventChangeDetail: si.EventRecord_QUEUE_APP,
+		},
+	})
+	assert.Equal(t, app.GetQueue(), "root.placed")
+}
+
+func TestAddApplicationResourceAccountingPolicy(t *testing.T) {
+	context := initContextForTest()
+	lister, ok := context.apiProvider.GetAPIs().NamespaceInformer.Lister().(*test.MockNamespaceLister)
+	if !ok {
+		t.Fatalf("could not mock NamespaceLister")
+	}
+	lister.Add(&v1.Namespace{
+		ObjectMeta: apis.ObjectMeta{
+			Name: "limits",
+			Annotations: map[string]string{
+				constants.NamespaceResourceAccountingPolicy: schedulerconf.ResourceAccountingLimits,
+			},
+		},
+	})
+	lister.Add(&v1.Namespace{
+		ObjectMeta: apis.ObjectMeta{
+			Name: "invalid",
+			Annotations: map[string]string{
+				constants.NamespaceResourceAccountingPolicy: "invalid",
+			},
+		},
+	})
+	err := schedulerconf.UpdateConfigMaps([]*v1.ConfigMap{nil, {Data: map[string]string{
+		schedulerconf.CMSvcResourceAccountingPolicy:                 schedulerconf.ResourceAccountingMax,
+		schedulerconf.CMSvcQueueResourceAccountingPrefix + "root.a": schedulerconf.ResourceAccountingFloor,
+	}}}, true)
+	assert.NilError(t, err, "failed to update configmap")
+	defer func() {
+		err = schedulerconf.UpdateConfigMaps([]*v1.ConfigMap{nil, nil}, true)
+		assert.NilError(t, err, "failed to reset configmap")
+	}()
+
+	tests := []struct {
+		name      string
+		appID     string
+		namespace string
+		queue     string
+		want      string
+	}{
+		{"namespace level", "app-1", "limits", queueNameA, schedulerconf.ResourceAccountingLimits},
+		{"queue level", "app-2", "invalid", queueNameA, schedulerconf.ResourceAccountingFloor},
+		{"global", "app-3", "unknown", "root.b", schedulerconf.ResourceAccountingMax},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			app := context.AddApplication(&AddApplicationRequest{
+				Metadata: ApplicationMetadata{
+					ApplicationID: tt.appID,
+					QueueName:     tt.queue,
+					User:          testUser,
+					Tags:          map[string]string{constants.AppTagNamespace: tt.namespace},
+				},
+			})
+			assert.Equal(t, app.resourceAccountingPolicy, tt.want)
+		})
+	}
+
+	// tasks of the application use the policy of the application
+	pod := newPodHelper("pod", "limits", "uid-1", "", "app-1", v1.PodPending)
+	pod.Spec.Containers = []v1.Container{{
+		Name: "c1",
+		Resources: v1.ResourceRequirements{
+			Requests: v1.ResourceList{v1.ResourceCPU: resource.MustParse("1")},
+			Limits:   v1.ResourceList{v1.ResourceCPU: resource.MustParse("2")},
+		},
+	}}
+	task := NewTask("uid-1", context.GetApplication("app-1"), context, pod)
+	assert.Equal(t, task.getTaskResource().Resources[siCommon.CPU].GetValue(), int64(2000))
+
+	// the policy of the queue the core places the application in applies, unless it is set on the namespace
+	app := context.AddApplication(&AddApplicationRequest{
+		Metadata: ApplicationMetadata{
+			ApplicationID: "app-4",
+			User:          testUser,
+			Tags:          map[string]string{constants.AppTagNamespace: "unknown"},
+		},
+	})
+	assert.Equal(t, app.resourceAccountingPolicy, schedulerconf.ResourceAccountingMax)
+	task = NewTask("uid-4", app, context, pod)
+	app.addTask(task)
+	assert.Equal(t, task.getTaskResource().Resources[siCommon.CPU].GetValue(), int64(2000))
+	placed := func(appID string) *si.EventRecord {
+		return &si.EventRecord{
+			Type:              si.EventRecord_QUEUE,
+			ObjectID:          queueNameA,
+			ReferenceID:       appID,
+			EventChangeType:   si.EventRecord_ADD,
+			EventChangeDetail: si.EventRecord_QUEUE_APP,
+		}
+	}
+	context.PublishEvents([]*si.EventRecord{placed("app-1"), placed("app-4")})
+	assert.Equal(t, context.GetApplication("app-1").resourceAccountingPolicy, schedulerconf.ResourceAccountingLimits)
+	assert.Equal(t, app.resourceAccountingPolicy, schedulerconf.ResourceAccountingFloor)
+	assert.Equal(t, task.getTaskResource().Resources[siCommon.CPU].GetValue(), int64(1000))
+}
+
 //nolint:funlen
+func TestAddApplicationMaxRuntime(t *testing.T) {
+	context := initContextForTest()
+	lister, ok := context.apiProvider.GetAPIs().NamespaceInformer.Lister().(*test.MockNamespaceLister)
+	if !ok {
+		t.Fatalf("could not mock NamespaceLister")
+	}
+	lister.Add(&v1.Namespace{
+		ObjectMeta: apis.ObjectMeta{
+			Name: "limited",
+			Annotations: map[string]string{
+				constants.NamespaceMaxRuntime: "3h",
+			},
+		},
+	})
+	lister.Add(&v1.Namespace{
+		ObjectMeta: apis.ObjectMeta{
+			Name: "unlimited",
+		},
+	})
+	err := schedulerconf.UpdateConfigMaps([]*v1.ConfigMap{nil, {Data: map[string]string{
+		schedulerconf.CMSvcQueueMaxRuntimePrefix + "root.a": "4h",
+	}}}, true)
+	assert.NilError(t, err, "failed to update configmap")
+	defer func() {
+		err = schedulerconf.UpdateConfigMaps([]*v1.ConfigMap{nil, nil}, true)
+		assert.NilError(t, err, "failed to reset configmap")
+	}()
+
+	tests := []struct {
+		name      string
+		appID     string
+		namespace string
+		params    *SchedulingPolicyParameters
+		want      time.Duration
+	}{
+		{"pod level", "app-1", "limited", &SchedulingPolicyParameters{maxRuntime: time.Hour}, time.Hour},
+		{"namespace level", "app-2", "limited", nil, 3 * time.Hour},
+		{"queue level", "app-3", "unlimited", NewSchedulingPolicyParameters(0, "Soft"), 4 * time.Hour},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			app := context.AddApplication(&AddApplicationRequest{
+				Metadata: ApplicationMetadata{
+					ApplicationID:              tt.appID,
+					QueueName:                  queueNameA,
+					User:                       testUser,
+					Tags:                       map[string]string{constants.AppTagNamespace: tt.namespace},
+					SchedulingPolicyParameters: tt.params,
+				},
+			})
+			assert.Equal(t, app.getMaxRuntime(), tt.want)
+		})
+	}
+}
+
+func TestGetTaskPendingTimeout(t *testing.T) {
+	context := initContextForTest()
+	lister, ok := context.apiProvider.GetAPIs().NamespaceInformer.Lister().(*test.MockNamespaceLister)
+	if !ok {
+		t.Fatalf("could not mock NamespaceLister")
+	}
+	lister.Add(&v1.Namespace{
+		ObjectMeta: apis.ObjectMeta{
+			Name: "limited",
+			Annotations: map[string]string{
+				constants.NamespaceTaskPendingTimeout: "30m",
+			},
+		},
+	})
+	lister.Add(&v1.Namespace{
+		ObjectMeta: apis.ObjectMeta{
+			Name: "unlimited",
+		},
+	})
+	err := schedulerconf.UpdateConfigMaps([]*v1.ConfigMap{nil, {Data: map[string]string{
+		schedulerconf.CMSvcTaskPendingTimeout: "1h",
+	}}}, true)
+	assert.NilError(t, err, "failed to update configmap")
+	defer func() {
+		err = schedulerconf.UpdateConfigMaps([]*v1.ConfigMap{nil, nil}, true)
+		assert.NilError(t, err, "failed to reset configmap")
+	}()
+
+	tests := []struct {
+		name       string
+		namespace  string
+		annotation string
+		want       time.Duration
+	}{
+		{"pod level", "limited", "120", 2 * time.Minute},
+		{"invalid pod level", "limited", "invalid", 30 * time.Minute},
+		{"namespace level", "limited", "", 30 * time.Minute},
+		{"global", "unlimited", "", time.Hour},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pod := &v1.Pod{
+				ObjectMeta: apis.ObjectMeta{
+					Name:      "pod-1",
+					Namespace: tt.namespace,
+				},
+			}
+			if tt.annotation != "" {
+				pod.Annotations = map[string]string{constants.AnnotationTaskPendingTimeout: tt.annotation}
+			}
+			assert.Equal(t, context.getTaskPendingTimeout(pod), tt.want)
+		})
+	}
+}
+
 func TestAddApplicationsWithTags(t *testing.T) {
 	context := initContextForTest()
 
@@ -1841,6 +2389,7 @@ func TestCtxUpdatePodCondition(t *testing.T) {
 }
 
 func TestGetExistingAllocation(t *testing.T) {
+	context := initContextForTest()
 	pod := &v1.Pod{
 		TypeMeta: apis.TypeMeta{
 			Kind:       "Pod",
@@ -1865,7 +2414,7 @@ func TestGetExistingAllocation(t *testing.T) {
 	}
 
 	// verifies the existing allocation is correctly returned
-	alloc := getExistingAllocation(pod)
+	alloc := context.getExistingAllocation(pod)
 	assert.Equal(t, alloc.ApplicationID, appID1)
 	assert.Equal(t, alloc.AllocationKey, string(pod.UID))
 	assert.Equal(t, alloc.NodeID, "allocated-node")
@@ -2530,3 +3079,92 @@ func assertListerPods(pods []*v1.Pod, count int) bool {
 	}
 	return count == counted
 }
+
+func TestResolveTaskGroupOverheads(t *testing.T) {
+	context, apiProvider := initContextAndAPIProviderForTest()
+	taskGroups := []TaskGroup{
+		{Name: "plain", MinMember: 1},
+		{Name: "kata", MinMember: 1, RuntimeClassName: "kata"},
+		{Name: "missing", MinMember: 1, RuntimeClassName: "missing"},
+	}
+	// without runtime classes the task groups are used as is
+	assert.DeepEqual(t, context.resolveTaskGroupOverheads(taskGroups), taskGroups)
+
+	overhead := v1.ResourceList{v1.ResourceCPU: resource.MustParse("250m"), v1.ResourceMemory: resource.MustParse("64Mi")}
+	informer, ok := apiProvider.GetAPIs().RuntimeClassInformer.(*client.MockedRuntimeClassInformer)
+	assert.Assert(t, ok, "could not mock RuntimeClassInformer")
+	err := informer.Add(&nodev1.RuntimeClass{
+		ObjectMeta: apis.ObjectMeta{Name: "kata"},
+		Handler:    "kata",
+		Overhead:   &nodev1.Overhead{PodFixed: overhead},
+	})
+	assert.NilError(t, err, "failed to add runtime class")
+
+	resolved := context.resolveTaskGroupOverheads(taskGroups)
+	assert.Assert(t, resolved[0].Overhead == nil)
+	assert.DeepEqual(t, resolved[1].Overhead, overhead)
+	assert.Assert(t, resolved[2].Overhead == nil, "unknown runtime class should not set an overhead")
+	assert.Assert(t, taskGroups[1].Overhead == nil, "task groups of the request should not be changed")
+}
+
+func TestPartitionMapping(t *testing.T) {
+	err := schedulerconf.UpdateConfigMaps([]*v1.ConfigMap{nil, {Data: map[string]string{
+		schedulerconf.CMSvcPartitionNodeSelectorPrefix + "gpu": "pool=gpu",
+		schedulerconf.CMSvcPartitionNamespacesPrefix + "gpu":   "ml",
+	}}}, true)
+	assert.NilError(t, err, "failed to set configmap")
+	t.Cleanup(func() {
+		err = schedulerconf.UpdateConfigMaps([]*v1.ConfigMap{nil, nil}, true)
+		assert.NilError(t, err, "failed to reset configmap")
+	})
+
+	context, apiProvider := initContextAndAPIProviderForTest()
+	dispatcher.Start()
+	defer dispatcher.UnregisterAllEventHandlers()
+	defer dispatcher.Stop()
+	var nodeRequest *si.NodeRequest
+	apiProvider.MockSchedulerAPIUpdateNodeFn(func(request *si.NodeRequest) error {
+		nodeRequest = request
+		for _, node := range request.Nodes {
+			if node.Action == si.NodeInfo_CREATE_DRAIN {
+				dispatcher.Dispatch(CachedSchedulerNodeEvent{NodeID: node.NodeID, Event: NodeAccepted})
+			}
+		}
+		return nil
+	})
+	gpuNode := &v1.Node{ObjectMeta: apis.ObjectMeta{Name: "gpu-node", Labels: map[string]string{"pool": "gpu"}}}
+	cpuNode := &v1.Node{ObjectMeta: apis.ObjectMeta{Name: "cpu-node"}}
+	context.addNode(gpuNode)
+	context.addNode(cpuNode)
+
+	// node requests carry the partition of the node
+	assert.NilError(t, context.updateNodeResources(gpuNode, &si.Resource{}))
+	assert.Equal(t, nodeRequest.Nodes[0].Attributes[siCommon.NodePartition], "gpu")
+	assert.NilError(t, context.decommissionNode(cpuNode))
+	assert.Equal(t, nodeRequest.Nodes[0].Attributes[siCommon.NodePartition], constants.DefaultPartition)
+	assert.NilError(t, context.enableNode(gpuNode))
+	assert.Equal(t, nodeRequest.Nodes[0].Attributes[siCommon.NodePartition], "gpu")
+
+	// foreign pods follow the node, unknown nodes are in the default partition
+	assert.Equal(t, context.getNodePartition("gpu-node"), "gpu")
+	assert.Equal(t, context.getNodePartition("unknown"), constants.DefaultPartition)
+
+	// the partition is fixed at registration, a label change does not move the node
+	relabelled := gpuNode.DeepCopy()
+	relabelled.Labels = map[string]string{"pool": "cpu"}
+	context.updateNode(gpuNode, relabelled)
+	assert.Equal(t, context.getNodePartition("gpu-node"), "gpu")
+
+	// applications are mapped using the namespace of the pod
+	pod := newPodHelper("pod", "ml", "uid-ml", "", "app-ml", v1.PodPending)
+	appMeta, ok := getAppMetadata(pod)
+	assert.Assert(t, ok)
+	assert.Equal(t, appMeta.Partition, "gpu")
+	app := context.AddApplication(&AddApplicationRequest{Metadata: appMeta})
+	assert.Equal(t, app.partition, "gpu")
+	assert.Equal(t, context.getExistingAllocation(pod).PartitionName, "gpu")
+
+	partitions := context.getPartitionsDao(context.schedulerCache.GetSchedulerCacheDao().Nodes)
+	assert.DeepEqual(t, partitions["gpu"], &partitionDao{Nodes: []string{"gpu-node"}, Applications: []string{"app-ml"}})
+	assert.DeepEqual(t, partitions[constants.DefaultPartition], &partitionDao{Nodes: []string{"cpu-node"}})
+}
@@ -20,6 +20,7 @@ package external
 
 import (
 	"fmt"
+	"maps"
 	"sync/atomic"
 
 	"go.uber.org/zap"
@@ -32,6 +33,7 @@ import (
 	"k8s.io/kubernetes/pkg/scheduler/framework"
 
 	"github.com/apache/yunikorn-k8shim/pkg/client"
+	"github.com/apache/yunikorn-k8shim/pkg/common"
 	"github.com/apache/yunikorn-k8shim/pkg/common/utils"
 	"github.com/apache/yunikorn-k8shim/pkg/locking"
 	"github.com/apache/yunikorn-k8shim/pkg/log"
@@ -70,6 +72,9 @@ type SchedulerCache struct {
 
 	// task bloom filter, recomputed whenever task scheduling state changes
 	taskBloomFilterRef atomic.Pointer[taskBloomFilter]
+
+	// incremented on changes that can affect predicate results on more than one node
+	topologyGeneration atomic.Int64
 }
 
 type taskBloomFilter struct {
@@ -149,6 +154,13 @@ func (cache *SchedulerCache) GetNodesInfoPodsWithReqAntiAffinity() []*framework.
 	return cache.nodesInfoPodsWithReqAntiAffinity
 }
 
+// TopologyGeneration returns a counter that changes whenever a change in the cache can affect the predicate results of
+// nodes other than the changed node: pods with required anti-affinity are added or removed, nodes are added or removed,
+// or node labels change. Changes limited to a single node are tracked by the generation of the node.
+func (cache *SchedulerCache) TopologyGeneration() int64 {
+	return cache.topologyGeneration.Load()
+}
+
 func (cache *SchedulerCache) LockForReads() {
 	cache.lock.RLock()
 }
@@ -200,6 +212,9 @@ func (cache *SchedulerCache) updateNode(node *v1.Node) (*v1.Node, []*v1.Pod) {
 		prevNode = nodeInfo.Node()
 		nodeInfo.SetNode(node)
 	}
+	if prevNode == nil || !maps.Equal(prevNode.Labels, node.Labels) {
+		cache.topologyGeneration.Add(1)
+	}
 
 	cache.nodesInfoPodsWithAffinity = nil
 	cache.nodesInfoPodsWithReqAntiAffinity = nil
@@ -241,6 +256,7 @@ func (cache *SchedulerCache) removeNode(node *v1.Node) (*v1.Node, []*v1.Pod) {
 	cache.nodesInfo = nil
 	cache.nodesInfoPodsWithAffinity = nil
 	cache.nodesInfoPodsWithReqAntiAffinity = nil
+	cache.topologyGeneration.Add(1)
 	cache.updatePVCRefCounts(nodeInfo, true)
 
 	return result, orphans
@@ -418,6 +434,7 @@ func (cache *SchedulerCache) updatePod(pod *v1.Pod) bool {
 				}
 				if podWithRequiredAntiAffinity(pod) {
 					cache.nodesInfoPodsWithReqAntiAffinity = nil
+					cache.topologyGeneration.Add(1)
 				}
 			}
 			if pod.Spec.NodeName == "" {
@@ -455,6 +472,7 @@ func (cache *SchedulerCache) updatePod(pod *v1.Pod) bool {
 			}
 			if podWithRequiredAntiAffinity(pod) {
 				cache.nodesInfoPodsWithReqAntiAffinity = nil
+				cache.topologyGeneration.Add(1)
 			}
 			cache.updatePVCRefCounts(nodeInfo, false)
 		}
@@ -512,6 +530,9 @@ func (cache *SchedulerCache) removePod(pod *v1.Pod) {
 	cache.removeSchedulingTask(key)
 	cache.nodesInfoPodsWithAffinity = nil
 	cache.nodesInfoPodsWithReqAntiAffinity = nil
+	if ok && podWithRequiredAntiAffinity(pod) {
+		cache.topologyGeneration.Add(1)
+	}
 }
 
 func (cache *SchedulerCache) removeSchedulingTask(taskID string) {
@@ -752,6 +773,7 @@ func (cache *SchedulerCache) GetSchedulerCacheDao() SchedulerCacheDao {
 			Addresses:         node.Status.Addresses,
 			Allocatable:       node.Status.Allocatable,
 			Capacity:          node.Status.Capacity,
+			EffectiveCapacity: getEffectiveCapacity(node),
 			Conditions:        node.Status.Conditions,
 		}
 	}
@@ -846,6 +868,16 @@ func (cache *SchedulerCache) GetSchedulerCacheDao() SchedulerCacheDao {
 	}
 }
 
+// getEffectiveCapacity returns the node capacity as sent to the core, keyed by the resource name used by the core
+func getEffectiveCapacity(node *v1.Node) map[string]int64 {
+	capacity := common.GetNodeCapacity(node)
+	result := make(map[string]int64, len(capacity.Resources))
+	for name, quantity := range capacity.Resources {
+		result[name] = quantity.Value
+	}
+	return result
+}
+
 func podWithAffinity(p *v1.Pod) bool {
 	affinity := p.Spec.Affinity
 	return affinity != nil && (affinity.PodAffinity != nil || affinity.PodAntiAffinity != nil)
@@ -56,6 +56,7 @@ type NodeDao struct {
 	Addresses         []v1.NodeAddress   `json:"addresses,omitempty"`
 	Allocatable       v1.ResourceList    `json:"allocatable,omitempty"`
 	Capacity          v1.ResourceList    `json:"capacity,omitempty"`
+	EffectiveCapacity map[string]int64   `json:"effectiveCapacity,omitempty"` // capacity sent to the core after adjustments
 	Conditions        []v1.NodeCondition `json:"conditions,omitempty"`
 }
 
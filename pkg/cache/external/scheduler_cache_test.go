@@ -632,6 +632,65 @@ func TestGetNodesInfoPodsWithReqAntiAffinity(t *testing.T) {
 	assert.Assert(t, cache.nodesInfoPodsWithReqAntiAffinity == nil, "node list was not invalidated")
 }
 
+func TestTopologyGeneration(t *testing.T) {
+	cache := NewSchedulerCache(client.NewMockedAPIProvider(false).GetAPIs())
+	generation := cache.TopologyGeneration()
+	assertChanged := func(changed bool, msg string) {
+		t.Helper()
+		current := cache.TopologyGeneration()
+		if changed {
+			assert.Assert(t, current > generation, msg)
+		} else {
+			assert.Equal(t, current, generation, msg)
+		}
+		generation = current
+	}
+
+	node := &v1.Node{
+		ObjectMeta: apis.ObjectMeta{
+			Name:   host1,
+			UID:    nodeUID1,
+			Labels: map[string]string{"zone": "a"},
+		},
+	}
+	cache.UpdateNode(node)
+	assertChanged(true, "adding a node should change the generation")
+	node = node.DeepCopy()
+	node.Spec.Unschedulable = true
+	cache.UpdateNode(node)
+	assertChanged(false, "updating a node without label changes should not change the generation")
+	node = node.DeepCopy()
+	node.Labels = map[string]string{"zone": "b"}
+	cache.UpdateNode(node)
+	assertChanged(true, "updating the node labels should change the generation")
+
+	pod1 := &v1.Pod{
+		ObjectMeta: apis.ObjectMeta{Name: podName1, UID: podUID1},
+		Spec:       v1.PodSpec{NodeName: host1},
+	}
+	cache.UpdatePod(pod1)
+	assertChanged(false, "adding a pod without anti-affinity should not change the generation")
+	pod2 := &v1.Pod{
+		ObjectMeta: apis.ObjectMeta{Name: podName2, UID: podUID2},
+		Spec: v1.PodSpec{
+			NodeName: host1,
+			Affinity: &v1.Affinity{
+				PodAntiAffinity: &v1.PodAntiAffinity{
+					RequiredDuringSchedulingIgnoredDuringExecution: []v1.PodAffinityTerm{{TopologyKey: "zone"}},
+				},
+			},
+		},
+	}
+	cache.UpdatePod(pod2)
+	assertChanged(true, "adding a pod with required anti-affinity should change the generation")
+	cache.RemovePod(pod1)
+	assertChanged(false, "removing a pod without anti-affinity should not change the generation")
+	cache.RemovePod(pod2)
+	assertChanged(true, "removing a pod with required anti-affinity should change the generation")
+	cache.RemoveNode(node)
+	assertChanged(true, "removing a node should change the generation")
+}
+
 func TestUpdateNonExistNode(t *testing.T) {
 	cache := NewSchedulerCache(client.NewMockedAPIProvider(false).GetAPIs())
 
@@ -970,6 +1029,7 @@ func TestGetSchedulerCacheDao(t *testing.T) {
 	assert.Assert(t, ok)
 	assert.DeepEqual(t, *nodeDao.Allocatable.Memory(), resourceList["memory"])
 	assert.DeepEqual(t, *nodeDao.Allocatable.Cpu(), resourceList["cpu"])
+	assert.DeepEqual(t, nodeDao.EffectiveCapacity, map[string]int64{"memory": 1024 * 1000 * 1000, "vcore": 10000})
 	assert.Equal(t, len(dao.Pods), 1)
 	podDao, ok := dao.Pods["test/pod0001"]
 	assert.Assert(t, ok)
@@ -0,0 +1,266 @@
+/*
+ Licensed to the Apache Software Foundation (ASF) under one
+ or more contributor license agreements.  See the NOTICE file
+ distributed with this work for additional information
+ regarding copyright ownership.  The ASF licenses this file
+ to you under the Apache License, Version 2.0 (the
+ "License"); you may not use this file except in compliance
+ with the License.  You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package cache
+
+import (
+	"sort"
+	"time"
+
+	"go.uber.org/zap"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	corev1helpers "k8s.io/component-helpers/scheduling/corev1"
+	"k8s.io/kubernetes/pkg/scheduler/framework"
+
+	schedulercache "github.com/apache/yunikorn-k8shim/pkg/cache/external"
+	"github.com/apache/yunikorn-k8shim/pkg/common/events"
+	"github.com/apache/yunikorn-k8shim/pkg/log"
+)
+
+// number of topology domains tried for a task group before the timeout style of the group applies
+const maxTopologyDomainAttempts = 3
+
+// selectTopologyDomain picks the topology domain for a task group: the value of the topology key label shared by
+// the nodes that can run the most members of the group. A domain that fits the whole group is preferred, if none
+// does the domain with the most room is returned. Domains in the exclude set are skipped, an empty string is
+// returned if no node carries the topology key.
+func selectTopologyDomain(cache *schedulercache.SchedulerCache, tg TaskGroup, exclude map[string]bool) string {
+	if cache == nil || tg.TopologyKey == "" {
+		return ""
+	}
+	requests := GetPlaceholderResourceRequests(tg.podResource())
+	selector := labels.SelectorFromSet(tg.NodeSelector)
+	var required *v1.NodeSelector
+	if tg.Affinity != nil && tg.Affinity.NodeAffinity != nil {
+		required = tg.Affinity.NodeAffinity.RequiredDuringSchedulingIgnoredDuringExecution
+	}
+
+	fits := make(map[string]int64)
+	cache.LockForReads()
+	for _, nodeInfo := range cache.GetNodesInfoMap() {
+		node := nodeInfo.Node()
+		if node == nil || node.Spec.Unschedulable {
+			continue
+		}
+		domain, ok := node.Labels[tg.TopologyKey]
+		if !ok || exclude[domain] || !selector.Matches(labels.Set(node.Labels)) {
+			continue
+		}
+		if required != nil {
+			if match, err := corev1helpers.MatchNodeSelectorTerms(node, required); err != nil || !match {
+				continue
+			}
+		}
+		fits[domain] += taskGroupFitCount(nodeInfo, requests)
+	}
+	cache.UnlockForReads()
+
+	domains := make([]string, 0, len(fits))
+	for domain := range fits {
+		domains = append(domains, domain)
+	}
+	// most room first, the name breaks ties to keep the choice stable
+	sort.Slice(domains, func(i, j int) bool {
+		if fits[domains[i]] != fits[domains[j]] {
+			return fits[domains[i]] > fits[domains[j]]
+		}
+		return domains[i] < domains[j]
+	})
+	if len(domains) == 0 {
+		return ""
+	}
+	if fits[domains[0]] < int64(tg.MinMember) {
+		log.Log(log.ShimCacheApplication).Info("no topology domain fits the whole task group",
+			zap.String("taskGroup", tg.Name),
+			zap.String("topologyKey", tg.TopologyKey),
+			zap.String("domain", domains[0]),
+			zap.Int64("fit", fits[domains[0]]),
+			zap.Int32("minMember", tg.MinMember))
+	}
+	return domains[0]
+}
+
+// taskGroupFitCount returns the number of members with the given requests that fit in the free space of the node
+func taskGroupFitCount(nodeInfo *framework.NodeInfo, requests v1.ResourceList) int64 {
+	fit := int64(nodeInfo.Allocatable.AllowedPodNumber - len(nodeInfo.Pods))
+	for name, quantity := range requests {
+		var free, request int64
+		switch name {
+		case v1.ResourceCPU:
+			free = nodeInfo.Allocatable.MilliCPU - nodeInfo.Requested.MilliCPU
+			request = quantity.MilliValue()
+		case v1.ResourceMemory:
+			free = nodeInfo.Allocatable.Memory - nodeInfo.Requested.Memory
+			request = quantity.Value()
+		case v1.ResourceEphemeralStorage:
+			free = nodeInfo.Allocatable.EphemeralStorage - nodeInfo.Requested.EphemeralStorage
+			request = quantity.Value()
+		case v1.ResourcePods:
+			continue
+		default:
+			free = nodeInfo.Allocatable.ScalarResources[name] - nodeInfo.Requested.ScalarResources[name]
+			request = quantity.Value()
+		}
+		if request > 0 {
+			fit = min(fit, free/request)
+		}
+	}
+	return max(fit, 0)
+}
+
+// pinToDomain returns a copy of the affinity that requires the node to be in the topology domain.
+// The requirement is added to every required node selector term as the terms are ORed.
+func pinToDomain(affinity *v1.Affinity, topologyKey, domain string) *v1.Affinity {
+	pinned := affinity.DeepCopy()
+	if pinned == nil {
+		pinned = &v1.Affinity{}
+	}
+	if pinned.NodeAffinity == nil {
+		pinned.NodeAffinity = &v1.NodeAffinity{}
+	}
+	if pinned.NodeAffinity.RequiredDuringSchedulingIgnoredDuringExecution == nil {
+		pinned.NodeAffinity.RequiredDuringSchedulingIgnoredDuringExecution = &v1.NodeSelector{}
+	}
+	required := pinned.NodeAffinity.RequiredDuringSchedulingIgnoredDuringExecution
+	if len(required.NodeSelectorTerms) == 0 {
+		required.NodeSelectorTerms = []v1.NodeSelectorTerm{{}}
+	}
+	requirement := v1.NodeSelectorRequirement{
+		Key:      topologyKey,
+		Operator: v1.NodeSelectorOpIn,
+		Values:   []string{domain},
+	}
+	for i := range required.NodeSelectorTerms {
+		required.NodeSelectorTerms[i].MatchExpressions = append(required.NodeSelectorTerms[i].MatchExpressions, requirement)
+	}
+	return pinned
+}
+
+// getPinnedDomain returns the topology domain a placeholder pod was pinned to, used to restore the domain on recovery
+func getPinnedDomain(pod *v1.Pod, topologyKey string) string {
+	if pod == nil || pod.Spec.Affinity == nil || pod.Spec.Affinity.NodeAffinity == nil ||
+		pod.Spec.Affinity.NodeAffinity.RequiredDuringSchedulingIgnoredDuringExecution == nil {
+		return ""
+	}
+	for _, term := range pod.Spec.Affinity.NodeAffinity.RequiredDuringSchedulingIgnoredDuringExecution.NodeSelectorTerms {
+		for _, expr := range term.MatchExpressions {
+			if expr.Key == topologyKey && expr.Operator == v1.NodeSelectorOpIn && len(expr.Values) == 1 {
+				return expr.Values[0]
+			}
+		}
+	}
+	return ""
+}
+
+// selectTaskGroupDomains picks the topology domain of each topology aware task group that does not have one yet.
+// A domain found on an existing placeholder is kept, this restores the domain after a restart.
+// The application lock must be held.
+func (app *Application) selectTaskGroupDomains() {
+	for _, tg := range app.taskGroups {
+		if tg.TopologyKey == "" || app.taskGroupDomains[tg.Name] != "" {
+			continue
+		}
+		domain := ""
+		for _, ph := range app.getPlaceHolderTasks() {
+			if ph.GetTaskGroupName() == tg.Name {
+				if domain = getPinnedDomain(ph.GetTaskPod(), tg.TopologyKey); domain != "" {
+					break
+				}
+			}
+		}
+		if domain == "" {
+			domain = selectTopologyDomain(app.schedulerCache, tg, app.triedDomains[tg.Name])
+		}
+		if domain == "" {
+			log.Log(log.ShimCacheApplication).Warn("no node carries the topology key, task group placeholders are not pinned",
+				zap.String("appID", app.applicationID),
+				zap.String("taskGroup", tg.Name),
+				zap.String("topologyKey", tg.TopologyKey))
+			continue
+		}
+		log.Log(log.ShimCacheApplication).Info("task group pinned to topology domain",
+			zap.String("appID", app.applicationID),
+			zap.String("taskGroup", tg.Name),
+			zap.String("topologyKey", tg.TopologyKey),
+			zap.String("domain", domain))
+		if app.taskGroupDomains == nil {
+			app.taskGroupDomains = make(map[string]string)
+		}
+		app.taskGroupDomains[tg.Name] = domain
+	}
+}
+
+func (app *Application) getTaskGroupDomain(taskGroupName string) string {
+	app.lock.RLock()
+	defer app.lock.RUnlock()
+	return app.taskGroupDomains[taskGroupName]
+}
+
+// retryTaskGroupDomain moves a task group that timed out to the next topology domain: the placeholders of the group
+// are replaced by placeholders pinned to the new domain and the timer of the group is restarted.
+// It returns false if no other domain can be tried. The application lock must be held.
+func (app *Application) retryTaskGroupDomain(taskGroup *TaskGroup, placeholders []*Task) bool {
+	if app.triedDomains == nil {
+		app.triedDomains = make(map[string]map[string]bool)
+	}
+	tried := app.triedDomains[taskGroup.Name]
+	if tried == nil {
+		tried = make(map[string]bool)
+		app.triedDomains[taskGroup.Name] = tried
+	}
+	current := app.taskGroupDomains[taskGroup.Name]
+	if current != "" {
+		tried[current] = true
+	}
+	if len(tried) >= maxTopologyDomainAttempts {
+		return false
+	}
+	next := selectTopologyDomain(app.schedulerCache, *taskGroup, tried)
+	if next == "" {
+		return false
+	}
+	log.Log(log.ShimCacheApplication).Info("task group placeholders timed out, moving to the next topology domain",
+		zap.String("appID", app.applicationID),
+		zap.String("taskGroup", taskGroup.Name),
+		zap.String("previousDomain", current),
+		zap.String("domain", next))
+	if app.originatingTask != nil {
+		events.GetRecorder().Eventf(app.originatingTask.GetTaskPod().DeepCopy(), nil, v1.EventTypeNormal, "GangScheduling",
+			"TaskGroupDomainRetry", "Application %s task group %s placeholders timed out in %s=%s, retrying in %s",
+			app.applicationID, taskGroup.Name, taskGroup.TopologyKey, current, next)
+	}
+	if app.taskGroupDomains == nil {
+		app.taskGroupDomains = make(map[string]string)
+	}
+	app.taskGroupDomains[taskGroup.Name] = next
+	name := taskGroup.Name
+	app.taskGroupTimers[name] = time.AfterFunc(app.getTaskGroupTimeout(*taskGroup), func() {
+		app.handleTaskGroupTimeout(name)
+	})
+	tg := *taskGroup
+	// replacing the pods is done outside the lock as each create and delete is an API call
+	go func() {
+		mgr := getPlaceholderManager()
+		mgr.deletePlaceholders(placeholders)
+		if err := mgr.createTaskGroupPlaceholders(app, tg, tg.MinMember); err != nil {
+			app.failPlaceholderCreate(err)
+		}
+	}()
+	return true
+}
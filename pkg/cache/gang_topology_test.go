@@ -0,0 +1,160 @@
+/*
+ Licensed to the Apache Software Foundation (ASF) under one
+ or more contributor license agreements.  See the NOTICE file
+ distributed with this work for additional information
+ regarding copyright ownership.  The ASF licenses this file
+ to you under the Apache License, Version 2.0 (the
+ "License"); you may not use this file except in compliance
+ with the License.  You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package cache
+
+import (
+	"testing"
+	"time"
+
+	"gotest.tools/v3/assert"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	apis "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	schedulercache "github.com/apache/yunikorn-k8shim/pkg/cache/external"
+	"github.com/apache/yunikorn-k8shim/pkg/client"
+	"github.com/apache/yunikorn-k8shim/pkg/common/utils"
+)
+
+const zoneKey = "topology.kubernetes.io/zone"
+
+func newTopologyCacheForTest() *schedulercache.SchedulerCache {
+	cache := schedulercache.NewSchedulerCache(client.NewMockedAPIProvider(false).GetAPIs())
+	for _, n := range []struct {
+		name string
+		zone string
+		pool string
+	}{
+		{"node-a1", "zone-a", "gpu"},
+		{"node-a2", "zone-a", "gpu"},
+		{"node-b1", "zone-b", "gpu"},
+		{"node-c1", "zone-c", "cpu"},
+		{"node-c2", "zone-c", "cpu"},
+		{"node-c3", "zone-c", "cpu"},
+	} {
+		cache.UpdateNode(&v1.Node{
+			ObjectMeta: apis.ObjectMeta{
+				Name:   n.name,
+				Labels: map[string]string{zoneKey: n.zone, "pool": n.pool},
+			},
+			Status: v1.NodeStatus{Allocatable: v1.ResourceList{
+				v1.ResourceCPU:    resource.MustParse("4"),
+				v1.ResourceMemory: resource.MustParse("8Gi"),
+				v1.ResourcePods:   resource.MustParse("110"),
+			}},
+		})
+	}
+	return cache
+}
+
+func newTopologyTaskGroup(minMember int32) TaskGroup {
+	return TaskGroup{
+		Name:         "worker",
+		MinMember:    minMember,
+		MinResource:  map[string]resource.Quantity{"cpu": resource.MustParse("1")},
+		NodeSelector: map[string]string{"pool": "gpu"},
+		TopologyKey:  zoneKey,
+	}
+}
+
+func TestSelectTopologyDomain(t *testing.T) {
+	cache := newTopologyCacheForTest()
+	tg := newTopologyTaskGroup(6)
+	// zone-c has the most room but does not match the node selector
+	assert.Equal(t, selectTopologyDomain(cache, tg, nil), "zone-a")
+	assert.Equal(t, selectTopologyDomain(cache, tg, map[string]bool{"zone-a": true}), "zone-b")
+	assert.Equal(t, selectTopologyDomain(cache, tg, map[string]bool{"zone-a": true, "zone-b": true}), "")
+
+	tg.NodeSelector = nil
+	assert.Equal(t, selectTopologyDomain(cache, tg, nil), "zone-c")
+	tg.TopologyKey = "unknown"
+	assert.Equal(t, selectTopologyDomain(cache, tg, nil), "")
+	assert.Equal(t, selectTopologyDomain(nil, newTopologyTaskGroup(1), nil), "")
+}
+
+func TestPinToDomain(t *testing.T) {
+	pinned := pinToDomain(nil, zoneKey, "zone-a")
+	terms := pinned.NodeAffinity.RequiredDuringSchedulingIgnoredDuringExecution.NodeSelectorTerms
+	assert.Equal(t, len(terms), 1)
+	assert.Equal(t, terms[0].MatchExpressions[0].Key, zoneKey)
+	assert.DeepEqual(t, terms[0].MatchExpressions[0].Values, []string{"zone-a"})
+
+	affinity := &v1.Affinity{NodeAffinity: &v1.NodeAffinity{
+		RequiredDuringSchedulingIgnoredDuringExecution: &v1.NodeSelector{NodeSelectorTerms: []v1.NodeSelectorTerm{
+			{MatchExpressions: []v1.NodeSelectorRequirement{{Key: "pool", Operator: v1.NodeSelectorOpIn, Values: []string{"gpu"}}}},
+			{MatchExpressions: []v1.NodeSelectorRequirement{{Key: "pool", Operator: v1.NodeSelectorOpIn, Values: []string{"tpu"}}}},
+		}},
+	}}
+	pinned = pinToDomain(affinity, zoneKey, "zone-b")
+	for _, term := range pinned.NodeAffinity.RequiredDuringSchedulingIgnoredDuringExecution.NodeSelectorTerms {
+		assert.Equal(t, len(term.MatchExpressions), 2, "every term must be pinned")
+	}
+	// the task group affinity is shared and must not change
+	for _, term := range affinity.NodeAffinity.RequiredDuringSchedulingIgnoredDuringExecution.NodeSelectorTerms {
+		assert.Equal(t, len(term.MatchExpressions), 1)
+	}
+	assert.Equal(t, getPinnedDomain(&v1.Pod{Spec: v1.PodSpec{Affinity: pinned}}, zoneKey), "zone-b")
+	assert.Equal(t, getPinnedDomain(&v1.Pod{Spec: v1.PodSpec{Affinity: affinity}}, zoneKey), "")
+}
+
+func TestNewPlaceholderPinnedToDomain(t *testing.T) {
+	app := NewApplication(appID, "root.a", "testuser", testGroups, map[string]string{}, newMockSchedulerAPI())
+	tg := newTopologyTaskGroup(2)
+	app.setTaskGroups([]TaskGroup{tg})
+	app.schedulerCache = newTopologyCacheForTest()
+	app.lock.Lock()
+	app.selectTaskGroupDomains()
+	app.lock.Unlock()
+	assert.Equal(t, app.getTaskGroupDomain("worker"), "zone-a")
+
+	holder := newPlaceholder("ph-1", app, tg)
+	assert.Equal(t, getPinnedDomain(holder.pod, zoneKey), "zone-a")
+	assert.Equal(t, holder.pod.Spec.NodeSelector["pool"], "gpu")
+}
+
+func TestTaskGroupTimeoutNextDomain(t *testing.T) {
+	context, app, deleted := newTaskGroupTestApp(t)
+	tg := newTopologyTaskGroup(2)
+	tg.GangSchedulingStyle = "Hard"
+	app.setTaskGroups([]TaskGroup{tg})
+	app.schedulerCache = newTopologyCacheForTest()
+	app.SetState(ApplicationStates().Reserving)
+	addTaskGroupPlaceholder(context, app, "ph-worker-1", "worker", TaskStates().Bound, time.Now())
+	addTaskGroupPlaceholder(context, app, "ph-worker-2", "worker", TaskStates().Scheduling, time.Now())
+	app.lock.Lock()
+	app.selectTaskGroupDomains()
+	app.startTaskGroupTimers()
+	app.lock.Unlock()
+	assert.Equal(t, app.getTaskGroupDomain("worker"), "zone-a")
+	// the core backstop covers a timeout per domain
+	assert.Equal(t, app.getCorePlaceholderTimeout(), int64((maxTopologyDomainAttempts*120*time.Second+corePlaceholderTimeoutMargin)/time.Second))
+
+	// the group moves to the next domain, the old placeholders are replaced
+	app.handleTaskGroupTimeout("worker")
+	assert.Equal(t, app.getTaskGroupDomain("worker"), "zone-b")
+	assert.Equal(t, app.GetApplicationState(), ApplicationStates().Reserving)
+	err := utils.WaitForCondition(func() bool {
+		return deleted.count() == 2
+	}, 10*time.Millisecond, time.Second)
+	assert.NilError(t, err, "placeholders of the old domain should have been deleted")
+
+	// no other domain fits the node selector: the style of the group applies
+	app.handleTaskGroupTimeout("worker")
+	assertAppState(t, app, ApplicationStates().Failed, 3*time.Second)
+}
@@ -23,6 +23,7 @@ import (
 	"math/rand"
 	"strconv"
 	"strings"
+	"time"
 
 	"go.uber.org/zap"
 	v1 "k8s.io/api/core/v1"
@@ -33,6 +34,12 @@ import (
 	"github.com/apache/yunikorn-k8shim/pkg/log"
 )
 
+// the placeholder timeout the core uses if the application does not set one
+const defaultPlaceholderTimeout = 15 * time.Minute
+
+// time added to the core placeholder timeout when the shim enforces the timeout of each task group
+const corePlaceholderTimeoutMargin = time.Minute
+
 func FindAppTaskGroup(appTaskGroups []*TaskGroup, groupName string) (*TaskGroup, error) {
 	if groupName == "" {
 		// task has no group defined
@@ -95,10 +102,12 @@ func GetSchedulingPolicyParam(pod *v1.Pod) *SchedulingPolicyParameters {
 	timeout := int64(0)
 	style := constants.SchedulingPolicyStyleParamDefault
 	schedulingPolicyParams := NewSchedulingPolicyParameters(timeout, style)
+	schedulingPolicyParams.maxRuntime = getMaxRuntimeFromAnnotation(pod)
 	param := utils.GetPodAnnotationValue(pod, constants.AnnotationSchedulingPolicyParam)
 	if param == "" {
 		return schedulingPolicyParams
 	}
+	maxRuntime := schedulingPolicyParams.maxRuntime
 	params := strings.Split(param, constants.SchedulingPolicyParamDelimiter)
 	var err error
 	for _, p := range params {
@@ -120,8 +129,38 @@ func GetSchedulingPolicyParam(pod *v1.Pod) *SchedulingPolicyParameters {
 				log.Log(log.ShimUtils).Warn("Unknown gang scheduling style, using "+constants.SchedulingPolicyStyleParamDefault+" style as default",
 					zap.String("namespace", pod.Namespace), zap.String("name", pod.Name), zap.String("Gang scheduling style passed in annotation: ", p))
 			}
+		case constants.SchedulingPolicyMaxRuntimeParam:
+			// the dedicated annotation takes precedence over the scheduling policy parameter
+			if maxRuntime != 0 {
+				continue
+			}
+			seconds, parseErr := strconv.ParseInt(param[1], 10, 64)
+			if parseErr != nil || seconds < 0 {
+				log.Log(log.ShimUtils).Warn("Failed to parse max runtime value from annotation", zap.String("namespace", pod.Namespace), zap.String("name", pod.Name), zap.String("Max runtime passed in annotation: ", p))
+				continue
+			}
+			maxRuntime = time.Duration(seconds) * time.Second
 		}
 	}
 	schedulingPolicyParams = NewSchedulingPolicyParameters(timeout, style)
+	schedulingPolicyParams.maxRuntime = maxRuntime
 	return schedulingPolicyParams
 }
+
+// getMaxRuntimeFromAnnotation returns the max runtime set using the dedicated pod annotation, 0 if not set or invalid
+func getMaxRuntimeFromAnnotation(pod *v1.Pod) time.Duration {
+	value := utils.GetPodAnnotationValue(pod, constants.AnnotationMaxRuntime)
+	if value == "" {
+		return 0
+	}
+	maxRuntime, err := utils.ParseDurationOrSeconds(value)
+	if err != nil {
+		log.Log(log.ShimUtils).Warn("Failed to parse max runtime annotation",
+			zap.String("namespace", pod.Namespace),
+			zap.String("name", pod.Name),
+			zap.String("maxRuntime", value),
+			zap.Error(err))
+		return 0
+	}
+	return maxRuntime
+}
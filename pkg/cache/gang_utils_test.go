@@ -22,6 +22,7 @@ import (
 	"fmt"
 	"reflect"
 	"testing"
+	"time"
 
 	"gotest.tools/v3/assert"
 	v1 "k8s.io/api/core/v1"
@@ -139,6 +140,39 @@ func TestGetSchedulingPolicyParams(t *testing.T) {
 	}
 }
 
+func TestGetSchedulingPolicyParamMaxRuntime(t *testing.T) {
+	tests := []struct {
+		name        string
+		annotations map[string]string
+		want        time.Duration
+	}{
+		{"not set", nil, 0},
+		{"policy param", map[string]string{constants.AnnotationSchedulingPolicyParam: "maxRuntimeInSeconds=60"}, time.Minute},
+		{"policy param invalid", map[string]string{constants.AnnotationSchedulingPolicyParam: "maxRuntimeInSeconds=1m"}, 0},
+		{"policy param negative", map[string]string{constants.AnnotationSchedulingPolicyParam: "maxRuntimeInSeconds=-1"}, 0},
+		{"annotation seconds", map[string]string{constants.AnnotationMaxRuntime: "120"}, 2 * time.Minute},
+		{"annotation duration", map[string]string{constants.AnnotationMaxRuntime: "2h"}, 2 * time.Hour},
+		{"annotation invalid", map[string]string{constants.AnnotationMaxRuntime: "forever"}, 0},
+		{"annotation wins", map[string]string{
+			constants.AnnotationMaxRuntime:            "2h",
+			constants.AnnotationSchedulingPolicyParam: "maxRuntimeInSeconds=60 placeholderTimeoutInSeconds=10",
+		}, 2 * time.Hour},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pod := &v1.Pod{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:        "test-pod",
+					Namespace:   "test",
+					Annotations: tt.annotations,
+				},
+			}
+			params := GetSchedulingPolicyParam(pod)
+			assert.Equal(t, params.GetMaxRuntime(), tt.want)
+		})
+	}
+}
+
 func Test_GetPlaceholderResourceRequest(t *testing.T) {
 	tests := []struct {
 		name   string
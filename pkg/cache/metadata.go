@@ -19,6 +19,7 @@
 package cache
 
 import (
+	"strconv"
 	"strings"
 
 	v1 "k8s.io/api/core/v1"
@@ -130,6 +131,7 @@ func getAppMetadata(pod *v1.Pod) (ApplicationMetadata, bool) {
 		ownerReferences = getOwnerReference(pod)
 	}
 
+	appGroup, appGroupSize := getAppGroup(pod)
 	schedulingPolicyParams := GetSchedulingPolicyParam(pod)
 	tags[constants.AnnotationSchedulingPolicyParam] = pod.Annotations[constants.AnnotationSchedulingPolicyParam]
 	creationTime := pod.CreationTimestamp.Unix()
@@ -144,9 +146,35 @@ func getAppMetadata(pod *v1.Pod) (ApplicationMetadata, bool) {
 		OwnerReferences:            ownerReferences,
 		SchedulingPolicyParameters: schedulingPolicyParams,
 		CreationTime:               creationTime,
+		AppGroup:                   appGroup,
+		AppGroupSize:               appGroupSize,
+		Partition:                  conf.GetSchedulerConf().GetAppPartition(pod.Namespace, pod.Labels),
 	}, true
 }
 
+// getAppGroup returns the application group of the pod and the expected number of applications in the group
+func getAppGroup(pod *v1.Pod) (string, int) {
+	if conf.GetSchedulerConf().DisableGangScheduling {
+		return "", 0
+	}
+	name := utils.GetPodAnnotationValue(pod, constants.AnnotationAppGroup)
+	if name == "" {
+		return "", 0
+	}
+	size := 0
+	if value := utils.GetPodAnnotationValue(pod, constants.AnnotationAppGroupSize); value != "" {
+		var err error
+		if size, err = strconv.Atoi(value); err != nil || size < 0 {
+			log.Log(log.ShimCacheApplication).Warn("invalid application group size, waiting for the known members only",
+				zap.String("namespace", pod.Namespace),
+				zap.String("name", pod.Name),
+				zap.String("value", value))
+			size = 0
+		}
+	}
+	return name, size
+}
+
 func getOwnerReference(pod *v1.Pod) []metav1.OwnerReference {
 	// Just return the originator pod as the owner of placeholder pods
 	controller := false
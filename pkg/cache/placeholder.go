@@ -81,48 +81,62 @@ func newPlaceholder(placeholderName string, app *Application, taskGroup TaskGrou
 	var priorityClassName string
 	if task := app.GetOriginatingTask(); task != nil {
 		priorityClassName = task.GetTaskPod().Spec.PriorityClassName
+		// Add "yunikorn.apache.org/max-runtime" to the placeholder to aid recovery
+		if maxRuntime := utils.GetPodAnnotationValue(task.GetTaskPod(), constants.AnnotationMaxRuntime); maxRuntime != "" {
+			annotations = utils.MergeMaps(annotations, map[string]string{
+				constants.AnnotationMaxRuntime: maxRuntime,
+			})
+		}
 	}
 
 	// prepare the resource lists
 	requests := GetPlaceholderResourceRequests(taskGroup.MinResource)
-	var zeroSeconds int64 = 0
-	placeholderPod := &v1.Pod{
-		ObjectMeta: metav1.ObjectMeta{
-			Name:      placeholderName,
-			Namespace: app.tags[constants.AppTagNamespace],
-			Labels: utils.MergeMaps(taskGroup.Labels, map[string]string{
-				constants.CanonicalLabelApplicationID: app.GetApplicationID(),
-				constants.CanonicalLabelQueueName:     app.GetQueue(),
-			}),
-			Annotations:     annotations,
-			OwnerReferences: ownerRefs,
-		},
-		Spec: v1.PodSpec{
-			SecurityContext: &v1.PodSecurityContext{
-				RunAsUser:  &runAsUser,
-				RunAsGroup: &runAsGroup,
-			},
-			ImagePullSecrets: imagePullSecrets,
-			Containers: []v1.Container{
-				{
-					Name:            constants.PlaceholderContainerName,
-					Image:           conf.GetSchedulerConf().PlaceHolderImage,
-					ImagePullPolicy: v1.PullIfNotPresent,
-					Resources: v1.ResourceRequirements{
-						Requests: requests,
-						Limits:   requests,
-					},
-				},
-			},
-			RestartPolicy:                 constants.PlaceholderPodRestartPolicy,
-			SchedulerName:                 constants.SchedulerName,
-			NodeSelector:                  taskGroup.NodeSelector,
-			Tolerations:                   taskGroup.Tolerations,
-			Affinity:                      taskGroup.Affinity,
-			TopologySpreadConstraints:     taskGroup.TopologySpreadConstraints,
-			PriorityClassName:             priorityClassName,
-			TerminationGracePeriodSeconds: &zeroSeconds,
-		},
+	// start from the configured template, the task group and scheduler derived fields are merged on top
+	namespace := app.tags[constants.AppTagNamespace]
+	placeholderPod := newPlaceholderPodFromTemplate(namespace)
+	placeholderPod.Name = placeholderName
+	placeholderPod.Namespace = namespace
+	placeholderPod.Labels = utils.MergeMaps(utils.MergeMaps(placeholderPod.Labels, taskGroup.Labels), map[string]string{
+		constants.CanonicalLabelApplicationID: app.GetApplicationID(),
+		constants.CanonicalLabelQueueName:     app.GetQueue(),
+	})
+	placeholderPod.Annotations = utils.MergeMaps(placeholderPod.Annotations, annotations)
+	placeholderPod.OwnerReferences = ownerRefs
+
+	spec := &placeholderPod.Spec
+	spec.ImagePullSecrets = append(spec.ImagePullSecrets, imagePullSecrets...)
+	spec.Containers[0].Resources = v1.ResourceRequirements{
+		Requests: requests,
+		Limits:   requests,
+	}
+	spec.SchedulerName = constants.SchedulerName
+	spec.NodeSelector = utils.MergeMaps(spec.NodeSelector, taskGroup.NodeSelector)
+	spec.Tolerations = append(spec.Tolerations, taskGroup.Tolerations...)
+	if taskGroup.Affinity != nil {
+		spec.Affinity = taskGroup.Affinity
+	}
+	// a topology aware task group is pinned to its domain, the real pods follow as they replace the placeholders
+	if taskGroup.TopologyKey != "" {
+		if domain := app.getTaskGroupDomain(taskGroup.Name); domain != "" {
+			spec.Affinity = pinToDomain(spec.Affinity, taskGroup.TopologyKey, domain)
+		}
+	}
+	if len(taskGroup.TopologySpreadConstraints) > 0 {
+		spec.TopologySpreadConstraints = taskGroup.TopologySpreadConstraints
+	}
+	if taskGroup.PriorityClassName != "" {
+		priorityClassName = taskGroup.PriorityClassName
+	}
+	if priorityClassName != "" {
+		spec.PriorityClassName = priorityClassName
+	}
+	// the overhead must match the runtime class, it is left to the API server if the class was not found
+	if taskGroup.RuntimeClassName != "" {
+		runtimeClassName := taskGroup.RuntimeClassName
+		spec.RuntimeClassName = &runtimeClassName
+		if len(taskGroup.Overhead) > 0 {
+			spec.Overhead = taskGroup.Overhead.DeepCopy()
+		}
 	}
 
 	return &Placeholder{
@@ -132,6 +146,48 @@ func newPlaceholder(placeholderName string, app *Application, taskGroup TaskGrou
 	}
 }
 
+// newPlaceholderPodFromTemplate creates the base placeholder pod from the configured template for the namespace.
+// Anything the template does not set falls back to the built-in placeholder defaults.
+func newPlaceholderPodFromTemplate(namespace string) *v1.Pod {
+	pod := &v1.Pod{}
+	if tmpl := conf.GetSchedulerConf().GetPlaceholderTemplate(namespace); tmpl != nil {
+		pod.ObjectMeta = metav1.ObjectMeta{
+			Labels:      tmpl.Labels,
+			Annotations: tmpl.Annotations,
+		}
+		pod.Spec = tmpl.Spec
+	}
+
+	spec := &pod.Spec
+	if spec.SecurityContext == nil {
+		spec.SecurityContext = &v1.PodSecurityContext{
+			RunAsUser:  &runAsUser,
+			RunAsGroup: &runAsGroup,
+		}
+	}
+	if len(spec.Containers) == 0 {
+		spec.Containers = []v1.Container{{}}
+	}
+	container := &spec.Containers[0]
+	if container.Name == "" {
+		container.Name = constants.PlaceholderContainerName
+	}
+	if container.Image == "" {
+		container.Image = conf.GetSchedulerConf().PlaceHolderImage
+	}
+	if container.ImagePullPolicy == "" {
+		container.ImagePullPolicy = v1.PullIfNotPresent
+	}
+	if spec.RestartPolicy == "" {
+		spec.RestartPolicy = constants.PlaceholderPodRestartPolicy
+	}
+	if spec.TerminationGracePeriodSeconds == nil {
+		var zeroSeconds int64 = 0
+		spec.TerminationGracePeriodSeconds = &zeroSeconds
+	}
+	return pod
+}
+
 func (p *Placeholder) String() string {
 	return fmt.Sprintf("appID: %s, taskGroup: %s, podName: %s/%s",
 		p.appID, p.taskGroupName, p.pod.Namespace, p.pod.Name)
@@ -0,0 +1,232 @@
+/*
+ Licensed to the Apache Software Foundation (ASF) under one
+ or more contributor license agreements.  See the NOTICE file
+ distributed with this work for additional information
+ regarding copyright ownership.  The ASF licenses this file
+ to you under the Apache License, Version 2.0 (the
+ "License"); you may not use this file except in compliance
+ with the License.  You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package cache
+
+import (
+	"errors"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"go.uber.org/zap"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/types"
+
+	"github.com/apache/yunikorn-k8shim/pkg/common/utils"
+	"github.com/apache/yunikorn-k8shim/pkg/conf"
+	"github.com/apache/yunikorn-k8shim/pkg/log"
+)
+
+// reasons a placeholder is considered an orphan, used as the metric label
+const (
+	orphanNoApplication = "no_application"
+	orphanNoOwner       = "no_owner"
+	orphanExpired       = "expired"
+)
+
+// placeholderGCGracePeriod is the minimum age of a placeholder before the collector looks at it.
+// It is independent of the collector interval: a short interval must not shrink the time an application
+// has to register after its placeholders are created.
+const placeholderGCGracePeriod = 2 * time.Minute
+
+// The collectors are registered in the default Prometheus registry, the same registry the core metrics use.
+// They are scraped together with the core metrics from the scheduler REST endpoint /ws/v1/metrics.
+
+var (
+	placeholderGCRuns = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: "yunikorn",
+		Subsystem: "k8shim",
+		Name:      "placeholder_gc_runs_total",
+		Help:      "Total number of orphan placeholder collector runs.",
+	})
+	placeholderGCOrphans = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: "yunikorn",
+		Subsystem: "k8shim",
+		Name:      "placeholder_gc_orphans",
+		Help:      "Number of orphan placeholders found in the last collector run, by reason.",
+	}, []string{"reason"})
+	placeholderGCDeleted = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "yunikorn",
+		Subsystem: "k8shim",
+		Name:      "placeholder_gc_deleted_total",
+		Help:      "Total number of orphan placeholders deleted, by reason.",
+	}, []string{"reason"})
+	placeholderGCFailures = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: "yunikorn",
+		Subsystem: "k8shim",
+		Name:      "placeholder_gc_delete_failures_total",
+		Help:      "Total number of orphan placeholders that could not be deleted.",
+	})
+	registerGCMetrics sync.Once
+)
+
+// PlaceholderGC periodically removes placeholder pods that are no longer used by an application.
+// Unlike the orphan list of the PlaceholderManager the state is rebuilt from the pod informer on each run,
+// which means placeholders left behind before a restart are cleaned up too.
+type PlaceholderGC struct {
+	context  *Context
+	stopChan chan struct{}
+	running  atomic.Bool
+}
+
+func NewPlaceholderGC(ctx *Context) *PlaceholderGC {
+	registerGCMetrics.Do(func() {
+		for _, collector := range []prometheus.Collector{placeholderGCRuns, placeholderGCOrphans, placeholderGCDeleted, placeholderGCFailures} {
+			if err := prometheus.Register(collector); err != nil {
+				var are prometheus.AlreadyRegisteredError
+				if !errors.As(err, &are) {
+					log.Log(log.ShimCachePlaceholder).Warn("failed to register placeholder GC metric", zap.Error(err))
+				}
+			}
+		}
+	})
+	return &PlaceholderGC{
+		context:  ctx,
+		stopChan: make(chan struct{}),
+	}
+}
+
+// Start runs the collector. It must be started after the scheduler state has been recovered,
+// otherwise the placeholders of applications that are not recovered yet are seen as orphans.
+func (gc *PlaceholderGC) Start() {
+	if !gc.running.CompareAndSwap(false, true) {
+		log.Log(log.ShimCachePlaceholder).Info("placeholder GC is already started")
+		return
+	}
+	log.Log(log.ShimCachePlaceholder).Info("starting the placeholder GC")
+	go func() {
+		for {
+			// the interval is re-read each run as the configuration can be reloaded
+			interval := conf.GetSchedulerConf().GetPlaceholderGCInterval()
+			wait := interval
+			if interval <= 0 {
+				// disabled: check again later in case it is enabled
+				wait = conf.DefaultPlaceholderGCInterval
+			}
+			select {
+			case <-gc.stopChan:
+				gc.running.Store(false)
+				log.Log(log.ShimCachePlaceholder).Info("placeholder GC has been stopped")
+				return
+			case <-time.After(wait):
+				if interval > 0 {
+					gc.collect(placeholderGCGracePeriod, conf.GetSchedulerConf().IsPlaceholderGCDryRun())
+				}
+			}
+		}
+	}()
+}
+
+func (gc *PlaceholderGC) Stop() {
+	if !gc.running.Load() {
+		log.Log(log.ShimCachePlaceholder).Info("placeholder GC already stopped")
+		return
+	}
+	log.Log(log.ShimCachePlaceholder).Info("stopping the placeholder GC")
+	gc.stopChan <- struct{}{}
+}
+
+// collect finds and deletes the orphan placeholders. Placeholders younger than the grace period are skipped
+// to not race with an application that is still being set up. It returns the number of deleted placeholders.
+func (gc *PlaceholderGC) collect(grace time.Duration, dryRun bool) int {
+	placeholderGCRuns.Inc()
+	pods, err := gc.context.apiProvider.GetAPIs().PodInformer.Lister().List(labels.Everything())
+	if err != nil {
+		log.Log(log.ShimCachePlaceholder).Warn("placeholder GC failed to list pods", zap.Error(err))
+		return 0
+	}
+	podUIDs := make(map[types.UID]bool, len(pods))
+	for _, pod := range pods {
+		podUIDs[pod.UID] = true
+	}
+
+	now := time.Now()
+	counts := map[string]int{orphanNoApplication: 0, orphanNoOwner: 0, orphanExpired: 0}
+	deleted := 0
+	for _, pod := range pods {
+		if !utils.GetPlaceholderFlagFromPodSpec(pod) || pod.DeletionTimestamp != nil {
+			continue
+		}
+		age := now.Sub(pod.CreationTimestamp.Time)
+		if age < grace {
+			continue
+		}
+		reason := gc.getOrphanReason(pod, podUIDs, age-grace)
+		if reason == "" {
+			continue
+		}
+		counts[reason]++
+		if dryRun {
+			log.Log(log.ShimCachePlaceholder).Info("placeholder GC dry run: orphan placeholder found",
+				zap.String("namespace", pod.Namespace),
+				zap.String("podName", pod.Name),
+				zap.String("reason", reason))
+			continue
+		}
+		if err = gc.context.apiProvider.GetAPIs().KubeClient.Delete(pod); err != nil {
+			placeholderGCFailures.Inc()
+			log.Log(log.ShimCachePlaceholder).Warn("placeholder GC failed to delete orphan placeholder",
+				zap.String("namespace", pod.Namespace),
+				zap.String("podName", pod.Name),
+				zap.Error(err))
+			continue
+		}
+		placeholderGCDeleted.WithLabelValues(reason).Inc()
+		deleted++
+		log.Log(log.ShimCachePlaceholder).Info("placeholder GC deleted orphan placeholder",
+			zap.String("namespace", pod.Namespace),
+			zap.String("podName", pod.Name),
+			zap.String("reason", reason))
+	}
+	for reason, count := range counts {
+		placeholderGCOrphans.WithLabelValues(reason).Set(float64(count))
+	}
+	return deleted
+}
+
+// getOrphanReason returns why the placeholder is an orphan, or an empty string if it is still in use
+func (gc *PlaceholderGC) getOrphanReason(pod *v1.Pod, podUIDs map[types.UID]bool, age time.Duration) string {
+	app := gc.context.GetApplication(utils.GetApplicationIDFromPod(pod))
+	if app == nil {
+		return orphanNoApplication
+	}
+	switch app.GetApplicationState() {
+	case ApplicationStates().Completed, ApplicationStates().Failed, ApplicationStates().Killed, ApplicationStates().Rejected:
+		return orphanNoApplication
+	}
+	if len(pod.OwnerReferences) == 0 {
+		return orphanNoOwner
+	}
+	// only a pod owner can be checked without an extra lister, other owners are handled by the K8s garbage collector
+	for _, ref := range pod.OwnerReferences {
+		if ref.Kind == "Pod" && !podUIDs[ref.UID] {
+			return orphanNoOwner
+		}
+	}
+	timeout := defaultPlaceholderTimeout
+	if appTimeout := app.getPlaceholderTimeout(); appTimeout > 0 {
+		timeout = appTimeout
+	}
+	if age > timeout {
+		return orphanExpired
+	}
+	return ""
+}
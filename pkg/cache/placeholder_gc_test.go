@@ -0,0 +1,116 @@
+/*
+ Licensed to the Apache Software Foundation (ASF) under one
+ or more contributor license agreements.  See the NOTICE file
+ distributed with this work for additional information
+ regarding copyright ownership.  The ASF licenses this file
+ to you under the Apache License, Version 2.0 (the
+ "License"); you may not use this file except in compliance
+ with the License.  You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package cache
+
+import (
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"gotest.tools/v3/assert"
+	v1 "k8s.io/api/core/v1"
+	apis "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/apache/yunikorn-k8shim/pkg/common/constants"
+	"github.com/apache/yunikorn-k8shim/pkg/common/utils"
+	"github.com/apache/yunikorn-k8shim/pkg/locking"
+)
+
+func newGCPlaceholderHelper(name, appID string, age time.Duration, owner *v1.Pod) *v1.Pod {
+	pod := newPodHelper(name, namespace, name, "", appID, v1.PodRunning)
+	pod.Annotations = map[string]string{constants.AnnotationPlaceholderFlag: constants.True}
+	pod.CreationTimestamp = apis.NewTime(time.Now().Add(-age))
+	if owner != nil {
+		pod.OwnerReferences = []apis.OwnerReference{{Kind: "Pod", Name: owner.Name, UID: owner.UID}}
+	}
+	return pod
+}
+
+func TestPlaceholderGC(t *testing.T) {
+	context, apiProvider := initContextAndAPIProviderForTest()
+	lister := apiProvider.GetPodListerMock()
+	var lock locking.Mutex
+	deleted := make([]string, 0)
+	apiProvider.MockDeleteFn(func(pod *v1.Pod) error {
+		lock.Lock()
+		defer lock.Unlock()
+		deleted = append(deleted, pod.Name)
+		return nil
+	})
+
+	running := NewApplication("app-running", queue, "bob", testGroups, map[string]string{constants.AppTagNamespace: namespace}, newMockSchedulerAPI())
+	running.SetState(ApplicationStates().Running)
+	running.SetPlaceholderTimeout(3600)
+	context.addApplicationToContext(running)
+	timedOut := NewApplication("app-timeout", queue, "bob", testGroups, map[string]string{constants.AppTagNamespace: namespace}, newMockSchedulerAPI())
+	timedOut.SetState(ApplicationStates().Running)
+	timedOut.SetPlaceholderTimeout(60)
+	context.addApplicationToContext(timedOut)
+	completed := NewApplication("app-completed", queue, "bob", testGroups, map[string]string{constants.AppTagNamespace: namespace}, newMockSchedulerAPI())
+	completed.SetState(ApplicationStates().Completed)
+	context.addApplicationToContext(completed)
+
+	originator := newPodHelper("originator", namespace, "originator", "node-1", "app-running", v1.PodRunning)
+	goneOwner := newPodHelper("gone", namespace, "gone", "node-1", "app-running", v1.PodRunning)
+	lister.AddPod(originator)
+	lister.AddPod(newGCPlaceholderHelper("ph-in-use", "app-running", 10*time.Minute, originator))
+	lister.AddPod(newGCPlaceholderHelper("ph-no-app", "app-unknown", 10*time.Minute, originator))
+	lister.AddPod(newGCPlaceholderHelper("ph-completed-app", "app-completed", 10*time.Minute, originator))
+	lister.AddPod(newGCPlaceholderHelper("ph-no-owner", "app-running", 10*time.Minute, nil))
+	lister.AddPod(newGCPlaceholderHelper("ph-owner-gone", "app-running", 10*time.Minute, goneOwner))
+	lister.AddPod(newGCPlaceholderHelper("ph-expired", "app-timeout", 10*time.Minute, originator))
+	lister.AddPod(newGCPlaceholderHelper("ph-young", "app-unknown", time.Second, originator))
+	terminating := newGCPlaceholderHelper("ph-terminating", "app-unknown", 10*time.Minute, originator)
+	terminating.DeletionTimestamp = &apis.Time{Time: time.Now()}
+	lister.AddPod(terminating)
+	lister.AddPod(newPodHelper("no-placeholder", namespace, "no-placeholder", "node-1", "app-unknown", v1.PodRunning))
+
+	gc := NewPlaceholderGC(context)
+	deletedBefore := testutil.ToFloat64(placeholderGCDeleted.WithLabelValues(orphanNoOwner))
+
+	// dry run only reports
+	assert.Equal(t, gc.collect(placeholderGCGracePeriod, true), 0)
+	assert.Equal(t, len(deleted), 0, "dry run should not delete")
+	assert.Equal(t, testutil.ToFloat64(placeholderGCOrphans.WithLabelValues(orphanNoApplication)), float64(2))
+	assert.Equal(t, testutil.ToFloat64(placeholderGCOrphans.WithLabelValues(orphanNoOwner)), float64(2))
+	assert.Equal(t, testutil.ToFloat64(placeholderGCOrphans.WithLabelValues(orphanExpired)), float64(1))
+
+	assert.Equal(t, gc.collect(placeholderGCGracePeriod, false), 5)
+	assert.Equal(t, len(deleted), 5)
+	for _, name := range []string{"ph-no-app", "ph-completed-app", "ph-no-owner", "ph-owner-gone", "ph-expired"} {
+		found := false
+		for _, d := range deleted {
+			found = found || d == name
+		}
+		assert.Assert(t, found, "placeholder %s should have been deleted", name)
+	}
+	assert.Equal(t, testutil.ToFloat64(placeholderGCDeleted.WithLabelValues(orphanNoOwner))-deletedBefore, float64(2))
+}
+
+func TestPlaceholderGCStartStop(t *testing.T) {
+	context, _ := initContextAndAPIProviderForTest()
+	gc := NewPlaceholderGC(context)
+	gc.Start()
+	assert.Assert(t, gc.running.Load(), "GC should be running")
+	gc.Stop()
+	err := utils.WaitForCondition(func() bool {
+		return !gc.running.Load()
+	}, 10*time.Millisecond, time.Second)
+	assert.NilError(t, err, "GC should have stopped")
+}
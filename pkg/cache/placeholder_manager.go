@@ -19,14 +19,22 @@
 package cache
 
 import (
-	"strings"
+	"context"
+	"sync"
 	"sync/atomic"
 	"time"
 
 	"go.uber.org/zap"
 	v1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	utilnet "k8s.io/apimachinery/pkg/util/net"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/util/flowcontrol"
+	"k8s.io/client-go/util/retry"
 
 	"github.com/apache/yunikorn-k8shim/pkg/client"
+	"github.com/apache/yunikorn-k8shim/pkg/common/utils"
+	"github.com/apache/yunikorn-k8shim/pkg/conf"
 	"github.com/apache/yunikorn-k8shim/pkg/locking"
 	"github.com/apache/yunikorn-k8shim/pkg/log"
 )
@@ -42,6 +50,12 @@ type PlaceholderManager struct {
 	stopChan    chan struct{}
 	running     atomic.Bool
 	cleanupTime time.Duration
+	// client side rate limit for placeholder creation, shared by all applications
+	limiter      flowcontrol.RateLimiter
+	limiterQPS   int
+	limiterBurst int
+	// initial delay before retrying a failed placeholder create
+	retryBackoff time.Duration
 	// a simple mutex will do we do not have separate read and write paths
 	locking.RWMutex
 }
@@ -55,10 +69,11 @@ func NewPlaceholderManager(clients *client.Clients) *PlaceholderManager {
 	mu.Lock()
 	defer mu.Unlock()
 	placeholderMgr = &PlaceholderManager{
-		clients:     clients,
-		orphanPods:  make(map[string]*v1.Pod),
-		stopChan:    make(chan struct{}),
-		cleanupTime: 5 * time.Second,
+		clients:      clients,
+		orphanPods:   make(map[string]*v1.Pod),
+		stopChan:     make(chan struct{}),
+		cleanupTime:  5 * time.Second,
+		retryBackoff: 100 * time.Millisecond,
 	}
 	return placeholderMgr
 }
@@ -69,57 +84,224 @@ func getPlaceholderManager() *PlaceholderManager {
 	return placeholderMgr
 }
 
+// createAppPlaceholders creates the missing placeholders of all task groups of the application.
+// Placeholders are created concurrently, limited by the configured parallelism and rate limit. A placeholder
+// that fails with a retryable error is retried. If any placeholder cannot be created the remaining creates are
+// abandoned and the placeholders created by this call are deleted before the error is returned.
+// The creation is serialised per application, different applications create their placeholders in parallel.
 func (mgr *PlaceholderManager) createAppPlaceholders(app *Application) error {
-	mgr.Lock()
-	defer mgr.Unlock()
-
+	app.placeholderCreateLock.Lock()
+	defer app.placeholderCreateLock.Unlock()
 	// map task group to count of already created placeholders
 	tgCounts := make(map[string]int32)
 	for _, ph := range app.getPlaceHolderTasks() {
-		tgCounts[ph.GetTaskGroupName()]++
+		if !ph.isTerminated() {
+			tgCounts[ph.GetTaskGroupName()]++
+		}
 	}
 
-	// iterate all task groups, create placeholders for all the min members
+	// iterate all task groups, collect the missing placeholders for all the min members
+	missing := make([]TaskGroup, 0)
 	for _, tg := range app.getTaskGroups() {
-		count := tgCounts[tg.Name]
-		// only create missing pods for each task group
-		for i := count; i < tg.MinMember; i++ {
-			placeholderName := GeneratePlaceholderName(tg.Name, app.GetApplicationID())
-			placeholder := newPlaceholder(placeholderName, app, tg)
-			// create the placeholder on K8s
-			_, err := mgr.clients.KubeClient.Create(placeholder.pod)
-			if err != nil {
-				log.Log(log.ShimCachePlaceholder).Error("failed to create placeholder pod",
-					zap.Error(err))
-				return err
+		for i := tgCounts[tg.Name]; i < tg.MinMember; i++ {
+			missing = append(missing, tg)
+		}
+	}
+	return mgr.createPlaceholders(app, missing)
+}
+
+// createTaskGroupPlaceholders creates the given number of placeholders for a single task group
+func (mgr *PlaceholderManager) createTaskGroupPlaceholders(app *Application, tg TaskGroup, count int32) error {
+	app.placeholderCreateLock.Lock()
+	defer app.placeholderCreateLock.Unlock()
+	missing := make([]TaskGroup, 0, count)
+	for i := int32(0); i < count; i++ {
+		missing = append(missing, tg)
+	}
+	return mgr.createPlaceholders(app, missing)
+}
+
+// createPlaceholders creates one placeholder for each entry in the list, rolling back on failure
+func (mgr *PlaceholderManager) createPlaceholders(app *Application, missing []TaskGroup) error {
+	if len(missing) == 0 {
+		return nil
+	}
+	createConf := conf.GetSchedulerConf().PlaceholderCreate
+	limiter := mgr.getRateLimiter(createConf.QPS, createConf.Burst)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	var (
+		wg       sync.WaitGroup
+		lock     locking.Mutex
+		created  []*v1.Pod
+		firstErr error
+	)
+	work := make(chan TaskGroup)
+	workers := min(createConf.Parallelism, len(missing))
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for tg := range work {
+				pod, err := mgr.createPlaceholder(ctx, app, tg, limiter, createConf.Retries)
+				lock.Lock()
+				if err != nil {
+					// keep the first error and stop all further creates
+					if firstErr == nil {
+						firstErr = err
+						cancel()
+					}
+				} else {
+					created = append(created, pod)
+				}
+				lock.Unlock()
 			}
-			log.Log(log.ShimCachePlaceholder).Info("placeholder created",
-				zap.Stringer("placeholder", placeholder))
+		}()
+	}
+feed:
+	for _, tg := range missing {
+		select {
+		case work <- tg:
+		case <-ctx.Done():
+			break feed
 		}
 	}
+	close(work)
+	wg.Wait()
 
+	if firstErr != nil {
+		log.Log(log.ShimCachePlaceholder).Error("failed to create placeholders, rolling back",
+			zap.String("appID", app.GetApplicationID()),
+			zap.Int("created", len(created)),
+			zap.Int("required", len(missing)),
+			zap.Error(firstErr))
+		mgr.rollback(created)
+		return firstErr
+	}
 	return nil
 }
 
-// clean up all the placeholders for an application
-func (mgr *PlaceholderManager) cleanUp(app *Application) {
+// createPlaceholder creates a single placeholder, retrying on errors that are expected to be transient.
+// A retry uses the same name: a create that timed out could have succeeded, the placeholder that already exists is
+// used if it belongs to the application. A name that is taken on the first attempt is replaced by a new name.
+func (mgr *PlaceholderManager) createPlaceholder(ctx context.Context, app *Application, tg TaskGroup, limiter flowcontrol.RateLimiter, retries int) (*v1.Pod, error) {
+	backoff := wait.Backoff{
+		Steps:    retries + 1,
+		Duration: mgr.getRetryBackoff(),
+		Factor:   2.0,
+		Jitter:   0.1,
+	}
+	var created *v1.Pod
+	placeholderName := GeneratePlaceholderName(tg.Name, app.GetApplicationID())
+	sent := false
+	err := retry.OnError(backoff, isRetryablePlaceholderError, func() error {
+		if err := limiter.Wait(ctx); err != nil {
+			return err
+		}
+		placeholder := newPlaceholder(placeholderName, app, tg)
+		// create the placeholder on K8s
+		pod, err := mgr.clients.KubeClient.Create(placeholder.pod)
+		if apierrors.IsAlreadyExists(err) && sent {
+			if existing := mgr.getCreatedPlaceholder(app, placeholder.pod); existing != nil {
+				pod, err = existing, nil
+			}
+		}
+		if err != nil {
+			log.Log(log.ShimCachePlaceholder).Warn("failed to create placeholder pod",
+				zap.Stringer("placeholder", placeholder),
+				zap.Error(err))
+			if apierrors.IsAlreadyExists(err) {
+				placeholderName = GeneratePlaceholderName(tg.Name, app.GetApplicationID())
+				sent = false
+			} else {
+				sent = true
+			}
+			return err
+		}
+		log.Log(log.ShimCachePlaceholder).Info("placeholder created",
+			zap.Stringer("placeholder", placeholder))
+		created = pod
+		return nil
+	})
+	return created, err
+}
+
+// getCreatedPlaceholder returns the existing pod with the name of the placeholder if it is a placeholder of the
+// application, nil otherwise.
+func (mgr *PlaceholderManager) getCreatedPlaceholder(app *Application, placeholder *v1.Pod) *v1.Pod {
+	pod, err := mgr.clients.KubeClient.Get(placeholder.Namespace, placeholder.Name)
+	if err != nil || !utils.GetPlaceholderFlagFromPodSpec(pod) || utils.GetApplicationIDFromPod(pod) != app.GetApplicationID() {
+		return nil
+	}
+	log.Log(log.ShimCachePlaceholder).Info("placeholder was created by an earlier attempt",
+		zap.String("podName", pod.Name))
+	return pod
+}
+
+// isRetryablePlaceholderError returns true if the create failure is expected to be transient
+func isRetryablePlaceholderError(err error) bool {
+	return apierrors.IsAlreadyExists(err) ||
+		apierrors.IsServerTimeout(err) ||
+		apierrors.IsTimeout(err) ||
+		apierrors.IsTooManyRequests(err) ||
+		apierrors.IsInternalError(err) ||
+		apierrors.IsServiceUnavailable(err) ||
+		utilnet.IsConnectionReset(err) ||
+		utilnet.IsConnectionRefused(err) ||
+		utilnet.IsProbableEOF(err)
+}
+
+// rollback deletes the placeholders of a partially created gang
+func (mgr *PlaceholderManager) rollback(pods []*v1.Pod) {
 	mgr.Lock()
 	defer mgr.Unlock()
+	for _, pod := range pods {
+		err := mgr.clients.KubeClient.Delete(pod)
+		if err != nil {
+			log.Log(log.ShimCachePlaceholder).Warn("failed to roll back placeholder pod",
+				zap.String("podName", pod.Name),
+				zap.Error(err))
+			if !apierrors.IsNotFound(err) {
+				mgr.orphanPods[getOrphanKey(pod)] = pod
+			}
+		}
+	}
+}
+
+// getOrphanKey returns the key for the orphan map: the pod UID which is also the task ID, or the name if the
+// pod was never seen by the API server
+func getOrphanKey(pod *v1.Pod) string {
+	if pod.UID != "" {
+		return string(pod.UID)
+	}
+	return pod.Namespace + "/" + pod.Name
+}
+
+// clean up all the placeholders for an application
+func (mgr *PlaceholderManager) cleanUp(app *Application) {
 	log.Log(log.ShimCachePlaceholder).Info("start to clean up app placeholders",
 		zap.String("appID", app.GetApplicationID()))
-	for _, task := range app.GetPlaceHolderTasks() {
+	mgr.deletePlaceholders(app.GetPlaceHolderTasks())
+	log.Log(log.ShimCachePlaceholder).Info("finished cleaning up app placeholders",
+		zap.String("appID", app.GetApplicationID()))
+}
+
+// deletePlaceholders removes the pods of the placeholder tasks, pods that cannot be deleted are tracked as orphans
+func (mgr *PlaceholderManager) deletePlaceholders(tasks []*Task) {
+	mgr.Lock()
+	defer mgr.Unlock()
+	for _, task := range tasks {
 		// remove pod
 		err := mgr.clients.KubeClient.Delete(task.GetTaskPod())
 		if err != nil {
 			log.Log(log.ShimCachePlaceholder).Warn("failed to clean up placeholder pod",
 				zap.Error(err))
-			if !strings.Contains(err.Error(), "not found") {
+			if !apierrors.IsNotFound(err) {
 				mgr.orphanPods[task.GetTaskID()] = task.GetTaskPod()
 			}
 		}
 	}
-	log.Log(log.ShimCachePlaceholder).Info("finished cleaning up app placeholders",
-		zap.String("appID", app.GetApplicationID()))
 }
 
 func (mgr *PlaceholderManager) cleanOrphanPlaceholders() {
@@ -189,6 +371,30 @@ func (mgr *PlaceholderManager) setCleanupTime(value time.Duration) {
 	mgr.cleanupTime = value
 }
 
+// getRateLimiter returns the shared placeholder rate limiter, replacing it when the configured limits changed
+func (mgr *PlaceholderManager) getRateLimiter(qps, burst int) flowcontrol.RateLimiter {
+	mgr.Lock()
+	defer mgr.Unlock()
+	if mgr.limiter == nil || mgr.limiterQPS != qps || mgr.limiterBurst != burst {
+		mgr.limiter = flowcontrol.NewTokenBucketRateLimiter(float32(qps), burst)
+		mgr.limiterQPS = qps
+		mgr.limiterBurst = burst
+	}
+	return mgr.limiter
+}
+
+func (mgr *PlaceholderManager) setRetryBackoff(value time.Duration) {
+	mgr.Lock()
+	defer mgr.Unlock()
+	mgr.retryBackoff = value
+}
+
+func (mgr *PlaceholderManager) getRetryBackoff() time.Duration {
+	mgr.RLock()
+	defer mgr.RUnlock()
+	return mgr.retryBackoff
+}
+
 func (mgr *PlaceholderManager) getCleanupTime() time.Duration {
 	mgr.RLock()
 	defer mgr.RUnlock()
@@ -27,6 +27,7 @@ import (
 	"gotest.tools/v3/assert"
 	is "gotest.tools/v3/assert/cmp"
 	v1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/api/resource"
 	apis "k8s.io/apimachinery/pkg/apis/meta/v1"
 
@@ -94,6 +95,102 @@ func TestCreateAppPlaceholdersWithExistingPods(t *testing.T) {
 	}
 }
 
+func TestCreateAppPlaceholdersRetry(t *testing.T) {
+	app := createAppWIthTaskGroupForTest()
+	mockedAPIProvider := client.NewMockedAPIProvider(false)
+	createdPods := make(map[string]*v1.Pod)
+	failures := 0
+	mockedAPIProvider.MockCreateFn(func(pod *v1.Pod) (*v1.Pod, error) {
+		// the first creates fail with transient errors which are retried
+		if failures < 3 {
+			failures++
+			return nil, apierrors.NewTooManyRequests("slow down", 0)
+		}
+		createdPods[pod.Name] = pod
+		return pod, nil
+	})
+	placeholderMgr = NewPlaceholderManager(mockedAPIProvider.GetAPIs())
+	placeholderMgr.setRetryBackoff(time.Millisecond)
+	err := placeholderMgr.createAppPlaceholders(app)
+	assert.NilError(t, err, "transient errors should have been retried")
+	assert.Equal(t, 3, failures)
+	assert.Equal(t, 30, len(createdPods))
+}
+
+func TestCreateAppPlaceholdersTimedOut(t *testing.T) {
+	app := createAppWIthTaskGroupForTest()
+	mockedAPIProvider := client.NewMockedAPIProvider(false)
+	attempts := make(map[string]int)
+	collisions := 0
+	mockedAPIProvider.MockCreateFn(func(pod *v1.Pod) (*v1.Pod, error) {
+		// the first name is taken by another pod, a new name is used
+		if collisions == 0 {
+			collisions++
+			return nil, apierrors.NewAlreadyExists(v1.Resource("pods"), pod.Name)
+		}
+		// the create times out but the pod is created, the retry finds the pod
+		attempts[pod.Name]++
+		if attempts[pod.Name] == 1 {
+			return nil, apierrors.NewTimeoutError("create timed out", 0)
+		}
+		return nil, apierrors.NewAlreadyExists(v1.Resource("pods"), pod.Name)
+	})
+	placeholderMgr = NewPlaceholderManager(mockedAPIProvider.GetAPIs())
+	placeholderMgr.setRetryBackoff(time.Millisecond)
+	err := placeholderMgr.createAppPlaceholders(app)
+	assert.NilError(t, err, "timed out creates should have been found on retry")
+	assert.Equal(t, 30, len(attempts), "placeholders must not be created twice")
+	for name, count := range attempts {
+		assert.Equal(t, 2, count, "placeholder %s not retried with the same name", name)
+	}
+}
+
+func TestCreateAppPlaceholdersRollback(t *testing.T) {
+	app := createAppWIthTaskGroupForTest()
+	mockedAPIProvider := client.NewMockedAPIProvider(false)
+	createdPods := make(map[string]*v1.Pod)
+	attempts := 0
+	mockedAPIProvider.MockCreateFn(func(pod *v1.Pod) (*v1.Pod, error) {
+		attempts++
+		// retries of this placeholder are exhausted and the whole gang is rolled back
+		if attempts > 5 {
+			return nil, apierrors.NewServiceUnavailable("unavailable")
+		}
+		createdPods[pod.Name] = pod
+		return pod, nil
+	})
+	deletedPods := make(map[string]bool)
+	mockedAPIProvider.MockDeleteFn(func(pod *v1.Pod) error {
+		if len(deletedPods) == 0 {
+			deletedPods[pod.Name] = false
+			return fmt.Errorf("delete failed")
+		}
+		deletedPods[pod.Name] = true
+		return nil
+	})
+	placeholderMgr = NewPlaceholderManager(mockedAPIProvider.GetAPIs())
+	placeholderMgr.setRetryBackoff(time.Millisecond)
+	err := placeholderMgr.createAppPlaceholders(app)
+	assert.Assert(t, apierrors.IsServiceUnavailable(err), "unexpected error: %v", err)
+	assert.Equal(t, 5, len(createdPods))
+	assert.Equal(t, 5, len(deletedPods), "all created placeholders should have been deleted")
+	for name := range createdPods {
+		_, ok := deletedPods[name]
+		assert.Assert(t, ok, "placeholder %s not rolled back", name)
+	}
+	assert.Equal(t, 1, placeholderMgr.getOrphanPodsLength(), "failed rollback should leave an orphan")
+}
+
+func TestPlaceholderRateLimiter(t *testing.T) {
+	mockedAPIProvider := client.NewMockedAPIProvider(false)
+	mgr := NewPlaceholderManager(mockedAPIProvider.GetAPIs())
+	limiter := mgr.getRateLimiter(10, 20)
+	assert.Equal(t, limiter, mgr.getRateLimiter(10, 20), "unchanged limits should reuse the limiter")
+	assert.Equal(t, float32(10), limiter.QPS())
+	limiter = mgr.getRateLimiter(5, 20)
+	assert.Equal(t, float32(5), limiter.QPS(), "changed limits should replace the limiter")
+}
+
 func createAndCheckPlaceholderCreate(mockedAPIProvider *client.MockedAPIProvider, app *Application, t *testing.T) map[string]*v1.Pod {
 	createdPods := make(map[string]*v1.Pod)
 	mockedAPIProvider.MockCreateFn(func(pod *v1.Pod) (*v1.Pod, error) {
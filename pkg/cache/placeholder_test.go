@@ -29,6 +29,7 @@ import (
 
 	"github.com/apache/yunikorn-k8shim/pkg/common"
 	"github.com/apache/yunikorn-k8shim/pkg/common/constants"
+	"github.com/apache/yunikorn-k8shim/pkg/conf"
 	siCommon "github.com/apache/yunikorn-scheduler-interface/lib/go/common"
 )
 
@@ -281,6 +282,37 @@ func TestNewPlaceholderWithPriorityClassName(t *testing.T) {
 	assert.Equal(t, priorityClassName, holder.pod.Spec.PriorityClassName)
 }
 
+func TestNewPlaceholderWithTaskGroupClasses(t *testing.T) {
+	app := NewApplication(appID, queue,
+		"bob", testGroups, map[string]string{constants.AppTagNamespace: namespace}, newMockSchedulerAPI())
+	tg := taskGroups[0]
+	tg.PriorityClassName = "tg-priority"
+	tg.RuntimeClassName = "kata"
+	tg.Overhead = v1.ResourceList{v1.ResourceCPU: resource.MustParse("250m")}
+	app.setTaskGroups([]TaskGroup{tg})
+	// the overhead is part of the placeholder ask of every member
+	assert.Equal(t, app.placeholderAsk.Resources[siCommon.CPU].Value, int64(10*750))
+	assert.Equal(t, app.placeholderAsk.Resources[siCommon.Memory].Value, int64(10*1024*1000*1000))
+
+	pod := &v1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: "originator", UID: "UID-01"},
+		Spec:       v1.PodSpec{PriorityClassName: priorityClassName},
+	}
+	task := NewTask("task1-01", app, initContextForTest(), pod)
+	task.originator = true
+	app.setOriginatingTask(task)
+
+	holder := newPlaceholder("ph-name", app, app.taskGroups[0])
+	assert.Equal(t, holder.pod.Spec.PriorityClassName, "tg-priority", "task group priority class should take precedence")
+	assert.Equal(t, *holder.pod.Spec.RuntimeClassName, "kata")
+	assert.DeepEqual(t, holder.pod.Spec.Overhead, tg.Overhead)
+	// the overhead is not added to the container: the pod overhead is accounted separately
+	cpu := holder.pod.Spec.Containers[0].Resources.Requests[v1.ResourceCPU]
+	assert.Equal(t, cpu.MilliValue(), int64(500))
+	podResource := common.GetPodResource(holder.pod)
+	assert.Equal(t, podResource.Resources[siCommon.CPU].Value, int64(750))
+}
+
 func TestNewPlaceholderWithTopologySpreadConstraints(t *testing.T) {
 	mockedSchedulerAPI := newMockSchedulerAPI()
 	app := NewApplication(appID, queue,
@@ -297,3 +329,85 @@ func TestNewPlaceholderWithTopologySpreadConstraints(t *testing.T) {
 		"labelKey1": "labelKeyValue1",
 	})
 }
+
+func TestNewPlaceholderWithTemplate(t *testing.T) {
+	err := conf.UpdateConfigMaps([]*v1.ConfigMap{nil, {Data: map[string]string{
+		conf.CMSvcPlaceholderTemplate: `
+metadata:
+  labels:
+    cost-center: analytics
+    labelKey0: fromTemplate
+spec:
+  runtimeClassName: gvisor
+  securityContext:
+    runAsNonRoot: true
+    runAsUser: 2000
+    seccompProfile:
+      type: RuntimeDefault
+  containers:
+  - name: placeholder
+    securityContext:
+      allowPrivilegeEscalation: false
+      capabilities:
+        drop: ["ALL"]
+  nodeSelector:
+    pool: batch
+  tolerations:
+  - key: dedicated
+    operator: Exists
+`,
+		conf.CMSvcPlaceholderTemplatePrefix + "other": `
+spec:
+  priorityClassName: low
+`,
+	}}}, true)
+	assert.NilError(t, err, "failed to set placeholder template")
+	defer func() {
+		err = conf.UpdateConfigMaps([]*v1.ConfigMap{nil, nil}, true)
+		assert.NilError(t, err, "failed to reset configmap")
+	}()
+
+	mockedSchedulerAPI := newMockSchedulerAPI()
+	app := NewApplication(appID, queue, "bob", testGroups, map[string]string{constants.AppTagNamespace: namespace}, mockedSchedulerAPI)
+	app.setTaskGroups(taskGroups)
+
+	holder := newPlaceholder("ph-name", app, app.taskGroups[0])
+	assert.DeepEqual(t, holder.pod.Labels, map[string]string{
+		constants.CanonicalLabelApplicationID: appID,
+		constants.CanonicalLabelQueueName:     queue,
+		"cost-center":                         "analytics",
+		"labelKey0":                           "labelKeyValue0",
+		"labelKey1":                           "labelKeyValue1",
+	})
+	spec := holder.pod.Spec
+	assert.Equal(t, *spec.RuntimeClassName, "gvisor")
+	assert.Equal(t, *spec.SecurityContext.RunAsUser, int64(2000))
+	assert.Assert(t, spec.SecurityContext.RunAsGroup == nil, "template security context should not be merged with the defaults")
+	assert.Equal(t, *spec.SecurityContext.RunAsNonRoot, true)
+	assert.Equal(t, len(spec.Containers), 1)
+	assert.Equal(t, spec.Containers[0].Name, "placeholder")
+	assert.Equal(t, spec.Containers[0].Image, constants.PlaceholderContainerImage)
+	assert.Equal(t, *spec.Containers[0].SecurityContext.AllowPrivilegeEscalation, false)
+	assert.Equal(t, len(spec.Containers[0].Resources.Requests), 5, "expected requests not found")
+	assert.Equal(t, spec.RestartPolicy, v1.RestartPolicy(constants.PlaceholderPodRestartPolicy))
+	assert.Equal(t, *spec.TerminationGracePeriodSeconds, int64(0))
+	assert.Equal(t, spec.SchedulerName, constants.SchedulerName)
+	assert.DeepEqual(t, spec.NodeSelector, map[string]string{
+		"pool":      "batch",
+		"nodeType":  "test",
+		"nodeState": "healthy",
+	})
+	assert.Equal(t, len(spec.Tolerations), 2, "unexpected number of tolerations")
+	assert.Assert(t, spec.Affinity != nil, "task group affinity not set")
+
+	// namespace override replaces the global template
+	app = NewApplication(appID, queue, "bob", testGroups, map[string]string{constants.AppTagNamespace: "other"}, mockedSchedulerAPI)
+	app.setTaskGroups(taskGroups)
+	holder = newPlaceholder("ph-name", app, app.taskGroups[0])
+	spec = holder.pod.Spec
+	assert.Equal(t, spec.PriorityClassName, "low")
+	assert.Assert(t, spec.RuntimeClassName == nil, "global template should not be used")
+	assert.Equal(t, spec.SecurityContext.RunAsUser, &runAsUser)
+	assert.Equal(t, spec.Containers[0].Name, constants.PlaceholderContainerName)
+	assert.Equal(t, holder.pod.Labels["cost-center"], "")
+}
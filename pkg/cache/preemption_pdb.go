@@ -0,0 +1,143 @@
+/*
+ Licensed to the Apache Software Foundation (ASF) under one
+ or more contributor license agreements.  See the NOTICE file
+ distributed with this work for additional information
+ regarding copyright ownership.  The ASF licenses this file
+ to you under the Apache License, Version 2.0 (the
+ "License"); you may not use this file except in compliance
+ with the License.  You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package cache
+
+import (
+	"strings"
+
+	"go.uber.org/zap"
+	v1 "k8s.io/api/core/v1"
+
+	"github.com/apache/yunikorn-k8shim/pkg/common/events"
+	"github.com/apache/yunikorn-k8shim/pkg/common/utils"
+	schedulerconf "github.com/apache/yunikorn-k8shim/pkg/conf"
+	"github.com/apache/yunikorn-k8shim/pkg/locking"
+	"github.com/apache/yunikorn-k8shim/pkg/log"
+)
+
+// pdbRejections tracks the nodes rejected for the preemption of a pod because preempting the victims on the node
+// violates a PodDisruptionBudget. The response to the core only tells if the pod fits, a rejection lets the core
+// try the other nodes first.
+type pdbRejections struct {
+	nodes map[string]map[string]int // number of victims violating a PDB by node, by pod UID
+	locking.Mutex
+}
+
+func newPDBRejections() *pdbRejections {
+	return &pdbRejections{
+		nodes: make(map[string]map[string]int),
+	}
+}
+
+// reject records the number of victims violating a PDB for the preemption on the node for the pod, it returns true
+// if the node was rejected before
+func (r *pdbRejections) reject(podUID, nodeID string, violations int) bool {
+	r.Lock()
+	defer r.Unlock()
+	nodes, ok := r.nodes[podUID]
+	if !ok {
+		nodes = make(map[string]int)
+		r.nodes[podUID] = nodes
+	}
+	_, rejected := nodes[nodeID]
+	nodes[nodeID] = violations
+	return rejected
+}
+
+// preferred returns true if no other node rejected for the pod needs fewer violations than the node. The node with
+// the fewest violations is forgotten otherwise: a node the core does not retry cannot block the other nodes more
+// than once.
+func (r *pdbRejections) preferred(podUID, nodeID string) bool {
+	r.Lock()
+	defer r.Unlock()
+	nodes := r.nodes[podUID]
+	better := ""
+	for node, violations := range nodes {
+		if violations < nodes[nodeID] && (better == "" || violations < nodes[better]) {
+			better = node
+		}
+	}
+	if better == "" {
+		return true
+	}
+	delete(nodes, better)
+	return false
+}
+
+func (r *pdbRejections) remove(podUID string) {
+	r.Lock()
+	defer r.Unlock()
+	delete(r.nodes, podUID)
+}
+
+// isPDBCovered returns true if PodDisruptionBudgets are checked for preemption and a PDB covers the pod.
+// The SI has no victim order of its own: the shim cannot reorder the victims the core evaluates, a pod that does not
+// allow preemption is sorted last by the core instead.
+func (ctx *Context) isPDBCovered(pod *v1.Pod) bool {
+	if schedulerconf.GetSchedulerConf().GetPreemptionPDBPolicy() == schedulerconf.PreemptionPDBPolicyIgnore {
+		return false
+	}
+	// an error is returned if no PDB covers the pod
+	pdbs, err := ctx.apiProvider.GetAPIs().PodDisruptionBudgetInformer.Lister().GetPodPodDisruptionBudgets(pod)
+	return err == nil && len(pdbs) > 0
+}
+
+// checkPreemptionPDBs returns true if the victims can be preempted on the node to make room for the pod. The victims
+// are scored by PDB violation, the outcome for victims that violate a PDB depends on the configured policy:
+// the Prefer policy rejects the node and accepts it when the core asks again and no other node needs fewer
+// violations, the Enforce policy always rejects the node.
+func (ctx *Context) checkPreemptionPDBs(pod *v1.Pod, nodeID string, victims []*v1.Pod) bool {
+	policy := schedulerconf.GetSchedulerConf().GetPreemptionPDBPolicy()
+	if policy == schedulerconf.PreemptionPDBPolicyIgnore {
+		return true
+	}
+	var violating []string
+	lister := ctx.apiProvider.GetAPIs().PodDisruptionBudgetInformer.Lister()
+	for index, violation := range utils.GetPodDisruptionBudgetViolations(victims, lister) {
+		if violation {
+			violating = append(violating, victims[index].Namespace+"/"+victims[index].Name)
+		}
+	}
+	if len(violating) == 0 {
+		return true
+	}
+	podUID := string(pod.UID)
+	rejectedBefore := ctx.pdbRejections.reject(podUID, nodeID, len(violating))
+	if policy == schedulerconf.PreemptionPDBPolicyPrefer && rejectedBefore && ctx.pdbRejections.preferred(podUID, nodeID) {
+		log.Log(log.ShimContext).Info("preemption violates PodDisruptionBudgets, no node with fewer violations was found",
+			zap.String("podName", pod.Name),
+			zap.String("nodeID", nodeID),
+			zap.Strings("victims", violating))
+		events.GetRecorder().Eventf(pod.DeepCopy(), nil, v1.EventTypeWarning, "PreemptionViolatesPDB", "PreemptionViolatesPDB",
+			"Preempting %s on node %s violates a PodDisruptionBudget, no node with fewer violations was found",
+			strings.Join(violating, ", "), nodeID)
+		return true
+	}
+	log.Log(log.ShimContext).Debug("preemption rejected: violates PodDisruptionBudgets",
+		zap.String("podName", pod.Name),
+		zap.String("nodeID", nodeID),
+		zap.Strings("victims", violating),
+		zap.String("policy", policy))
+	if !rejectedBefore {
+		events.GetRecorder().Eventf(pod.DeepCopy(), nil, v1.EventTypeNormal, "PreemptionRejectedByPDB", "PreemptionRejectedByPDB",
+			"Node %s rejected for preemption: preempting %s violates a PodDisruptionBudget",
+			nodeID, strings.Join(violating, ", "))
+	}
+	return false
+}
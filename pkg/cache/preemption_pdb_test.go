@@ -0,0 +1,45 @@
+/*
+ Licensed to the Apache Software Foundation (ASF) under one
+ or more contributor license agreements.  See the NOTICE file
+ distributed with this work for additional information
+ regarding copyright ownership.  The ASF licenses this file
+ to you under the Apache License, Version 2.0 (the
+ "License"); you may not use this file except in compliance
+ with the License.  You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package cache
+
+import (
+	"testing"
+
+	"gotest.tools/v3/assert"
+)
+
+func TestPDBRejectionsPreferred(t *testing.T) {
+	r := newPDBRejections()
+	assert.Assert(t, !r.reject("pod", "node-1", 2), "first rejection of node-1")
+	assert.Assert(t, !r.reject("pod", "node-2", 1), "first rejection of node-2")
+	assert.Assert(t, !r.reject("other", "node-1", 1), "rejections are tracked per pod")
+
+	// node-2 needs fewer violations: node-1 is rejected again and node-2 is forgotten
+	assert.Assert(t, r.reject("pod", "node-1", 2), "node-1 was rejected before")
+	assert.Assert(t, !r.preferred("pod", "node-1"), "node-2 needs fewer violations")
+	assert.Assert(t, r.preferred("pod", "node-1"), "node-2 should have been forgotten")
+
+	// the node with the fewest violations is preferred
+	assert.Assert(t, !r.reject("pod", "node-2", 1), "node-2 was forgotten")
+	assert.Assert(t, r.reject("pod", "node-2", 1), "node-2 was rejected before")
+	assert.Assert(t, r.preferred("pod", "node-2"), "node-2 needs the fewest violations")
+
+	r.remove("pod")
+	assert.Assert(t, !r.reject("pod", "node-1", 2), "rejections should have been removed")
+}
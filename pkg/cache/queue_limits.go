@@ -0,0 +1,152 @@
+/*
+ Licensed to the Apache Software Foundation (ASF) under one
+ or more contributor license agreements.  See the NOTICE file
+ distributed with this work for additional information
+ regarding copyright ownership.  The ASF licenses this file
+ to you under the Apache License, Version 2.0 (the
+ "License"); you may not use this file except in compliance
+ with the License.  You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package cache
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/apache/yunikorn-core/pkg/common/configs"
+	"github.com/apache/yunikorn-core/pkg/common/resources"
+	"github.com/apache/yunikorn-core/pkg/common/security"
+	"github.com/apache/yunikorn-k8shim/pkg/common"
+	"github.com/apache/yunikorn-k8shim/pkg/common/utils"
+	schedulerconf "github.com/apache/yunikorn-k8shim/pkg/conf"
+)
+
+const rootQueue = "root"
+
+// getQueueConfigPath returns the configuration of the queues from the root down to the given queue, as defined in
+// the current core scheduler configuration. An error is returned if the partition or queue does not exist.
+// Must be called with the context lock held.
+func (ctx *Context) getQueueConfigPath(partitionName, queuePath string) ([]configs.QueueConfig, error) {
+	config := utils.GetCoreSchedulerConfigFromConfigMap(schedulerconf.FlattenConfigMaps(ctx.configMaps))
+	if config == "" {
+		config = configs.DefaultSchedulerConfig
+	}
+	schedulerConfig, err := configs.LoadSchedulerConfigFromByteArray([]byte(config))
+	if err != nil {
+		return nil, fmt.Errorf("unable to load the queue configuration: %w", err)
+	}
+	var partition *configs.PartitionConfig
+	for i := range schedulerConfig.Partitions {
+		if strings.EqualFold(schedulerConfig.Partitions[i].Name, partitionName) {
+			partition = &schedulerConfig.Partitions[i]
+			break
+		}
+	}
+	if partition == nil {
+		return nil, fmt.Errorf("partition %s does not exist", partitionName)
+	}
+	var result []configs.QueueConfig
+	queues := partition.Queues
+	for _, name := range strings.Split(queuePath, ".") {
+		found := false
+		for _, queue := range queues {
+			if strings.EqualFold(queue.Name, name) {
+				result = append(result, queue)
+				queues = queue.Queues
+				found = true
+				break
+			}
+		}
+		if !found {
+			return nil, fmt.Errorf("queue %s does not exist", queuePath)
+		}
+	}
+	return result, nil
+}
+
+// getQueueUsage returns the number of active applications and the allocated resources of the applications in the
+// queue and its children, as known to the shim.
+// Must be called with the context lock held.
+func (ctx *Context) getQueueUsage(path string) (uint64, *resources.Resource) {
+	activeApps := uint64(0)
+	usage := resources.NewResourceFromProto(common.NewResourceBuilder().Build())
+	for _, app := range ctx.applications {
+		if !isQueueInPath(normalizeQueuePath(app.GetQueue()), path) {
+			continue
+		}
+		switch app.GetApplicationState() {
+		case ApplicationStates().Completed, ApplicationStates().Failed, ApplicationStates().Killed, ApplicationStates().Rejected:
+			continue
+		}
+		activeApps++
+		usage.AddTo(resources.NewResourceFromProto(app.getAllocatedResource()))
+	}
+	return activeApps, usage
+}
+
+// checkQueueMaxApplications checks if the additional applications fit in the queue on top of the active applications
+func (ctx *Context) checkQueueMaxApplications(queue configs.QueueConfig, path string, additional uint64) error {
+	if queue.MaxApplications == 0 {
+		return nil
+	}
+	if activeApps, _ := ctx.getQueueUsage(path); activeApps+additional > queue.MaxApplications {
+		return fmt.Errorf("queue %s has reached its maximum of %d applications", path, queue.MaxApplications)
+	}
+	return nil
+}
+
+// checkQueueMaxResource checks if the additional resources fit in the queue on top of the current usage
+func (ctx *Context) checkQueueMaxResource(queue configs.QueueConfig, path string, additional *resources.Resource) error {
+	if len(queue.Resources.Max) == 0 {
+		return nil
+	}
+	maxResource, err := resources.NewResourceFromConf(queue.Resources.Max)
+	if err != nil {
+		return fmt.Errorf("invalid maximum resources for queue %s: %w", path, err)
+	}
+	_, usage := ctx.getQueueUsage(path)
+	usage.AddTo(additional)
+	if !maxResource.FitInMaxUndef(usage) {
+		return fmt.Errorf("resources do not fit in the maximum resources of queue %s: max %s, required %s",
+			path, maxResource, usage)
+	}
+	return nil
+}
+
+func checkQueueACL(aclStr string, user security.UserGroup) bool {
+	acl, err := security.NewACL(aclStr, true)
+	if err != nil {
+		return false
+	}
+	return acl.CheckAccess(user)
+}
+
+// normalizeQueuePath returns the lower case fully qualified queue name
+func normalizeQueuePath(queue string) string {
+	queue = strings.ToLower(queue)
+	if queue != rootQueue && !strings.HasPrefix(queue, rootQueue+".") {
+		queue = joinQueuePath(rootQueue, queue)
+	}
+	return queue
+}
+
+func joinQueuePath(parent, name string) string {
+	if parent == "" {
+		return strings.ToLower(name)
+	}
+	return parent + "." + strings.ToLower(name)
+}
+
+// isQueueInPath returns true if the queue is the given queue or one of its children
+func isQueueInPath(queue, path string) bool {
+	return queue == path || strings.HasPrefix(queue, path+".")
+}
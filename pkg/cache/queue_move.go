@@ -0,0 +1,157 @@
+/*
+ Licensed to the Apache Software Foundation (ASF) under one
+ or more contributor license agreements.  See the NOTICE file
+ distributed with this work for additional information
+ regarding copyright ownership.  The ASF licenses this file
+ to you under the Apache License, Version 2.0 (the
+ "License"); you may not use this file except in compliance
+ with the License.  You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package cache
+
+import (
+	"fmt"
+
+	"go.uber.org/zap"
+	v1 "k8s.io/api/core/v1"
+
+	"github.com/apache/yunikorn-core/pkg/common/resources"
+	"github.com/apache/yunikorn-core/pkg/common/security"
+	"github.com/apache/yunikorn-k8shim/pkg/common/events"
+	"github.com/apache/yunikorn-k8shim/pkg/common/utils"
+	"github.com/apache/yunikorn-k8shim/pkg/log"
+)
+
+// moveApplicationQueue moves the application to the target queue.
+// The move is checked against the queue configuration before the application is removed from the core: the queue
+// must exist and be a leaf queue, the user of the application must be allowed to submit to the queue and the
+// application must fit in the queue limits. A rejected move is published as an event on the originator pod.
+// Must be called with the context lock held.
+func (ctx *Context) moveApplicationQueue(app *Application, targetQueue string) error {
+	targetQueue = normalizeQueuePath(targetQueue)
+	if targetQueue == normalizeQueuePath(app.GetQueue()) {
+		return nil
+	}
+	err := ctx.validateQueueMove(app, targetQueue)
+	if err == nil {
+		err = app.moveQueue(targetQueue)
+	}
+	if err != nil {
+		log.Log(log.ShimContext).Warn("queue move rejected",
+			zap.String("appID", app.GetApplicationID()),
+			zap.String("targetQueue", targetQueue),
+			zap.Error(err))
+		if task := app.GetOriginatingTask(); task != nil {
+			events.GetRecorder().Eventf(task.GetTaskPod().DeepCopy(), nil, v1.EventTypeWarning, "QueueMoveRejected",
+				"QueueMoveRejected", "Application %s cannot be moved to queue %s: %v", app.GetApplicationID(), targetQueue, err)
+		}
+	}
+	return err
+}
+
+// updateApplicationQueue triggers a queue move if the queue set on the originator pod of the application has changed.
+func (ctx *Context) updateApplicationQueue(app *Application, oldPod, pod *v1.Pod) {
+	if app == nil || oldPod == nil {
+		return
+	}
+	if task := app.GetOriginatingTask(); task == nil || task.GetTaskID() != string(pod.UID) {
+		return
+	}
+	oldQueue := utils.GetQueueNameFromPod(oldPod)
+	newQueue := utils.GetQueueNameFromPod(pod)
+	if newQueue == "" || newQueue == oldQueue {
+		return
+	}
+	// errors are logged and published as events on the pod
+	_ = ctx.moveApplicationQueue(app, newQueue)
+}
+
+// validateQueueMove checks the move against the current core scheduler configuration. The usage of the queues is
+// based on the applications known to the shim.
+// Must be called with the context lock held.
+func (ctx *Context) validateQueueMove(app *Application, targetQueue string) error {
+	queuePath, err := ctx.getQueueConfigPath(app.partition, targetQueue)
+	if err != nil {
+		return err
+	}
+	leaf := queuePath[len(queuePath)-1]
+	if len(queuePath) == 1 || leaf.Parent || len(leaf.Queues) > 0 {
+		return fmt.Errorf("queue %s is not a leaf queue", targetQueue)
+	}
+
+	// the user needs submit or admin access on the queue or any of its parents
+	user := security.UserGroup{User: app.GetUser(), Groups: app.groups}
+	allowed := false
+	for _, queue := range queuePath {
+		if checkQueueACL(queue.SubmitACL, user) || checkQueueACL(queue.AdminACL, user) {
+			allowed = true
+			break
+		}
+	}
+	if !allowed {
+		return fmt.Errorf("user %s is not allowed to submit applications to queue %s", app.GetUser(), targetQueue)
+	}
+
+	// check the limits of each queue in the path the application is not already part of
+	currentQueue := normalizeQueuePath(app.GetQueue())
+	appAllocated := resources.NewResourceFromProto(app.getAllocatedResource())
+	path := ""
+	for _, queue := range queuePath {
+		path = joinQueuePath(path, queue.Name)
+		if isQueueInPath(currentQueue, path) {
+			continue
+		}
+		if err = ctx.checkQueueMaxApplications(queue, path, 1); err != nil {
+			return err
+		}
+		if err = ctx.checkQueueMaxResource(queue, path, appAllocated); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// checkAcceptedQueueMove checks the limits of the queues the application was moved to after the core accepted it.
+// The core does not check the limits for the allocations that are registered again, another application could have
+// been added to the queue after the move was validated.
+func (ctx *Context) checkAcceptedQueueMove(app *Application) error {
+	ctx.lock.RLock()
+	defer ctx.lock.RUnlock()
+	fromQueue, toQueue, reverting := app.getQueueMove()
+	if fromQueue == "" || reverting {
+		return nil
+	}
+	queuePath, err := ctx.getQueueConfigPath(app.partition, normalizeQueuePath(toQueue))
+	if err != nil {
+		// the configuration changed after the move was validated, the core accepted the queue
+		log.Log(log.ShimContext).Debug("unable to check the queue limits after the move",
+			zap.String("appID", app.GetApplicationID()),
+			zap.Error(err))
+		return nil
+	}
+	fromQueue = normalizeQueuePath(fromQueue)
+	path := ""
+	for _, queue := range queuePath {
+		path = joinQueuePath(path, queue.Name)
+		if isQueueInPath(fromQueue, path) {
+			continue
+		}
+		// the application is already counted in the usage of the queue
+		if err = ctx.checkQueueMaxApplications(queue, path, 0); err != nil {
+			return err
+		}
+		if err = ctx.checkQueueMaxResource(queue, path, resources.NewResource()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,223 @@
+/*
+ Licensed to the Apache Software Foundation (ASF) under one
+ or more contributor license agreements.  See the NOTICE file
+ distributed with this work for additional information
+ regarding copyright ownership.  The ASF licenses this file
+ to you under the Apache License, Version 2.0 (the
+ "License"); you may not use this file except in compliance
+ with the License.  You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package cache
+
+import (
+	"testing"
+
+	"gotest.tools/v3/assert"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	apis "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/apache/yunikorn-k8shim/pkg/client"
+	"github.com/apache/yunikorn-k8shim/pkg/common"
+	"github.com/apache/yunikorn-k8shim/pkg/common/constants"
+	"github.com/apache/yunikorn-k8shim/pkg/common/events"
+	"github.com/apache/yunikorn-scheduler-interface/lib/go/si"
+)
+
+const queueMoveConfig = `
+partitions:
+  - name: default
+    queues:
+      - name: root
+        queues:
+          - name: a
+            submitacl: '*'
+          - name: b
+            submitacl: 'alice'
+          - name: c
+            submitacl: '*'
+            maxapplications: 1
+          - name: d
+            submitacl: '*'
+            resources:
+              max:
+                memory: 100M
+          - name: e
+            parent: true
+            submitacl: '*'
+`
+
+func initQueueMoveContext() (*Context, *client.MockedAPIProvider, *Application) {
+	context, mockedAPIProvider := initContextAndAPIProviderForTest()
+	context.configMaps = []*v1.ConfigMap{nil, {Data: map[string]string{"queues.yaml": queueMoveConfig}}}
+	app := NewApplication(appID, "root.a", testUser, testGroups, map[string]string{}, context.apiProvider.GetAPIs().SchedulerAPI)
+	context.addApplicationToContext(app)
+	pod := &v1.Pod{
+		ObjectMeta: apis.ObjectMeta{
+			Name: "pod-1",
+			UID:  "UID-00001",
+		},
+		Spec: v1.PodSpec{
+			NodeName: "node-1",
+			Containers: []v1.Container{{
+				Resources: v1.ResourceRequirements{
+					Requests: v1.ResourceList{v1.ResourceMemory: resource.MustParse("200M")},
+				},
+			}},
+		},
+	}
+	task := NewTask("UID-00001", app, context, pod)
+	task.sm.SetState(TaskStates().Bound)
+	app.addTask(task)
+	app.setOriginatingTask(task)
+	app.SetState(ApplicationStates().Running)
+	return context, mockedAPIProvider, app
+}
+
+func TestValidateQueueMove(t *testing.T) {
+	tests := []struct {
+		name   string
+		queue  string
+		errMsg string
+	}{
+		{"allowed", "root.a", ""},
+		{"short name", "a", ""},
+		{"unknown queue", "root.unknown", "does not exist"},
+		{"parent queue", "root.e", "not a leaf queue"},
+		{"root queue", "root", "not a leaf queue"},
+		{"acl denied", "root.b", "not allowed to submit"},
+		{"max resources", "root.d", "do not fit"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			context, _, app := initQueueMoveContext()
+			err := context.validateQueueMove(app, normalizeQueuePath(tt.queue))
+			if tt.errMsg == "" {
+				assert.NilError(t, err)
+			} else {
+				assert.ErrorContains(t, err, tt.errMsg)
+			}
+		})
+	}
+}
+
+func TestValidateQueueMoveMaxApplications(t *testing.T) {
+	context, _, app := initQueueMoveContext()
+	assert.NilError(t, context.validateQueueMove(app, "root.c"))
+
+	other := NewApplication("app-other", "root.c", testUser, testGroups, map[string]string{}, newMockSchedulerAPI())
+	other.SetState(ApplicationStates().Running)
+	context.addApplicationToContext(other)
+	assert.ErrorContains(t, context.validateQueueMove(app, "root.c"), "maximum of 1 applications")
+
+	// terminated apps do not count
+	other.SetState(ApplicationStates().Completed)
+	assert.NilError(t, context.validateQueueMove(app, "root.c"))
+}
+
+func TestMoveApplicationQueue(t *testing.T) {
+	context, mockedAPIProvider, app := initQueueMoveContext()
+	var requests []*si.ApplicationRequest
+	mockedAPIProvider.MockSchedulerAPIUpdateApplicationFn(func(request *si.ApplicationRequest) error {
+		requests = append(requests, request)
+		return nil
+	})
+	events.SetRecorder(events.NewMockedRecorder())
+	defer events.SetRecorder(events.NewMockedRecorder())
+
+	// rejected move does not touch the core
+	err := context.moveApplicationQueue(app, "root.b")
+	assert.ErrorContains(t, err, "not allowed to submit")
+	assert.Equal(t, len(requests), 0)
+	assert.Equal(t, app.GetQueue(), "root.a")
+
+	// remove and add back in the new queue, the bound allocation is registered again
+	err = context.moveApplicationQueue(app, "c")
+	assert.NilError(t, err)
+	assert.Equal(t, app.GetQueue(), "root.c")
+	assert.Equal(t, len(requests), 2)
+	assert.Equal(t, len(requests[0].Remove), 1)
+	assert.Equal(t, requests[0].Remove[0].ApplicationID, appID)
+	assert.Equal(t, len(requests[1].New), 1)
+	assert.Equal(t, requests[1].New[0].QueueName, "root.c")
+	assert.Equal(t, mockedAPIProvider.GetSchedulerAPIUpdateAllocationCount(), int32(1))
+
+	// a second move is rejected until the core accepted the first one
+	err = context.moveApplicationQueue(app, "root.a")
+	assert.ErrorContains(t, err, "already being moved")
+	app.confirmQueueMove()
+	assert.Equal(t, app.previousQueue, "")
+
+	// a rejection by the core moves the application back
+	err = context.moveApplicationQueue(app, "root.a")
+	assert.NilError(t, err)
+	assert.Assert(t, app.revertQueueMove("rejected by core"))
+	assert.Equal(t, app.GetQueue(), "root.c")
+	assert.Equal(t, len(requests), 5)
+	assert.Equal(t, requests[4].New[0].QueueName, "root.c")
+	assert.Assert(t, !app.revertQueueMove("no move in progress"))
+}
+
+func TestCheckAcceptedQueueMove(t *testing.T) {
+	context, _, app := initQueueMoveContext()
+	events.SetRecorder(events.NewMockedRecorder())
+	defer events.SetRecorder(events.NewMockedRecorder())
+	assert.NilError(t, context.checkAcceptedQueueMove(app), "no move in progress")
+
+	err := context.moveApplicationQueue(app, "root.c")
+	assert.NilError(t, err)
+	assert.NilError(t, context.checkAcceptedQueueMove(app))
+
+	// another application was added to the queue after the move was validated
+	other := NewApplication("app-other", "root.c", testUser, testGroups, map[string]string{}, newMockSchedulerAPI())
+	other.SetState(ApplicationStates().Running)
+	context.addApplicationToContext(other)
+	assert.ErrorContains(t, context.checkAcceptedQueueMove(app), "maximum of 1 applications")
+
+	// the move back is not checked
+	app.abortQueueMove("over limit")
+	assert.Equal(t, app.GetQueue(), "root.a")
+	from, to, reverting := app.getQueueMove()
+	assert.Equal(t, from, "root.c")
+	assert.Equal(t, to, "root.a")
+	assert.Assert(t, reverting)
+	assert.NilError(t, context.checkAcceptedQueueMove(app))
+	app.confirmQueueMove()
+	assert.Equal(t, app.previousQueue, "")
+	assert.Assert(t, !app.queueMoveReverting)
+}
+
+func TestUpdateApplicationQueueFromPod(t *testing.T) {
+	context, _, app := initQueueMoveContext()
+	oldPod := app.GetOriginatingTask().GetTaskPod()
+	newPod := oldPod.DeepCopy()
+	newPod.Labels = map[string]string{constants.CanonicalLabelQueueName: "root.c"}
+
+	// only the originator pod triggers a move
+	otherPod := newPod.DeepCopy()
+	otherPod.UID = "UID-00002"
+	context.updateApplicationQueue(app, oldPod, otherPod)
+	assert.Equal(t, app.GetQueue(), "root.a")
+
+	context.updateApplicationQueue(app, oldPod, newPod)
+	assert.Equal(t, app.GetQueue(), "root.c")
+	assert.Equal(t, app.previousQueue, "root.a")
+}
+
+func TestNormalizeQueuePath(t *testing.T) {
+	assert.Equal(t, normalizeQueuePath("root"), "root")
+	assert.Equal(t, normalizeQueuePath("Root.A"), "root.a")
+	assert.Equal(t, normalizeQueuePath("a.b"), "root.a.b")
+	assert.Assert(t, isQueueInPath("root.a.b", "root.a"))
+	assert.Assert(t, !isQueueInPath("root.ab", "root.a"))
+	assert.Assert(t, common.IsZero(NewApplication("app", "root.a", testUser, testGroups, nil, nil).getAllocatedResource()))
+}
@@ -90,13 +90,26 @@ func (callback *AsyncRMCallback) UpdateAllocation(response *si.AllocationRespons
 		log.Log(log.ShimRMCallback).Debug("callback: response to released allocations",
 			zap.String("AllocationKey", release.AllocationKey))
 
+		// the core releases the allocations of an application the shim removes to move it to a different queue, the
+		// bound pods keep running and are registered again with the application
+		if release.TerminationType == si.TerminationType_STOPPED_BY_RM {
+			if app := callback.context.GetApplication(release.ApplicationID); app != nil && app.isQueueMoving() {
+				if task := app.GetTask(release.AllocationKey); task != nil && task.GetTaskState() == TaskStates().Bound {
+					log.Log(log.ShimRMCallback).Debug("ignoring release of bound task during queue move",
+						zap.String("appID", release.ApplicationID),
+						zap.String("allocationKey", release.AllocationKey))
+					continue
+				}
+			}
+		}
+
 		// update cache
 		callback.context.ForgetPod(release.GetAllocationKey())
 
 		// TerminationType 0 mean STOPPED_BY_RM
 		if release.TerminationType != si.TerminationType_STOPPED_BY_RM {
 			// send release app allocation to application states machine
-			ev := NewReleaseAppAllocationEvent(release.ApplicationID, release.TerminationType, release.AllocationKey)
+			ev := NewReleaseAppAllocationEvent(release.ApplicationID, release.TerminationType, release.AllocationKey, release.Message)
 			dispatcher.Dispatch(ev)
 		}
 	}
@@ -116,6 +129,25 @@ func (callback *AsyncRMCallback) UpdateApplication(response *si.ApplicationRespo
 
 		if app := callback.context.GetApplication(app.ApplicationID); app != nil {
 			log.Log(log.ShimRMCallback).Info("Accepting app", zap.String("appID", app.GetApplicationID()))
+			// the core accepts a moved application without checking the limits of the new queue
+			if err := callback.context.checkAcceptedQueueMove(app); err != nil {
+				app.abortQueueMove(err.Error())
+				continue
+			}
+			app.confirmQueueMove()
+			// the queue defaults apply to the queue known to the shim, the core only reports a different queue
+			// placed by the placement rules if event publishing is enabled
+			if queue := app.GetQueue(); queue != "" {
+				app.setPlacedQueue(queue)
+				app.setPlacedQueueResourceAccounting(queue)
+			}
+			// an application added back by the shim after a queue move is accepted again, the shim state is not changed
+			if state := app.GetApplicationState(); state != ApplicationStates().Submitted {
+				log.Log(log.ShimRMCallback).Debug("application accepted again",
+					zap.String("appID", app.GetApplicationID()),
+					zap.String("state", state))
+				continue
+			}
 			ev := NewSimpleApplicationEvent(app.GetApplicationID(), AcceptApplication)
 			dispatcher.Dispatch(ev)
 		}
@@ -127,6 +159,10 @@ func (callback *AsyncRMCallback) UpdateApplication(response *si.ApplicationRespo
 			zap.String("appID", rejectedApp.ApplicationID))
 
 		if app := callback.context.GetApplication(rejectedApp.ApplicationID); app != nil {
+			// a rejected queue move puts the app back in its previous queue
+			if app.revertQueueMove(rejectedApp.Reason) {
+				continue
+			}
 			ev := NewApplicationEvent(app.GetApplicationID(), RejectApplication, rejectedApp.Reason)
 			dispatcher.Dispatch(ev)
 		}
@@ -142,7 +178,9 @@ func (callback *AsyncRMCallback) UpdateApplication(response *si.ApplicationRespo
 			callback.context.RemoveApplication(updated.ApplicationID)
 		case ApplicationStates().Resuming:
 			app := callback.context.GetApplication(updated.ApplicationID)
-			if app != nil && app.GetApplicationState() == ApplicationStates().Reserving {
+			// a suspended application defers the event until it is resumed
+			if app != nil && (app.GetApplicationState() == ApplicationStates().Reserving ||
+				app.GetApplicationState() == ApplicationStates().Suspended) {
 				ev := NewResumingApplicationEvent(updated.ApplicationID)
 				dispatcher.Dispatch(ev)
 			}
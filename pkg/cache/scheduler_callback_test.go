@@ -27,6 +27,7 @@ import (
 
 	"gotest.tools/v3/assert"
 	v1 "k8s.io/api/core/v1"
+	policyv1 "k8s.io/api/policy/v1"
 	apis "k8s.io/apimachinery/pkg/apis/meta/v1"
 	k8sEvents "k8s.io/client-go/tools/events"
 	"k8s.io/kubernetes/pkg/scheduler/framework"
@@ -36,6 +37,7 @@ import (
 	"github.com/apache/yunikorn-k8shim/pkg/common/events"
 	"github.com/apache/yunikorn-k8shim/pkg/common/test"
 	"github.com/apache/yunikorn-k8shim/pkg/common/utils"
+	"github.com/apache/yunikorn-k8shim/pkg/conf"
 	"github.com/apache/yunikorn-k8shim/pkg/dispatcher"
 	"github.com/apache/yunikorn-k8shim/pkg/plugin/predicates"
 	"github.com/apache/yunikorn-scheduler-interface/lib/go/si"
@@ -164,11 +166,15 @@ func TestUpdateAllocation_AllocationReleased(t *testing.T) {
 	app := context.getApplication(appID)
 	assert.Assert(t, app != nil)
 	app.sm.SetState(ApplicationStates().Running)
-	var deleteCalled atomic.Bool
+	var deleteCalled, evictCalled atomic.Bool
 	context.apiProvider.(*client.MockedAPIProvider).MockDeleteFn(func(pod *v1.Pod) error { //nolint:errcheck
 		deleteCalled.Store(true)
 		return nil
 	})
+	context.apiProvider.(*client.MockedAPIProvider).MockEvictFn(func(pod *v1.Pod) error { //nolint:errcheck
+		evictCalled.Store(true)
+		return nil
+	})
 	task := context.getTask(appID, taskUID1)
 	task.allocationKey = taskUID1
 
@@ -183,8 +189,10 @@ func TestUpdateAllocation_AllocationReleased(t *testing.T) {
 	})
 	assert.NilError(t, err, "error updating allocation")
 	assert.Assert(t, !context.schedulerCache.IsAssumedPod(taskUID1))
-	err = utils.WaitForCondition(deleteCalled.Load, 10*time.Millisecond, time.Second)
-	assert.NilError(t, err, "pod has not been deleted")
+	// preempted pods are evicted, not deleted
+	err = utils.WaitForCondition(evictCalled.Load, 10*time.Millisecond, time.Second)
+	assert.NilError(t, err, "pod has not been evicted")
+	assert.Assert(t, !deleteCalled.Load(), "pod should not have been deleted")
 }
 
 func TestUpdateAllocation_AllocationReleased_StoppedByRM(t *testing.T) {
@@ -218,6 +226,40 @@ func TestUpdateAllocation_AllocationReleased_StoppedByRM(t *testing.T) {
 	assert.Error(t, err, "timeout waiting for condition") // pod is not expected to be deleted
 }
 
+func TestUpdateAllocation_AllocationReleased_QueueMove(t *testing.T) {
+	// the core releases the allocations of an application that is moved to a different queue
+	callback, context := initCallbackTest(t, false, false)
+	defer dispatcher.UnregisterAllEventHandlers()
+	defer dispatcher.Stop()
+	err := context.AssumePod(taskUID1, fakeNodeName)
+	assert.NilError(t, err, "could not assume pod")
+	app := context.getApplication(appID)
+	app.queue = "root.b"
+	app.previousQueue = "root.a"
+	task := context.getTask(appID, taskUID1)
+	task.sm.SetState(TaskStates().Bound)
+	release := &si.AllocationResponse{
+		Released: []*si.AllocationRelease{
+			{
+				ApplicationID:   appID,
+				AllocationKey:   taskUID1,
+				TerminationType: si.TerminationType_STOPPED_BY_RM,
+			},
+		},
+	}
+
+	err = callback.UpdateAllocation(release)
+	assert.NilError(t, err, "error updating allocation")
+	assert.Assert(t, context.schedulerCache.IsAssumedPod(taskUID1), "bound pod should not be forgotten")
+	assert.Equal(t, task.GetTaskState(), TaskStates().Bound)
+
+	// without a queue move the release is not triggered by the shim
+	app.previousQueue = ""
+	err = callback.UpdateAllocation(release)
+	assert.NilError(t, err, "error updating allocation")
+	assert.Assert(t, !context.schedulerCache.IsAssumedPod(taskUID1), "released pod should be forgotten")
+}
+
 func TestUpdateApplication_Accepted(t *testing.T) {
 	callback, context := initCallbackTest(t, false, false)
 	defer dispatcher.UnregisterAllEventHandlers()
@@ -239,6 +281,105 @@ func TestUpdateApplication_Accepted(t *testing.T) {
 	assert.NilError(t, err, "application has not transitioned to Accepted state")
 }
 
+func TestUpdateApplication_AcceptedQueueDefaults(t *testing.T) {
+	err := conf.UpdateConfigMaps([]*v1.ConfigMap{nil, {Data: map[string]string{
+		conf.CMSvcQueueMaxRuntimePrefix + "root.a": "1h",
+	}}}, true)
+	assert.NilError(t, err, "failed to update configmap")
+	t.Cleanup(func() {
+		err = conf.UpdateConfigMaps([]*v1.ConfigMap{nil, nil}, true)
+		assert.NilError(t, err, "failed to reset configmap")
+	})
+	callback, context := initCallbackTest(t, false, false)
+	defer dispatcher.UnregisterAllEventHandlers()
+	defer dispatcher.Stop()
+	app := context.getApplication(appID)
+	app.sm.SetState(ApplicationStates().Submitted)
+	app.queue = "root.a"
+
+	// without a queue event from the core the defaults of the known queue apply
+	err = callback.UpdateApplication(&si.ApplicationResponse{
+		Accepted: []*si.AcceptedApplication{
+			{
+				ApplicationID: appID,
+			},
+		},
+	})
+	assert.NilError(t, err, "error updating application")
+	assert.Equal(t, app.getMaxRuntime(), time.Hour)
+	app.stopTimers()
+}
+
+func TestUpdateApplication_AcceptedAfterMove(t *testing.T) {
+	callback, context := initCallbackTest(t, false, false)
+	defer dispatcher.UnregisterAllEventHandlers()
+	defer dispatcher.Stop()
+	app := context.getApplication(appID)
+	app.sm.SetState(ApplicationStates().Running)
+	app.queue = "root.b"
+	app.previousQueue = "root.a"
+
+	// the core accepts the application added back in the new queue, the shim state is unchanged
+	err := callback.UpdateApplication(&si.ApplicationResponse{
+		Accepted: []*si.AcceptedApplication{
+			{
+				ApplicationID: appID,
+			},
+		},
+	})
+	assert.NilError(t, err, "error updating application")
+	assert.Equal(t, app.previousQueue, "")
+	assert.Equal(t, app.GetApplicationState(), ApplicationStates().Running)
+}
+
+func TestUpdateApplication_AcceptedOverQueueLimits(t *testing.T) {
+	callback, context := initCallbackTest(t, false, false)
+	defer dispatcher.UnregisterAllEventHandlers()
+	defer dispatcher.Stop()
+	events.SetRecorder(events.NewMockedRecorder())
+	defer events.SetRecorder(events.NewMockedRecorder())
+	var requests []*si.ApplicationRequest
+	context.apiProvider.(*client.MockedAPIProvider).MockSchedulerAPIUpdateApplicationFn(func(request *si.ApplicationRequest) error { //nolint:errcheck
+		requests = append(requests, request)
+		return nil
+	})
+	context.configMaps = []*v1.ConfigMap{nil, {Data: map[string]string{"queues.yaml": queueMoveConfig}}}
+	app := context.getApplication(appID)
+	app.sm.SetState(ApplicationStates().Running)
+	app.queue = "root.c"
+	app.previousQueue = "root.a"
+	other := NewApplication("app-other", "root.c", testUser, testGroups, map[string]string{}, newMockSchedulerAPI())
+	other.SetState(ApplicationStates().Running)
+	context.addApplicationToContext(other)
+
+	// the core accepted the application over the queue maximum, the application is moved back
+	err := callback.UpdateApplication(&si.ApplicationResponse{
+		Accepted: []*si.AcceptedApplication{
+			{
+				ApplicationID: appID,
+			},
+		},
+	})
+	assert.NilError(t, err, "error updating application")
+	assert.Equal(t, app.GetQueue(), "root.a")
+	assert.Equal(t, len(requests), 2)
+	assert.Equal(t, len(requests[0].Remove), 1)
+	assert.Equal(t, requests[1].New[0].QueueName, "root.a")
+
+	// the application accepted in its previous queue finalises the move
+	err = callback.UpdateApplication(&si.ApplicationResponse{
+		Accepted: []*si.AcceptedApplication{
+			{
+				ApplicationID: appID,
+			},
+		},
+	})
+	assert.NilError(t, err, "error updating application")
+	assert.Equal(t, app.previousQueue, "")
+	assert.Equal(t, app.GetQueue(), "root.a")
+	assert.Equal(t, len(requests), 2)
+}
+
 func TestUpdateApplication_Rejected(t *testing.T) {
 	callback, context := initCallbackTest(t, false, false)
 	defer dispatcher.UnregisterAllEventHandlers()
@@ -457,6 +598,66 @@ func TestPreemptionPredicates(t *testing.T) {
 	assert.Equal(t, int32(0), resp.Index)
 }
 
+func TestPreemptionPredicatesPDB(t *testing.T) {
+	callback, context := initCallbackTest(t, false, false)
+	defer dispatcher.UnregisterAllEventHandlers()
+	defer dispatcher.Stop()
+	context.predManager = &mockPredicateManager{}
+	recorder := k8sEvents.NewFakeRecorder(1024)
+	events.SetRecorder(recorder)
+	defer events.SetRecorder(events.NewMockedRecorder())
+	t.Cleanup(func() {
+		err := conf.UpdateConfigMaps([]*v1.ConfigMap{nil, nil}, true)
+		assert.NilError(t, err, "failed to reset configmap")
+	})
+	apiProvider, ok := context.apiProvider.(*client.MockedAPIProvider)
+	assert.Assert(t, ok, "unexpected api provider")
+	// empty selector covers all pods in the namespace, including the victim
+	apiProvider.AddPodDisruptionBudget(&policyv1.PodDisruptionBudget{
+		ObjectMeta: apis.ObjectMeta{Name: "pdb"},
+		Spec:       policyv1.PodDisruptionBudgetSpec{Selector: &apis.LabelSelector{}},
+	})
+	args := &si.PreemptionPredicatesArgs{AllocationKey: taskUID1, NodeID: fakeNodeName, StartIndex: 0, PreemptAllocationKeys: []string{taskUID1}}
+
+	// ignore by default: PDBs are not checked
+	resp := callback.PreemptionPredicates(args)
+	assert.Assert(t, resp.Success, "PDB should have been ignored")
+	assert.Equal(t, 0, len(recorder.Events), "no event expected when PDBs are ignored")
+
+	// prefer: rejected the first time, allowed when the core asks again
+	err := conf.UpdateConfigMaps([]*v1.ConfigMap{nil, {Data: map[string]string{conf.CMSvcPreemptionPDBPolicy: conf.PreemptionPDBPolicyPrefer}}}, true)
+	assert.NilError(t, err, "failed to update configmap")
+	resp = callback.PreemptionPredicates(args)
+	assert.Assert(t, !resp.Success, "first PDB violation should have been rejected")
+	assert.Equal(t, int32(-1), resp.Index)
+	event := <-recorder.Events
+	assert.Assert(t, strings.Contains(event, "PreemptionRejectedByPDB"), "unexpected event: %s", event)
+	resp = callback.PreemptionPredicates(args)
+	assert.Assert(t, resp.Success, "second PDB violation should have been allowed")
+	assert.Equal(t, int32(0), resp.Index)
+	event = <-recorder.Events
+	assert.Assert(t, strings.Contains(event, "PreemptionViolatesPDB"), "unexpected event: %s", event)
+
+	// enforce: always rejected
+	err = conf.UpdateConfigMaps([]*v1.ConfigMap{nil, {Data: map[string]string{conf.CMSvcPreemptionPDBPolicy: conf.PreemptionPDBPolicyEnforce}}}, true)
+	assert.NilError(t, err, "failed to update configmap")
+	resp = callback.PreemptionPredicates(args)
+	assert.Assert(t, !resp.Success, "PDB violation should have been rejected")
+	assert.Equal(t, 0, len(recorder.Events), "no new event expected for a node rejected before")
+
+	// ignore: PDBs are not checked
+	err = conf.UpdateConfigMaps([]*v1.ConfigMap{nil, {Data: map[string]string{conf.CMSvcPreemptionPDBPolicy: conf.PreemptionPDBPolicyIgnore}}}, true)
+	assert.NilError(t, err, "failed to update configmap")
+	resp = callback.PreemptionPredicates(args)
+	assert.Assert(t, resp.Success, "PDB should have been ignored")
+
+	// removing the pod clears the rejections
+	context.DeletePod(context.schedulerCache.GetPod(taskUID1))
+	context.pdbRejections.Lock()
+	assert.Equal(t, 0, len(context.pdbRejections.nodes), "rejections not removed")
+	context.pdbRejections.Unlock()
+}
+
 func TestSendEvent(t *testing.T) {
 	callback, _ := initCallbackTest(t, false, false)
 	recorder := k8sEvents.NewFakeRecorder(1024)
@@ -27,18 +27,24 @@ import (
 	"github.com/looplab/fsm"
 	"go.uber.org/zap"
 	v1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	podutil "k8s.io/kubernetes/pkg/api/v1/pod"
 
 	"github.com/apache/yunikorn-k8shim/pkg/common"
 	"github.com/apache/yunikorn-k8shim/pkg/common/constants"
 	"github.com/apache/yunikorn-k8shim/pkg/common/events"
 	"github.com/apache/yunikorn-k8shim/pkg/common/utils"
+	"github.com/apache/yunikorn-k8shim/pkg/conf"
 	"github.com/apache/yunikorn-k8shim/pkg/dispatcher"
 	"github.com/apache/yunikorn-k8shim/pkg/locking"
 	"github.com/apache/yunikorn-k8shim/pkg/log"
 	"github.com/apache/yunikorn-scheduler-interface/lib/go/si"
 )
 
+// evictionRetryInterval is the time between retries of the eviction of a preempted pod
+var evictionRetryInterval = 5 * time.Second
+
 type Task struct {
 	taskID        string
 	alias         string
@@ -52,30 +58,32 @@ type Task struct {
 	sm            *fsm.FSM
 
 	// mutable resources, require locking
-	allocationKey   string
-	nodeName        string
-	taskGroupName   string
-	terminationType string
-	schedulingState TaskSchedulingState
-	resource        *si.Resource
-	pod             *v1.Pod
+	allocationKey    string
+	nodeName         string
+	taskGroupName    string
+	terminationType  string
+	schedulingState  TaskSchedulingState
+	schedulingReason string
+	resource         *si.Resource
+	pod              *v1.Pod
+	pendingTimer     *time.Timer
 
 	lock *locking.RWMutex
 }
 
 func NewTask(tid string, app *Application, ctx *Context, pod *v1.Pod) *Task {
-	taskResource := common.GetPodResource(pod)
+	taskResource := app.getPodResource(pod)
 	return createTaskInternal(tid, app, taskResource, pod, false, "", ctx, false)
 }
 
 func NewTaskPlaceholder(tid string, app *Application, ctx *Context, pod *v1.Pod) *Task {
-	taskResource := common.GetPodResource(pod)
+	taskResource := app.getPodResource(pod)
 	return createTaskInternal(tid, app, taskResource, pod, true, "", ctx, false)
 }
 
 func NewFromTaskMeta(tid string, app *Application, ctx *Context, metadata TaskMetadata, originator bool) *Task {
 	taskPod := metadata.Pod
-	taskResource := common.GetPodResource(taskPod)
+	taskResource := app.getPodResource(taskPod)
 	return createTaskInternal(
 		tid,
 		app,
@@ -184,6 +192,55 @@ func (task *Task) DeleteTaskPod() error {
 	return task.context.apiProvider.GetAPIs().KubeClient.Delete(task.GetTaskPod())
 }
 
+// PreemptTaskPod marks the pod of the task as a disruption target and evicts it, the preemptor is the allocation key
+// of the task that triggered the preemption. An eviction refused because of a PodDisruptionBudget is retried, the pod
+// is deleted if it is not evicted within the configured timeout or the eviction fails for any other reason.
+func (task *Task) PreemptTaskPod(preemptor string) error {
+	pod := task.GetTaskPod()
+	message := fmt.Sprintf("Task %s is preempted by the scheduler", task.alias)
+	if alias, queue, ok := task.context.getTaskAliasAndQueue(preemptor); ok {
+		message = fmt.Sprintf("Task %s is preempted by task %s in queue %s", task.alias, alias, queue)
+	}
+	condition := &v1.PodCondition{
+		Type:               v1.DisruptionTarget,
+		Status:             v1.ConditionTrue,
+		Reason:             v1.PodReasonPreemptionByScheduler,
+		Message:            message,
+		LastTransitionTime: metav1.Now(),
+	}
+	if ok, podCopy := task.UpdatePodCondition(condition); ok {
+		if _, err := task.UpdateTaskPodStatus(podCopy); err != nil {
+			// the eviction does not depend on the condition, only log the error
+			log.Log(log.ShimCacheTask).Warn("failed to set disruption target condition on preempted pod",
+				zap.String("taskID", task.taskID),
+				zap.String("taskAlias", task.alias),
+				zap.Error(err))
+		}
+	}
+	events.GetRecorder().Eventf(pod.DeepCopy(), nil, v1.EventTypeNormal, v1.PodReasonPreemptionByScheduler,
+		v1.PodReasonPreemptionByScheduler, message)
+
+	kubeClient := task.context.apiProvider.GetAPIs().KubeClient
+	deadline := time.Now().Add(conf.GetSchedulerConf().GetPreemptionEvictionTimeout())
+	for {
+		err := kubeClient.Evict(pod)
+		if err == nil || apierrors.IsNotFound(err) {
+			return nil
+		}
+		// too many requests is returned when the eviction violates a PodDisruptionBudget
+		remaining := time.Until(deadline)
+		if !apierrors.IsTooManyRequests(err) || remaining <= 0 {
+			log.Log(log.ShimCacheTask).Info("eviction of preempted pod failed, deleting pod",
+				zap.String("taskID", task.taskID),
+				zap.String("taskAlias", task.alias),
+				zap.Error(err))
+			break
+		}
+		time.Sleep(min(evictionRetryInterval, remaining))
+	}
+	return task.DeleteTaskPod()
+}
+
 func (task *Task) UpdateTaskPodStatus(pod *v1.Pod) (*v1.Pod, error) {
 	return task.context.apiProvider.GetAPIs().KubeClient.UpdateStatus(pod)
 }
@@ -238,6 +295,11 @@ func (task *Task) isPreemptSelfAllowed() bool {
 	case constants.False:
 		return false
 	default:
+		// the core sorts the victims that do not allow preemption last: PDB covered pods are only preempted if the
+		// other pods on the node are not enough
+		if task.context.isPDBCovered(task.pod) {
+			return false
+		}
 		return task.context.IsPreemptSelfAllowed(task.pod.Spec.PriorityClassName)
 	}
 }
@@ -263,6 +325,14 @@ func (task *Task) SetTaskSchedulingState(state TaskSchedulingState) {
 	task.schedulingState = state
 }
 
+// setSchedulingStateAndReason records the latest scheduling state reported by the core together with its reason
+func (task *Task) setSchedulingStateAndReason(state TaskSchedulingState, reason string) {
+	task.lock.Lock()
+	defer task.lock.Unlock()
+	task.schedulingState = state
+	task.schedulingReason = reason
+}
+
 func (task *Task) MarkPreviouslyAllocated(allocationKey string, nodeID string) {
 	task.sm.SetState(TaskStates().Bound)
 	task.lock.Lock()
@@ -291,6 +361,7 @@ func (task *Task) handleSubmitTaskEvent() {
 
 	// send update allocation event to core
 	task.updateAllocation()
+	task.startPendingTimer()
 
 	if !utils.PodAlreadyBound(task.pod) {
 		// if this is a new request, add events to pod
@@ -306,6 +377,82 @@ func (task *Task) handleSubmitTaskEvent() {
 	}
 }
 
+// resubmitToCore sends the ask or the existing allocation of the task to the core again. This is used when the
+// application has been removed from the core and added back, e.g. after a queue move.
+func (task *Task) resubmitToCore() {
+	task.lock.Lock()
+	defer task.lock.Unlock()
+	switch task.sm.Current() {
+	case TaskStates().Scheduling, TaskStates().Bound:
+		task.updateAllocation()
+	}
+}
+
+func (task *Task) getTaskResource() *si.Resource {
+	task.lock.RLock()
+	defer task.lock.RUnlock()
+	return task.resource
+}
+
+// startPendingTimer starts the timer which fails the task if it is not allocated within the pending timeout.
+// The timeout is measured from the creation of the pod, a task submitted again after a restart or a withdrawal does
+// not get a new timeout. Placeholders are not covered, they have their own timeout handled by the core.
+// This function must be called with the task lock held.
+func (task *Task) startPendingTimer() {
+	if task.placeholder || utils.PodAlreadyBound(task.pod) {
+		return
+	}
+	timeout := task.context.getTaskPendingTimeout(task.pod)
+	if timeout <= 0 {
+		return
+	}
+	if task.pendingTimer != nil {
+		task.pendingTimer.Stop()
+	}
+	created := task.pod.CreationTimestamp.Time
+	if created.IsZero() {
+		created = time.Now()
+	}
+	log.Log(log.ShimCacheTask).Debug("starting task pending timer",
+		zap.String("appID", task.applicationID),
+		zap.String("taskID", task.taskID),
+		zap.Duration("timeout", timeout),
+		zap.Time("deadline", created.Add(timeout)))
+	task.pendingTimer = time.AfterFunc(time.Until(created.Add(timeout)), func() {
+		task.handlePendingTimeout(timeout)
+	})
+}
+
+// stopPendingTimer stops the pending timer, called when the task leaves the Scheduling state.
+// This function must be called with the task lock held.
+func (task *Task) stopPendingTimer() {
+	if task.pendingTimer != nil {
+		task.pendingTimer.Stop()
+		task.pendingTimer = nil
+	}
+}
+
+// handlePendingTimeout fails a task which is still waiting for an allocation after the timeout.
+// The TaskPendingTimeout event is only handled in the Scheduling state: a task allocated in the meantime is not failed.
+func (task *Task) handlePendingTimeout(timeout time.Duration) {
+	task.lock.RLock()
+	if task.sm.Current() != TaskStates().Scheduling {
+		task.lock.RUnlock()
+		return
+	}
+	msg := fmt.Sprintf("task %s was not scheduled within %s, scheduling state: %s",
+		task.alias, timeout, task.schedulingState)
+	if task.schedulingReason != "" {
+		msg = fmt.Sprintf("%s, reason: %s", msg, task.schedulingReason)
+	}
+	task.lock.RUnlock()
+	log.Log(log.ShimCacheTask).Info("task pending timeout exceeded",
+		zap.String("appID", task.applicationID),
+		zap.String("taskID", task.taskID),
+		zap.String("message", msg))
+	dispatcher.Dispatch(NewPendingTimeoutTaskEvent(task.applicationID, task.taskID, msg))
+}
+
 // updateAllocation updates the core scheduler when task information changes.
 // This function must be called with the task lock held.
 func (task *Task) updateAllocation() {
@@ -320,6 +467,7 @@ func (task *Task) updateAllocation() {
 		task.applicationID,
 		task.taskID,
 		task.pod.Spec.NodeName,
+		task.application.partition,
 		task.resource,
 		task.placeholder,
 		task.taskGroupName,
@@ -460,6 +608,15 @@ func (task *Task) beforeTaskFail() {
 	task.releaseAllocation()
 }
 
+// beforeTaskPendingTimeout releases the ask from the core and sets the pod to failed.
+func (task *Task) beforeTaskPendingTimeout(msg string) {
+	task.releaseAllocation()
+	events.GetRecorder().Eventf(task.pod.DeepCopy(), nil, v1.EventTypeWarning,
+		constants.TaskPendingTimeoutFailure, constants.TaskPendingTimeoutFailure, msg)
+	// the pod update reads the pod with the task lock
+	go failTaskPodWithReasonAndMsg(task, constants.TaskPendingTimeoutFailure, msg)
+}
+
 func (task *Task) postTaskFailed(reason string) {
 	log.Log(log.ShimCacheTask).Error("task failed",
 		zap.String("appID", task.applicationID),
@@ -470,6 +627,18 @@ func (task *Task) postTaskFailed(reason string) {
 		"Task %s is failed", task.alias)
 }
 
+// beforeTaskWithdraw removes the ask of the task from the core, the task moves back to New
+// and will be submitted again when its application is resumed.
+func (task *Task) beforeTaskWithdraw() {
+	task.releaseAllocation()
+	task.schedulingState = TaskSchedPending
+	task.schedulingReason = ""
+
+	events.GetRecorder().Eventf(task.pod.DeepCopy(), nil,
+		v1.EventTypeNormal, "TaskWithdrawn", "TaskWithdrawn",
+		"Task %s is withdrawn from scheduling, application is suspended", task.alias)
+}
+
 // beforeTaskCompleted releases the allocation or ask from scheduler core
 // this is done as a before hook because the releaseAllocation() call needs to
 // send different requests to scheduler-core, depending on current task state
@@ -645,12 +814,29 @@ func (task *Task) SetTaskPod(pod *v1.Pod) {
 
 	task.pod = pod
 	oldResource := task.resource
-	newResource := common.GetPodResource(pod)
+	newResource := task.application.getPodResource(pod)
 	if !common.Equals(oldResource, newResource) {
 		// pod resources have changed
 		task.resource = newResource
 
-		// update allocation in core
-		task.updateAllocation()
+		// update the ask or allocation in the core, tasks that have not been submitted yet
+		// or are terminated are not known to the core
+		switch task.sm.Current() {
+		case TaskStates().Scheduling, TaskStates().Allocated, TaskStates().Bound:
+			log.Log(log.ShimCacheTask).Info("task resources changed, updating core",
+				zap.String("appID", task.applicationID),
+				zap.String("taskID", task.taskID),
+				zap.Stringer("oldResource", oldResource),
+				zap.Stringer("newResource", newResource))
+			task.updateAllocation()
+		}
 	}
 }
+
+// setTaskPodKeepResource updates the pod of the task without changing the resource of the task, the core is not
+// updated. Used when a resize of the pod is not accepted.
+func (task *Task) setTaskPodKeepResource(pod *v1.Pod) {
+	task.lock.Lock()
+	defer task.lock.Unlock()
+	task.pod = pod
+}
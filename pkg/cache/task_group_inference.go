@@ -0,0 +1,206 @@
+/*
+ Licensed to the Apache Software Foundation (ASF) under one
+ or more contributor license agreements.  See the NOTICE file
+ distributed with this work for additional information
+ regarding copyright ownership.  The ASF licenses this file
+ to you under the Apache License, Version 2.0 (the
+ "License"); you may not use this file except in compliance
+ with the License.  You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package cache
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"go.uber.org/zap"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/util/jsonpath"
+	resourcehelper "k8s.io/component-helpers/resource"
+
+	"github.com/apache/yunikorn-k8shim/pkg/common/constants"
+	"github.com/apache/yunikorn-k8shim/pkg/common/utils"
+	"github.com/apache/yunikorn-k8shim/pkg/conf"
+	"github.com/apache/yunikorn-k8shim/pkg/log"
+)
+
+// inferNewApplicationTaskGroups infers the task groups of a pod that would create a new application, nil is returned
+// for pods of existing applications. This can call the API server and must be called without the context lock held.
+func (ctx *Context) inferNewApplicationTaskGroups(pod *v1.Pod) []TaskGroup {
+	appID := utils.GetApplicationIDFromPod(pod)
+	if appID == "" || utils.IsPodTerminated(pod) || ctx.GetApplication(appID) != nil {
+		return nil
+	}
+	return ctx.inferTaskGroups(pod)
+}
+
+// inferTaskGroups builds the task group of a pod that does not define task groups from the workload that controls
+// the pod. This is only done if the namespace opts in, the gang size is taken from the workload and the resources,
+// node selection and tolerations are taken from the pod. Nil is returned if no task group can be inferred.
+func (ctx *Context) inferTaskGroups(pod *v1.Pod) []TaskGroup {
+	if !ctx.isTaskGroupInferenceEnabled(pod) {
+		return nil
+	}
+	owner := metav1.GetControllerOf(pod)
+	if owner == nil {
+		return nil
+	}
+	size, err := ctx.getWorkloadSize(pod.Namespace, *owner)
+	if err != nil {
+		log.Log(log.ShimContext).Info("unable to infer task groups from the pod owner",
+			zap.String("namespace", pod.Namespace),
+			zap.String("podName", pod.Name),
+			zap.String("ownerKind", owner.Kind),
+			zap.String("ownerName", owner.Name),
+			zap.Error(err))
+		return nil
+	}
+	// a single pod is not a gang
+	if size < 2 {
+		return nil
+	}
+	minResource := make(map[string]resource.Quantity)
+	for name, value := range resourcehelper.PodRequests(pod, resourcehelper.PodResourcesOptions{}) {
+		minResource[name.String()] = value
+	}
+	log.Log(log.ShimContext).Info("inferred task group from the pod owner",
+		zap.String("namespace", pod.Namespace),
+		zap.String("podName", pod.Name),
+		zap.String("ownerKind", owner.Kind),
+		zap.String("ownerName", owner.Name),
+		zap.Int32("minMember", size))
+	return []TaskGroup{{
+		Name:                      owner.Name,
+		MinMember:                 size,
+		MinResource:               minResource,
+		NodeSelector:              pod.Spec.NodeSelector,
+		Tolerations:               pod.Spec.Tolerations,
+		Affinity:                  pod.Spec.Affinity,
+		TopologySpreadConstraints: pod.Spec.TopologySpreadConstraints,
+	}}
+}
+
+// getInferredTaskGroupName returns the name of the inferred task group the pod belongs to, or an empty string.
+// Pods of an inferred gang do not carry the task group name, the group is named after the workload instead.
+func (ctx *Context) getInferredTaskGroupName(pod *v1.Pod, app *Application) string {
+	if utils.GetTaskGroupFromPodSpec(pod) != "" || !ctx.isTaskGroupInferenceEnabled(pod) {
+		return ""
+	}
+	owner := metav1.GetControllerOf(pod)
+	if owner == nil {
+		return ""
+	}
+	for _, tg := range app.getTaskGroups() {
+		if tg.Name == owner.Name {
+			return tg.Name
+		}
+	}
+	return ""
+}
+
+// isTaskGroupInferenceEnabled checks that the pod is a real pod without task groups, inline or referenced, in a
+// namespace that opted in.
+// Pods with a generated application ID are skipped: the ID is shared by unrelated pods or unique per pod,
+// in both cases the application is not the workload.
+func (ctx *Context) isTaskGroupInferenceEnabled(pod *v1.Pod) bool {
+	if conf.GetSchedulerConf().DisableGangScheduling || utils.GetPlaceholderFlagFromPodSpec(pod) {
+		return false
+	}
+	if pod.Annotations[constants.AnnotationTaskGroups] != "" || pod.Annotations[constants.AnnotationTaskGroupsRef] != "" {
+		return false
+	}
+	appID := utils.GetApplicationIDFromPod(pod)
+	if appID == utils.GenerateApplicationID(pod.Namespace, false, string(pod.UID)) ||
+		appID == utils.GenerateApplicationID(pod.Namespace, true, string(pod.UID)) {
+		return false
+	}
+	namespaceObj := ctx.getNamespaceObject(pod.Namespace)
+	if namespaceObj == nil {
+		return false
+	}
+	enabled, err := strconv.ParseBool(utils.GetNameSpaceAnnotationValue(namespaceObj, constants.NamespaceInferTaskGroups))
+	return err == nil && enabled
+}
+
+// getWorkloadSize returns the number of pods the workload runs in parallel. Built-in workloads are read from the
+// informers, other workloads are retrieved from the API server and the size is read using the configured JSON path.
+// Must be called without the context lock held.
+func (ctx *Context) getWorkloadSize(namespace string, owner metav1.OwnerReference) (int32, error) {
+	gv, err := schema.ParseGroupVersion(owner.APIVersion)
+	if err != nil {
+		return 0, err
+	}
+	apis := ctx.apiProvider.GetAPIs()
+	switch {
+	case gv.Group == "apps" && owner.Kind == "StatefulSet" && apis.StatefulSetInformer != nil:
+		sts, err := apis.StatefulSetInformer.Lister().StatefulSets(namespace).Get(owner.Name)
+		if err != nil {
+			return 0, err
+		}
+		return replicasOrDefault(sts.Spec.Replicas), nil
+	case gv.Group == "apps" && owner.Kind == "ReplicaSet" && apis.ReplicaSetInformer != nil:
+		rs, err := apis.ReplicaSetInformer.Lister().ReplicaSets(namespace).Get(owner.Name)
+		if err != nil {
+			return 0, err
+		}
+		return replicasOrDefault(rs.Spec.Replicas), nil
+	case gv.Group == "" && owner.Kind == "ReplicationController" && apis.ReplicationControllerInformer != nil:
+		rc, err := apis.ReplicationControllerInformer.Lister().ReplicationControllers(namespace).Get(owner.Name)
+		if err != nil {
+			return 0, err
+		}
+		return replicasOrDefault(rc.Spec.Replicas), nil
+	case gv.Group == "batch" && owner.Kind == "Job" && apis.JobInformer != nil:
+		job, err := apis.JobInformer.Lister().Jobs(namespace).Get(owner.Name)
+		if err != nil {
+			return 0, err
+		}
+		return replicasOrDefault(job.Spec.Parallelism), nil
+	}
+
+	path := conf.GetSchedulerConf().GetTaskGroupInferencePath(owner.Kind, gv.Group)
+	if path == "" {
+		return 0, fmt.Errorf("no task group inference path configured for %s", owner.Kind)
+	}
+	workload, err := apis.KubeClient.GetWorkload(namespace, owner)
+	if err != nil {
+		return 0, err
+	}
+	parser := jsonpath.New(owner.Kind)
+	if err = parser.Parse(path); err != nil {
+		return 0, err
+	}
+	results, err := parser.FindResults(workload.Object)
+	if err != nil {
+		return 0, err
+	}
+	if len(results) != 1 || len(results[0]) != 1 {
+		return 0, fmt.Errorf("path %s does not select a single value", path)
+	}
+	size, err := strconv.ParseInt(strings.TrimSpace(fmt.Sprint(results[0][0].Interface())), 10, 32)
+	if err != nil {
+		return 0, fmt.Errorf("path %s does not select a number: %w", path, err)
+	}
+	return int32(size), nil
+}
+
+// replicasOrDefault returns the replicas of a workload, which default to 1 when not set
+func replicasOrDefault(replicas *int32) int32 {
+	if replicas == nil {
+		return 1
+	}
+	return *replicas
+}
@@ -0,0 +1,202 @@
+/*
+ Licensed to the Apache Software Foundation (ASF) under one
+ or more contributor license agreements.  See the NOTICE file
+ distributed with this work for additional information
+ regarding copyright ownership.  The ASF licenses this file
+ to you under the Apache License, Version 2.0 (the
+ "License"); you may not use this file except in compliance
+ with the License.  You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package cache
+
+import (
+	"fmt"
+	"testing"
+
+	"gotest.tools/v3/assert"
+	appsv1 "k8s.io/api/apps/v1"
+	batchv1 "k8s.io/api/batch/v1"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	apis "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	"github.com/apache/yunikorn-k8shim/pkg/client"
+	"github.com/apache/yunikorn-k8shim/pkg/common/constants"
+	"github.com/apache/yunikorn-k8shim/pkg/common/test"
+	schedulerconf "github.com/apache/yunikorn-k8shim/pkg/conf"
+)
+
+func initInferenceContextForTest(t *testing.T) (*Context, *client.MockedAPIProvider) {
+	context, apiProvider := initContextAndAPIProviderForTest()
+	lister, ok := apiProvider.GetAPIs().NamespaceInformer.Lister().(*test.MockNamespaceLister)
+	if !ok {
+		t.Fatalf("could not mock NamespaceLister")
+	}
+	lister.Add(&v1.Namespace{ObjectMeta: apis.ObjectMeta{
+		Name:        "gang",
+		Annotations: map[string]string{constants.NamespaceInferTaskGroups: constants.True},
+	}})
+	lister.Add(&v1.Namespace{ObjectMeta: apis.ObjectMeta{Name: "plain"}})
+
+	replicas := int32(3)
+	apiProvider.GetAPIs().StatefulSetInformer = apiProvider.GetAPIs().InformerFactory.Apps().V1().StatefulSets()
+	for _, ns := range []string{"gang", "plain"} {
+		err := apiProvider.GetAPIs().StatefulSetInformer.Informer().GetIndexer().Add(&appsv1.StatefulSet{
+			ObjectMeta: apis.ObjectMeta{Name: "db", Namespace: ns},
+			Spec:       appsv1.StatefulSetSpec{Replicas: &replicas},
+		})
+		assert.NilError(t, err, "failed to add statefulset")
+	}
+	return context, apiProvider
+}
+
+func newOwnedPodForTest(name, namespace, appID string, owner apis.OwnerReference) *v1.Pod {
+	isController := true
+	owner.Controller = &isController
+	pod := newPodHelper(name, namespace, name, "", appID, v1.PodPending)
+	pod.OwnerReferences = []apis.OwnerReference{owner}
+	pod.Spec.NodeSelector = map[string]string{"disk": "ssd"}
+	pod.Spec.Containers = []v1.Container{{
+		Name: "main",
+		Resources: v1.ResourceRequirements{Requests: v1.ResourceList{
+			v1.ResourceCPU:    resource.MustParse("500m"),
+			v1.ResourceMemory: resource.MustParse("1Gi"),
+		}},
+	}}
+	return pod
+}
+
+func TestInferTaskGroupsStatefulSet(t *testing.T) {
+	context, _ := initInferenceContextForTest(t)
+	owner := apis.OwnerReference{APIVersion: "apps/v1", Kind: "StatefulSet", Name: "db"}
+
+	taskGroups := context.inferTaskGroups(newOwnedPodForTest("db-0", "gang", "app-db", owner))
+	assert.Equal(t, len(taskGroups), 1)
+	assert.Equal(t, taskGroups[0].Name, "db")
+	assert.Equal(t, taskGroups[0].MinMember, int32(3))
+	cpu := taskGroups[0].MinResource["cpu"]
+	assert.Equal(t, cpu.MilliValue(), int64(500))
+	memory := taskGroups[0].MinResource["memory"]
+	assert.Equal(t, memory.Value(), int64(1024*1024*1024))
+	assert.Equal(t, taskGroups[0].NodeSelector["disk"], "ssd")
+
+	// namespace did not opt in
+	assert.Assert(t, context.inferTaskGroups(newOwnedPodForTest("db-0", "plain", "app-db", owner)) == nil)
+	// generated application ID
+	assert.Assert(t, context.inferTaskGroups(newOwnedPodForTest("db-0", "gang", "yunikorn-gang-autogen", owner)) == nil)
+	// task groups defined on the pod
+	pod := newOwnedPodForTest("db-0", "gang", "app-db", owner)
+	pod.Annotations = map[string]string{constants.AnnotationTaskGroups: `[{"name": "tg", "minMember": 1, "minResource": {"cpu": 1}}]`}
+	assert.Assert(t, context.inferTaskGroups(pod) == nil)
+	// unknown workload
+	owner.Name = "unknown"
+	assert.Assert(t, context.inferTaskGroups(newOwnedPodForTest("db-0", "gang", "app-db", owner)) == nil)
+}
+
+func TestInferTaskGroupsCustomWorkload(t *testing.T) {
+	context, apiProvider := initInferenceContextForTest(t)
+	apiProvider.MockGetWorkloadFn(func(namespace string, ref apis.OwnerReference) (*unstructured.Unstructured, error) {
+		if ref.Kind == "MPIJob" {
+			return &unstructured.Unstructured{Object: map[string]interface{}{
+				"spec": map[string]interface{}{"mpiReplicaSpecs": map[string]interface{}{
+					"Worker": map[string]interface{}{"replicas": int64(8)},
+				}},
+			}}, nil
+		}
+		return nil, fmt.Errorf("not found")
+	})
+
+	// Job is read from the informer, not from the API server
+	parallelism := int32(4)
+	apiProvider.GetAPIs().JobInformer = apiProvider.GetAPIs().InformerFactory.Batch().V1().Jobs()
+	err := apiProvider.GetAPIs().JobInformer.Informer().GetIndexer().Add(&batchv1.Job{
+		ObjectMeta: apis.ObjectMeta{Name: "train", Namespace: "gang"},
+		Spec:       batchv1.JobSpec{Parallelism: &parallelism},
+	})
+	assert.NilError(t, err, "failed to add job")
+	job := apis.OwnerReference{APIVersion: "batch/v1", Kind: "Job", Name: "train"}
+	taskGroups := context.inferTaskGroups(newOwnedPodForTest("train-x", "gang", "app-train", job))
+	assert.Equal(t, len(taskGroups), 1)
+	assert.Equal(t, taskGroups[0].MinMember, int32(4))
+
+	// custom resources need a configured path
+	mpiJob := apis.OwnerReference{APIVersion: "kubeflow.org/v2beta1", Kind: "MPIJob", Name: "mpi"}
+	assert.Assert(t, context.inferTaskGroups(newOwnedPodForTest("mpi-worker-0", "gang", "app-mpi", mpiJob)) == nil)
+	err = schedulerconf.UpdateConfigMaps([]*v1.ConfigMap{nil, {Data: map[string]string{
+		schedulerconf.CMSvcTaskGroupInferencePrefix + "MPIJob.kubeflow.org": "{.spec.mpiReplicaSpecs.Worker.replicas}",
+	}}}, true)
+	assert.NilError(t, err, "failed to update configmap")
+	defer func() {
+		err = schedulerconf.UpdateConfigMaps([]*v1.ConfigMap{nil, nil}, true)
+		assert.NilError(t, err, "failed to reset configmap")
+	}()
+	taskGroups = context.inferTaskGroups(newOwnedPodForTest("mpi-worker-0", "gang", "app-mpi", mpiJob))
+	assert.Equal(t, len(taskGroups), 1)
+	assert.Equal(t, taskGroups[0].Name, "mpi")
+	assert.Equal(t, taskGroups[0].MinMember, int32(8))
+}
+
+func TestAddPodInferredTaskGroups(t *testing.T) {
+	context, _ := initInferenceContextForTest(t)
+	owner := apis.OwnerReference{APIVersion: "apps/v1", Kind: "StatefulSet", Name: "db"}
+
+	pod0 := newOwnedPodForTest("db-0", "gang", "app-db", owner)
+	context.AddPod(pod0)
+	app := context.getApplication("app-db")
+	assert.Assert(t, app != nil, "application should have been created")
+	assert.Equal(t, len(app.getTaskGroups()), 1)
+	assert.Equal(t, app.GetTask("db-0").GetTaskGroupName(), "db")
+
+	// later pods of the workload join the inferred task group, the workload is not read again
+	pod1 := newOwnedPodForTest("db-1", "gang", "app-db", owner)
+	assert.Assert(t, context.inferNewApplicationTaskGroups(pod1) == nil, "workload should not be read for an existing application")
+	context.AddPod(pod1)
+	assert.Equal(t, app.GetTask("db-1").GetTaskGroupName(), "db")
+
+	// without opting in the application is created without task groups
+	context.AddPod(newOwnedPodForTest("db-0-plain", "plain", "app-plain", owner))
+	app = context.getApplication("app-plain")
+	assert.Assert(t, app != nil, "application should have been created")
+	assert.Equal(t, len(app.getTaskGroups()), 0)
+	assert.Equal(t, app.GetTask("db-0-plain").GetTaskGroupName(), "")
+}
+
+func TestEnsureAppAndTaskCreatedTaskGroupsRef(t *testing.T) {
+	context, apiProvider := initInferenceContextForTest(t)
+	definition := `[{"name": "worker", "minMember": 4, "minResource": {"cpu": 1}}]`
+	apiProvider.GetAPIs().TaskGroupConfigMapInformer = apiProvider.GetAPIs().InformerFactory.Core().V1().ConfigMaps()
+	err := apiProvider.GetAPIs().TaskGroupConfigMapInformer.Informer().GetIndexer().Add(&v1.ConfigMap{
+		ObjectMeta: apis.ObjectMeta{Name: "gang", Namespace: "gang", Labels: map[string]string{constants.LabelTaskGroups: "true"}},
+		Data:       map[string]string{constants.TaskGroupsRefDefaultKey: definition},
+	})
+	assert.NilError(t, err, "failed to add configmap")
+
+	// the reference takes precedence over inference, the resolved definition is kept for the placeholders
+	owner := apis.OwnerReference{APIVersion: "apps/v1", Kind: "StatefulSet", Name: "db"}
+	pod := newOwnedPodForTest("db-0", "gang", "app-db", owner)
+	pod.Annotations = map[string]string{constants.AnnotationTaskGroupsRef: "gang"}
+	context.ensureAppAndTaskCreated(pod, nil, nil)
+	app := context.getApplication("app-db")
+	assert.Assert(t, app != nil, "application should have been created")
+	assert.Equal(t, len(app.getTaskGroups()), 1)
+	assert.Equal(t, app.getTaskGroups()[0].Name, "worker")
+	assert.Equal(t, app.GetTaskGroupsDefinition(), definition)
+
+	// an unresolved reference creates the application without task groups
+	pod = newPodHelper("other", "gang", "other", "", "app-other", v1.PodPending)
+	pod.Annotations = map[string]string{constants.AnnotationTaskGroupsRef: "missing"}
+	context.ensureAppAndTaskCreated(pod, nil, nil)
+	app = context.getApplication("app-other")
+	assert.Assert(t, app != nil, "application should have been created")
+	assert.Equal(t, len(app.getTaskGroups()), 0)
+}
@@ -47,10 +47,12 @@ const (
 	TaskFail
 	KillTask
 	TaskKilled
+	WithdrawTask
+	TaskPendingTimeout
 )
 
 func (ae TaskEventType) String() string {
-	return [...]string{"InitTask", "SubmitTask", "TaskAllocated", "TaskRejected", "TaskBound", "CompleteTask", "TaskFail", "KillTask", "TaskKilled"}[ae]
+	return [...]string{"InitTask", "SubmitTask", "TaskAllocated", "TaskRejected", "TaskBound", "CompleteTask", "TaskFail", "KillTask", "TaskKilled", "WithdrawTask", "TaskPendingTimeout"}[ae]
 }
 
 // ------------------------
@@ -211,6 +213,17 @@ func NewFailTaskEvent(appID string, taskID string, failedMessage string) FailTas
 	}
 }
 
+// NewPendingTimeoutTaskEvent fails a task that was not allocated within its pending timeout, unlike a TaskFail event it
+// is only handled while the task is still scheduling.
+func NewPendingTimeoutTaskEvent(appID string, taskID string, failedMessage string) FailTaskEvent {
+	return FailTaskEvent{
+		applicationID: appID,
+		taskID:        taskID,
+		event:         TaskPendingTimeout,
+		message:       failedMessage,
+	}
+}
+
 func (fe FailTaskEvent) GetEvent() string {
 	return fe.event.String()
 }
@@ -366,11 +379,21 @@ func eventDesc(states *TStates) fsm.Events {
 			Src:  []string{states.New, states.Pending, states.Scheduling},
 			Dst:  states.Rejected,
 		},
+		{
+			Name: WithdrawTask.String(),
+			Src:  []string{states.Pending, states.Scheduling},
+			Dst:  states.New,
+		},
 		{
 			Name: TaskFail.String(),
 			Src:  []string{states.New, states.Pending, states.Scheduling, states.Rejected, states.Allocated},
 			Dst:  states.Failed,
 		},
+		{
+			Name: TaskPendingTimeout.String(),
+			Src:  []string{states.Scheduling},
+			Dst:  states.Failed,
+		},
 	}
 }
 
@@ -419,6 +442,16 @@ func callbacks(states *TStates) fsm.Callbacks {
 			task := event.Args[0].(*Task) //nolint:errcheck
 			task.beforeTaskFail()
 		},
+		beforeHook(TaskPendingTimeout): func(_ context.Context, event *fsm.Event) {
+			task := event.Args[0].(*Task) //nolint:errcheck
+			eventArgs := make([]string, 1)
+			generic := event.Args[1].([]interface{}) //nolint:errcheck
+			if err := events.GetEventArgsAsStrings(eventArgs, generic); err != nil {
+				log.Log(log.ShimFSM).Error("failed to parse event arg", zap.Error(err))
+				return
+			}
+			task.beforeTaskPendingTimeout(eventArgs[0])
+		},
 		beforeHook(TaskAllocated): func(_ context.Context, event *fsm.Event) {
 			task := event.Args[0].(*Task) //nolint:errcheck
 			eventArgs := make([]string, 2)
@@ -431,6 +464,10 @@ func callbacks(states *TStates) fsm.Callbacks {
 			nodeID := eventArgs[1]
 			task.beforeTaskAllocated(event.Src, allocationKey, nodeID)
 		},
+		beforeHook(WithdrawTask): func(_ context.Context, event *fsm.Event) {
+			task := event.Args[0].(*Task) //nolint:errcheck
+			task.beforeTaskWithdraw()
+		},
 		beforeHook(CompleteTask): func(_ context.Context, event *fsm.Event) {
 			task := event.Args[0].(*Task) //nolint:errcheck
 			task.beforeTaskCompleted()
@@ -439,9 +476,17 @@ func callbacks(states *TStates) fsm.Callbacks {
 			task := event.Args[0].(*Task) //nolint:errcheck
 			task.handleSubmitTaskEvent()
 		},
+		leaveState(states.Scheduling): func(_ context.Context, event *fsm.Event) {
+			task := event.Args[0].(*Task) //nolint:errcheck
+			task.stopPendingTimer()
+		},
 	}
 }
 
 func beforeHook(event TaskEventType) string {
 	return fmt.Sprintf("before_%s", event)
 }
+
+func leaveState(state string) string {
+	return fmt.Sprintf("leave_%s", state)
+}
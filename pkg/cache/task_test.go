@@ -19,19 +19,26 @@
 package cache
 
 import (
+	"fmt"
+	"strings"
 	"testing"
 	"time"
 
 	"gotest.tools/v3/assert"
 	v1 "k8s.io/api/core/v1"
+	policyv1 "k8s.io/api/policy/v1"
 	schedulingv1 "k8s.io/api/scheduling/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	k8sEvents "k8s.io/client-go/tools/events"
 
 	"github.com/apache/yunikorn-k8shim/pkg/client"
 	"github.com/apache/yunikorn-k8shim/pkg/common/constants"
 	"github.com/apache/yunikorn-k8shim/pkg/common/events"
 	"github.com/apache/yunikorn-k8shim/pkg/common/utils"
+	"github.com/apache/yunikorn-k8shim/pkg/conf"
+	"github.com/apache/yunikorn-k8shim/pkg/dispatcher"
 	"github.com/apache/yunikorn-k8shim/pkg/locking"
 	"github.com/apache/yunikorn-scheduler-interface/lib/go/si"
 )
@@ -332,6 +339,132 @@ func TestReleaseTaskAsk(t *testing.T) {
 	assert.Equal(t, mockedApiProvider.GetSchedulerAPIUpdateAllocationCount(), int32(2))
 }
 
+func TestTaskPendingTimeout(t *testing.T) {
+	context, mockedAPIProvider := initContextAndAPIProviderForTest()
+	dispatcher.RegisterEventHandler("TestTaskHandler", dispatcher.EventTypeTask, context.TaskEventHandler())
+	dispatcher.Start()
+	defer dispatcher.UnregisterAllEventHandlers()
+	defer dispatcher.Stop()
+	events.SetRecorder(events.NewMockedRecorder())
+	defer events.SetRecorder(events.NewMockedRecorder())
+
+	mockClient := mockedAPIProvider.GetAPIs().KubeClient
+	pod, err := mockClient.Create(&v1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: "pod-timeout-00001",
+			UID:  "UID-00001",
+			Annotations: map[string]string{
+				constants.AnnotationTaskPendingTimeout: "100ms",
+			},
+		},
+	})
+	assert.NilError(t, err)
+
+	app := NewApplication("app01", "root.default",
+		"bob", testGroups, map[string]string{}, mockedAPIProvider.GetAPIs().SchedulerAPI)
+	context.applications[app.applicationID] = app
+	task := NewTask("task01", app, context, pod)
+	app.addTask(task)
+	err = task.handle(NewSimpleTaskEvent(app.applicationID, task.taskID, InitTask))
+	assert.NilError(t, err, "failed to handle InitTask event")
+	err = task.handle(NewSubmitTaskEvent(app.applicationID, task.taskID))
+	assert.NilError(t, err, "failed to handle SubmitTask event")
+	assert.Equal(t, task.GetTaskState(), TaskStates().Scheduling)
+	context.HandleContainerStateUpdate(&si.UpdateContainerSchedulingStateRequest{
+		ApplicationID: app.applicationID,
+		AllocationKey: task.taskID,
+		State:         si.UpdateContainerSchedulingStateRequest_FAILED,
+		Reason:        "insufficient memory",
+	})
+
+	// the timer fails the task and releases the ask
+	err = utils.WaitForCondition(func() bool {
+		return task.GetTaskState() == TaskStates().Failed
+	}, 10*time.Millisecond, 3*time.Second)
+	assert.NilError(t, err, "task was not failed after the pending timeout")
+	// 1 update for submit, 1 for release
+	assert.Equal(t, mockedAPIProvider.GetSchedulerAPIUpdateAllocationCount(), int32(2))
+	// the pod is set to failed asynchronously
+	var failedPod *v1.Pod
+	err = utils.WaitForCondition(func() bool {
+		failedPod, err = mockClient.Get(pod.Namespace, pod.Name)
+		return err == nil && failedPod.Status.Phase == v1.PodFailed
+	}, 10*time.Millisecond, 3*time.Second)
+	assert.NilError(t, err, "pod was not failed after the pending timeout")
+	assert.Equal(t, failedPod.Status.Reason, constants.TaskPendingTimeoutFailure)
+	assert.Assert(t, strings.Contains(failedPod.Status.Message, "insufficient memory"), failedPod.Status.Message)
+}
+
+func TestTaskPendingTimeoutStoppedOnAllocation(t *testing.T) {
+	context, mockedAPIProvider := initContextAndAPIProviderForTest()
+	pod := &v1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: "pod-timeout-00002",
+			UID:  "UID-00002",
+			Annotations: map[string]string{
+				constants.AnnotationTaskPendingTimeout: "1h",
+			},
+		},
+	}
+	app := NewApplication("app01", "root.default",
+		"bob", testGroups, map[string]string{}, mockedAPIProvider.GetAPIs().SchedulerAPI)
+	task := NewTask("task01", app, context, pod)
+	err := task.handle(NewSimpleTaskEvent(app.applicationID, task.taskID, InitTask))
+	assert.NilError(t, err, "failed to handle InitTask event")
+	err = task.handle(NewSubmitTaskEvent(app.applicationID, task.taskID))
+	assert.NilError(t, err, "failed to handle SubmitTask event")
+	assert.Assert(t, task.pendingTimer != nil, "pending timer was not started")
+
+	err = task.handle(NewAllocateTaskEvent(app.applicationID, task.taskID, task.taskID, "node-1"))
+	assert.NilError(t, err, "failed to handle AllocateTask event")
+	assert.Assert(t, task.pendingTimer == nil, "pending timer was not stopped")
+
+	// a timeout firing after the allocation does not fail the task
+	assert.Assert(t, !task.canHandle(NewPendingTimeoutTaskEvent(app.applicationID, task.taskID, "timeout")),
+		"pending timeout must not be handled after the allocation")
+	task.handlePendingTimeout(time.Hour)
+	assert.Equal(t, task.GetTaskState(), TaskStates().Allocated)
+	assert.Equal(t, task.GetTaskPod().Status.Phase, v1.PodPhase(""))
+}
+
+func TestTaskPendingTimeoutFromCreation(t *testing.T) {
+	context, mockedAPIProvider := initContextAndAPIProviderForTest()
+	dispatcher.RegisterEventHandler("TestTaskHandler", dispatcher.EventTypeTask, context.TaskEventHandler())
+	dispatcher.Start()
+	defer dispatcher.UnregisterAllEventHandlers()
+	defer dispatcher.Stop()
+	events.SetRecorder(events.NewMockedRecorder())
+	defer events.SetRecorder(events.NewMockedRecorder())
+
+	// the pod has been waiting longer than the timeout before the task is submitted, e.g. after a restart
+	mockClient := mockedAPIProvider.GetAPIs().KubeClient
+	pod, err := mockClient.Create(&v1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:              "pod-timeout-00003",
+			UID:               "UID-00003",
+			CreationTimestamp: metav1.NewTime(time.Now().Add(-2 * time.Hour)),
+			Annotations: map[string]string{
+				constants.AnnotationTaskPendingTimeout: "1h",
+			},
+		},
+	})
+	assert.NilError(t, err)
+	app := NewApplication("app01", "root.default",
+		"bob", testGroups, map[string]string{}, mockedAPIProvider.GetAPIs().SchedulerAPI)
+	context.applications[app.applicationID] = app
+	task := NewTask("task01", app, context, pod)
+	app.addTask(task)
+	err = task.handle(NewSimpleTaskEvent(app.applicationID, task.taskID, InitTask))
+	assert.NilError(t, err, "failed to handle InitTask event")
+	err = task.handle(NewSubmitTaskEvent(app.applicationID, task.taskID))
+	assert.NilError(t, err, "failed to handle SubmitTask event")
+
+	err = utils.WaitForCondition(func() bool {
+		return task.GetTaskState() == TaskStates().Failed
+	}, 10*time.Millisecond, 3*time.Second)
+	assert.NilError(t, err, "task was not failed after the pending timeout")
+}
+
 func TestCreateTask(t *testing.T) {
 	time0 := time.Now()
 	mockedContext := initContextForTest()
@@ -928,3 +1061,153 @@ func TestCheckPodMetadataBeforeScheduling(t *testing.T) {
 		})
 	}
 }
+
+func TestPreemptTaskPod(t *testing.T) {
+	context, apiProvider := initContextAndAPIProviderForTest()
+	recorder := k8sEvents.NewFakeRecorder(1024)
+	events.SetRecorder(recorder)
+	defer events.SetRecorder(events.NewMockedRecorder())
+	defer func(interval time.Duration) { evictionRetryInterval = interval }(evictionRetryInterval)
+	evictionRetryInterval = time.Millisecond
+	// other tests might still publish events in the background, look for the event in all recorded events
+	hasEvent := func(message string) bool {
+		for {
+			select {
+			case event := <-recorder.Events:
+				if strings.Contains(event, message) {
+					return true
+				}
+			default:
+				return false
+			}
+		}
+	}
+	t.Cleanup(func() {
+		err := conf.UpdateConfigMaps([]*v1.ConfigMap{nil, nil}, true)
+		assert.NilError(t, err, "failed to reset configmap")
+	})
+
+	// preemptor task in another application and queue
+	context.AddApplication(&AddApplicationRequest{
+		Metadata: ApplicationMetadata{ApplicationID: "app-preemptor", QueueName: "root.high", User: "user"},
+	})
+	context.schedulerCache.UpdatePod(newPodHelper("preemptor", "ns", "preemptor-uid", "", "app-preemptor", v1.PodPending))
+
+	var statusUpdates []*v1.Pod
+	apiProvider.MockUpdateStatusFn(func(pod *v1.Pod) (*v1.Pod, error) {
+		statusUpdates = append(statusUpdates, pod)
+		return pod, nil
+	})
+	var evictions, deletions int
+	evictErr := apierrors.NewTooManyRequests("Cannot evict pod as it would violate the pod's disruption budget.", 0)
+	apiProvider.MockEvictFn(func(_ *v1.Pod) error {
+		evictions++
+		if evictions < 3 {
+			return evictErr
+		}
+		return nil
+	})
+	apiProvider.MockDeleteFn(func(_ *v1.Pod) error {
+		deletions++
+		return nil
+	})
+
+	app := NewApplication(appID, "root.default", "user", testGroups, map[string]string{}, nil)
+	task := NewTask("victim-uid", app, context, newPodHelper("victim", "ns", "victim-uid", "node-1", appID, v1.PodRunning))
+
+	// eviction refused by a PDB is retried
+	err := task.PreemptTaskPod("preemptor-uid")
+	assert.NilError(t, err)
+	assert.Equal(t, 3, evictions, "eviction not retried")
+	assert.Equal(t, 0, deletions, "pod should not have been deleted")
+	assert.Equal(t, 1, len(statusUpdates), "condition not updated")
+	conditions := statusUpdates[0].Status.Conditions
+	assert.Equal(t, 1, len(conditions))
+	assert.Equal(t, v1.DisruptionTarget, conditions[0].Type)
+	assert.Equal(t, v1.ConditionTrue, conditions[0].Status)
+	assert.Equal(t, v1.PodReasonPreemptionByScheduler, conditions[0].Reason)
+	assert.Assert(t, hasEvent("Task ns/victim is preempted by task ns/preemptor in queue root.high"), "preemption event not found")
+
+	// eviction refused until the timeout falls back to delete, unknown preemptor
+	err = conf.UpdateConfigMaps([]*v1.ConfigMap{nil, {Data: map[string]string{conf.CMSvcPreemptionEvictionTimeout: "0s"}}}, true)
+	assert.NilError(t, err, "failed to update configmap")
+	evictions = 0
+	apiProvider.MockEvictFn(func(_ *v1.Pod) error {
+		evictions++
+		return evictErr
+	})
+	err = task.PreemptTaskPod("")
+	assert.NilError(t, err)
+	assert.Equal(t, 1, evictions)
+	assert.Equal(t, 1, deletions, "pod should have been deleted")
+	assert.Equal(t, 1, len(statusUpdates), "condition should not be updated twice")
+	assert.Assert(t, hasEvent("Task ns/victim is preempted by the scheduler"), "preemption event not found")
+
+	// a release message in an unknown format does not name a preemptor
+	err = task.PreemptTaskPod(getPreemptorAllocationKey("preempted: preemptor-uid"))
+	assert.NilError(t, err)
+	assert.Equal(t, 2, deletions, "pod should have been deleted")
+	assert.Assert(t, hasEvent("Task ns/victim is preempted by the scheduler"), "preemption event not found")
+
+	// any other eviction error falls back to delete directly
+	err = conf.UpdateConfigMaps([]*v1.ConfigMap{nil, nil}, true)
+	assert.NilError(t, err, "failed to reset configmap")
+	evictions = 0
+	apiProvider.MockEvictFn(func(_ *v1.Pod) error {
+		evictions++
+		return fmt.Errorf("eviction not allowed")
+	})
+	err = task.PreemptTaskPod("")
+	assert.NilError(t, err)
+	assert.Equal(t, 1, evictions)
+	assert.Equal(t, 3, deletions, "pod should have been deleted")
+
+	// pod already gone
+	apiProvider.MockEvictFn(func(pod *v1.Pod) error {
+		return apierrors.NewNotFound(v1.Resource("pods"), pod.Name)
+	})
+	err = task.PreemptTaskPod("")
+	assert.NilError(t, err)
+	assert.Equal(t, 3, deletions, "pod should not have been deleted")
+}
+
+func TestIsPreemptSelfAllowedPDB(t *testing.T) {
+	context, apiProvider := initContextAndAPIProviderForTest()
+	t.Cleanup(func() {
+		err := conf.UpdateConfigMaps([]*v1.ConfigMap{nil, nil}, true)
+		assert.NilError(t, err, "failed to reset configmap")
+	})
+	apiProvider.AddPodDisruptionBudget(&policyv1.PodDisruptionBudget{
+		ObjectMeta: metav1.ObjectMeta{Name: "pdb", Namespace: "ns"},
+		Spec:       policyv1.PodDisruptionBudgetSpec{Selector: &metav1.LabelSelector{MatchLabels: map[string]string{"pdb": "covered"}}},
+	})
+	app := NewApplication(appID, "root.default", "user", testGroups, map[string]string{}, nil)
+	coveredPod := newPodHelper("covered", "ns", "covered-uid", "node-1", appID, v1.PodRunning)
+	coveredPod.Labels["pdb"] = "covered"
+	covered := NewTask("covered-uid", app, context, coveredPod)
+	other := NewTask("other-uid", app, context, newPodHelper("other", "ns", "other-uid", "node-1", appID, v1.PodRunning))
+
+	// PDBs are ignored by default
+	assert.Assert(t, covered.isPreemptSelfAllowed(), "PDB should have been ignored")
+
+	// PDB covered pods are sorted last by the core
+	err := conf.UpdateConfigMaps([]*v1.ConfigMap{nil, {Data: map[string]string{conf.CMSvcPreemptionPDBPolicy: conf.PreemptionPDBPolicyPrefer}}}, true)
+	assert.NilError(t, err, "failed to update configmap")
+	assert.Assert(t, !covered.isPreemptSelfAllowed(), "PDB covered pod should not allow preemption")
+	assert.Assert(t, other.isPreemptSelfAllowed(), "pod without PDB should allow preemption")
+
+	// the annotation of the pod wins
+	coveredPod.Annotations = map[string]string{constants.AnnotationAllowPreemption: constants.True}
+	assert.Assert(t, covered.isPreemptSelfAllowed(), "annotation should have been used")
+}
+
+func TestGetPreemptorAllocationKey(t *testing.T) {
+	assert.Equal(t, getPreemptorAllocationKey("preempting allocations to free up resources to run ask: ask-1"), "ask-1")
+	assert.Equal(t, getPreemptorAllocationKey("preempting allocations to free up resources to run daemon set ask: ask-2"), "ask-2")
+	assert.Equal(t, getPreemptorAllocationKey("released"), "")
+	assert.Equal(t, getPreemptorAllocationKey(""), "")
+	// a changed message format does not attribute the preemption to a task
+	assert.Equal(t, getPreemptorAllocationKey("preempting allocations to free up resources to run ask: "), "")
+	assert.Equal(t, getPreemptorAllocationKey("preempted by ask-1"), "")
+	assert.Equal(t, getPreemptorAllocationKey("preempted: quota exceeded"), "")
+}
@@ -21,8 +21,12 @@ package cache
 import (
 	"encoding/json"
 	"fmt"
+	"strings"
 
 	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/apimachinery/pkg/util/validation"
+	listerv1 "k8s.io/client-go/listers/core/v1"
 
 	"github.com/apache/yunikorn-k8shim/pkg/common/constants"
 	"github.com/apache/yunikorn-k8shim/pkg/common/utils"
@@ -33,7 +37,40 @@ func GetTaskGroupsFromAnnotation(pod *v1.Pod) ([]TaskGroup, error) {
 	if taskGroupInfo == "" {
 		return nil, nil
 	}
+	return parseTaskGroups(taskGroupInfo)
+}
 
+// GetTaskGroupsFromReference resolves the task groups from the ConfigMap referenced by the pod. The definition is
+// returned with the task groups: the placeholders carry the resolved definition for recovery.
+func GetTaskGroupsFromReference(pod *v1.Pod, lister listerv1.ConfigMapLister) ([]TaskGroup, string, error) {
+	ref := utils.GetPodAnnotationValue(pod, constants.AnnotationTaskGroupsRef)
+	if ref == "" {
+		return nil, "", nil
+	}
+	name, key, found := strings.Cut(ref, "/")
+	if !found {
+		key = constants.TaskGroupsRefDefaultKey
+	}
+	if errs := validation.IsDNS1123Subdomain(name); len(errs) > 0 || key == "" {
+		return nil, "", fmt.Errorf("invalid task groups reference %s", ref)
+	}
+	configMap, err := lister.ConfigMaps(pod.Namespace).Get(name)
+	if err != nil {
+		return nil, "", fmt.Errorf("unable to get task groups reference %s, the ConfigMap must be labelled %s=true: %w",
+			ref, constants.LabelTaskGroups, err)
+	}
+	taskGroupInfo, ok := configMap.Data[key]
+	if !ok || taskGroupInfo == "" {
+		return nil, "", fmt.Errorf("key %s not found in task groups reference %s", key, ref)
+	}
+	taskGroups, err := parseTaskGroups(taskGroupInfo)
+	if err != nil {
+		return nil, "", err
+	}
+	return taskGroups, taskGroupInfo, nil
+}
+
+func parseTaskGroups(taskGroupInfo string) ([]TaskGroup, error) {
 	taskGroups := []TaskGroup{}
 	err := json.Unmarshal([]byte(taskGroupInfo), &taskGroups)
 	if err != nil {
@@ -57,6 +94,67 @@ func GetTaskGroupsFromAnnotation(pod *v1.Pod) ([]TaskGroup, error) {
 			return nil, fmt.Errorf("minMember cannot be negative, %s",
 				taskGroupInfo)
 		}
+		if taskGroup.PlaceholderTimeoutInSeconds < 0 {
+			return nil, fmt.Errorf("placeholderTimeoutInSeconds cannot be negative, %s",
+				taskGroupInfo)
+		}
+		if taskGroup.GangSchedulingStyle != "" && constants.SchedulingPolicyStyleParamValues[taskGroup.GangSchedulingStyle] == "" {
+			return nil, fmt.Errorf("unknown gangSchedulingStyle %s, %s",
+				taskGroup.GangSchedulingStyle, taskGroupInfo)
+		}
+		if taskGroup.TopologyKey != "" {
+			if errs := validation.IsQualifiedName(taskGroup.TopologyKey); len(errs) > 0 {
+				return nil, fmt.Errorf("invalid topologyKey %s: %s, %s",
+					taskGroup.TopologyKey, strings.Join(errs, ", "), taskGroupInfo)
+			}
+		}
+		for _, className := range []string{taskGroup.PriorityClassName, taskGroup.RuntimeClassName} {
+			if className == "" {
+				continue
+			}
+			if errs := validation.IsDNS1123Subdomain(className); len(errs) > 0 {
+				return nil, fmt.Errorf("invalid class name %s: %s, %s",
+					className, strings.Join(errs, ", "), taskGroupInfo)
+			}
+		}
+		if taskGroup.MinReady != nil {
+			minReady, err := intstr.GetScaledValueFromIntOrPercent(taskGroup.MinReady, int(taskGroup.MinMember), true)
+			if err != nil {
+				return nil, fmt.Errorf("invalid minReady, %s: %w", taskGroupInfo, err)
+			}
+			// a minReady of minMember or more is the same as not allowing a partial start
+			if minReady < 1 || minReady >= int(taskGroup.MinMember) {
+				return nil, fmt.Errorf("minReady must be at least 1 and less than minMember, %s",
+					taskGroupInfo)
+			}
+		}
 	}
 	return taskGroups, nil
 }
+
+// getMinReady returns the number of bound placeholders that allows the task group to start after the
+// placeholder timeout, MinMember if the task group does not allow a partial start
+func getMinReady(tg TaskGroup) int32 {
+	if tg.MinReady == nil {
+		return tg.MinMember
+	}
+	minReady, err := intstr.GetScaledValueFromIntOrPercent(tg.MinReady, int(tg.MinMember), true)
+	if err != nil || minReady > int(tg.MinMember) {
+		return tg.MinMember
+	}
+	return int32(minReady) //nolint:gosec
+}
+
+// preemptorMessageMarker precedes the allocation key of the preemptor in the release message of a preempted allocation
+const preemptorMessageMarker = "ask: "
+
+// getPreemptorAllocationKey returns the allocation key of the ask that triggered the preemption, the core adds it
+// at the end of the release message: "preempting allocations to free up resources to run ask: <allocationKey>".
+// An empty string is returned for any other message, the preemption is then not attributed to a task.
+func getPreemptorAllocationKey(message string) string {
+	idx := strings.LastIndex(message, preemptorMessageMarker)
+	if idx < 0 {
+		return ""
+	}
+	return strings.TrimSpace(message[idx+len(preemptorMessageMarker):])
+}
@@ -25,6 +25,8 @@ import (
 	v1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/informers"
+	k8fake "k8s.io/client-go/kubernetes/fake"
 
 	"github.com/apache/yunikorn-k8shim/pkg/common/constants"
 )
@@ -217,3 +219,138 @@ func TestGetTaskGroupFromAnnotation(t *testing.T) {
 	assert.Equal(t, taskGroups2[0].MinResource["cpu"], resource.MustParse("2"))
 	assert.Equal(t, taskGroups2[0].MinResource["memory"], resource.MustParse("1Gi"))
 }
+
+func TestGetTaskGroupPolicyFromAnnotation(t *testing.T) {
+	pod := &v1.Pod{}
+	pod.Annotations = map[string]string{constants.AnnotationTaskGroups: `[
+		{"name": "ps", "minMember": 2, "minResource": {"cpu": 1}, "gangSchedulingStyle": "Hard"},
+		{"name": "worker", "minMember": 8, "minResource": {"cpu": 1}, "placeholderTimeoutInSeconds": 60, "gangSchedulingStyle": "Soft"},
+		{"name": "other", "minMember": 1, "minResource": {"cpu": 1}}
+	]`}
+	taskGroups, err := GetTaskGroupsFromAnnotation(pod)
+	assert.NilError(t, err)
+	assert.Equal(t, taskGroups[0].GangSchedulingStyle, "Hard")
+	assert.Equal(t, taskGroups[0].PlaceholderTimeoutInSeconds, int64(0))
+	assert.Equal(t, taskGroups[1].GangSchedulingStyle, "Soft")
+	assert.Equal(t, taskGroups[1].PlaceholderTimeoutInSeconds, int64(60))
+	assert.Equal(t, taskGroups[2].GangSchedulingStyle, "")
+
+	pod.Annotations[constants.AnnotationTaskGroups] = `[{"name": "ps", "minMember": 2, "minResource": {"cpu": 1}, "placeholderTimeoutInSeconds": -1}]`
+	_, err = GetTaskGroupsFromAnnotation(pod)
+	assert.ErrorContains(t, err, "placeholderTimeoutInSeconds cannot be negative")
+	pod.Annotations[constants.AnnotationTaskGroups] = `[{"name": "ps", "minMember": 2, "minResource": {"cpu": 1}, "gangSchedulingStyle": "Strict"}]`
+	_, err = GetTaskGroupsFromAnnotation(pod)
+	assert.ErrorContains(t, err, "unknown gangSchedulingStyle Strict")
+}
+
+func TestGetTaskGroupMinReadyFromAnnotation(t *testing.T) {
+	pod := &v1.Pod{}
+	pod.Annotations = map[string]string{constants.AnnotationTaskGroups: `[
+		{"name": "ps", "minMember": 4, "minResource": {"cpu": 1}, "minReady": 3},
+		{"name": "worker", "minMember": 5, "minResource": {"cpu": 1}, "minReady": "50%"},
+		{"name": "other", "minMember": 2, "minResource": {"cpu": 1}}
+	]`}
+	taskGroups, err := GetTaskGroupsFromAnnotation(pod)
+	assert.NilError(t, err)
+	assert.Equal(t, getMinReady(taskGroups[0]), int32(3))
+	// percentages are rounded up
+	assert.Equal(t, getMinReady(taskGroups[1]), int32(3))
+	assert.Assert(t, taskGroups[2].MinReady == nil)
+	assert.Equal(t, getMinReady(taskGroups[2]), int32(2))
+
+	pod.Annotations[constants.AnnotationTaskGroups] = `[{"name": "ps", "minMember": 2, "minResource": {"cpu": 1}, "minReady": 3}]`
+	_, err = GetTaskGroupsFromAnnotation(pod)
+	assert.ErrorContains(t, err, "minReady must be at least 1 and less than minMember")
+	// boundary: minReady equal to minMember is rejected, one less is accepted
+	pod.Annotations[constants.AnnotationTaskGroups] = `[{"name": "ps", "minMember": 2, "minResource": {"cpu": 1}, "minReady": 2}]`
+	_, err = GetTaskGroupsFromAnnotation(pod)
+	assert.ErrorContains(t, err, "minReady must be at least 1 and less than minMember")
+	pod.Annotations[constants.AnnotationTaskGroups] = `[{"name": "ps", "minMember": 2, "minResource": {"cpu": 1}, "minReady": "100%"}]`
+	_, err = GetTaskGroupsFromAnnotation(pod)
+	assert.ErrorContains(t, err, "minReady must be at least 1 and less than minMember")
+	pod.Annotations[constants.AnnotationTaskGroups] = `[{"name": "ps", "minMember": 2, "minResource": {"cpu": 1}, "minReady": 1}]`
+	taskGroups, err = GetTaskGroupsFromAnnotation(pod)
+	assert.NilError(t, err)
+	assert.Equal(t, getMinReady(taskGroups[0]), int32(1))
+	pod.Annotations[constants.AnnotationTaskGroups] = `[{"name": "ps", "minMember": 2, "minResource": {"cpu": 1}, "minReady": "0%"}]`
+	_, err = GetTaskGroupsFromAnnotation(pod)
+	assert.ErrorContains(t, err, "minReady must be at least 1 and less than minMember")
+	pod.Annotations[constants.AnnotationTaskGroups] = `[{"name": "ps", "minMember": 2, "minResource": {"cpu": 1}, "minReady": "half"}]`
+	_, err = GetTaskGroupsFromAnnotation(pod)
+	assert.ErrorContains(t, err, "invalid minReady")
+}
+
+func TestGetTaskGroupTopologyKeyFromAnnotation(t *testing.T) {
+	pod := &v1.Pod{}
+	pod.Annotations = map[string]string{constants.AnnotationTaskGroups: `[
+		{"name": "worker", "minMember": 4, "minResource": {"cpu": 1}, "topologyKey": "topology.kubernetes.io/zone"}
+	]`}
+	taskGroups, err := GetTaskGroupsFromAnnotation(pod)
+	assert.NilError(t, err)
+	assert.Equal(t, taskGroups[0].TopologyKey, "topology.kubernetes.io/zone")
+
+	pod.Annotations[constants.AnnotationTaskGroups] = `[{"name": "worker", "minMember": 4, "minResource": {"cpu": 1}, "topologyKey": "not a key"}]`
+	_, err = GetTaskGroupsFromAnnotation(pod)
+	assert.ErrorContains(t, err, "invalid topologyKey")
+}
+
+func TestGetTaskGroupClassNamesFromAnnotation(t *testing.T) {
+	pod := &v1.Pod{}
+	pod.Annotations = map[string]string{constants.AnnotationTaskGroups: `[
+		{"name": "worker", "minMember": 4, "minResource": {"cpu": 1}, "priorityClassName": "high", "runtimeClassName": "kata"}
+	]`}
+	taskGroups, err := GetTaskGroupsFromAnnotation(pod)
+	assert.NilError(t, err)
+	assert.Equal(t, taskGroups[0].PriorityClassName, "high")
+	assert.Equal(t, taskGroups[0].RuntimeClassName, "kata")
+
+	pod.Annotations[constants.AnnotationTaskGroups] = `[{"name": "worker", "minMember": 4, "minResource": {"cpu": 1}, "runtimeClassName": "Not_Valid"}]`
+	_, err = GetTaskGroupsFromAnnotation(pod)
+	assert.ErrorContains(t, err, "invalid class name")
+}
+
+func TestGetTaskGroupsFromReference(t *testing.T) {
+	definition := `[{"name": "worker", "minMember": 2, "minResource": {"cpu": 1}}]`
+	informer := informers.NewSharedInformerFactory(k8fake.NewSimpleClientset(), 0).Core().V1().ConfigMaps()
+	err := informer.Informer().GetIndexer().Add(&v1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: "gang", Namespace: "default", Labels: map[string]string{constants.LabelTaskGroups: "true"}},
+		Data: map[string]string{
+			constants.TaskGroupsRefDefaultKey: definition,
+			"broken":                          `[{"name": "worker"}]`,
+		},
+	})
+	assert.NilError(t, err, "failed to add configmap")
+
+	pod := &v1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "pod", Namespace: "default"}}
+	taskGroups, def, err := GetTaskGroupsFromReference(pod, informer.Lister())
+	assert.NilError(t, err)
+	assert.Assert(t, taskGroups == nil, "no task groups expected without reference")
+	assert.Equal(t, def, "")
+
+	tests := []struct {
+		name string
+		ref  string
+		err  string
+	}{
+		{"default key", "gang", ""},
+		{"explicit key", "gang/" + constants.TaskGroupsRefDefaultKey, ""},
+		{"missing key", "gang/other", "key other not found"},
+		{"invalid definition", "gang/broken", "can't get taskGroup MinResource"},
+		{"missing configmap", "other", "must be labelled " + constants.LabelTaskGroups},
+		{"invalid reference", "Not_Valid/key", "invalid task groups reference"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pod.Annotations = map[string]string{constants.AnnotationTaskGroupsRef: tt.ref}
+			taskGroups, def, err = GetTaskGroupsFromReference(pod, informer.Lister())
+			if tt.err != "" {
+				assert.ErrorContains(t, err, tt.err)
+				return
+			}
+			assert.NilError(t, err)
+			assert.Equal(t, len(taskGroups), 1)
+			assert.Equal(t, taskGroups[0].MinMember, int32(2))
+			assert.Equal(t, def, definition)
+		})
+	}
+}
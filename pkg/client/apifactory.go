@@ -23,11 +23,13 @@ import (
 	"time"
 
 	"go.uber.org/zap"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/client-go/informers"
 	"k8s.io/client-go/tools/cache"
 	"k8s.io/klog/v2"
 	"k8s.io/kubernetes/pkg/scheduler/framework/plugins/volumebinding"
 
+	"github.com/apache/yunikorn-k8shim/pkg/common/constants"
 	"github.com/apache/yunikorn-k8shim/pkg/conf"
 	"github.com/apache/yunikorn-k8shim/pkg/locking"
 	"github.com/apache/yunikorn-k8shim/pkg/log"
@@ -92,6 +94,11 @@ type APIFactory struct {
 func NewAPIFactory(scheduler api.SchedulerAPI, informerFactory informers.SharedInformerFactory, configs *conf.SchedulerConf, testMode bool) *APIFactory {
 	kubeClient := NewKubeClient(configs.KubeConfig)
 	namespaceInformerFactory := informers.NewSharedInformerFactoryWithOptions(kubeClient.GetClientSet(), 0, informers.WithNamespace(configs.Namespace))
+	// only ConfigMaps labelled as task groups can be referenced, the rest of the cluster is not cached
+	taskGroupInformerFactory := informers.NewSharedInformerFactoryWithOptions(kubeClient.GetClientSet(), 0,
+		informers.WithTweakListOptions(func(options *metav1.ListOptions) {
+			options.LabelSelector = constants.LabelTaskGroups + "=true"
+		}))
 	// init informers
 	// volume informers are also used to get the Listers for the predicates
 	podInformer := informerFactory.Core().V1().Pods()
@@ -100,14 +107,18 @@ func NewAPIFactory(scheduler api.SchedulerAPI, informerFactory informers.SharedI
 	pvInformer := informerFactory.Core().V1().PersistentVolumes()
 	pvcInformer := informerFactory.Core().V1().PersistentVolumeClaims()
 	storageInformer := informerFactory.Storage().V1().StorageClasses()
+	taskGroupConfigMapInformer := taskGroupInformerFactory.Core().V1().ConfigMaps()
 	csiNodeInformer := informerFactory.Storage().V1().CSINodes()
 	csiDriverInformer := informerFactory.Storage().V1().CSIDrivers()
 	csiStorageCapacityInformer := informerFactory.Storage().V1().CSIStorageCapacities()
+	jobInformer := informerFactory.Batch().V1().Jobs()
 	namespaceInformer := informerFactory.Core().V1().Namespaces()
 	priorityClassInformer := informerFactory.Scheduling().V1().PriorityClasses()
+	pdbInformer := informerFactory.Policy().V1().PodDisruptionBudgets()
 	serviceInformer := informerFactory.Core().V1().Services()
 	replicationControllerInformer := informerFactory.Core().V1().ReplicationControllers()
 	replicaSetInformer := informerFactory.Apps().V1().ReplicaSets()
+	runtimeClassInformer := informerFactory.Node().V1().RuntimeClasses()
 	statefulSetInformer := informerFactory.Apps().V1().StatefulSets()
 	volumeAttachmentInformer := informerFactory.Storage().V1().VolumeAttachments()
 
@@ -140,14 +151,18 @@ func NewAPIFactory(scheduler api.SchedulerAPI, informerFactory informers.SharedI
 			PVInformer:                    pvInformer,
 			PVCInformer:                   pvcInformer,
 			StorageClassInformer:          storageInformer,
+			TaskGroupConfigMapInformer:    taskGroupConfigMapInformer,
 			CSINodeInformer:               csiNodeInformer,
 			CSIDriverInformer:             csiDriverInformer,
 			CSIStorageCapacityInformer:    csiStorageCapacityInformer,
+			JobInformer:                   jobInformer,
 			NamespaceInformer:             namespaceInformer,
 			PriorityClassInformer:         priorityClassInformer,
+			PodDisruptionBudgetInformer:   pdbInformer,
 			ServiceInformer:               serviceInformer,
 			ReplicationControllerInformer: replicationControllerInformer,
 			ReplicaSetInformer:            replicaSetInformer,
+			RuntimeClassInformer:          runtimeClassInformer,
 			StatefulSetInformer:           statefulSetInformer,
 			VolumeAttachmentInformer:      volumeAttachmentInformer,
 			VolumeBinder:                  volumeBinder,
@@ -24,11 +24,17 @@ import (
 
 	"go.uber.org/zap"
 	v1 "k8s.io/api/core/v1"
+	nodeapiv1 "k8s.io/api/node/v1"
+	policyv1 "k8s.io/api/policy/v1"
 	schedv1 "k8s.io/api/scheduling/v1"
+	apis "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/client-go/informers"
 	k8fake "k8s.io/client-go/kubernetes/fake"
 	appsv1 "k8s.io/client-go/listers/apps/v1"
+	batchv1 "k8s.io/client-go/listers/batch/v1"
 	corev1 "k8s.io/client-go/listers/core/v1"
+	nodev1 "k8s.io/client-go/listers/node/v1"
 	storagev1 "k8s.io/client-go/listers/storage/v1"
 	"k8s.io/client-go/tools/cache"
 	"k8s.io/kubernetes/pkg/scheduler/framework/plugins/volumebinding"
@@ -67,19 +73,21 @@ const (
 func NewMockedAPIProvider(showError bool) *MockedAPIProvider {
 	return &MockedAPIProvider{
 		clients: &Clients{
-			KubeClient:            NewKubeClientMock(showError),
-			SchedulerAPI:          test.NewSchedulerAPIMock(),
-			PodInformer:           test.NewMockedPodInformer(),
-			NodeInformer:          test.NewMockedNodeInformer(),
-			ConfigMapInformer:     test.NewMockedConfigMapInformer(),
-			PVInformer:            NewMockedPersistentVolumeInformer(),
-			PVCInformer:           NewMockedPersistentVolumeClaimInformer(),
-			StorageClassInformer:  NewMockedStorageClassInformer(),
-			VolumeBinder:          test.NewVolumeBinderMock(),
-			NamespaceInformer:     test.NewMockNamespaceInformer(false),
-			PriorityClassInformer: test.NewMockPriorityClassInformer(),
-			CSINodeInformer:       NewMockedCSINodeInformer(),
-			InformerFactory:       informers.NewSharedInformerFactory(k8fake.NewSimpleClientset(), time.Second*60),
+			KubeClient:                  NewKubeClientMock(showError),
+			SchedulerAPI:                test.NewSchedulerAPIMock(),
+			PodInformer:                 test.NewMockedPodInformer(),
+			NodeInformer:                test.NewMockedNodeInformer(),
+			ConfigMapInformer:           test.NewMockedConfigMapInformer(),
+			PVInformer:                  NewMockedPersistentVolumeInformer(),
+			PVCInformer:                 NewMockedPersistentVolumeClaimInformer(),
+			StorageClassInformer:        NewMockedStorageClassInformer(),
+			VolumeBinder:                test.NewVolumeBinderMock(),
+			NamespaceInformer:           test.NewMockNamespaceInformer(false),
+			PriorityClassInformer:       test.NewMockPriorityClassInformer(),
+			PodDisruptionBudgetInformer: test.NewMockPodDisruptionBudgetInformer(),
+			CSINodeInformer:             NewMockedCSINodeInformer(),
+			RuntimeClassInformer:        NewMockedRuntimeClassInformer(),
+			InformerFactory:             informers.NewSharedInformerFactory(k8fake.NewSimpleClientset(), time.Second*60),
 		},
 		events:       make(chan informerEvent),
 		eventHandler: make(chan *ResourceEventHandlers),
@@ -146,6 +154,18 @@ func (m *MockedAPIProvider) MockDeleteFn(dfn func(pod *v1.Pod) error) {
 	}
 }
 
+func (m *MockedAPIProvider) MockEvictFn(efn func(pod *v1.Pod) error) {
+	if mock, ok := m.clients.KubeClient.(*KubeClientMock); ok {
+		mock.evictFn = efn
+	}
+}
+
+func (m *MockedAPIProvider) MockGetWorkloadFn(wfn func(namespace string, ref apis.OwnerReference) (*unstructured.Unstructured, error)) {
+	if mock, ok := m.clients.KubeClient.(*KubeClientMock); ok {
+		mock.MockGetWorkloadFn(wfn)
+	}
+}
+
 func (m *MockedAPIProvider) MockCreateFn(cfn func(pod *v1.Pod) (*v1.Pod, error)) {
 	if mock, ok := m.clients.KubeClient.(*KubeClientMock); ok {
 		mock.createFn = cfn
@@ -171,6 +191,12 @@ func (m *MockedAPIProvider) SetNodeLister(lister corev1.NodeLister) {
 	}
 }
 
+func (m *MockedAPIProvider) AddPodDisruptionBudget(pdb *policyv1.PodDisruptionBudget) {
+	if i, ok := m.clients.PodDisruptionBudgetInformer.(*test.MockPodDisruptionBudgetInformer); ok {
+		i.Add(pdb)
+	}
+}
+
 func (m *MockedAPIProvider) SetPodLister(lister corev1.PodLister) {
 	informer := m.clients.PodInformer
 	if i, ok := informer.(*test.MockedPodInformer); ok {
@@ -594,6 +620,49 @@ func NewMockedStatefulSetInformer() *MockedStatefulSetInformer {
 	}
 }
 
+type MockedJobInformer struct {
+	informer cache.SharedIndexInformer
+}
+
+func (m *MockedJobInformer) Informer() cache.SharedIndexInformer {
+	return m.informer
+}
+
+func (m *MockedJobInformer) Lister() batchv1.JobLister {
+	return nil
+}
+
+func NewMockedJobInformer() *MockedJobInformer {
+	return &MockedJobInformer{
+		informer: &test.SharedInformerMock{},
+	}
+}
+
+type MockedRuntimeClassInformer struct {
+	informer cache.SharedIndexInformer
+	indexer  cache.Indexer
+}
+
+func (m *MockedRuntimeClassInformer) Informer() cache.SharedIndexInformer {
+	return m.informer
+}
+
+func (m *MockedRuntimeClassInformer) Lister() nodev1.RuntimeClassLister {
+	return nodev1.NewRuntimeClassLister(m.indexer)
+}
+
+// Add makes the runtime class available from the lister
+func (m *MockedRuntimeClassInformer) Add(runtimeClass *nodeapiv1.RuntimeClass) error {
+	return m.indexer.Add(runtimeClass)
+}
+
+func NewMockedRuntimeClassInformer() *MockedRuntimeClassInformer {
+	return &MockedRuntimeClassInformer{
+		informer: &test.SharedInformerMock{},
+		indexer:  cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{}),
+	}
+}
+
 func (m *MockedAPIProvider) SetVolumeBinder(binder volumebinding.SchedulerVolumeBinder) {
 	m.clients.VolumeBinder = binder
 }
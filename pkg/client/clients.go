@@ -25,7 +25,10 @@ import (
 
 	"k8s.io/client-go/informers"
 	appsInformerV1 "k8s.io/client-go/informers/apps/v1"
+	batchInformerV1 "k8s.io/client-go/informers/batch/v1"
 	coreInformerV1 "k8s.io/client-go/informers/core/v1"
+	nodeInformerV1 "k8s.io/client-go/informers/node/v1"
+	policyInformerV1 "k8s.io/client-go/informers/policy/v1"
 	schedulingInformerV1 "k8s.io/client-go/informers/scheduling/v1"
 	storageInformerV1 "k8s.io/client-go/informers/storage/v1"
 	"k8s.io/kubernetes/pkg/scheduler/framework/plugins/volumebinding"
@@ -50,10 +53,13 @@ type Clients struct {
 	CSIDriverInformer             storageInformerV1.CSIDriverInformer
 	CSINodeInformer               storageInformerV1.CSINodeInformer
 	CSIStorageCapacityInformer    storageInformerV1.CSIStorageCapacityInformer
+	JobInformer                   batchInformerV1.JobInformer
 	NamespaceInformer             coreInformerV1.NamespaceInformer
 	NodeInformer                  coreInformerV1.NodeInformer
 	PodInformer                   coreInformerV1.PodInformer
+	PodDisruptionBudgetInformer   policyInformerV1.PodDisruptionBudgetInformer
 	StorageClassInformer          storageInformerV1.StorageClassInformer
+	TaskGroupConfigMapInformer    coreInformerV1.ConfigMapInformer // labelled ConfigMaps in all namespaces, resolves task group references
 	PVCInformer                   coreInformerV1.PersistentVolumeClaimInformer
 	PVInformer                    coreInformerV1.PersistentVolumeInformer
 	ReplicaSetInformer            appsInformerV1.ReplicaSetInformer
@@ -61,6 +67,7 @@ type Clients struct {
 	ServiceInformer               coreInformerV1.ServiceInformer
 	StatefulSetInformer           appsInformerV1.StatefulSetInformer
 	ReplicationControllerInformer coreInformerV1.ReplicationControllerInformer
+	RuntimeClassInformer          nodeInformerV1.RuntimeClassInformer
 	VolumeAttachmentInformer      storageInformerV1.VolumeAttachmentInformer
 
 	// volume binder handles PV/PVC related operations
@@ -75,17 +82,21 @@ func (c *Clients) WaitForSync() {
 			c.CSIDriverInformer.Informer().HasSynced() &&
 			c.CSINodeInformer.Informer().HasSynced() &&
 			c.CSIStorageCapacityInformer.Informer().HasSynced() &&
+			c.JobInformer.Informer().HasSynced() &&
 			c.NamespaceInformer.Informer().HasSynced() &&
 			c.NodeInformer.Informer().HasSynced() &&
 			c.PodInformer.Informer().HasSynced() &&
+			c.PodDisruptionBudgetInformer.Informer().HasSynced() &&
 			c.PriorityClassInformer.Informer().HasSynced() &&
 			c.PVCInformer.Informer().HasSynced() &&
 			c.PVInformer.Informer().HasSynced() &&
 			c.ReplicaSetInformer.Informer().HasSynced() &&
 			c.ReplicationControllerInformer.Informer().HasSynced() &&
+			c.RuntimeClassInformer.Informer().HasSynced() &&
 			c.ServiceInformer.Informer().HasSynced() &&
 			c.StatefulSetInformer.Informer().HasSynced() &&
 			c.StorageClassInformer.Informer().HasSynced() &&
+			c.TaskGroupConfigMapInformer.Informer().HasSynced() &&
 			c.VolumeAttachmentInformer.Informer().HasSynced() {
 			return
 		}
@@ -103,16 +114,20 @@ func (c *Clients) Run(stopCh <-chan struct{}) {
 	go c.CSIDriverInformer.Informer().Run(stopCh)
 	go c.CSINodeInformer.Informer().Run(stopCh)
 	go c.CSIStorageCapacityInformer.Informer().Run(stopCh)
+	go c.JobInformer.Informer().Run(stopCh)
 	go c.NamespaceInformer.Informer().Run(stopCh)
 	go c.NodeInformer.Informer().Run(stopCh)
 	go c.PodInformer.Informer().Run(stopCh)
+	go c.PodDisruptionBudgetInformer.Informer().Run(stopCh)
 	go c.PriorityClassInformer.Informer().Run(stopCh)
 	go c.PVCInformer.Informer().Run(stopCh)
 	go c.PVInformer.Informer().Run(stopCh)
 	go c.ReplicaSetInformer.Informer().Run(stopCh)
 	go c.ReplicationControllerInformer.Informer().Run(stopCh)
+	go c.RuntimeClassInformer.Informer().Run(stopCh)
 	go c.ServiceInformer.Informer().Run(stopCh)
 	go c.StatefulSetInformer.Informer().Run(stopCh)
 	go c.StorageClassInformer.Informer().Run(stopCh)
+	go c.TaskGroupConfigMapInformer.Informer().Run(stopCh)
 	go c.VolumeAttachmentInformer.Informer().Run(stopCh)
 }
@@ -29,7 +29,7 @@ import (
 )
 
 const (
-	noOfInformers = 16 // total number of active informers
+	noOfInformers = 20 // total number of active informers
 )
 
 func TestWaitForSync(t *testing.T) {
@@ -77,17 +77,21 @@ func getClients() *Clients {
 		CSIDriverInformer:             NewMockedCSIDriverInformer(),
 		CSINodeInformer:               NewMockedCSINodeInformer(),
 		CSIStorageCapacityInformer:    NewMockedCSIStorageCapacityInformer(),
+		JobInformer:                   NewMockedJobInformer(),
 		NamespaceInformer:             test.NewMockNamespaceInformer(false),
 		NodeInformer:                  test.NewMockedNodeInformer(),
 		PodInformer:                   test.NewMockedPodInformer(),
+		PodDisruptionBudgetInformer:   test.NewMockPodDisruptionBudgetInformer(),
 		PriorityClassInformer:         test.NewMockPriorityClassInformer(),
 		PVCInformer:                   NewMockedPersistentVolumeClaimInformer(),
 		PVInformer:                    NewMockedPersistentVolumeInformer(),
 		ReplicaSetInformer:            NewMockedReplicaSetInformer(),
 		ReplicationControllerInformer: NewMockedReplicationControllerInformer(),
+		RuntimeClassInformer:          NewMockedRuntimeClassInformer(),
 		ServiceInformer:               NewMockedServiceInformer(),
 		StatefulSetInformer:           NewMockedStatefulSetInformer(),
 		StorageClassInformer:          NewMockedStorageClassInformer(),
+		TaskGroupConfigMapInformer:    test.NewMockedConfigMapInformer(),
 		VolumeAttachmentInformer:      test.NewMockVolumeAttachmentInformer(),
 	}
 }
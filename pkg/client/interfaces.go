@@ -20,6 +20,8 @@ package client
 
 import (
 	v1 "k8s.io/api/core/v1"
+	apis "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/rest"
 )
@@ -34,6 +36,9 @@ type KubeClient interface {
 	// Delete a pod from a host
 	Delete(pod *v1.Pod) error
 
+	// Evict a pod from a host using the eviction API, the eviction is refused if it violates a PodDisruptionBudget
+	Evict(pod *v1.Pod) error
+
 	// Update a pod
 	UpdatePod(pod *v1.Pod, podMutator func(pod *v1.Pod)) (*v1.Pod, error)
 
@@ -49,6 +54,9 @@ type KubeClient interface {
 	GetConfigs() *rest.Config
 
 	GetConfigMap(namespace string, name string) (*v1.ConfigMap, error)
+
+	// Get the workload referenced by an owner reference, this supports custom resources
+	GetWorkload(namespace string, ref apis.OwnerReference) (*unstructured.Unstructured, error)
 }
 
 func NewKubeClient(kc string) KubeClient {
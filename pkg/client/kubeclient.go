@@ -24,10 +24,17 @@ import (
 
 	"go.uber.org/zap"
 	v1 "k8s.io/api/core/v1"
+	policyv1 "k8s.io/api/policy/v1"
 	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
 	apis "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/discovery/cached/memory"
+	"k8s.io/client-go/dynamic"
 	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/rest"
+	"k8s.io/client-go/restmapper"
 	"k8s.io/client-go/tools/clientcmd"
 	"k8s.io/client-go/util/retry"
 
@@ -36,8 +43,10 @@ import (
 )
 
 type SchedulerKubeClient struct {
-	clientSet *kubernetes.Clientset
-	configs   *rest.Config
+	clientSet     *kubernetes.Clientset
+	configs       *rest.Config
+	dynamicClient dynamic.Interface
+	restMapper    *restmapper.DeferredDiscoveryRESTMapper
 }
 
 func newBootstrapSchedulerKubeClient(kc string) SchedulerKubeClient {
@@ -46,10 +55,7 @@ func newBootstrapSchedulerKubeClient(kc string) SchedulerKubeClient {
 	if err != nil {
 		log.Log(log.ShimClient).Fatal("failed to get Clientset", zap.Error(err))
 	}
-	return SchedulerKubeClient{
-		clientSet: configuredClient,
-		configs:   config,
-	}
+	return newSchedulerKubeClientFromConfig(config, configuredClient)
 }
 
 func newSchedulerKubeClient(kc string) SchedulerKubeClient {
@@ -62,9 +68,19 @@ func newSchedulerKubeClient(kc string) SchedulerKubeClient {
 	if err != nil {
 		log.Log(log.ShimClient).Fatal("failed to get Clientset", zap.Error(err))
 	}
+	return newSchedulerKubeClientFromConfig(config, configuredClient)
+}
+
+func newSchedulerKubeClientFromConfig(config *rest.Config, clientSet *kubernetes.Clientset) SchedulerKubeClient {
+	dynamicClient, err := dynamic.NewForConfig(config)
+	if err != nil {
+		log.Log(log.ShimClient).Fatal("failed to get dynamic client", zap.Error(err))
+	}
 	return SchedulerKubeClient{
-		clientSet: configuredClient,
-		configs:   config,
+		clientSet:     clientSet,
+		configs:       config,
+		dynamicClient: dynamicClient,
+		restMapper:    restmapper.NewDeferredDiscoveryRESTMapper(memory.NewMemCacheClient(clientSet.Discovery())),
 	}
 }
 
@@ -148,6 +164,26 @@ func (nc SchedulerKubeClient) Delete(pod *v1.Pod) error {
 	return nil
 }
 
+func (nc SchedulerKubeClient) Evict(pod *v1.Pod) error {
+	eviction := &policyv1.Eviction{
+		ObjectMeta: apis.ObjectMeta{
+			Namespace: pod.Namespace,
+			Name:      pod.Name,
+		},
+		DeleteOptions: &apis.DeleteOptions{
+			Preconditions: apis.NewUIDPreconditions(string(pod.UID)),
+		},
+	}
+	if err := nc.clientSet.PolicyV1().Evictions(pod.Namespace).Evict(context.Background(), eviction); err != nil {
+		log.Log(log.ShimClient).Warn("failed to evict pod",
+			zap.String("namespace", pod.Namespace),
+			zap.String("podName", pod.Name),
+			zap.Error(err))
+		return err
+	}
+	return nil
+}
+
 func (nc SchedulerKubeClient) GetConfigMap(namespace string, name string) (*v1.ConfigMap, error) {
 	configmap, err := nc.clientSet.CoreV1().ConfigMaps(namespace).Get(context.Background(), name, apis.GetOptions{})
 	if err != nil && !errors.IsNotFound(err) {
@@ -160,6 +196,37 @@ func (nc SchedulerKubeClient) GetConfigMap(namespace string, name string) (*v1.C
 	return configmap, nil
 }
 
+func (nc SchedulerKubeClient) GetWorkload(namespace string, ref apis.OwnerReference) (*unstructured.Unstructured, error) {
+	gv, err := schema.ParseGroupVersion(ref.APIVersion)
+	if err != nil {
+		return nil, err
+	}
+	gk := schema.GroupKind{Group: gv.Group, Kind: ref.Kind}
+	mapping, err := nc.restMapper.RESTMapping(gk, gv.Version)
+	if meta.IsNoMatchError(err) {
+		// the kind could have been installed after the discovery information was cached
+		nc.restMapper.Reset()
+		mapping, err = nc.restMapper.RESTMapping(gk, gv.Version)
+	}
+	if err != nil {
+		log.Log(log.ShimClient).Warn("failed to map workload kind to a resource",
+			zap.String("apiVersion", ref.APIVersion),
+			zap.String("kind", ref.Kind),
+			zap.Error(err))
+		return nil, err
+	}
+	workload, err := nc.dynamicClient.Resource(mapping.Resource).Namespace(namespace).Get(context.Background(), ref.Name, apis.GetOptions{})
+	if err != nil {
+		log.Log(log.ShimClient).Warn("failed to get workload",
+			zap.String("namespace", namespace),
+			zap.String("kind", ref.Kind),
+			zap.String("name", ref.Name),
+			zap.Error(err))
+		return nil, err
+	}
+	return workload, nil
+}
+
 func (nc SchedulerKubeClient) Get(podNamespace string, podName string) (*v1.Pod, error) {
 	pod, err := nc.clientSet.CoreV1().Pods(podNamespace).Get(context.Background(), podName, apis.GetOptions{})
 	if err != nil {
@@ -24,6 +24,8 @@ import (
 
 	"go.uber.org/zap"
 	v1 "k8s.io/api/core/v1"
+	apis "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/kubernetes/fake"
 	"k8s.io/client-go/rest"
@@ -36,10 +38,12 @@ import (
 type KubeClientMock struct {
 	bindFn         func(pod *v1.Pod, hostID string) error
 	deleteFn       func(pod *v1.Pod) error
+	evictFn        func(pod *v1.Pod) error
 	createFn       func(pod *v1.Pod) (*v1.Pod, error)
 	updateFn       func(pod *v1.Pod, podMutator func(pod *v1.Pod)) (*v1.Pod, error)
 	updateStatusFn func(pod *v1.Pod) (*v1.Pod, error)
 	getFn          func(podName string) (*v1.Pod, error)
+	getWorkloadFn  func(namespace string, ref apis.OwnerReference) (*unstructured.Unstructured, error)
 	clientSet      kubernetes.Interface
 	pods           map[string]*v1.Pod
 	lock           locking.RWMutex
@@ -72,6 +76,14 @@ func NewKubeClientMock(err bool) *KubeClientMock {
 				zap.String("PodName", pod.Name))
 			return nil
 		},
+		evictFn: func(pod *v1.Pod) error {
+			if err {
+				return fmt.Errorf("error evicting pod")
+			}
+			log.Log(log.Test).Info("pod evicted",
+				zap.String("PodName", pod.Name))
+			return nil
+		},
 		createFn: func(pod *v1.Pod) (*v1.Pod, error) {
 			if err {
 				return pod, fmt.Errorf("error creating pod")
@@ -149,6 +161,14 @@ func (c *KubeClientMock) MockDeleteFn(dfn func(pod *v1.Pod) error) {
 	c.deleteFn = dfn
 }
 
+func (c *KubeClientMock) MockEvictFn(efn func(pod *v1.Pod) error) {
+	c.evictFn = efn
+}
+
+func (c *KubeClientMock) MockGetWorkloadFn(wfn func(namespace string, ref apis.OwnerReference) (*unstructured.Unstructured, error)) {
+	c.getWorkloadFn = wfn
+}
+
 func (c *KubeClientMock) MockCreateFn(cfn func(pod *v1.Pod) (*v1.Pod, error)) {
 	c.createFn = cfn
 }
@@ -198,6 +218,16 @@ func (c *KubeClientMock) Delete(pod *v1.Pod) error {
 	return c.deleteFn(pod)
 }
 
+func (c *KubeClientMock) Evict(pod *v1.Pod) error {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	err := c.evictFn(pod)
+	if err == nil {
+		delete(c.pods, getPodKey(pod))
+	}
+	return err
+}
+
 func (c *KubeClientMock) GetClientSet() kubernetes.Interface {
 	c.lock.RLock()
 	defer c.lock.RUnlock()
@@ -216,6 +246,15 @@ func (c *KubeClientMock) GetConfigMap(namespace string, name string) (*v1.Config
 	return nil, nil
 }
 
+func (c *KubeClientMock) GetWorkload(namespace string, ref apis.OwnerReference) (*unstructured.Unstructured, error) {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+	if c.getWorkloadFn == nil {
+		return nil, fmt.Errorf("workload %s/%s not found", namespace, ref.Name)
+	}
+	return c.getWorkloadFn(namespace, ref)
+}
+
 func (c *KubeClientMock) GetBindStats() BindStats {
 	c.lock.RLock()
 	defer c.lock.RUnlock()
@@ -39,6 +39,9 @@ const DefaultNodeAttributeHostNameKey = "si.io/hostname"
 const DefaultNodeAttributeRackNameKey = "si.io/rackname"
 const DefaultNodeInstanceTypeNodeLabelKey = "node.kubernetes.io/instance-type"
 const DefaultRackName = "/rack-default"
+const NodeAttributeZoneKey = siCommon.FailureDomainZone
+const NodeAttributeRegionKey = siCommon.FailureDomainRegion
+const NodeAttributeInstanceTypeKey = siCommon.InstanceType
 const DomainYuniKorn = siCommon.DomainYuniKorn
 const DomainYuniKornInternal = siCommon.DomainYuniKornInternal
 
@@ -90,11 +93,43 @@ const SchedulingPolicyTimeoutParam = "placeholderTimeoutInSeconds"
 const SchedulingPolicyParamDelimiter = " "
 const SchedulingPolicyStyleParam = "gangSchedulingStyle"
 const SchedulingPolicyStyleParamDefault = "Soft"
+const SchedulingPolicyMaxRuntimeParam = "maxRuntimeInSeconds"
 
 var SchedulingPolicyStyleParamValues = map[string]string{"Hard": "Hard", "Soft": "Soft"}
 
+// AnnotationTaskGroupsRef references a ConfigMap in the pod namespace holding the task groups: <configmap>[/<key>]
+const AnnotationTaskGroupsRef = DomainYuniKorn + "task-groups-ref"
+
+// LabelTaskGroups marks a ConfigMap that can be referenced as task groups, other ConfigMaps are not watched
+const LabelTaskGroups = DomainYuniKorn + "task-groups"
+
+// TaskGroupsRefDefaultKey is the ConfigMap key used if the task groups reference does not set one
+const TaskGroupsRefDefaultKey = "task-groups"
+
+// AnnotationAppGroup names the application group: the gangs of all applications in the group start together
+const AnnotationAppGroup = DomainYuniKorn + "app-group"
+
+// AnnotationAppGroupSize sets the number of applications in the application group
+const AnnotationAppGroupSize = DomainYuniKorn + "app-group-size"
+
 const ApplicationInsufficientResourcesFailure = "ResourceReservationTimeout"
 const ApplicationRejectedFailure = "ApplicationRejected"
+const ApplicationDeadlineExceededFailure = "DeadlineExceeded"
+const PlaceholderCreateFailure = "PlaceholderCreateFailed"
+
+// AnnotationMaxRuntime sets the maximum wall-clock runtime of an application, measured from the time it reaches the
+// Running state. The value is either a duration (e.g. 2h30m) or a number of seconds.
+const AnnotationMaxRuntime = DomainYuniKorn + "max-runtime"
+
+// AnnotationRunningSince records the time, in RFC3339 format, the application reached the Running state.
+// It is set by the scheduler on an application pod and used to restore the max runtime deadline after a restart.
+const AnnotationRunningSince = DomainYuniKorn + "running-since"
+
+// AnnotationTaskPendingTimeout sets the maximum time a pod may wait for an allocation before it is failed, measured
+// from the creation of the pod.
+// The value is either a duration (e.g. 10m) or a number of seconds.
+const AnnotationTaskPendingTimeout = DomainYuniKorn + "task-pending-timeout"
+const TaskPendingTimeoutFailure = "TaskPendingTimeout"
 
 // namespace.max.* (Retaining for backwards compatibility. Need to be removed in next major release)
 const CPUQuota = DomainYuniKorn + "namespace.max.cpu"
@@ -109,9 +144,24 @@ const NamespaceGuaranteed = DomainYuniKorn + "namespace.guaranteed"
 // NamespaceMaxApps Namespace Max Apps
 const NamespaceMaxApps = DomainYuniKorn + "namespace.maxApps"
 
+// NamespaceMaxRuntime default maximum runtime for applications in the namespace
+const NamespaceMaxRuntime = DomainYuniKorn + "namespace.maxRuntime"
+
+// NamespaceTaskPendingTimeout default time a task in the namespace may wait for an allocation
+const NamespaceTaskPendingTimeout = DomainYuniKorn + "namespace.taskPendingTimeout"
+
+// NamespaceResourceAccountingPolicy resource accounting policy for the pods in the namespace
+const NamespaceResourceAccountingPolicy = DomainYuniKorn + "namespace.resourceAccountingPolicy"
+
+// NamespaceInferTaskGroups set to true builds the task groups from the owning workload for pods without task groups
+const NamespaceInferTaskGroups = DomainYuniKorn + "namespace.inferTaskGroups"
+
 // AnnotationAllowPreemption set on PriorityClass, opt out of preemption for pods with this priority class
 const AnnotationAllowPreemption = DomainYuniKorn + "allow-preemption"
 
+// AnnotationSuspended set to true on a pod of an application suspends the scheduling of the application
+const AnnotationSuspended = DomainYuniKorn + "suspended"
+
 // AnnotationIgnoreApplication set on Pod prevents by admission controller, prevents YuniKorn from honoring application ID
 const AnnotationIgnoreApplication = DomainYuniKorn + "ignore-application"
 
@@ -25,6 +25,7 @@ import (
 	helpers "k8s.io/component-helpers/resource"
 
 	"github.com/apache/yunikorn-k8shim/pkg/common/constants"
+	"github.com/apache/yunikorn-k8shim/pkg/conf"
 	"github.com/apache/yunikorn-k8shim/pkg/log"
 	siCommon "github.com/apache/yunikorn-scheduler-interface/lib/go/common"
 	"github.com/apache/yunikorn-scheduler-interface/lib/go/si"
@@ -50,10 +51,65 @@ func (w *ResourceBuilder) Build() *si.Resource {
 	return &si.Resource{Resources: w.resourceMap}
 }
 
+// addKubernetesResource adds the quantity of a Kubernetes resource using the name and value of the core after
+// applying the resource mapping. Resources that map to the same name are added up, dropped resources are skipped.
+func (w *ResourceBuilder) addKubernetesResource(name v1.ResourceName, quantity resource.Quantity) *ResourceBuilder {
+	siName, value, ok := toSIResource(name, quantity)
+	if !ok {
+		return w
+	}
+	if current, exists := w.resourceMap[siName]; exists {
+		value += current.Value
+	}
+	return w.AddResource(siName, value)
+}
+
+// toSIResource converts a Kubernetes resource quantity into the name and value used by the core: cpu is converted
+// to vcore in millicores, other resources keep their name and value. The configured resource mapping is applied on
+// top, false is returned if the resource is dropped.
+func toSIResource(name v1.ResourceName, quantity resource.Quantity) (string, int64, bool) {
+	siName := string(name)
+	value := quantity.Value()
+	if name == v1.ResourceCPU {
+		siName = siCommon.CPU
+		value = quantity.MilliValue()
+	}
+	mapping, ok := conf.GetSchedulerConf().GetResourceMapping(string(name))
+	if !ok {
+		return siName, value, true
+	}
+	if mapping.Drop {
+		return "", 0, false
+	}
+	if mapping.Name != "" {
+		siName = mapping.Name
+	}
+	if mapping.Scale > 0 {
+		value = int64(float64(value) * mapping.Scale)
+	}
+	return siName, value, true
+}
+
+// GetSIResourceName returns the name used by the core for a Kubernetes resource, false if the resource is dropped
+func GetSIResourceName(name v1.ResourceName) (string, bool) {
+	siName, _, ok := toSIResource(name, resource.Quantity{})
+	return siName, ok
+}
+
 // GetPodResource from a pod's containers and convert that into an internal resource.
 // Scheduling only accounts for requests.
 // Convert the pod into a resource to allow for pod count checks in quotas and nodes.
 func GetPodResource(pod *v1.Pod) (resource *si.Resource) {
+	return GetPodResourceForPolicy(pod, conf.ResourceAccountingRequests)
+}
+
+// GetPodResourceForPolicy computes the pod resource like GetPodResource, counting the container resources
+// selected by the given resource accounting policy.
+func GetPodResourceForPolicy(pod *v1.Pod, policy string) *si.Resource {
+	var floor v1.ResourceList
+	if policy == conf.ResourceAccountingFloor {
+		floor = conf.GetSchedulerConf().GetResourceAccountingFloor()
+	}
 	podResource := &si.Resource{
 		Resources: map[string]*si.Quantity{"pods": {Value: 1}},
 	}
@@ -72,14 +128,14 @@ func GetPodResource(pod *v1.Pod) (resource *si.Resource) {
 
 	// Add usage for each container
 	for _, container := range pod.Spec.Containers {
-		podResource = Add(podResource, computeContainerResource(pod, &container, containerStatuses))
+		podResource = Add(podResource, computeContainerResource(pod, &container, containerStatuses, policy, floor))
 	}
 
 	// each resource compare between initcontainer and sum of containers
 	// InitContainers(i) requirement=sum(Sidecar requirement i-1)+InitContainer(i) request
 	// max(sum(Containers requirement)+sum(Sidecar requirement), InitContainer(i) requirement)
 	if len(pod.Spec.InitContainers) > 0 {
-		podResource = checkInitContainerRequest(pod, podResource, containerStatuses)
+		podResource = checkInitContainerRequest(pod, podResource, containerStatuses, policy, floor)
 	}
 
 	// PodLevelResources feature:
@@ -88,7 +144,7 @@ func GetPodResource(pod *v1.Pod) (resource *si.Resource) {
 	if pod.Spec.Resources != nil && len(pod.Spec.Resources.Requests) > 0 {
 		// pod-level resources, if present, override sum of container-level resources
 		// only memory and cpu are supported
-		for name, value := range getPodLevelResource(pod.Spec.Resources.Requests).GetResources() {
+		for name, value := range getPodLevelResource(accountedResources(*pod.Spec.Resources, policy, floor)).GetResources() {
 			podResource.Resources[name] = value
 		}
 	}
@@ -110,22 +166,59 @@ func GetPodResource(pod *v1.Pod) (resource *si.Resource) {
 
 // computeContainerResource computes the max(spec...resources, status...allocatedResources, status...resources)
 // per KEP-1287 (in-place update of pod resources), unless resize status is PodResizeStatusInfeasible.
-func computeContainerResource(pod *v1.Pod, container *v1.Container, containerStatuses map[string]*v1.ContainerStatus) *si.Resource {
+// The resources of the spec and status are counted according to the resource accounting policy.
+func computeContainerResource(pod *v1.Pod, container *v1.Container, containerStatuses map[string]*v1.ContainerStatus, policy string, floor v1.ResourceList) *si.Resource {
 	combined := &si.Resource{Resources: make(map[string]*si.Quantity)}
-	updateMax(combined, getResource(container.Resources.Requests))
+	updateMax(combined, getResource(accountedResources(container.Resources, policy, floor)))
 	if containerStatus := containerStatuses[container.Name]; containerStatus != nil {
 		if isResizeInfeasible(pod) && containerStatus.Resources != nil {
 			// resize request was denied; use container status requests as current value
-			return getResource(containerStatus.Resources.Requests)
+			return getResource(accountedResources(*containerStatus.Resources, policy, floor))
 		}
 		updateMax(combined, getResource(containerStatus.AllocatedResources))
 		if containerStatus.Resources != nil {
-			updateMax(combined, getResource(containerStatus.Resources.Requests))
+			updateMax(combined, getResource(accountedResources(*containerStatus.Resources, policy, floor)))
 		}
 	}
 	return combined
 }
 
+// accountedResources returns the resources of the requirements that are counted by the resource accounting policy.
+// Unknown policies count the requests.
+func accountedResources(requirements v1.ResourceRequirements, policy string, floor v1.ResourceList) v1.ResourceList {
+	switch policy {
+	case conf.ResourceAccountingLimits:
+		result := requirements.Requests.DeepCopy()
+		if result == nil && len(requirements.Limits) > 0 {
+			result = v1.ResourceList{}
+		}
+		for name, limit := range requirements.Limits {
+			result[name] = limit
+		}
+		return result
+	case conf.ResourceAccountingMax:
+		return maxResourceList(requirements.Requests, requirements.Limits)
+	case conf.ResourceAccountingFloor:
+		return maxResourceList(requirements.Requests, floor)
+	default:
+		return requirements.Requests
+	}
+}
+
+// maxResourceList returns a new resource list with the maximum quantity of each resource in left and right
+func maxResourceList(left, right v1.ResourceList) v1.ResourceList {
+	result := left.DeepCopy()
+	if result == nil && len(right) > 0 {
+		result = v1.ResourceList{}
+	}
+	for name, quantity := range right {
+		if current, ok := result[name]; !ok || quantity.Cmp(current) > 0 {
+			result[name] = quantity
+		}
+	}
+	return result
+}
+
 // isResizeInfeasible determines if a currently in-progress pod resize is infeasible. This takes into account both the
 // current pod.Status.Resize field as well as the upcoming PodResizePending pod condition (in spec, but not yet
 // implemented).
@@ -161,20 +254,20 @@ func updateMax(left *si.Resource, right *si.Resource) {
 	}
 }
 
-func checkInitContainerRequest(pod *v1.Pod, containersResources *si.Resource, containerStatuses map[string]*v1.ContainerStatus) *si.Resource {
+func checkInitContainerRequest(pod *v1.Pod, containersResources *si.Resource, containerStatuses map[string]*v1.ContainerStatus, policy string, floor v1.ResourceList) *si.Resource {
 	updatedRes := containersResources
 
 	// update total pod resource usage with sidecar containers
 	for _, c := range pod.Spec.InitContainers {
 		if isSideCarContainer(&c) {
-			sideCarResources := computeContainerResource(pod, &c, containerStatuses)
+			sideCarResources := computeContainerResource(pod, &c, containerStatuses, policy, floor)
 			updatedRes = Add(updatedRes, sideCarResources)
 		}
 	}
 
 	var sideCarRequests *si.Resource // cumulative value of sidecar requests so far
 	for _, c := range pod.Spec.InitContainers {
-		ICResource := computeContainerResource(pod, &c, containerStatuses)
+		ICResource := computeContainerResource(pod, &c, containerStatuses, policy, floor)
 		if isSideCarContainer(&c) {
 			sideCarRequests = Add(sideCarRequests, ICResource)
 		}
@@ -208,6 +301,33 @@ func GetNodeResource(nodeStatus *v1.NodeStatus) *si.Resource {
 	return getResource(nodeStatus.Allocatable)
 }
 
+// GetNodeCapacity returns the capacity of the node that is sent to the core: the allocatable resources of the node
+// with the configured capacity adjustment for the node applied. The reservation is subtracted first, without going
+// below zero, then the overcommit ratio is applied.
+func GetNodeCapacity(node *v1.Node) *si.Resource {
+	capacity := GetNodeResource(&node.Status)
+	adjustment := conf.GetSchedulerConf().GetNodeCapacityAdjustment(node.Labels)
+	if adjustment == nil {
+		return capacity
+	}
+	reserved := getResource(adjustment.Reserved)
+	for name, quantity := range capacity.Resources {
+		if r, ok := reserved.Resources[name]; ok {
+			quantity.Value = max(quantity.Value-r.Value, 0)
+		}
+	}
+	for name, ratio := range adjustment.Overcommit {
+		siName, ok := GetSIResourceName(v1.ResourceName(name))
+		if !ok {
+			continue
+		}
+		if quantity, ok := capacity.Resources[siName]; ok {
+			quantity.Value = int64(float64(quantity.Value) * ratio)
+		}
+	}
+	return capacity
+}
+
 // parse cpu and memory from string to si.Resource, both of them are optional
 // if parse failed with some errors, log the error and return a nil
 func ParseResource(cpuStr, memStr string) *si.Resource {
@@ -218,7 +338,7 @@ func ParseResource(cpuStr, memStr string) *si.Resource {
 	result := NewResourceBuilder()
 	if cpuStr != "" {
 		if vcore, err := resource.ParseQuantity(cpuStr); err == nil {
-			result.AddResource(siCommon.CPU, vcore.MilliValue())
+			result.addKubernetesResource(v1.ResourceCPU, vcore)
 		} else {
 			log.Log(log.ShimResources).Error("failed to parse cpu resource",
 				zap.String("cpuStr", cpuStr),
@@ -229,7 +349,7 @@ func ParseResource(cpuStr, memStr string) *si.Resource {
 
 	if memStr != "" {
 		if mem, err := resource.ParseQuantity(memStr); err == nil {
-			result.AddResource(siCommon.Memory, mem.Value())
+			result.addKubernetesResource(v1.ResourceMemory, mem)
 		} else {
 			log.Log(log.ShimResources).Error("failed to parse memory resource",
 				zap.String("memStr", memStr),
@@ -247,7 +367,7 @@ func GetResource(resMap map[string]string) *si.Resource {
 		switch resName {
 		case v1.ResourceCPU.String():
 			if actualValue, err := resource.ParseQuantity(resValue); err == nil {
-				result.AddResource(siCommon.CPU, actualValue.MilliValue())
+				result.addKubernetesResource(v1.ResourceCPU, actualValue)
 			} else {
 				log.Log(log.ShimResources).Error("failed to parse cpu resource",
 					zap.String("res name", "cpu"),
@@ -257,7 +377,7 @@ func GetResource(resMap map[string]string) *si.Resource {
 			}
 		default:
 			if actualValue, err := resource.ParseQuantity(resValue); err == nil {
-				result.AddResource(resName, actualValue.Value())
+				result.addKubernetesResource(v1.ResourceName(resName), actualValue)
 			} else {
 				log.Log(log.ShimResources).Error("failed to parse resource",
 					zap.String("res name", resName),
@@ -272,28 +392,20 @@ func GetResource(resMap map[string]string) *si.Resource {
 
 func GetTGResource(resMap map[string]resource.Quantity, members int64) *si.Resource {
 	result := NewResourceBuilder()
-	result.AddResource("pods", members)
 	for resName, resValue := range resMap {
-		switch resName {
-		case v1.ResourceCPU.String():
-			result.AddResource(siCommon.CPU, members*resValue.MilliValue())
-		default:
-			result.AddResource(resName, members*resValue.Value())
-		}
+		result.addKubernetesResource(v1.ResourceName(resName), resValue)
 	}
+	for _, quantity := range result.resourceMap {
+		quantity.Value *= members
+	}
+	result.AddResource("pods", members)
 	return result.Build()
 }
 
 func getResource(resourceList v1.ResourceList) *si.Resource {
 	resources := NewResourceBuilder()
 	for name, value := range resourceList {
-		switch name {
-		case v1.ResourceCPU:
-			vcore := value.MilliValue()
-			resources.AddResource(siCommon.CPU, vcore)
-		default:
-			resources.AddResource(string(name), value.Value())
-		}
+		resources.addKubernetesResource(name, value)
 	}
 	return resources.Build()
 }
@@ -302,13 +414,7 @@ func getPodLevelResource(resourceList v1.ResourceList) *si.Resource {
 	resources := NewResourceBuilder()
 	for name, value := range resourceList {
 		if helpers.IsSupportedPodLevelResource(name) {
-			switch name {
-			case v1.ResourceCPU:
-				vcore := value.MilliValue()
-				resources.AddResource(siCommon.CPU, vcore)
-			default:
-				resources.AddResource(string(name), value.Value())
-			}
+			resources.addKubernetesResource(name, value)
 		}
 	}
 	return resources.Build()
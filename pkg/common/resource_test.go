@@ -28,6 +28,7 @@ import (
 	k8res "k8s.io/component-helpers/resource"
 
 	"github.com/apache/yunikorn-k8shim/pkg/common/constants"
+	"github.com/apache/yunikorn-k8shim/pkg/conf"
 	"github.com/apache/yunikorn-k8shim/pkg/plugin/predicates"
 	siCommon "github.com/apache/yunikorn-scheduler-interface/lib/go/common"
 	"github.com/apache/yunikorn-scheduler-interface/lib/go/si"
@@ -559,6 +560,243 @@ func TestGetPodResourcesWithInPlacePodVerticalScaling(t *testing.T) {
 	assert.Equal(t, res.Resources["pods"].GetValue(), int64(1))
 }
 
+//nolint:funlen
+func TestGetPodResourceForPolicy(t *testing.T) {
+	// ensure required K8s feature gates are enabled
+	predicates.EnableOptionalKubernetesFeatureGates()
+
+	err := conf.UpdateConfigMaps([]*v1.ConfigMap{nil, {Data: map[string]string{
+		conf.CMSvcResourceAccountingFloor: `{"cpu": "250m", "memory": "256M"}`,
+	}}}, true)
+	assert.NilError(t, err, "failed to set configmap")
+	t.Cleanup(func() {
+		err = conf.UpdateConfigMaps([]*v1.ConfigMap{nil, nil}, true)
+		assert.NilError(t, err, "failed to reset configmap")
+	})
+
+	resources := func(cpu, memory string) v1.ResourceList {
+		list := v1.ResourceList{}
+		if cpu != "" {
+			list[v1.ResourceCPU] = resource.MustParse(cpu)
+		}
+		if memory != "" {
+			list[v1.ResourceMemory] = resource.MustParse(memory)
+		}
+		return list
+	}
+	always := v1.ContainerRestartPolicyAlways
+
+	tests := []struct {
+		name           string
+		policy         string
+		containers     []v1.Container
+		initContainers []v1.Container
+		podResources   *v1.ResourceRequirements
+		overhead       v1.ResourceList
+		expectedCPU    int64
+		expectedMemory int64
+	}{
+		{
+			name:   "requests only, requests policy",
+			policy: conf.ResourceAccountingRequests,
+			containers: []v1.Container{
+				{Name: "c1", Resources: v1.ResourceRequirements{Requests: resources("1", "1G")}},
+			},
+			expectedCPU:    1000,
+			expectedMemory: 1000 * 1000 * 1000,
+		},
+		{
+			name:   "requests and limits, requests policy",
+			policy: conf.ResourceAccountingRequests,
+			containers: []v1.Container{
+				{Name: "c1", Resources: v1.ResourceRequirements{Requests: resources("1", "1G"), Limits: resources("2", "4G")}},
+			},
+			expectedCPU:    1000,
+			expectedMemory: 1000 * 1000 * 1000,
+		},
+		{
+			name:   "unknown policy uses requests",
+			policy: "unknown",
+			containers: []v1.Container{
+				{Name: "c1", Resources: v1.ResourceRequirements{Requests: resources("1", "1G"), Limits: resources("2", "4G")}},
+			},
+			expectedCPU:    1000,
+			expectedMemory: 1000 * 1000 * 1000,
+		},
+		{
+			name:   "requests and limits, limits policy",
+			policy: conf.ResourceAccountingLimits,
+			containers: []v1.Container{
+				{Name: "c1", Resources: v1.ResourceRequirements{Requests: resources("1", "1G"), Limits: resources("2", "4G")}},
+			},
+			expectedCPU:    2000,
+			expectedMemory: 4 * 1000 * 1000 * 1000,
+		},
+		{
+			name:   "memory limit only, limits policy falls back to the cpu request",
+			policy: conf.ResourceAccountingLimits,
+			containers: []v1.Container{
+				{Name: "c1", Resources: v1.ResourceRequirements{Requests: resources("1", "1G"), Limits: resources("", "2G")}},
+			},
+			expectedCPU:    1000,
+			expectedMemory: 2 * 1000 * 1000 * 1000,
+		},
+		{
+			name:   "limits without requests, limits policy",
+			policy: conf.ResourceAccountingLimits,
+			containers: []v1.Container{
+				{Name: "c1", Resources: v1.ResourceRequirements{Limits: resources("3", "1G")}},
+			},
+			expectedCPU:    3000,
+			expectedMemory: 1000 * 1000 * 1000,
+		},
+		{
+			name:   "limits without requests, requests policy",
+			policy: conf.ResourceAccountingRequests,
+			containers: []v1.Container{
+				{Name: "c1", Resources: v1.ResourceRequirements{Limits: resources("3", "1G")}},
+			},
+			expectedCPU:    0,
+			expectedMemory: 0,
+		},
+		{
+			name:   "multiple containers, max policy",
+			policy: conf.ResourceAccountingMax,
+			containers: []v1.Container{
+				{Name: "c1", Resources: v1.ResourceRequirements{Requests: resources("1", "1G"), Limits: resources("2", "")}},
+				{Name: "c2", Resources: v1.ResourceRequirements{Requests: resources("500m", ""), Limits: resources("", "2G")}},
+			},
+			expectedCPU:    2500,
+			expectedMemory: 3 * 1000 * 1000 * 1000,
+		},
+		{
+			name:   "requests below the floor, floor policy",
+			policy: conf.ResourceAccountingFloor,
+			containers: []v1.Container{
+				{Name: "c1", Resources: v1.ResourceRequirements{Requests: resources("10m", "1M"), Limits: resources("4", "4G")}},
+				{Name: "c2", Resources: v1.ResourceRequirements{Requests: resources("1", "1G")}},
+			},
+			expectedCPU:    1250,
+			expectedMemory: 1256 * 1000 * 1000,
+		},
+		{
+			name:   "no requests, floor policy",
+			policy: conf.ResourceAccountingFloor,
+			containers: []v1.Container{
+				{Name: "c1"},
+			},
+			expectedCPU:    250,
+			expectedMemory: 256 * 1000 * 1000,
+		},
+		{
+			name:   "init container larger than containers, limits policy",
+			policy: conf.ResourceAccountingLimits,
+			containers: []v1.Container{
+				{Name: "c1", Resources: v1.ResourceRequirements{Requests: resources("1", "1G"), Limits: resources("2", "2G")}},
+			},
+			initContainers: []v1.Container{
+				{Name: "init", Resources: v1.ResourceRequirements{Requests: resources("1", "1G"), Limits: resources("4", "1G")}},
+			},
+			expectedCPU:    4000,
+			expectedMemory: 2 * 1000 * 1000 * 1000,
+		},
+		{
+			name:   "sidecar container, max policy",
+			policy: conf.ResourceAccountingMax,
+			containers: []v1.Container{
+				{Name: "c1", Resources: v1.ResourceRequirements{Requests: resources("1", "1G"), Limits: resources("2", "1G")}},
+			},
+			initContainers: []v1.Container{
+				{Name: "sidecar", RestartPolicy: &always, Resources: v1.ResourceRequirements{Requests: resources("100m", "100M"), Limits: resources("500m", "100M")}},
+			},
+			expectedCPU:    2500,
+			expectedMemory: 1100 * 1000 * 1000,
+		},
+		{
+			name:   "pod level resources, limits policy",
+			policy: conf.ResourceAccountingLimits,
+			containers: []v1.Container{
+				{Name: "c1", Resources: v1.ResourceRequirements{Requests: resources("1", "1G")}},
+			},
+			podResources:   &v1.ResourceRequirements{Requests: resources("2", "2G"), Limits: resources("4", "")},
+			expectedCPU:    4000,
+			expectedMemory: 2 * 1000 * 1000 * 1000,
+		},
+		{
+			name:   "pod level resources, requests policy",
+			policy: conf.ResourceAccountingRequests,
+			containers: []v1.Container{
+				{Name: "c1", Resources: v1.ResourceRequirements{Requests: resources("1", "1G")}},
+			},
+			podResources:   &v1.ResourceRequirements{Requests: resources("2", "2G"), Limits: resources("4", "")},
+			expectedCPU:    2000,
+			expectedMemory: 2 * 1000 * 1000 * 1000,
+		},
+		{
+			name:   "overhead is added, limits policy",
+			policy: conf.ResourceAccountingLimits,
+			containers: []v1.Container{
+				{Name: "c1", Resources: v1.ResourceRequirements{Requests: resources("1", "1G"), Limits: resources("2", "2G")}},
+			},
+			overhead:       resources("100m", "100M"),
+			expectedCPU:    2100,
+			expectedMemory: 2100 * 1000 * 1000,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pod := &v1.Pod{
+				ObjectMeta: apis.ObjectMeta{
+					Name: "pod-resource-policy-test",
+					UID:  "UID-00001",
+				},
+				Spec: v1.PodSpec{
+					Containers:     tt.containers,
+					InitContainers: tt.initContainers,
+					Resources:      tt.podResources,
+					Overhead:       tt.overhead,
+				},
+			}
+			res := GetPodResourceForPolicy(pod, tt.policy)
+			assert.Equal(t, res.Resources[siCommon.CPU].GetValue(), tt.expectedCPU)
+			assert.Equal(t, res.Resources[siCommon.Memory].GetValue(), tt.expectedMemory)
+			assert.Equal(t, res.Resources["pods"].GetValue(), int64(1))
+		})
+	}
+}
+
+func TestGetPodResourceForPolicyInPlaceResize(t *testing.T) {
+	pod := &v1.Pod{
+		Spec: v1.PodSpec{
+			Containers: []v1.Container{{
+				Name: "c1",
+				Resources: v1.ResourceRequirements{
+					Requests: v1.ResourceList{v1.ResourceCPU: resource.MustParse("1")},
+					Limits:   v1.ResourceList{v1.ResourceCPU: resource.MustParse("2")},
+				},
+			}},
+		},
+		Status: v1.PodStatus{
+			ContainerStatuses: []v1.ContainerStatus{{
+				Name: "c1",
+				Resources: &v1.ResourceRequirements{
+					Requests: v1.ResourceList{v1.ResourceCPU: resource.MustParse("1")},
+					Limits:   v1.ResourceList{v1.ResourceCPU: resource.MustParse("3")},
+				},
+			}},
+		},
+	}
+	// the status limits are larger than the spec limits while the resize is in progress
+	assert.Equal(t, GetPodResourceForPolicy(pod, conf.ResourceAccountingLimits).Resources[siCommon.CPU].GetValue(), int64(3000))
+	assert.Equal(t, GetPodResourceForPolicy(pod, conf.ResourceAccountingRequests).Resources[siCommon.CPU].GetValue(), int64(1000))
+
+	// an infeasible resize uses the status only
+	pod.Status.Resize = v1.PodResizeStatusInfeasible
+	pod.Status.ContainerStatuses[0].Resources.Limits[v1.ResourceCPU] = resource.MustParse("1500m")
+	assert.Equal(t, GetPodResourceForPolicy(pod, conf.ResourceAccountingLimits).Resources[siCommon.CPU].GetValue(), int64(1500))
+}
+
 func TestBestEffortPod(t *testing.T) {
 	resources := make(map[v1.ResourceName]resource.Quantity)
 	containers := make([]v1.Container, 0)
@@ -657,6 +895,157 @@ func TestNodeResource(t *testing.T) {
 	assert.Equal(t, result.Resources[siCommon.CPU].GetValue(), int64(14500))
 }
 
+func TestGetNodeCapacity(t *testing.T) {
+	err := conf.UpdateConfigMaps([]*v1.ConfigMap{nil, {Data: map[string]string{
+		conf.CMSvcNodeCapacityAdjustmentPrefix + "dev": `{"nodeSelector": "pool=dev", "reserved": {"cpu": "500m", "memory": "2G"}, "overcommit": {"cpu": 0.5}}`,
+	}}}, true)
+	assert.NilError(t, err, "failed to set configmap")
+	t.Cleanup(func() {
+		err = conf.UpdateConfigMaps([]*v1.ConfigMap{nil, nil}, true)
+		assert.NilError(t, err, "failed to reset configmap")
+	})
+
+	node := &v1.Node{
+		Status: v1.NodeStatus{
+			Allocatable: v1.ResourceList{
+				v1.ResourceCPU:    resource.MustParse("4"),
+				v1.ResourceMemory: resource.MustParse("1G"),
+				v1.ResourcePods:   resource.MustParse("110"),
+			},
+		},
+	}
+	// no matching adjustment
+	capacity := GetNodeCapacity(node)
+	assert.Equal(t, capacity.Resources[siCommon.CPU].GetValue(), int64(4000))
+	assert.Equal(t, capacity.Resources[siCommon.Memory].GetValue(), int64(1000*1000*1000))
+
+	// reservation first, then the overcommit ratio, a reservation never makes the capacity negative
+	node.Labels = map[string]string{"pool": "dev"}
+	capacity = GetNodeCapacity(node)
+	assert.Equal(t, capacity.Resources[siCommon.CPU].GetValue(), int64(1750))
+	assert.Equal(t, capacity.Resources[siCommon.Memory].GetValue(), int64(0))
+	assert.Equal(t, capacity.Resources["pods"].GetValue(), int64(110))
+}
+
+func TestResourceMappings(t *testing.T) {
+	err := conf.UpdateConfigMaps([]*v1.ConfigMap{nil, {Data: map[string]string{
+		conf.CMSvcResourceMappings: `{
+			"nvidia.com/gpu": {"name": "accelerator"},
+			"amd.com/gpu": {"name": "accelerator", "scale": 2},
+			"hugepages-*": {"drop": true},
+			"ephemeral-storage": {"drop": true},
+			"memory": {"scale": 0.5}
+		}`,
+	}}}, true)
+	assert.NilError(t, err, "failed to set configmap")
+	t.Cleanup(func() {
+		err = conf.UpdateConfigMaps([]*v1.ConfigMap{nil, nil}, true)
+		assert.NilError(t, err, "failed to reset configmap")
+	})
+
+	tests := []struct {
+		name     string
+		convert  func() *si.Resource
+		expected map[string]int64
+	}{
+		{
+			name: "pod",
+			convert: func() *si.Resource {
+				return GetPodResource(&v1.Pod{
+					Spec: v1.PodSpec{
+						Containers: []v1.Container{
+							{Name: "c1", Resources: v1.ResourceRequirements{Requests: v1.ResourceList{
+								v1.ResourceCPU:              resource.MustParse("1"),
+								v1.ResourceMemory:           resource.MustParse("2G"),
+								"nvidia.com/gpu":            resource.MustParse("1"),
+								"hugepages-2Mi":             resource.MustParse("100Mi"),
+								v1.ResourceEphemeralStorage: resource.MustParse("1G"),
+							}}},
+							{Name: "c2", Resources: v1.ResourceRequirements{Requests: v1.ResourceList{
+								"amd.com/gpu": resource.MustParse("2"),
+							}}},
+						},
+					},
+				})
+			},
+			expected: map[string]int64{siCommon.CPU: 1000, siCommon.Memory: 1000 * 1000 * 1000, "accelerator": 5, "pods": 1},
+		},
+		{
+			name: "pod with both devices in one container",
+			convert: func() *si.Resource {
+				return GetPodResource(&v1.Pod{
+					Spec: v1.PodSpec{
+						Containers: []v1.Container{
+							{Name: "c1", Resources: v1.ResourceRequirements{Requests: v1.ResourceList{
+								"nvidia.com/gpu": resource.MustParse("1"),
+								"amd.com/gpu":    resource.MustParse("1"),
+							}}},
+						},
+					},
+				})
+			},
+			expected: map[string]int64{"accelerator": 3, "pods": 1},
+		},
+		{
+			name: "node",
+			convert: func() *si.Resource {
+				return GetNodeResource(&v1.NodeStatus{Allocatable: v1.ResourceList{
+					v1.ResourceCPU:    resource.MustParse("8"),
+					v1.ResourceMemory: resource.MustParse("16G"),
+					v1.ResourcePods:   resource.MustParse("110"),
+					"nvidia.com/gpu":  resource.MustParse("4"),
+					"hugepages-1Gi":   resource.MustParse("0"),
+				}})
+			},
+			expected: map[string]int64{siCommon.CPU: 8000, siCommon.Memory: 8 * 1000 * 1000 * 1000, "pods": 110, "accelerator": 4},
+		},
+		{
+			name: "namespace quota",
+			convert: func() *si.Resource {
+				return GetResource(map[string]string{"cpu": "2", "memory": "4G", "nvidia.com/gpu": "2", "amd.com/gpu": "1"})
+			},
+			expected: map[string]int64{siCommon.CPU: 2000, siCommon.Memory: 2 * 1000 * 1000 * 1000, "accelerator": 4},
+		},
+		{
+			name: "legacy namespace quota",
+			convert: func() *si.Resource {
+				return ParseResource("1", "2G")
+			},
+			expected: map[string]int64{siCommon.CPU: 1000, siCommon.Memory: 1000 * 1000 * 1000},
+		},
+		{
+			name: "task group",
+			convert: func() *si.Resource {
+				return GetTGResource(map[string]resource.Quantity{
+					"memory":        resource.MustParse("1G"),
+					"amd.com/gpu":   resource.MustParse("1"),
+					"hugepages-2Mi": resource.MustParse("2Mi"),
+				}, 3)
+			},
+			expected: map[string]int64{siCommon.Memory: 1500 * 1000 * 1000, "accelerator": 6, "pods": 3},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			res := tt.convert()
+			actual := make(map[string]int64, len(res.Resources))
+			for name, quantity := range res.Resources {
+				actual[name] = quantity.GetValue()
+			}
+			assert.DeepEqual(t, actual, tt.expected)
+		})
+	}
+
+	name, ok := GetSIResourceName("nvidia.com/gpu")
+	assert.Assert(t, ok)
+	assert.Equal(t, name, "accelerator")
+	_, ok = GetSIResourceName("hugepages-2Mi")
+	assert.Assert(t, !ok, "dropped resource should not have a name")
+	name, ok = GetSIResourceName(v1.ResourceCPU)
+	assert.Assert(t, ok)
+	assert.Equal(t, name, siCommon.CPU)
+}
+
 func TestIsZero(t *testing.T) {
 	r := NewResourceBuilder().
 		AddResource(siCommon.Memory, 1).
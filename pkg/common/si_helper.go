@@ -91,7 +91,7 @@ func CreateAllocationRequestForTask(appID, taskID string, resource *si.Resource,
 	}
 }
 
-func CreateAllocationForTask(appID, taskID, nodeID string, resource *si.Resource, placeholder bool, taskGroupName string, pod *v1.Pod, originator bool, preemptionPolicy *si.PreemptionPolicy) *si.AllocationRequest {
+func CreateAllocationForTask(appID, taskID, nodeID, partition string, resource *si.Resource, placeholder bool, taskGroupName string, pod *v1.Pod, originator bool, preemptionPolicy *si.PreemptionPolicy) *si.AllocationRequest {
 	allocation := si.Allocation{
 		AllocationKey:    taskID,
 		AllocationTags:   CreateTagsForTask(pod),
@@ -99,6 +99,7 @@ func CreateAllocationForTask(appID, taskID, nodeID string, resource *si.Resource
 		Priority:         CreatePriorityForTask(pod),
 		NodeID:           nodeID,
 		ApplicationID:    appID,
+		PartitionName:    partition,
 		TaskGroupName:    taskGroupName,
 		Placeholder:      placeholder,
 		Originator:       originator,
@@ -114,7 +115,7 @@ func CreateAllocationForTask(appID, taskID, nodeID string, resource *si.Resource
 	}
 }
 
-func CreateAllocationForForeignPod(pod *v1.Pod) *si.AllocationRequest {
+func CreateAllocationForForeignPod(pod *v1.Pod, partition, resourcePolicy string) *si.AllocationRequest {
 	podType := common.AllocTypeDefault
 	for _, ref := range pod.OwnerReferences {
 		if ref.Kind == constants.NodeKind {
@@ -129,9 +130,10 @@ func CreateAllocationForForeignPod(pod *v1.Pod) *si.AllocationRequest {
 	allocation := si.Allocation{
 		AllocationTags:   tags,
 		AllocationKey:    string(pod.UID),
-		ResourcePerAlloc: GetPodResource(pod),
+		ResourcePerAlloc: GetPodResourceForPolicy(pod, resourcePolicy),
 		Priority:         CreatePriorityForTask(pod),
 		NodeID:           pod.Spec.NodeName,
+		PartitionName:    partition,
 	}
 
 	return &si.AllocationRequest{
@@ -187,10 +189,10 @@ func CreateReleaseRequestForForeignPod(uid, partition string) *si.AllocationRequ
 }
 
 // CreateUpdateRequestForUpdatedNode builds a NodeRequest for capacity updates
-func CreateUpdateRequestForUpdatedNode(nodeID string, capacity *si.Resource) *si.NodeRequest {
+func CreateUpdateRequestForUpdatedNode(nodeID, partition string, capacity *si.Resource) *si.NodeRequest {
 	nodeInfo := &si.NodeInfo{
 		NodeID:              nodeID,
-		Attributes:          map[string]string{},
+		Attributes:          map[string]string{common.NodePartition: partition},
 		SchedulableResource: capacity,
 		Action:              si.NodeInfo_UPDATE,
 	}
@@ -205,11 +207,12 @@ func CreateUpdateRequestForUpdatedNode(nodeID string, capacity *si.Resource) *si
 
 // CreateUpdateRequestForDeleteOrRestoreNode builds a NodeRequest for Node actions like drain,
 // decommissioning & restore
-func CreateUpdateRequestForDeleteOrRestoreNode(nodeID string, action si.NodeInfo_ActionFromRM) *si.NodeRequest {
+func CreateUpdateRequestForDeleteOrRestoreNode(nodeID, partition string, action si.NodeInfo_ActionFromRM) *si.NodeRequest {
 	deletedNodes := make([]*si.NodeInfo, 1)
 	nodeInfo := &si.NodeInfo{
-		NodeID: nodeID,
-		Action: action,
+		NodeID:     nodeID,
+		Action:     action,
+		Attributes: map[string]string{common.NodePartition: partition},
 	}
 
 	deletedNodes[0] = nodeInfo
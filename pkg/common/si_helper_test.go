@@ -26,6 +26,7 @@ import (
 	"k8s.io/apimachinery/pkg/api/resource"
 	apis "k8s.io/apimachinery/pkg/apis/meta/v1"
 
+	"github.com/apache/yunikorn-k8shim/pkg/conf"
 	"github.com/apache/yunikorn-scheduler-interface/lib/go/common"
 	"github.com/apache/yunikorn-scheduler-interface/lib/go/si"
 )
@@ -211,29 +212,31 @@ func TestCreateTagsForTask(t *testing.T) {
 
 func TestCreateUpdateRequestForUpdatedNode(t *testing.T) {
 	capacity := NewResourceBuilder().AddResource(common.Memory, 200).AddResource(common.CPU, 2).Build()
-	request := CreateUpdateRequestForUpdatedNode(nodeID, capacity)
+	request := CreateUpdateRequestForUpdatedNode(nodeID, "partition", capacity)
 	assert.Equal(t, len(request.Nodes), 1)
 	assert.Equal(t, request.Nodes[0].NodeID, nodeID)
 	assert.Equal(t, request.Nodes[0].SchedulableResource, capacity)
-	assert.Equal(t, len(request.Nodes[0].Attributes), 0)
+	assert.Equal(t, len(request.Nodes[0].Attributes), 1)
+	assert.Equal(t, request.Nodes[0].Attributes[common.NodePartition], "partition")
 }
 
 func TestCreateUpdateRequestForDeleteNode(t *testing.T) {
 	action := si.NodeInfo_DECOMISSION
-	// asserting against this empty map ensures core doesn't have any issues
-	request := CreateUpdateRequestForDeleteOrRestoreNode(nodeID, action)
+	request := CreateUpdateRequestForDeleteOrRestoreNode(nodeID, "partition", action)
 	assert.Equal(t, len(request.Nodes), 1)
 	assert.Equal(t, request.Nodes[0].NodeID, nodeID)
 	assert.Equal(t, request.Nodes[0].Action, action)
+	// the core finds the node using the partition attribute
+	assert.Equal(t, request.Nodes[0].Attributes[common.NodePartition], "partition")
 
 	action1 := si.NodeInfo_DRAIN_NODE
-	request1 := CreateUpdateRequestForDeleteOrRestoreNode(nodeID, action1)
+	request1 := CreateUpdateRequestForDeleteOrRestoreNode(nodeID, "partition", action1)
 	assert.Equal(t, len(request1.Nodes), 1)
 	assert.Equal(t, request1.Nodes[0].NodeID, nodeID)
 	assert.Equal(t, request1.Nodes[0].Action, action1)
 
 	action2 := si.NodeInfo_DRAIN_TO_SCHEDULABLE
-	request2 := CreateUpdateRequestForDeleteOrRestoreNode(nodeID, action2)
+	request2 := CreateUpdateRequestForDeleteOrRestoreNode(nodeID, "partition", action2)
 	assert.Equal(t, len(request2.Nodes), 1)
 	assert.Equal(t, request2.Nodes[0].NodeID, nodeID)
 	assert.Equal(t, request2.Nodes[0].Action, action2)
@@ -340,7 +343,7 @@ func TestCreateAllocationForTask(t *testing.T) {
 		AllowPreemptOther: true,
 	}
 
-	updateRequest := CreateAllocationForTask("appId1", "taskId1", "node1", res, false, "", pod, false, preemptionPolicy)
+	updateRequest := CreateAllocationForTask("appId1", "taskId1", "node1", "default", res, false, "", pod, false, preemptionPolicy)
 	allocs := updateRequest.Allocations
 	assert.Equal(t, len(allocs), 1)
 	alloc := allocs[0]
@@ -348,6 +351,7 @@ func TestCreateAllocationForTask(t *testing.T) {
 		t.Fatal("alloc cannot be nil")
 	}
 	assert.Equal(t, alloc.Priority, int32(0))
+	assert.Equal(t, alloc.PartitionName, "default")
 	assert.Assert(t, alloc.PreemptionPolicy != nil)
 	assert.Equal(t, alloc.PreemptionPolicy.AllowPreemptSelf, false)
 	assert.Equal(t, alloc.PreemptionPolicy.AllowPreemptOther, true)
@@ -376,7 +380,7 @@ func TestCreateAllocationForTask(t *testing.T) {
 		AllowPreemptOther: false,
 	}
 
-	updateRequest1 := CreateAllocationForTask("appId1", "taskId1", "node1", res, false, "", pod1, false, preemptionPolicy1)
+	updateRequest1 := CreateAllocationForTask("appId1", "taskId1", "node1", "default", res, false, "", pod1, false, preemptionPolicy1)
 	allocs1 := updateRequest1.Allocations
 	assert.Equal(t, len(allocs1), 1)
 	alloc1 := allocs1[0]
@@ -450,12 +454,13 @@ func TestCreateAllocationForForeignPod(t *testing.T) {
 		},
 	}
 
-	allocReq := CreateAllocationForForeignPod(pod)
+	allocReq := CreateAllocationForForeignPod(pod, "partition", conf.ResourceAccountingRequests)
 	assert.Equal(t, 1, len(allocReq.Allocations))
 	assert.Equal(t, "mycluster", allocReq.RmID)
 	assert.Assert(t, allocReq.Releases == nil)
 	alloc := allocReq.Allocations[0]
 	assert.Equal(t, nodeID, alloc.NodeID)
+	assert.Equal(t, "partition", alloc.PartitionName)
 	assert.Equal(t, "UID-00001", alloc.AllocationKey)
 	assert.Equal(t, int32(0), alloc.Priority)
 	res := alloc.ResourcePerAlloc
@@ -477,7 +482,7 @@ func TestCreateAllocationForForeignPod(t *testing.T) {
 			Kind: "Node",
 		},
 	}
-	allocReq = CreateAllocationForForeignPod(pod)
+	allocReq = CreateAllocationForForeignPod(pod, "partition", conf.ResourceAccountingRequests)
 	assert.Equal(t, 5, len(alloc.AllocationTags))
 	alloc = allocReq.Allocations[0]
 	assert.Equal(t, common.AllocTypeStatic, alloc.AllocationTags[common.Foreign])
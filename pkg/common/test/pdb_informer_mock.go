@@ -0,0 +1,58 @@
+/*
+ Licensed to the Apache Software Foundation (ASF) under one
+ or more contributor license agreements.  See the NOTICE file
+ distributed with this work for additional information
+ regarding copyright ownership.  The ASF licenses this file
+ to you under the Apache License, Version 2.0 (the
+ "License"); you may not use this file except in compliance
+ with the License.  You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package test
+
+import (
+	policyv1 "k8s.io/api/policy/v1"
+	listersV1 "k8s.io/client-go/listers/policy/v1"
+	"k8s.io/client-go/tools/cache"
+)
+
+// MockPodDisruptionBudgetInformer uses the real lister on top of a plain indexer so the PDB matching logic of the
+// lister is used in the tests
+type MockPodDisruptionBudgetInformer struct {
+	indexer  cache.Indexer
+	lister   listersV1.PodDisruptionBudgetLister
+	informer cache.SharedIndexInformer
+}
+
+func NewMockPodDisruptionBudgetInformer() *MockPodDisruptionBudgetInformer {
+	indexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{cache.NamespaceIndex: cache.MetaNamespaceIndexFunc})
+	return &MockPodDisruptionBudgetInformer{
+		indexer:  indexer,
+		lister:   listersV1.NewPodDisruptionBudgetLister(indexer),
+		informer: &SharedInformerMock{},
+	}
+}
+
+func (m *MockPodDisruptionBudgetInformer) Informer() cache.SharedIndexInformer {
+	return m.informer
+}
+
+func (m *MockPodDisruptionBudgetInformer) Lister() listersV1.PodDisruptionBudgetLister {
+	return m.lister
+}
+
+func (m *MockPodDisruptionBudgetInformer) Add(pdb *policyv1.PodDisruptionBudget) {
+	_ = m.indexer.Add(pdb)
+}
+
+func (m *MockPodDisruptionBudgetInformer) Delete(pdb *policyv1.PodDisruptionBudget) {
+	_ = m.indexer.Delete(pdb)
+}
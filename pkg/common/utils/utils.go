@@ -23,6 +23,7 @@ import (
 	"errors"
 	"fmt"
 	"reflect"
+	"slices"
 	"strconv"
 	"strings"
 	"time"
@@ -31,6 +32,7 @@ import (
 
 	v1 "k8s.io/api/core/v1"
 	schedulingv1 "k8s.io/api/scheduling/v1"
+	policylisters "k8s.io/client-go/listers/policy/v1"
 	podv1 "k8s.io/kubernetes/pkg/api/v1/pod"
 
 	"github.com/apache/yunikorn-k8shim/pkg/common"
@@ -243,6 +245,70 @@ func PodUnderCondition(pod *v1.Pod, condition *v1.PodCondition) bool {
 	return current != nil && current.Status == condition.Status && current.Reason == condition.Reason
 }
 
+// nodeConditionTaintPrefix is the prefix of the taints set by the node lifecycle controller for node conditions
+const nodeConditionTaintPrefix = "node.kubernetes.io/"
+
+// GetNodeUnschedulableReason returns why the core should not schedule on the node: the node is cordoned, not ready
+// or has a NoSchedule or NoExecute node condition taint or a taint with one of the drain keys. An empty string is
+// returned if the node is schedulable.
+func GetNodeUnschedulableReason(node *v1.Node, drainTaintKeys []string) string {
+	if node.Spec.Unschedulable {
+		return "node is cordoned"
+	}
+	for _, condition := range node.Status.Conditions {
+		if condition.Type == v1.NodeReady && condition.Status != v1.ConditionTrue {
+			return fmt.Sprintf("node is not ready: %s", condition.Reason)
+		}
+	}
+	for _, taint := range node.Spec.Taints {
+		if taint.Effect != v1.TaintEffectNoSchedule && taint.Effect != v1.TaintEffectNoExecute {
+			continue
+		}
+		if !strings.HasPrefix(taint.Key, nodeConditionTaintPrefix) && !slices.Contains(drainTaintKeys, taint.Key) {
+			continue
+		}
+		return fmt.Sprintf("node has taint %s", taint.ToString())
+	}
+	return ""
+}
+
+// GetPodDisruptionBudgetViolations returns for each pod, evicted in the given order, if the eviction violates a
+// PodDisruptionBudget: a PDB of the pod has no disruptions left after the pods before it are evicted. A pod already
+// counted as disrupted by a PDB does not use up one of the allowed disruptions. Nil pods never violate a PDB.
+func GetPodDisruptionBudgetViolations(pods []*v1.Pod, lister policylisters.PodDisruptionBudgetLister) []bool {
+	violations := make([]bool, len(pods))
+	if lister == nil {
+		return violations
+	}
+	allowed := make(map[string]int32)
+	for i, pod := range pods {
+		if pod == nil {
+			continue
+		}
+		// an error is returned if no PDB covers the pod
+		pdbs, err := lister.GetPodPodDisruptionBudgets(pod)
+		if err != nil {
+			continue
+		}
+		for _, pdb := range pdbs {
+			if _, ok := pdb.Status.DisruptedPods[pod.Name]; ok {
+				continue
+			}
+			key := pdb.Namespace + "/" + pdb.Name
+			remaining, ok := allowed[key]
+			if !ok {
+				remaining = pdb.Status.DisruptionsAllowed
+			}
+			remaining--
+			allowed[key] = remaining
+			if remaining < 0 {
+				violations[i] = true
+			}
+		}
+	}
+	return violations
+}
+
 // get namespace guaranteed resource from namespace annotation
 func GetNamespaceGuaranteedFromAnnotation(namespaceObj *v1.Namespace) *si.Resource {
 	// retrieve guaranteed resource info from annotations
@@ -281,6 +347,62 @@ func GetNamespaceMaxAppsFromAnnotation(namespaceObj *v1.Namespace) string {
 	return ""
 }
 
+// get namespace default max runtime from namespace annotation, returns 0 if not set or invalid
+func GetNamespaceMaxRuntimeFromAnnotation(namespaceObj *v1.Namespace) time.Duration {
+	return getNamespaceDurationFromAnnotation(namespaceObj, constants.NamespaceMaxRuntime)
+}
+
+// get namespace default task pending timeout from namespace annotation, returns 0 if not set or invalid
+func GetNamespaceTaskPendingTimeoutFromAnnotation(namespaceObj *v1.Namespace) time.Duration {
+	return getNamespaceDurationFromAnnotation(namespaceObj, constants.NamespaceTaskPendingTimeout)
+}
+
+// get namespace resource accounting policy from namespace annotation, returns an empty string if not set or invalid
+func GetNamespaceResourceAccountingPolicyFromAnnotation(namespaceObj *v1.Namespace) string {
+	policy := GetNameSpaceAnnotationValue(namespaceObj, constants.NamespaceResourceAccountingPolicy)
+	if policy != "" && !conf.IsValidResourceAccountingPolicy(policy) {
+		log.Log(log.ShimUtils).Warn("Unknown resource accounting policy in namespace annotation",
+			zap.String("namespace", namespaceObj.Name),
+			zap.String("policy", policy))
+		return ""
+	}
+	return policy
+}
+
+func getNamespaceDurationFromAnnotation(namespaceObj *v1.Namespace, annotationKey string) time.Duration {
+	if value := GetNameSpaceAnnotationValue(namespaceObj, annotationKey); value != "" {
+		duration, err := ParseDurationOrSeconds(value)
+		if err != nil {
+			log.Log(log.ShimUtils).Warn("Unable to process namespace duration annotation",
+				zap.String("namespace", namespaceObj.Name),
+				zap.String("annotation", annotationKey),
+				zap.String("value", value),
+				zap.Error(err))
+			return 0
+		}
+		return duration
+	}
+	return 0
+}
+
+// ParseDurationOrSeconds parses a duration string (e.g. 1h30m) or a plain number of seconds.
+// Negative values are rejected.
+func ParseDurationOrSeconds(value string) (time.Duration, error) {
+	var duration time.Duration
+	if seconds, err := strconv.ParseInt(value, 10, 64); err == nil {
+		duration = time.Duration(seconds) * time.Second
+	} else {
+		duration, err = time.ParseDuration(value)
+		if err != nil {
+			return 0, err
+		}
+	}
+	if duration < 0 {
+		return 0, fmt.Errorf("negative duration is not allowed: %s", value)
+	}
+	return duration, nil
+}
+
 func GetNamespaceQuotaFromAnnotation(namespaceObj *v1.Namespace) *si.Resource {
 	// retrieve resource quota info from annotations
 	cpuQuota := GetNameSpaceAnnotationValue(namespaceObj, constants.CPUQuota)
@@ -29,12 +29,14 @@ import (
 
 	"gotest.tools/v3/assert"
 	v1 "k8s.io/api/core/v1"
+	policyv1 "k8s.io/api/policy/v1"
 	schedulingv1 "k8s.io/api/scheduling/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 
 	"github.com/apache/yunikorn-core/pkg/common/configs"
 	"github.com/apache/yunikorn-k8shim/pkg/common"
 	"github.com/apache/yunikorn-k8shim/pkg/common/constants"
+	"github.com/apache/yunikorn-k8shim/pkg/common/test"
 	"github.com/apache/yunikorn-k8shim/pkg/conf"
 	siCommon "github.com/apache/yunikorn-scheduler-interface/lib/go/common"
 	"github.com/apache/yunikorn-scheduler-interface/lib/go/si"
@@ -378,6 +380,87 @@ func TestGetNamespaceMaxAppsFromAnnotation(t *testing.T) {
 	}
 }
 
+func TestGetNamespaceMaxRuntimeFromAnnotation(t *testing.T) {
+	testCases := []struct {
+		name       string
+		annotation string
+		expected   time.Duration
+	}{
+		{"not set", "", 0},
+		{"seconds", "3600", time.Hour},
+		{"duration", "1h30m", 90 * time.Minute},
+		{"negative", "-5", 0},
+		{"invalid", "error", 0},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			namespace := &v1.Namespace{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: "test",
+				},
+			}
+			if tc.annotation != "" {
+				namespace.Annotations = map[string]string{constants.NamespaceMaxRuntime: tc.annotation}
+			}
+			assert.Equal(t, GetNamespaceMaxRuntimeFromAnnotation(namespace), tc.expected)
+		})
+	}
+}
+
+func TestGetNamespaceTaskPendingTimeoutFromAnnotation(t *testing.T) {
+	namespace := &v1.Namespace{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: "test",
+		},
+	}
+	assert.Equal(t, GetNamespaceTaskPendingTimeoutFromAnnotation(namespace), time.Duration(0))
+	namespace.Annotations = map[string]string{constants.NamespaceTaskPendingTimeout: "10m"}
+	assert.Equal(t, GetNamespaceTaskPendingTimeoutFromAnnotation(namespace), 10*time.Minute)
+	namespace.Annotations = map[string]string{constants.NamespaceTaskPendingTimeout: "invalid"}
+	assert.Equal(t, GetNamespaceTaskPendingTimeoutFromAnnotation(namespace), time.Duration(0))
+}
+
+func TestGetNamespaceResourceAccountingPolicyFromAnnotation(t *testing.T) {
+	namespace := &v1.Namespace{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: "test",
+		},
+	}
+	assert.Equal(t, GetNamespaceResourceAccountingPolicyFromAnnotation(namespace), "")
+	namespace.Annotations = map[string]string{constants.NamespaceResourceAccountingPolicy: conf.ResourceAccountingLimits}
+	assert.Equal(t, GetNamespaceResourceAccountingPolicyFromAnnotation(namespace), conf.ResourceAccountingLimits)
+	namespace.Annotations = map[string]string{constants.NamespaceResourceAccountingPolicy: "invalid"}
+	assert.Equal(t, GetNamespaceResourceAccountingPolicyFromAnnotation(namespace), "")
+}
+
+func TestParseDurationOrSeconds(t *testing.T) {
+	testCases := []struct {
+		value    string
+		expected time.Duration
+		isErr    bool
+	}{
+		{"0", 0, false},
+		{"30", 30 * time.Second, false},
+		{"2m", 2 * time.Minute, false},
+		{"1h30m", 90 * time.Minute, false},
+		{"-1", 0, true},
+		{"-1h", 0, true},
+		{"", 0, true},
+		{"abc", 0, true},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.value, func(t *testing.T) {
+			duration, err := ParseDurationOrSeconds(tc.value)
+			if tc.isErr {
+				assert.Assert(t, err != nil, "expected error for value %s", tc.value)
+				return
+			}
+			assert.NilError(t, err)
+			assert.Equal(t, duration, tc.expected)
+		})
+	}
+}
+
 func TestGetNamespaceQuotaFromAnnotationUsingNewAndOldAnnotations(t *testing.T) {
 	testCases := []struct {
 		namespace        *v1.Namespace
@@ -1383,3 +1466,68 @@ func TestWaitForCondition(t *testing.T) {
 		}
 	}
 }
+
+func TestGetNodeUnschedulableReason(t *testing.T) {
+	node := &v1.Node{
+		Status: v1.NodeStatus{
+			Conditions: []v1.NodeCondition{{Type: v1.NodeReady, Status: v1.ConditionTrue}},
+		},
+	}
+	assert.Equal(t, GetNodeUnschedulableReason(node, nil), "")
+
+	cordoned := node.DeepCopy()
+	cordoned.Spec.Unschedulable = true
+	assert.Equal(t, GetNodeUnschedulableReason(cordoned, nil), "node is cordoned")
+
+	notReady := node.DeepCopy()
+	notReady.Status.Conditions[0].Status = v1.ConditionUnknown
+	notReady.Status.Conditions[0].Reason = "NodeStatusUnknown"
+	assert.Equal(t, GetNodeUnschedulableReason(notReady, nil), "node is not ready: NodeStatusUnknown")
+
+	tainted := node.DeepCopy()
+	tainted.Spec.Taints = []v1.Taint{
+		{Key: "prefer", Effect: v1.TaintEffectPreferNoSchedule},
+		{Key: "node-role.kubernetes.io/control-plane", Effect: v1.TaintEffectNoSchedule},
+		{Key: "maintenance", Value: "true", Effect: v1.TaintEffectNoExecute},
+	}
+	assert.Equal(t, GetNodeUnschedulableReason(tainted, nil), "", "PreferNoSchedule and other taints should not be used")
+	assert.Equal(t, GetNodeUnschedulableReason(tainted, []string{"maintenance"}), "node has taint maintenance=true:NoExecute")
+	tainted.Spec.Taints = append(tainted.Spec.Taints, v1.Taint{Key: v1.TaintNodeMemoryPressure, Effect: v1.TaintEffectNoSchedule})
+	assert.Equal(t, GetNodeUnschedulableReason(tainted, nil), "node has taint node.kubernetes.io/memory-pressure:NoSchedule")
+}
+
+func TestGetPodDisruptionBudgetViolations(t *testing.T) {
+	newPod := func(name, namespace, app string) *v1.Pod {
+		return &v1.Pod{ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: namespace, Labels: map[string]string{"app": app}}}
+	}
+	newPDB := func(name, app string, allowed int32, disrupted ...string) *policyv1.PodDisruptionBudget {
+		pdb := &policyv1.PodDisruptionBudget{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default"},
+			Spec: policyv1.PodDisruptionBudgetSpec{
+				Selector: &metav1.LabelSelector{MatchLabels: map[string]string{"app": app}},
+			},
+			Status: policyv1.PodDisruptionBudgetStatus{DisruptionsAllowed: allowed, DisruptedPods: map[string]metav1.Time{}},
+		}
+		for _, name := range disrupted {
+			pdb.Status.DisruptedPods[name] = metav1.Now()
+		}
+		return pdb
+	}
+
+	informer := test.NewMockPodDisruptionBudgetInformer()
+	informer.Add(newPDB("pdb-web", "web", 1))
+	informer.Add(newPDB("pdb-db", "db", 0, "db-1"))
+
+	pods := []*v1.Pod{
+		newPod("web-1", "default", "web"),
+		nil,
+		newPod("db-1", "default", "db"),
+		newPod("web-2", "default", "web"),
+		newPod("batch-1", "default", "batch"),
+		newPod("web-3", "other", "web"),
+		newPod("db-2", "default", "db"),
+	}
+	assert.DeepEqual(t, GetPodDisruptionBudgetViolations(pods, informer.Lister()),
+		[]bool{false, false, false, true, false, false, true})
+	assert.DeepEqual(t, GetPodDisruptionBudgetViolations(pods[:1], nil), []bool{false})
+}
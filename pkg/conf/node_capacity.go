@@ -0,0 +1,133 @@
+/*
+ Licensed to the Apache Software Foundation (ASF) under one
+ or more contributor license agreements.  See the NOTICE file
+ distributed with this work for additional information
+ regarding copyright ownership.  The ASF licenses this file
+ to you under the Apache License, Version 2.0 (the
+ "License"); you may not use this file except in compliance
+ with the License.  You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package conf
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"go.uber.org/zap"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/util/yaml"
+
+	"github.com/apache/yunikorn-k8shim/pkg/log"
+)
+
+// NodeCapacityAdjustment changes the capacity of the nodes matching the node selector before it is sent to the core.
+// The reservation is subtracted from the node allocatable first, the result is then multiplied by the overcommit
+// ratio of the resource. The predicates and the kubelet check pods against the node allocatable: an adjustment can
+// only lower the capacity, a ratio above 1 is rejected.
+type NodeCapacityAdjustment struct {
+	NodeSelector string             `json:"nodeSelector"`
+	Reserved     v1.ResourceList    `json:"reserved,omitempty"`
+	Overcommit   map[string]float64 `json:"overcommit,omitempty"`
+}
+
+// GetNodeCapacityAdjustment returns a copy of the capacity adjustment for a node with the given labels: the first
+// adjustment, in name order, with a node selector that matches the labels. Nil is returned if none matches.
+func (conf *SchedulerConf) GetNodeCapacityAdjustment(nodeLabels map[string]string) *NodeCapacityAdjustment {
+	conf.RLock()
+	defer conf.RUnlock()
+	selectors := make(map[string]string, len(conf.NodeCapacityAdjustments))
+	for name, adjustment := range conf.NodeCapacityAdjustments {
+		selectors[name] = adjustment.NodeSelector
+	}
+	if name := matchLabelSelector(selectors, nodeLabels); name != "" {
+		return conf.NodeCapacityAdjustments[name].DeepCopy()
+	}
+	return nil
+}
+
+func (adj *NodeCapacityAdjustment) DeepCopy() *NodeCapacityAdjustment {
+	if adj == nil {
+		return nil
+	}
+	result := &NodeCapacityAdjustment{
+		NodeSelector: adj.NodeSelector,
+		Reserved:     adj.Reserved.DeepCopy(),
+	}
+	if adj.Overcommit != nil {
+		result.Overcommit = make(map[string]float64, len(adj.Overcommit))
+		for k, v := range adj.Overcommit {
+			result.Overcommit[k] = v
+		}
+	}
+	return result
+}
+
+func cloneNodeCapacityAdjustmentMap(src map[string]*NodeCapacityAdjustment) map[string]*NodeCapacityAdjustment {
+	if src == nil {
+		return nil
+	}
+	result := make(map[string]*NodeCapacityAdjustment, len(src))
+	for k, v := range src {
+		result[k] = v.DeepCopy()
+	}
+	return result
+}
+
+// nodeCapacityAdjustmentMapVar collects all adjustments with a key starting with the given prefix into a map keyed by the remainder of the key
+func (cp *configParser) nodeCapacityAdjustmentMapVar(p *map[string]*NodeCapacityAdjustment, prefix string) {
+	var result map[string]*NodeCapacityAdjustment
+	for name, newValue := range cp.config {
+		key, ok := strings.CutPrefix(name, prefix)
+		if !ok || key == "" {
+			continue
+		}
+		adjustment, err := parseNodeCapacityAdjustment(newValue)
+		if err != nil {
+			log.Log(log.ShimConfig).Error("Unable to parse configmap entry", zap.String("key", name), zap.Error(err))
+			cp.errors = append(cp.errors, fmt.Errorf("%s: %w", name, err))
+			continue
+		}
+		if result == nil {
+			result = make(map[string]*NodeCapacityAdjustment)
+		}
+		result[key] = adjustment
+	}
+	*p = result
+}
+
+// parseNodeCapacityAdjustment decodes a YAML or JSON capacity adjustment and validates it
+func parseNodeCapacityAdjustment(value string) (*NodeCapacityAdjustment, error) {
+	adjustment := &NodeCapacityAdjustment{}
+	if err := yaml.NewYAMLOrJSONDecoder(strings.NewReader(value), len(value)).Decode(adjustment); err != nil {
+		return nil, err
+	}
+	var errs []error
+	if _, err := labels.Parse(adjustment.NodeSelector); err != nil {
+		errs = append(errs, fmt.Errorf("invalid node selector: %w", err))
+	}
+	for name, quantity := range adjustment.Reserved {
+		if quantity.Sign() < 0 {
+			errs = append(errs, fmt.Errorf("reservation of %s must not be negative, got %s", name, quantity.String()))
+		}
+	}
+	for name, ratio := range adjustment.Overcommit {
+		if ratio <= 0 || ratio > 1 {
+			errs = append(errs, fmt.Errorf("overcommit ratio of %s must be above 0 and at most 1, got %v", name, ratio))
+		}
+	}
+	if len(errs) > 0 {
+		return nil, errors.Join(errs...)
+	}
+	return adjustment, nil
+}
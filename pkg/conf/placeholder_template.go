@@ -0,0 +1,192 @@
+/*
+ Licensed to the Apache Software Foundation (ASF) under one
+ or more contributor license agreements.  See the NOTICE file
+ distributed with this work for additional information
+ regarding copyright ownership.  The ASF licenses this file
+ to you under the Apache License, Version 2.0 (the
+ "License"); you may not use this file except in compliance
+ with the License.  You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package conf
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"go.uber.org/zap"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/validation"
+	"k8s.io/apimachinery/pkg/util/yaml"
+
+	"github.com/apache/yunikorn-k8shim/pkg/common/constants"
+	"github.com/apache/yunikorn-k8shim/pkg/log"
+)
+
+// labels and annotations which are always set by the shim on a placeholder and cannot be templated
+var reservedPlaceholderLabels = []string{
+	constants.CanonicalLabelApplicationID,
+	constants.CanonicalLabelQueueName,
+}
+
+var reservedPlaceholderAnnotations = []string{
+	constants.AnnotationPlaceholderFlag,
+	constants.AnnotationTaskGroupName,
+}
+
+// GetPlaceholderTemplate returns a copy of the placeholder pod template for the given namespace.
+// A namespace specific template replaces the global template completely, nil is returned if
+// no template is configured.
+func (conf *SchedulerConf) GetPlaceholderTemplate(namespace string) *v1.PodTemplateSpec {
+	conf.RLock()
+	defer conf.RUnlock()
+	if tmpl, ok := conf.NamespacePlaceholders[namespace]; ok {
+		return tmpl.DeepCopy()
+	}
+	return conf.PlaceholderTemplate.DeepCopy()
+}
+
+func clonePodTemplateMap(src map[string]*v1.PodTemplateSpec) map[string]*v1.PodTemplateSpec {
+	if src == nil {
+		return nil
+	}
+	result := make(map[string]*v1.PodTemplateSpec, len(src))
+	for k, v := range src {
+		result[k] = v.DeepCopy()
+	}
+	return result
+}
+
+func (cp *configParser) podTemplateVar(p **v1.PodTemplateSpec, name string) {
+	if newValue, ok := cp.config[name]; ok {
+		tmpl, err := parsePlaceholderTemplate(newValue)
+		if err != nil {
+			log.Log(log.ShimConfig).Error("Unable to parse configmap entry", zap.String("key", name), zap.Error(err))
+			cp.errors = append(cp.errors, fmt.Errorf("%s: %w", name, err))
+			return
+		}
+		*p = tmpl
+	}
+}
+
+// podTemplateMapVar collects all templates with a key starting with the given prefix into a map keyed by the remainder of the key
+func (cp *configParser) podTemplateMapVar(p *map[string]*v1.PodTemplateSpec, prefix string) {
+	var result map[string]*v1.PodTemplateSpec
+	for name, newValue := range cp.config {
+		key, ok := strings.CutPrefix(name, prefix)
+		if !ok || key == "" {
+			continue
+		}
+		tmpl, err := parsePlaceholderTemplate(newValue)
+		if err != nil {
+			log.Log(log.ShimConfig).Error("Unable to parse configmap entry", zap.String("key", name), zap.Error(err))
+			cp.errors = append(cp.errors, fmt.Errorf("%s: %w", name, err))
+			continue
+		}
+		if result == nil {
+			result = make(map[string]*v1.PodTemplateSpec)
+		}
+		result[key] = tmpl
+	}
+	*p = result
+}
+
+// parsePlaceholderTemplate decodes a YAML or JSON pod template and validates it for use as a placeholder.
+// An empty value is treated as no template.
+func parsePlaceholderTemplate(value string) (*v1.PodTemplateSpec, error) {
+	if strings.TrimSpace(value) == "" {
+		return nil, nil
+	}
+	tmpl := &v1.PodTemplateSpec{}
+	if err := yaml.NewYAMLOrJSONDecoder(strings.NewReader(value), len(value)).Decode(tmpl); err != nil {
+		return nil, err
+	}
+	if err := validatePlaceholderTemplate(tmpl); err != nil {
+		return nil, err
+	}
+	return tmpl, nil
+}
+
+// validatePlaceholderTemplate checks that the template can be merged with the task group into a valid
+// placeholder pod. Fields that are not set are filled in with the placeholder defaults during the merge.
+func validatePlaceholderTemplate(tmpl *v1.PodTemplateSpec) error {
+	var errs []error
+	for key, value := range tmpl.Labels {
+		for _, msg := range validation.IsQualifiedName(key) {
+			errs = append(errs, fmt.Errorf("invalid label key %q: %s", key, msg))
+		}
+		for _, msg := range validation.IsValidLabelValue(value) {
+			errs = append(errs, fmt.Errorf("invalid label value %q: %s", value, msg))
+		}
+	}
+	for key := range tmpl.Annotations {
+		for _, msg := range validation.IsQualifiedName(key) {
+			errs = append(errs, fmt.Errorf("invalid annotation key %q: %s", key, msg))
+		}
+	}
+	for _, key := range reservedPlaceholderLabels {
+		if _, ok := tmpl.Labels[key]; ok {
+			errs = append(errs, fmt.Errorf("label %s is reserved for the scheduler", key))
+		}
+	}
+	for _, key := range reservedPlaceholderAnnotations {
+		if _, ok := tmpl.Annotations[key]; ok {
+			errs = append(errs, fmt.Errorf("annotation %s is reserved for the scheduler", key))
+		}
+	}
+
+	spec := &tmpl.Spec
+	if spec.RestartPolicy != "" && spec.RestartPolicy != constants.PlaceholderPodRestartPolicy {
+		errs = append(errs, fmt.Errorf("restartPolicy must be %s, got %s", constants.PlaceholderPodRestartPolicy, spec.RestartPolicy))
+	}
+	if spec.SchedulerName != "" && spec.SchedulerName != constants.SchedulerName {
+		errs = append(errs, fmt.Errorf("schedulerName must be %s, got %s", constants.SchedulerName, spec.SchedulerName))
+	}
+	if spec.TerminationGracePeriodSeconds != nil && *spec.TerminationGracePeriodSeconds < 0 {
+		errs = append(errs, fmt.Errorf("terminationGracePeriodSeconds must not be negative"))
+	}
+	if spec.RuntimeClassName != nil {
+		for _, msg := range validation.IsDNS1123Subdomain(*spec.RuntimeClassName) {
+			errs = append(errs, fmt.Errorf("invalid runtimeClassName %q: %s", *spec.RuntimeClassName, msg))
+		}
+	}
+	if spec.SecurityContext != nil && isRootUser(spec.SecurityContext.RunAsUser) {
+		errs = append(errs, fmt.Errorf("placeholder pods must not run as root"))
+	}
+	// the resources of the placeholder are derived from the task group: the template must not add
+	// containers that would change the pod request
+	if len(spec.InitContainers) > 0 || len(spec.EphemeralContainers) > 0 {
+		errs = append(errs, fmt.Errorf("init and ephemeral containers are not supported"))
+	}
+	if len(spec.Containers) > 1 {
+		errs = append(errs, fmt.Errorf("at most one container is supported, got %d", len(spec.Containers)))
+	}
+	if len(spec.Containers) == 1 {
+		container := &spec.Containers[0]
+		if container.Name != "" {
+			for _, msg := range validation.IsDNS1123Label(container.Name) {
+				errs = append(errs, fmt.Errorf("invalid container name %q: %s", container.Name, msg))
+			}
+		}
+		if len(container.Resources.Requests) > 0 || len(container.Resources.Limits) > 0 {
+			errs = append(errs, fmt.Errorf("container resources are derived from the task group and must not be set"))
+		}
+		if container.SecurityContext != nil && isRootUser(container.SecurityContext.RunAsUser) {
+			errs = append(errs, fmt.Errorf("placeholder pods must not run as root"))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func isRootUser(uid *int64) bool {
+	return uid != nil && *uid == 0
+}
@@ -0,0 +1,112 @@
+/*
+ Licensed to the Apache Software Foundation (ASF) under one
+ or more contributor license agreements.  See the NOTICE file
+ distributed with this work for additional information
+ regarding copyright ownership.  The ASF licenses this file
+ to you under the Apache License, Version 2.0 (the
+ "License"); you may not use this file except in compliance
+ with the License.  You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package conf
+
+import (
+	"fmt"
+	"maps"
+	"strings"
+	"sync/atomic"
+)
+
+// predicatesValidator checks the plugin names and plugin args, the plugins are only known to the predicates package
+var predicatesValidator atomic.Pointer[func(PredicatesConf) error]
+
+// SetPredicatesValidator sets the check run on the plugin names and plugin args when the configuration is parsed.
+// The predicates package sets it so an invalid predicate configuration rejects the whole configuration update.
+func SetPredicatesValidator(validator func(PredicatesConf) error) {
+	predicatesValidator.Store(&validator)
+}
+
+// PredicatesConf configures the Kubernetes scheduler plugins used as predicates. The plugin lists are comma separated
+// plugin names: * adds all plugins and a name prefixed with - removes the plugin.
+type PredicatesConf struct {
+	Reservation string            `json:"reservation"`          // plugins run when checking a reservation
+	Allocation  string            `json:"allocation"`           // plugins run when checking an allocation
+	Plugins     string            `json:"plugins"`              // out-of-tree plugins, compiled into the binary, to enable
+	PluginArgs  map[string]string `json:"pluginArgs,omitempty"` // YAML or JSON plugin args by plugin name
+	CacheSize   int               `json:"cacheSize"`            // cached failed pod shapes per node, 0 disables the cache
+}
+
+// GetPredicatesConf returns a copy of the predicate configuration
+func (conf *SchedulerConf) GetPredicatesConf() PredicatesConf {
+	conf.RLock()
+	defer conf.RUnlock()
+	return conf.Predicates.Clone()
+}
+
+func (pc PredicatesConf) Clone() PredicatesConf {
+	pc.PluginArgs = cloneStringMap(pc.PluginArgs)
+	return pc
+}
+
+func (pc PredicatesConf) Equal(other PredicatesConf) bool {
+	return pc.Reservation == other.Reservation &&
+		pc.Allocation == other.Allocation &&
+		pc.Plugins == other.Plugins &&
+		pc.CacheSize == other.CacheSize &&
+		maps.Equal(pc.PluginArgs, other.PluginArgs)
+}
+
+// ParsePredicatePluginList converts a plugin list into a map of plugin name to enabled flag, the wildcard * is kept
+// as a plugin name
+func ParsePredicatePluginList(list string) (map[string]bool, error) {
+	result := make(map[string]bool)
+	for _, entry := range strings.Split(list, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		name, disabled := strings.CutPrefix(entry, "-")
+		if name == "" || (disabled && name == "*") {
+			return nil, fmt.Errorf("invalid plugin %q", entry)
+		}
+		result[name] = !disabled
+	}
+	return result, nil
+}
+
+func (pc PredicatesConf) validate() []error {
+	var errs []error
+	if _, err := ParsePredicatePluginList(pc.Reservation); err != nil {
+		errs = append(errs, fmt.Errorf("%s: %w", CMSvcPredicatesReservation, err))
+	}
+	if _, err := ParsePredicatePluginList(pc.Allocation); err != nil {
+		errs = append(errs, fmt.Errorf("%s: %w", CMSvcPredicatesAllocation, err))
+	}
+	plugins, err := ParsePredicatePluginList(pc.Plugins)
+	if err != nil {
+		errs = append(errs, fmt.Errorf("%s: %w", CMSvcPredicatesPlugins, err))
+	}
+	for name, enabled := range plugins {
+		if name == "*" || !enabled {
+			errs = append(errs, fmt.Errorf("%s: out-of-tree plugins must be listed by name, got %q", CMSvcPredicatesPlugins, pc.Plugins))
+			break
+		}
+	}
+	if pc.CacheSize < 0 {
+		errs = append(errs, fmt.Errorf("%s: cache size must not be negative, got %d", CMSvcPredicatesCacheSize, pc.CacheSize))
+	}
+	if validator := predicatesValidator.Load(); len(errs) == 0 && validator != nil {
+		if err = (*validator)(pc); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", strings.TrimSuffix(CMSvcPredicatesPrefix, "."), err))
+		}
+	}
+	return errs
+}
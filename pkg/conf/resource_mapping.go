@@ -0,0 +1,111 @@
+/*
+ Licensed to the Apache Software Foundation (ASF) under one
+ or more contributor license agreements.  See the NOTICE file
+ distributed with this work for additional information
+ regarding copyright ownership.  The ASF licenses this file
+ to you under the Apache License, Version 2.0 (the
+ "License"); you may not use this file except in compliance
+ with the License.  You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package conf
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"go.uber.org/zap"
+	"k8s.io/apimachinery/pkg/util/yaml"
+
+	"github.com/apache/yunikorn-k8shim/pkg/log"
+)
+
+// ResourceMapping translates a Kubernetes resource before it is sent to the core. Resources mapped to the same
+// name are added up, the scale is applied to the value used by the core (millicores for cpu).
+type ResourceMapping struct {
+	Name  string  `json:"name,omitempty"`  // name used in the core, the original name (vcore for cpu) if empty
+	Drop  bool    `json:"drop,omitempty"`  // do not send the resource to the core
+	Scale float64 `json:"scale,omitempty"` // multiplier for the value, 1 if not set
+}
+
+// GetResourceMapping returns the mapping for the Kubernetes resource with the given name. A mapping for the exact
+// name takes precedence over a prefix mapping (a name ending in *), the longest matching prefix wins.
+func (conf *SchedulerConf) GetResourceMapping(name string) (ResourceMapping, bool) {
+	conf.RLock()
+	defer conf.RUnlock()
+	if mapping, ok := conf.ResourceMappings[name]; ok {
+		return mapping, true
+	}
+	var result ResourceMapping
+	found := false
+	longest := -1
+	for key, mapping := range conf.ResourceMappings {
+		prefix, ok := strings.CutSuffix(key, "*")
+		if ok && strings.HasPrefix(name, prefix) && len(prefix) > longest {
+			result = mapping
+			found = true
+			longest = len(prefix)
+		}
+	}
+	return result, found
+}
+
+func cloneResourceMappingMap(src map[string]ResourceMapping) map[string]ResourceMapping {
+	if src == nil {
+		return nil
+	}
+	result := make(map[string]ResourceMapping, len(src))
+	for k, v := range src {
+		result[k] = v
+	}
+	return result
+}
+
+// resourceMappingVar parses a YAML or JSON map of resource mappings keyed by the Kubernetes resource name
+func (cp *configParser) resourceMappingVar(p *map[string]ResourceMapping, name string) {
+	if newValue, ok := cp.config[name]; ok {
+		mappings, err := parseResourceMappings(newValue)
+		if err != nil {
+			log.Log(log.ShimConfig).Error("Unable to parse configmap entry", zap.String("key", name), zap.Error(err))
+			cp.errors = append(cp.errors, fmt.Errorf("%s: %w", name, err))
+			return
+		}
+		*p = mappings
+	}
+}
+
+// parseResourceMappings decodes and validates the resource mappings, an empty value removes all mappings
+func parseResourceMappings(value string) (map[string]ResourceMapping, error) {
+	if strings.TrimSpace(value) == "" {
+		return nil, nil
+	}
+	var mappings map[string]ResourceMapping
+	if err := yaml.NewYAMLOrJSONDecoder(strings.NewReader(value), len(value)).Decode(&mappings); err != nil {
+		return nil, err
+	}
+	var errs []error
+	for name, mapping := range mappings {
+		if name == "" || name == "*" {
+			errs = append(errs, fmt.Errorf("invalid resource name %q", name))
+		}
+		if mapping.Drop && (mapping.Name != "" || mapping.Scale != 0) {
+			errs = append(errs, fmt.Errorf("resource %s is dropped and cannot be renamed or scaled", name))
+		}
+		if mapping.Scale < 0 {
+			errs = append(errs, fmt.Errorf("scale of resource %s must not be negative, got %v", name, mapping.Scale))
+		}
+	}
+	if len(errs) > 0 {
+		return nil, errors.Join(errs...)
+	}
+	return mappings, nil
+}
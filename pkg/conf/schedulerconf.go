@@ -26,7 +26,9 @@ import (
 	"flag"
 	"fmt"
 	"io"
+	"maps"
 	"os"
+	"sort"
 	"strconv"
 	"strings"
 	"sync"
@@ -36,6 +38,8 @@ import (
 	"go.uber.org/zap"
 	"go.uber.org/zap/zapcore"
 	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/client-go/util/jsonpath"
 	"k8s.io/klog/v2"
 
 	"github.com/apache/yunikorn-k8shim/pkg/common/constants"
@@ -58,16 +62,48 @@ const (
 	PrefixAdmissionController = "admissionController."
 
 	// service
-	CMSvcClusterID                    = PrefixService + "clusterId"
-	CMSvcPolicyGroup                  = PrefixService + "policyGroup"
-	CMSvcSchedulingInterval           = PrefixService + "schedulingInterval"
-	CMSvcVolumeBindTimeout            = PrefixService + "volumeBindTimeout"
-	CMSvcEventChannelCapacity         = PrefixService + "eventChannelCapacity"
-	CMSvcDispatchTimeout              = PrefixService + "dispatchTimeout"
-	CMSvcDisableGangScheduling        = PrefixService + "disableGangScheduling"
-	CMSvcEnableConfigHotRefresh       = PrefixService + "enableConfigHotRefresh"
-	CMSvcPlaceholderImage             = PrefixService + "placeholderImage"
-	CMSvcNodeInstanceTypeNodeLabelKey = PrefixService + "nodeInstanceTypeNodeLabelKey"
+	CMSvcClusterID                     = PrefixService + "clusterId"
+	CMSvcPolicyGroup                   = PrefixService + "policyGroup"
+	CMSvcSchedulingInterval            = PrefixService + "schedulingInterval"
+	CMSvcVolumeBindTimeout             = PrefixService + "volumeBindTimeout"
+	CMSvcEventChannelCapacity          = PrefixService + "eventChannelCapacity"
+	CMSvcDispatchTimeout               = PrefixService + "dispatchTimeout"
+	CMSvcDisableGangScheduling         = PrefixService + "disableGangScheduling"
+	CMSvcEnableConfigHotRefresh        = PrefixService + "enableConfigHotRefresh"
+	CMSvcPlaceholderImage              = PrefixService + "placeholderImage"
+	CMSvcNodeInstanceTypeNodeLabelKey  = PrefixService + "nodeInstanceTypeNodeLabelKey"
+	CMSvcQueueMaxRuntimePrefix         = PrefixService + "queueMaxRuntime."
+	CMSvcTaskPendingTimeout            = PrefixService + "taskPendingTimeout"
+	CMSvcPlaceholderTemplate           = PrefixService + "placeholderTemplate"
+	CMSvcPlaceholderTemplatePrefix     = CMSvcPlaceholderTemplate + "."
+	CMSvcPlaceholderCreateParallelism  = PrefixService + "placeholderCreateParallelism"
+	CMSvcPlaceholderCreateQPS          = PrefixService + "placeholderCreateQPS"
+	CMSvcPlaceholderCreateBurst        = PrefixService + "placeholderCreateBurst"
+	CMSvcPlaceholderCreateRetries      = PrefixService + "placeholderCreateRetries"
+	CMSvcPlaceholderCreateFailPolicy   = PrefixService + "placeholderCreateFailurePolicy"
+	CMSvcPlaceholderCreateRetryDelay   = PrefixService + "placeholderCreateRetryInterval"
+	CMSvcPlaceholderCreateMaxAttempts  = PrefixService + "placeholderCreateMaxAttempts"
+	CMSvcPlaceholderGCInterval         = PrefixService + "placeholderGCInterval"
+	CMSvcPlaceholderGCDryRun           = PrefixService + "placeholderGCDryRun"
+	CMSvcTaskGroupInferencePrefix      = PrefixService + "taskGroupInference."
+	CMSvcPartitionNodeSelectorPrefix   = PrefixService + "partition.nodeSelector."
+	CMSvcPartitionNamespacesPrefix     = PrefixService + "partition.namespaces."
+	CMSvcPartitionAppSelectorPrefix    = PrefixService + "partition.appSelector."
+	CMSvcNodeAttributeLabelPrefix      = PrefixService + "nodeAttributeLabel."
+	CMSvcNodeDrainTaintKeys            = PrefixService + "nodeDrainTaintKeys"
+	CMSvcNodeCapacityAdjustmentPrefix  = PrefixService + "nodeCapacityAdjustment."
+	CMSvcResourceAccountingPolicy      = PrefixService + "resourceAccountingPolicy"
+	CMSvcQueueResourceAccountingPrefix = PrefixService + "queueResourceAccountingPolicy."
+	CMSvcResourceAccountingFloor       = PrefixService + "resourceAccountingFloor"
+	CMSvcResourceMappings              = PrefixService + "resourceMappings"
+	CMSvcPreemptionPDBPolicy           = PrefixService + "preemptionPDBPolicy"
+	CMSvcPreemptionEvictionTimeout     = PrefixService + "preemptionEvictionTimeout"
+	CMSvcPredicatesPrefix              = PrefixService + "predicates."
+	CMSvcPredicatesReservation         = CMSvcPredicatesPrefix + "reservation"
+	CMSvcPredicatesAllocation          = CMSvcPredicatesPrefix + "allocation"
+	CMSvcPredicatesPlugins             = CMSvcPredicatesPrefix + "plugins"
+	CMSvcPredicatesPluginArgsPrefix    = CMSvcPredicatesPrefix + "pluginArgs."
+	CMSvcPredicatesCacheSize           = CMSvcPredicatesPrefix + "cacheSize"
 
 	// kubernetes
 	CMKubeQPS   = PrefixKubernetes + "qps"
@@ -91,6 +127,60 @@ const (
 	DefaultKubeQPS                         = 1000
 	DefaultKubeBurst                       = 1000
 	DefaultAMFilteringGenerateUniqueAppIds = false
+	DefaultTaskPendingTimeout              = time.Duration(0)
+	DefaultPlaceholderCreateParallelism    = 10
+	DefaultPlaceholderCreateQPS            = 50
+	DefaultPlaceholderCreateBurst          = 100
+	DefaultPlaceholderCreateRetries        = 3
+	DefaultPlaceholderCreateFailurePolicy  = PlaceholderCreatePolicyFallback
+	DefaultPlaceholderCreateRetryInterval  = 30 * time.Second
+	DefaultPlaceholderCreateMaxAttempts    = 3
+	DefaultPlaceholderGCInterval           = time.Minute
+	DefaultPlaceholderGCDryRun             = true
+	DefaultResourceAccountingPolicy        = ResourceAccountingRequests
+	DefaultPreemptionPDBPolicy             = PreemptionPDBPolicyIgnore
+	DefaultPreemptionEvictionTimeout       = time.Minute
+	DefaultPredicatesReservation           = "NodeUnschedulable,NodeName,TaintToleration,NodeAffinity,NodePorts,PodTopologySpread,InterPodAffinity"
+	DefaultPredicatesAllocation            = "*"
+	DefaultPredicatesCacheSize             = 64
+
+	// placeholder creation failure policies
+	// Fallback: remove the placeholders and schedule the application without gang scheduling
+	// Fail: remove the placeholders and fail the application
+	// Retry: remove the placeholders and try again later, fail the application after the maximum attempts
+	PlaceholderCreatePolicyFallback = "Fallback"
+	PlaceholderCreatePolicyFail     = "Fail"
+	PlaceholderCreatePolicyRetry    = "Retry"
+
+	// resource accounting policies, decide which container resources are counted for a pod
+	// Requests: the requests of the containers
+	// Limits: the limits of the containers, the requests for resources without a limit
+	// Max: the maximum of the requests and limits of the containers
+	// Floor: the requests of the containers, raised to the configured floor
+	ResourceAccountingRequests = "requests"
+	ResourceAccountingLimits   = "limits"
+	ResourceAccountingMax      = "max"
+	ResourceAccountingFloor    = "floor"
+
+	// preemption PodDisruptionBudget policies, decide if victims can be preempted when that violates a PDB
+	// Ignore: PDBs are not checked
+	// Prefer: a node that needs a PDB violation is rejected, it is accepted when the core asks again and no other
+	// rejected node needs fewer violations. The SI cannot report a fit that violates a PDB, a rejection delays the
+	// preemption until the core asks again. This assumes the core retries the preemption for a pod that is still
+	// pending, the core does that at most once every 15 seconds: each rejected node delays the preemption by at
+	// least one retry.
+	// Enforce: a node that needs a PDB violation is always rejected
+	// With Prefer and Enforce pods covered by a PDB are registered as not allowing preemption unless the pod sets
+	// the allow-preemption annotation, the core then sorts them after the other victims on the node.
+	PreemptionPDBPolicyIgnore  = "Ignore"
+	PreemptionPDBPolicyPrefer  = "Prefer"
+	PreemptionPDBPolicyEnforce = "Enforce"
+
+	// well known node attribute names, any other name is sent to the core as is
+	NodeAttributeRack         = "rack"
+	NodeAttributeZone         = "zone"
+	NodeAttributeRegion       = "region"
+	NodeAttributeInstanceType = "instanceType"
 )
 
 var (
@@ -104,30 +194,57 @@ var (
 	shimSHA         string
 )
 
+// node labels used for the well known node attributes, keyed by the attribute name used in the configuration
+var defaultNodeAttributeLabels = map[string]string{
+	NodeAttributeZone:   v1.LabelTopologyZone,
+	NodeAttributeRegion: v1.LabelTopologyRegion,
+}
+
 var once sync.Once
 var confHolder atomic.Value
 
 var kubeLoggerOnce sync.Once
 
 type SchedulerConf struct {
-	SchedulerName            string        `json:"schedulerName"`
-	ClusterID                string        `json:"clusterId"`
-	ClusterVersion           string        `json:"clusterVersion"`
-	PolicyGroup              string        `json:"policyGroup"`
-	Interval                 time.Duration `json:"schedulingIntervalSecond"`
-	KubeConfig               string        `json:"absoluteKubeConfigFilePath"`
-	VolumeBindTimeout        time.Duration `json:"volumeBindTimeout"`
-	EventChannelCapacity     int           `json:"eventChannelCapacity"`
-	DispatchTimeout          time.Duration `json:"dispatchTimeout"`
-	KubeQPS                  int           `json:"kubeQPS"`
-	KubeBurst                int           `json:"kubeBurst"`
-	EnableConfigHotRefresh   bool          `json:"enableConfigHotRefresh"`
-	DisableGangScheduling    bool          `json:"disableGangScheduling"`
-	UserLabelKey             string        `json:"userLabelKey"`
-	PlaceHolderImage         string        `json:"placeHolderImage"`
-	InstanceTypeNodeLabelKey string        `json:"instanceTypeNodeLabelKey"`
-	Namespace                string        `json:"namespace"`
-	GenerateUniqueAppIds     bool          `json:"generateUniqueAppIds"`
+	SchedulerName            string                             `json:"schedulerName"`
+	ClusterID                string                             `json:"clusterId"`
+	ClusterVersion           string                             `json:"clusterVersion"`
+	PolicyGroup              string                             `json:"policyGroup"`
+	Interval                 time.Duration                      `json:"schedulingIntervalSecond"`
+	KubeConfig               string                             `json:"absoluteKubeConfigFilePath"`
+	VolumeBindTimeout        time.Duration                      `json:"volumeBindTimeout"`
+	EventChannelCapacity     int                                `json:"eventChannelCapacity"`
+	DispatchTimeout          time.Duration                      `json:"dispatchTimeout"`
+	KubeQPS                  int                                `json:"kubeQPS"`
+	KubeBurst                int                                `json:"kubeBurst"`
+	EnableConfigHotRefresh   bool                               `json:"enableConfigHotRefresh"`
+	DisableGangScheduling    bool                               `json:"disableGangScheduling"`
+	UserLabelKey             string                             `json:"userLabelKey"`
+	PlaceHolderImage         string                             `json:"placeHolderImage"`
+	InstanceTypeNodeLabelKey string                             `json:"instanceTypeNodeLabelKey"`
+	Namespace                string                             `json:"namespace"`
+	GenerateUniqueAppIds     bool                               `json:"generateUniqueAppIds"`
+	QueueMaxRuntime          map[string]time.Duration           `json:"queueMaxRuntime"`
+	TaskPendingTimeout       time.Duration                      `json:"taskPendingTimeout"`
+	PlaceholderTemplate      *v1.PodTemplateSpec                `json:"placeholderTemplate,omitempty"`
+	NamespacePlaceholders    map[string]*v1.PodTemplateSpec     `json:"namespacePlaceholderTemplates,omitempty"`
+	PlaceholderCreate        PlaceholderCreateConf              `json:"placeholderCreate"`
+	PlaceholderGCInterval    time.Duration                      `json:"placeholderGCInterval"`
+	PlaceholderGCDryRun      bool                               `json:"placeholderGCDryRun"`
+	TaskGroupInference       map[string]string                  `json:"taskGroupInference,omitempty"`
+	PartitionNodeSelectors   map[string]string                  `json:"partitionNodeSelectors,omitempty"`
+	PartitionNamespaces      map[string]string                  `json:"partitionNamespaces,omitempty"`
+	PartitionAppSelectors    map[string]string                  `json:"partitionAppSelectors,omitempty"`
+	NodeAttributeLabels      map[string]string                  `json:"nodeAttributeLabels,omitempty"`
+	NodeDrainTaintKeys       string                             `json:"nodeDrainTaintKeys"`
+	NodeCapacityAdjustments  map[string]*NodeCapacityAdjustment `json:"nodeCapacityAdjustments,omitempty"`
+	ResourceAccountingPolicy string                             `json:"resourceAccountingPolicy"`
+	QueueResourceAccounting  map[string]string                  `json:"queueResourceAccountingPolicy,omitempty"`
+	ResourceAccountingFloor  v1.ResourceList                    `json:"resourceAccountingFloor,omitempty"`
+	ResourceMappings         map[string]ResourceMapping         `json:"resourceMappings,omitempty"`
+	PreemptionPDBPolicy      string                             `json:"preemptionPDBPolicy"`
+	PreemptionEvictTimeout   time.Duration                      `json:"preemptionEvictionTimeout"`
+	Predicates               PredicatesConf                     `json:"predicates"`
 
 	locking.RWMutex
 }
@@ -155,7 +272,87 @@ func (conf *SchedulerConf) Clone() *SchedulerConf {
 		InstanceTypeNodeLabelKey: conf.InstanceTypeNodeLabelKey,
 		Namespace:                conf.Namespace,
 		GenerateUniqueAppIds:     conf.GenerateUniqueAppIds,
+		QueueMaxRuntime:          cloneDurationMap(conf.QueueMaxRuntime),
+		TaskPendingTimeout:       conf.TaskPendingTimeout,
+		PlaceholderTemplate:      conf.PlaceholderTemplate.DeepCopy(),
+		NamespacePlaceholders:    clonePodTemplateMap(conf.NamespacePlaceholders),
+		PlaceholderCreate:        conf.PlaceholderCreate,
+		PlaceholderGCInterval:    conf.PlaceholderGCInterval,
+		PlaceholderGCDryRun:      conf.PlaceholderGCDryRun,
+		TaskGroupInference:       cloneStringMap(conf.TaskGroupInference),
+		PartitionNodeSelectors:   cloneStringMap(conf.PartitionNodeSelectors),
+		PartitionNamespaces:      cloneStringMap(conf.PartitionNamespaces),
+		PartitionAppSelectors:    cloneStringMap(conf.PartitionAppSelectors),
+		NodeAttributeLabels:      cloneStringMap(conf.NodeAttributeLabels),
+		NodeDrainTaintKeys:       conf.NodeDrainTaintKeys,
+		NodeCapacityAdjustments:  cloneNodeCapacityAdjustmentMap(conf.NodeCapacityAdjustments),
+		ResourceAccountingPolicy: conf.ResourceAccountingPolicy,
+		QueueResourceAccounting:  cloneStringMap(conf.QueueResourceAccounting),
+		ResourceAccountingFloor:  conf.ResourceAccountingFloor.DeepCopy(),
+		ResourceMappings:         cloneResourceMappingMap(conf.ResourceMappings),
+		PreemptionPDBPolicy:      conf.PreemptionPDBPolicy,
+		PreemptionEvictTimeout:   conf.PreemptionEvictTimeout,
+		Predicates:               conf.Predicates.Clone(),
+	}
+}
+
+// PlaceholderCreateConf controls how the placeholder pods of a gang are created
+type PlaceholderCreateConf struct {
+	Parallelism   int           `json:"parallelism"`   // number of placeholders created concurrently for one application
+	QPS           int           `json:"qps"`           // rate limit for placeholder create calls, shared by all applications
+	Burst         int           `json:"burst"`         // burst allowed on top of the rate limit
+	Retries       int           `json:"retries"`       // retries of a single placeholder on a retryable error
+	FailurePolicy string        `json:"failurePolicy"` // what to do when the gang could not be created
+	RetryInterval time.Duration `json:"retryInterval"` // delay before the gang is recreated using the Retry policy
+	MaxAttempts   int           `json:"maxAttempts"`   // gang creation attempts using the Retry policy before failing
+}
+
+func (pc PlaceholderCreateConf) validate() []error {
+	var errs []error
+	if pc.Parallelism < 1 {
+		errs = append(errs, fmt.Errorf("%s must be at least 1, got %d", CMSvcPlaceholderCreateParallelism, pc.Parallelism))
+	}
+	if pc.QPS < 1 {
+		errs = append(errs, fmt.Errorf("%s must be at least 1, got %d", CMSvcPlaceholderCreateQPS, pc.QPS))
+	}
+	if pc.Burst < 1 {
+		errs = append(errs, fmt.Errorf("%s must be at least 1, got %d", CMSvcPlaceholderCreateBurst, pc.Burst))
+	}
+	if pc.Retries < 0 {
+		errs = append(errs, fmt.Errorf("%s must not be negative, got %d", CMSvcPlaceholderCreateRetries, pc.Retries))
+	}
+	if pc.MaxAttempts < 1 {
+		errs = append(errs, fmt.Errorf("%s must be at least 1, got %d", CMSvcPlaceholderCreateMaxAttempts, pc.MaxAttempts))
+	}
+	switch pc.FailurePolicy {
+	case PlaceholderCreatePolicyFallback, PlaceholderCreatePolicyFail, PlaceholderCreatePolicyRetry:
+	default:
+		errs = append(errs, fmt.Errorf("%s must be one of %s, %s or %s, got %s", CMSvcPlaceholderCreateFailPolicy,
+			PlaceholderCreatePolicyFallback, PlaceholderCreatePolicyFail, PlaceholderCreatePolicyRetry, pc.FailurePolicy))
+	}
+	return errs
+}
+
+func cloneDurationMap(src map[string]time.Duration) map[string]time.Duration {
+	if src == nil {
+		return nil
+	}
+	result := make(map[string]time.Duration, len(src))
+	for k, v := range src {
+		result[k] = v
+	}
+	return result
+}
+
+func cloneStringMap(src map[string]string) map[string]string {
+	if src == nil {
+		return nil
 	}
+	result := make(map[string]string, len(src))
+	for k, v := range src {
+		result[k] = v
+	}
+	return result
 }
 
 func UpdateConfigMaps(configMaps []*v1.ConfigMap, initial bool) error {
@@ -212,6 +409,12 @@ func handleNonReloadableConfig(old *SchedulerConf, new *SchedulerConf) {
 	checkNonReloadableString(CMSvcPlaceholderImage, &old.PlaceHolderImage, &new.PlaceHolderImage)
 	checkNonReloadableString(CMSvcNodeInstanceTypeNodeLabelKey, &old.InstanceTypeNodeLabelKey, &new.InstanceTypeNodeLabelKey)
 	checkNonReloadableBool(AMFilteringGenerateUniqueAppIds, &old.GenerateUniqueAppIds, &new.GenerateUniqueAppIds)
+	// nodes and applications keep the partition they were registered in
+	checkNonReloadableStringMap(CMSvcPartitionNodeSelectorPrefix, &old.PartitionNodeSelectors, &new.PartitionNodeSelectors)
+	checkNonReloadableStringMap(CMSvcPartitionNamespacesPrefix, &old.PartitionNamespaces, &new.PartitionNamespaces)
+	checkNonReloadableStringMap(CMSvcPartitionAppSelectorPrefix, &old.PartitionAppSelectors, &new.PartitionAppSelectors)
+	// pods and nodes already sent to the core keep the resources they were sent with
+	checkNonReloadableResourceMappings(CMSvcResourceMappings, &old.ResourceMappings, &new.ResourceMappings)
 }
 
 const warningNonReloadable = "ignoring non-reloadable configuration change (restart required to update)"
@@ -244,6 +447,20 @@ func checkNonReloadableBool(name string, old *bool, new *bool) {
 	}
 }
 
+func checkNonReloadableStringMap(name string, old *map[string]string, new *map[string]string) {
+	if !maps.Equal(*old, *new) {
+		log.Log(log.ShimConfig).Warn(warningNonReloadable, zap.String("config", name), zap.Any("existing", *old), zap.Any("new", *new))
+		*new = *old
+	}
+}
+
+func checkNonReloadableResourceMappings(name string, old *map[string]ResourceMapping, new *map[string]ResourceMapping) {
+	if !maps.Equal(*old, *new) {
+		log.Log(log.ShimConfig).Warn(warningNonReloadable, zap.String("config", name), zap.Any("existing", *old), zap.Any("new", *new))
+		*new = *old
+	}
+}
+
 func GetSchedulerConf() *SchedulerConf {
 	once.Do(createConfigs)
 	return confHolder.Load().(*SchedulerConf) //nolint:errcheck
@@ -273,6 +490,240 @@ func (conf *SchedulerConf) GetKubeConfigPath() string {
 	return conf.KubeConfig
 }
 
+// GetQueueMaxRuntime returns the default maximum runtime for applications in the given queue.
+// The most specific configured queue in the hierarchy wins, 0 is returned if nothing is configured.
+func (conf *SchedulerConf) GetQueueMaxRuntime(queue string) time.Duration {
+	conf.RLock()
+	defer conf.RUnlock()
+	for queue != "" {
+		if maxRuntime, ok := conf.QueueMaxRuntime[queue]; ok {
+			return maxRuntime
+		}
+		idx := strings.LastIndex(queue, ".")
+		if idx < 0 {
+			break
+		}
+		queue = queue[:idx]
+	}
+	return 0
+}
+
+// GetResourceAccountingPolicy returns the resource accounting policy for pods in the given queue.
+// The most specific configured queue in the hierarchy wins, the global policy is used if no queue is configured.
+func (conf *SchedulerConf) GetResourceAccountingPolicy(queue string) string {
+	conf.RLock()
+	defer conf.RUnlock()
+	for queue != "" {
+		if policy, ok := conf.QueueResourceAccounting[queue]; ok {
+			return policy
+		}
+		idx := strings.LastIndex(queue, ".")
+		if idx < 0 {
+			break
+		}
+		queue = queue[:idx]
+	}
+	return conf.ResourceAccountingPolicy
+}
+
+// GetResourceAccountingFloor returns a copy of the minimum resources counted per container using the Floor policy
+func (conf *SchedulerConf) GetResourceAccountingFloor() v1.ResourceList {
+	conf.RLock()
+	defer conf.RUnlock()
+	return conf.ResourceAccountingFloor.DeepCopy()
+}
+
+// IsValidResourceAccountingPolicy returns true if the policy is one of the known resource accounting policies
+func IsValidResourceAccountingPolicy(policy string) bool {
+	switch policy {
+	case ResourceAccountingRequests, ResourceAccountingLimits, ResourceAccountingMax, ResourceAccountingFloor:
+		return true
+	}
+	return false
+}
+
+func (conf *SchedulerConf) validateResourceAccounting() []error {
+	var errs []error
+	if !IsValidResourceAccountingPolicy(conf.ResourceAccountingPolicy) {
+		errs = append(errs, fmt.Errorf("%s must be one of %s, %s, %s or %s, got %s", CMSvcResourceAccountingPolicy,
+			ResourceAccountingRequests, ResourceAccountingLimits, ResourceAccountingMax, ResourceAccountingFloor,
+			conf.ResourceAccountingPolicy))
+	}
+	for queue, policy := range conf.QueueResourceAccounting {
+		if !IsValidResourceAccountingPolicy(policy) {
+			errs = append(errs, fmt.Errorf("%s%s must be one of %s, %s, %s or %s, got %s", CMSvcQueueResourceAccountingPrefix,
+				queue, ResourceAccountingRequests, ResourceAccountingLimits, ResourceAccountingMax, ResourceAccountingFloor,
+				policy))
+		}
+	}
+	return errs
+}
+
+// GetPreemptionPDBPolicy returns how PodDisruptionBudgets of the victims are handled when checking preemption
+func (conf *SchedulerConf) GetPreemptionPDBPolicy() string {
+	conf.RLock()
+	defer conf.RUnlock()
+	return conf.PreemptionPDBPolicy
+}
+
+// GetPreemptionEvictionTimeout returns how long the eviction of a preempted pod is retried before the pod is deleted.
+// An eviction refused by a PodDisruptionBudget is retried for up to one minute by default, the preemptor waits for
+// the resources of the pod on the node until the pod is evicted or deleted.
+func (conf *SchedulerConf) GetPreemptionEvictionTimeout() time.Duration {
+	conf.RLock()
+	defer conf.RUnlock()
+	return conf.PreemptionEvictTimeout
+}
+
+func (conf *SchedulerConf) validatePreemptionPDBPolicy() []error {
+	switch conf.PreemptionPDBPolicy {
+	case PreemptionPDBPolicyIgnore, PreemptionPDBPolicyPrefer, PreemptionPDBPolicyEnforce:
+		return nil
+	}
+	return []error{fmt.Errorf("%s must be one of %s, %s or %s, got %s", CMSvcPreemptionPDBPolicy,
+		PreemptionPDBPolicyIgnore, PreemptionPDBPolicyPrefer, PreemptionPDBPolicyEnforce, conf.PreemptionPDBPolicy)}
+}
+
+// GetPlaceholderGCInterval returns the interval of the orphan placeholder collector, 0 means disabled
+func (conf *SchedulerConf) GetPlaceholderGCInterval() time.Duration {
+	conf.RLock()
+	defer conf.RUnlock()
+	return conf.PlaceholderGCInterval
+}
+
+// IsPlaceholderGCDryRun returns true if orphan placeholders are only reported and not deleted, this is the default
+func (conf *SchedulerConf) IsPlaceholderGCDryRun() bool {
+	conf.RLock()
+	defer conf.RUnlock()
+	return conf.PlaceholderGCDryRun
+}
+
+// GetTaskGroupInferencePath returns the JSON path of the gang size in a custom workload of the given kind and API group.
+// Built-in workloads are read from the informers, an empty string is returned if no path is configured for the kind.
+func (conf *SchedulerConf) GetTaskGroupInferencePath(kind, group string) string {
+	key := kind
+	if group != "" {
+		key = kind + "." + group
+	}
+	conf.RLock()
+	defer conf.RUnlock()
+	return conf.TaskGroupInference[key]
+}
+
+// GetNodeAttributes returns the node attributes for the core derived from the labels of a node.
+// Configured labels take precedence over the built-in labels, an empty label disables the attribute.
+// The instance type uses the nodeInstanceTypeNodeLabelKey label unless it is configured explicitly.
+func (conf *SchedulerConf) GetNodeAttributes(nodeLabels map[string]string) map[string]string {
+	conf.RLock()
+	defer conf.RUnlock()
+	nodeAttributeLabels := map[string]string{
+		NodeAttributeInstanceType: conf.InstanceTypeNodeLabelKey,
+	}
+	for name, label := range defaultNodeAttributeLabels {
+		nodeAttributeLabels[name] = label
+	}
+	for name, label := range conf.NodeAttributeLabels {
+		nodeAttributeLabels[name] = label
+	}
+	attributes := make(map[string]string)
+	for name, label := range nodeAttributeLabels {
+		if label == "" {
+			continue
+		}
+		if value, ok := nodeLabels[label]; ok {
+			attributes[nodeAttributeKey(name)] = value
+		}
+	}
+	return attributes
+}
+
+// nodeAttributeKey returns the attribute key used by the core for the well known attribute names
+func nodeAttributeKey(name string) string {
+	switch name {
+	case NodeAttributeRack:
+		return constants.DefaultNodeAttributeRackNameKey
+	case NodeAttributeZone:
+		return constants.NodeAttributeZoneKey
+	case NodeAttributeRegion:
+		return constants.NodeAttributeRegionKey
+	case NodeAttributeInstanceType:
+		return constants.NodeAttributeInstanceTypeKey
+	default:
+		return name
+	}
+}
+
+// GetNodeDrainTaintKeys returns the keys of the NoSchedule and NoExecute taints that make a node unschedulable in
+// the core on top of the node condition taints
+func (conf *SchedulerConf) GetNodeDrainTaintKeys() []string {
+	conf.RLock()
+	defer conf.RUnlock()
+	var keys []string
+	for _, key := range strings.Split(conf.NodeDrainTaintKeys, ",") {
+		if key = strings.TrimSpace(key); key != "" {
+			keys = append(keys, key)
+		}
+	}
+	return keys
+}
+
+// GetNodePartition returns the partition of the node with the given labels: the first partition, in name order,
+// with a node selector that matches the labels. Nodes that do not match any selector are in the default partition.
+func (conf *SchedulerConf) GetNodePartition(nodeLabels map[string]string) string {
+	conf.RLock()
+	defer conf.RUnlock()
+	if partition := matchLabelSelector(conf.PartitionNodeSelectors, nodeLabels); partition != "" {
+		return partition
+	}
+	return constants.DefaultPartition
+}
+
+// GetAppPartition returns the partition of an application in the given namespace with the given pod labels.
+// The namespace rules are checked before the label selectors, applications that match no rule are in the
+// default partition.
+func (conf *SchedulerConf) GetAppPartition(namespace string, podLabels map[string]string) string {
+	conf.RLock()
+	defer conf.RUnlock()
+	for _, partition := range sortedKeys(conf.PartitionNamespaces) {
+		for _, ns := range strings.Split(conf.PartitionNamespaces[partition], ",") {
+			if strings.TrimSpace(ns) == namespace {
+				return partition
+			}
+		}
+	}
+	if partition := matchLabelSelector(conf.PartitionAppSelectors, podLabels); partition != "" {
+		return partition
+	}
+	return constants.DefaultPartition
+}
+
+// matchLabelSelector returns the first key, in name order, with a selector matching the labels
+func matchLabelSelector(selectors map[string]string, objectLabels map[string]string) string {
+	for _, partition := range sortedKeys(selectors) {
+		// selectors are validated when the configuration is parsed
+		selector, err := labels.Parse(selectors[partition])
+		if err == nil && selector.Matches(labels.Set(objectLabels)) {
+			return partition
+		}
+	}
+	return ""
+}
+
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func (conf *SchedulerConf) GetTaskPendingTimeout() time.Duration {
+	conf.RLock()
+	defer conf.RUnlock()
+	return conf.TaskPendingTimeout
+}
+
 func GetSchedulerNamespace() string {
 	if value, ok := os.LookupEnv(EnvNamespace); ok {
 		return value
@@ -317,6 +768,26 @@ func CreateDefaultConfig() *SchedulerConf {
 		PlaceHolderImage:         constants.PlaceholderContainerImage,
 		InstanceTypeNodeLabelKey: constants.DefaultNodeInstanceTypeNodeLabelKey,
 		GenerateUniqueAppIds:     DefaultAMFilteringGenerateUniqueAppIds,
+		TaskPendingTimeout:       DefaultTaskPendingTimeout,
+		PlaceholderCreate: PlaceholderCreateConf{
+			Parallelism:   DefaultPlaceholderCreateParallelism,
+			QPS:           DefaultPlaceholderCreateQPS,
+			Burst:         DefaultPlaceholderCreateBurst,
+			Retries:       DefaultPlaceholderCreateRetries,
+			FailurePolicy: DefaultPlaceholderCreateFailurePolicy,
+			RetryInterval: DefaultPlaceholderCreateRetryInterval,
+			MaxAttempts:   DefaultPlaceholderCreateMaxAttempts,
+		},
+		PlaceholderGCInterval:    DefaultPlaceholderGCInterval,
+		PlaceholderGCDryRun:      DefaultPlaceholderGCDryRun,
+		ResourceAccountingPolicy: DefaultResourceAccountingPolicy,
+		PreemptionPDBPolicy:      DefaultPreemptionPDBPolicy,
+		PreemptionEvictTimeout:   DefaultPreemptionEvictionTimeout,
+		Predicates: PredicatesConf{
+			Reservation: DefaultPredicatesReservation,
+			Allocation:  DefaultPredicatesAllocation,
+			CacheSize:   DefaultPredicatesCacheSize,
+		},
 	}
 }
 
@@ -341,6 +812,37 @@ func parseConfig(config map[string]string, prev *SchedulerConf) (*SchedulerConf,
 	parser.boolVar(&conf.EnableConfigHotRefresh, CMSvcEnableConfigHotRefresh)
 	parser.stringVar(&conf.PlaceHolderImage, CMSvcPlaceholderImage)
 	parser.stringVar(&conf.InstanceTypeNodeLabelKey, CMSvcNodeInstanceTypeNodeLabelKey)
+	parser.durationMapVar(&conf.QueueMaxRuntime, CMSvcQueueMaxRuntimePrefix)
+	parser.durationVar(&conf.TaskPendingTimeout, CMSvcTaskPendingTimeout)
+	parser.podTemplateVar(&conf.PlaceholderTemplate, CMSvcPlaceholderTemplate)
+	parser.podTemplateMapVar(&conf.NamespacePlaceholders, CMSvcPlaceholderTemplatePrefix)
+	parser.intVar(&conf.PlaceholderCreate.Parallelism, CMSvcPlaceholderCreateParallelism)
+	parser.intVar(&conf.PlaceholderCreate.QPS, CMSvcPlaceholderCreateQPS)
+	parser.intVar(&conf.PlaceholderCreate.Burst, CMSvcPlaceholderCreateBurst)
+	parser.intVar(&conf.PlaceholderCreate.Retries, CMSvcPlaceholderCreateRetries)
+	parser.stringVar(&conf.PlaceholderCreate.FailurePolicy, CMSvcPlaceholderCreateFailPolicy)
+	parser.durationVar(&conf.PlaceholderCreate.RetryInterval, CMSvcPlaceholderCreateRetryDelay)
+	parser.intVar(&conf.PlaceholderCreate.MaxAttempts, CMSvcPlaceholderCreateMaxAttempts)
+	parser.durationVar(&conf.PlaceholderGCInterval, CMSvcPlaceholderGCInterval)
+	parser.boolVar(&conf.PlaceholderGCDryRun, CMSvcPlaceholderGCDryRun)
+	parser.jsonPathMapVar(&conf.TaskGroupInference, CMSvcTaskGroupInferencePrefix)
+	parser.labelSelectorMapVar(&conf.PartitionNodeSelectors, CMSvcPartitionNodeSelectorPrefix)
+	parser.stringMapVar(&conf.PartitionNamespaces, CMSvcPartitionNamespacesPrefix)
+	parser.labelSelectorMapVar(&conf.PartitionAppSelectors, CMSvcPartitionAppSelectorPrefix)
+	parser.stringMapVar(&conf.NodeAttributeLabels, CMSvcNodeAttributeLabelPrefix)
+	parser.stringVar(&conf.NodeDrainTaintKeys, CMSvcNodeDrainTaintKeys)
+	parser.nodeCapacityAdjustmentMapVar(&conf.NodeCapacityAdjustments, CMSvcNodeCapacityAdjustmentPrefix)
+	parser.stringVar(&conf.ResourceAccountingPolicy, CMSvcResourceAccountingPolicy)
+	parser.stringMapVar(&conf.QueueResourceAccounting, CMSvcQueueResourceAccountingPrefix)
+	parser.resourceListVar(&conf.ResourceAccountingFloor, CMSvcResourceAccountingFloor)
+	parser.resourceMappingVar(&conf.ResourceMappings, CMSvcResourceMappings)
+	parser.stringVar(&conf.PreemptionPDBPolicy, CMSvcPreemptionPDBPolicy)
+	parser.durationVar(&conf.PreemptionEvictTimeout, CMSvcPreemptionEvictionTimeout)
+	parser.stringVar(&conf.Predicates.Reservation, CMSvcPredicatesReservation)
+	parser.stringVar(&conf.Predicates.Allocation, CMSvcPredicatesAllocation)
+	parser.stringVar(&conf.Predicates.Plugins, CMSvcPredicatesPlugins)
+	parser.stringMapVar(&conf.Predicates.PluginArgs, CMSvcPredicatesPluginArgsPrefix)
+	parser.intVar(&conf.Predicates.CacheSize, CMSvcPredicatesCacheSize)
 
 	// kubernetes
 	parser.intVar(&conf.KubeQPS, CMKubeQPS)
@@ -349,6 +851,12 @@ func parseConfig(config map[string]string, prev *SchedulerConf) (*SchedulerConf,
 	// admission controller
 	parser.boolVar(&conf.GenerateUniqueAppIds, AMFilteringGenerateUniqueAppIds)
 
+	if len(parser.errors) == 0 {
+		parser.errors = append(parser.errors, conf.PlaceholderCreate.validate()...)
+		parser.errors = append(parser.errors, conf.validateResourceAccounting()...)
+		parser.errors = append(parser.errors, conf.validatePreemptionPDBPolicy()...)
+		parser.errors = append(parser.errors, conf.Predicates.validate()...)
+	}
 	if len(parser.errors) > 0 {
 		return nil, parser.errors
 	}
@@ -410,6 +918,100 @@ func (cp *configParser) durationVar(p *time.Duration, name string) {
 	}
 }
 
+// jsonPathMapVar collects all JSON paths with a key starting with the given prefix into a map keyed by the remainder of the key
+func (cp *configParser) jsonPathMapVar(p *map[string]string, prefix string) {
+	var result map[string]string
+	for name, newValue := range cp.config {
+		key, ok := strings.CutPrefix(name, prefix)
+		if !ok || key == "" {
+			continue
+		}
+		if err := jsonpath.New(key).Parse(newValue); err != nil {
+			log.Log(log.ShimConfig).Error("Unable to parse configmap entry", zap.String("key", name), zap.String("value", newValue), zap.Error(err))
+			cp.errors = append(cp.errors, fmt.Errorf("%s: %w", name, err))
+			continue
+		}
+		if result == nil {
+			result = make(map[string]string)
+		}
+		result[key] = newValue
+	}
+	*p = result
+}
+
+// labelSelectorMapVar collects all label selectors with a key starting with the given prefix into a map keyed by the remainder of the key
+func (cp *configParser) labelSelectorMapVar(p *map[string]string, prefix string) {
+	var result map[string]string
+	for name, newValue := range cp.config {
+		key, ok := strings.CutPrefix(name, prefix)
+		if !ok || key == "" {
+			continue
+		}
+		if _, err := labels.Parse(newValue); err != nil {
+			log.Log(log.ShimConfig).Error("Unable to parse configmap entry", zap.String("key", name), zap.String("value", newValue), zap.Error(err))
+			cp.errors = append(cp.errors, fmt.Errorf("%s: %w", name, err))
+			continue
+		}
+		if result == nil {
+			result = make(map[string]string)
+		}
+		result[key] = newValue
+	}
+	*p = result
+}
+
+// stringMapVar collects all entries starting with the given prefix into a map keyed by the remainder of the key
+func (cp *configParser) stringMapVar(p *map[string]string, prefix string) {
+	var result map[string]string
+	for name, newValue := range cp.config {
+		key, ok := strings.CutPrefix(name, prefix)
+		if !ok || key == "" {
+			continue
+		}
+		if result == nil {
+			result = make(map[string]string)
+		}
+		result[key] = newValue
+	}
+	*p = result
+}
+
+// resourceListVar parses a JSON map of resource quantities, for example {"cpu": "100m", "memory": "128Mi"}
+func (cp *configParser) resourceListVar(p *v1.ResourceList, name string) {
+	if newValue, ok := cp.config[name]; ok {
+		var result v1.ResourceList
+		if err := json.Unmarshal([]byte(newValue), &result); err != nil {
+			log.Log(log.ShimConfig).Error("Unable to parse configmap entry", zap.String("key", name), zap.String("value", newValue), zap.Error(err))
+			cp.errors = append(cp.errors, fmt.Errorf("%s: %w", name, err))
+			return
+		}
+		*p = result
+	}
+}
+
+// durationMapVar collects all entries starting with the given prefix into a map keyed by the remainder of the key.
+// The map replaces the previous map, it is nil if no key has the prefix.
+func (cp *configParser) durationMapVar(p *map[string]time.Duration, prefix string) {
+	var result map[string]time.Duration
+	for name, newValue := range cp.config {
+		key, ok := strings.CutPrefix(name, prefix)
+		if !ok || key == "" {
+			continue
+		}
+		durationValue, err := time.ParseDuration(newValue)
+		if err != nil {
+			log.Log(log.ShimConfig).Error("Unable to parse configmap entry", zap.String("key", name), zap.String("value", newValue), zap.Error(err))
+			cp.errors = append(cp.errors, err)
+			continue
+		}
+		if result == nil {
+			result = make(map[string]time.Duration)
+		}
+		result[key] = durationValue
+	}
+	*p = result
+}
+
 func updateKubeLogger() {
 	// if log level is debug, enable klog and set its log level verbosity to 4 (represents debug level),
 	// For details refer to the Logging Conventions of klog at
@@ -124,6 +124,13 @@ func TestParseConfigMap(t *testing.T) {
 		{CMSvcEnableConfigHotRefresh, "EnableConfigHotRefresh", false},
 		{CMSvcPlaceholderImage, "PlaceHolderImage", "test-image"},
 		{CMSvcNodeInstanceTypeNodeLabelKey, "InstanceTypeNodeLabelKey", "node.kubernetes.io/instance-type"},
+		{CMSvcTaskPendingTimeout, "TaskPendingTimeout", 10 * time.Minute},
+		{CMSvcPlaceholderGCInterval, "PlaceholderGCInterval", 5 * time.Minute},
+		{CMSvcPlaceholderGCDryRun, "PlaceholderGCDryRun", false},
+		{CMSvcNodeDrainTaintKeys, "NodeDrainTaintKeys", "dedicated,maintenance"},
+		{CMSvcResourceAccountingPolicy, "ResourceAccountingPolicy", ResourceAccountingLimits},
+		{CMSvcPreemptionPDBPolicy, "PreemptionPDBPolicy", PreemptionPDBPolicyEnforce},
+		{CMSvcPreemptionEvictionTimeout, "PreemptionEvictTimeout", 2 * time.Minute},
 		{CMKubeQPS, "KubeQPS", 2345},
 		{CMKubeBurst, "KubeBurst", 3456},
 	}
@@ -157,6 +164,11 @@ func TestUpdateConfigMapNonReloadable(t *testing.T) {
 		{CMSvcNodeInstanceTypeNodeLabelKey, "InstanceTypeNodeLabelKey", "node.kubernetes.io/instance-type", false},
 		{CMKubeQPS, "KubeQPS", 2345, false},
 		{CMKubeBurst, "KubeBurst", 3456, false},
+		{CMSvcTaskPendingTimeout, "TaskPendingTimeout", 10 * time.Minute, true},
+		{CMSvcPlaceholderGCInterval, "PlaceholderGCInterval", 5 * time.Minute, true},
+		{CMSvcPlaceholderGCDryRun, "PlaceholderGCDryRun", false, true},
+		{CMSvcNodeDrainTaintKeys, "NodeDrainTaintKeys", "dedicated,maintenance", true},
+		{CMSvcPreemptionEvictionTimeout, "PreemptionEvictTimeout", 2 * time.Minute, true},
 	}
 
 	for _, tc := range testCases {
@@ -182,6 +194,45 @@ func TestUpdateConfigMapNonReloadable(t *testing.T) {
 	}
 }
 
+func TestUpdateConfigMapNonReloadablePartitions(t *testing.T) {
+	defer func() {
+		err := UpdateConfigMaps([]*v1.ConfigMap{nil, nil}, true)
+		assert.NilError(t, err, "failed to reset configmap")
+	}()
+	err := UpdateConfigMaps([]*v1.ConfigMap{nil, {Data: map[string]string{
+		CMSvcPartitionNodeSelectorPrefix + "gpu": "pool=gpu",
+	}}}, true)
+	assert.NilError(t, err, "failed to set configmap")
+
+	err = UpdateConfigMaps([]*v1.ConfigMap{nil, {Data: map[string]string{
+		CMSvcPartitionNodeSelectorPrefix + "gpu": "pool=cpu",
+		CMSvcPartitionNamespacesPrefix + "gpu":   "ml",
+		CMSvcPartitionAppSelectorPrefix + "gpu":  "tier=gpu",
+	}}}, false)
+	assert.NilError(t, err, "failed to update configmap")
+	conf := GetSchedulerConf()
+	assert.DeepEqual(t, conf.PartitionNodeSelectors, map[string]string{"gpu": "pool=gpu"})
+	assert.Equal(t, len(conf.PartitionNamespaces), 0, "non-reloadable field updated")
+	assert.Equal(t, len(conf.PartitionAppSelectors), 0, "non-reloadable field updated")
+}
+
+func TestUpdateConfigMapNonReloadableResourceMappings(t *testing.T) {
+	defer func() {
+		err := UpdateConfigMaps([]*v1.ConfigMap{nil, nil}, true)
+		assert.NilError(t, err, "failed to reset configmap")
+	}()
+	err := UpdateConfigMaps([]*v1.ConfigMap{nil, {Data: map[string]string{
+		CMSvcResourceMappings: `{"hugepages-*": {"drop": true}}`,
+	}}}, true)
+	assert.NilError(t, err, "failed to set configmap")
+
+	err = UpdateConfigMaps([]*v1.ConfigMap{nil, {Data: map[string]string{
+		CMSvcResourceMappings: `{"nvidia.com/gpu": {"name": "accelerator"}}`,
+	}}}, false)
+	assert.NilError(t, err, "failed to update configmap")
+	assert.DeepEqual(t, GetSchedulerConf().ResourceMappings, map[string]ResourceMapping{"hugepages-*": {Drop: true}})
+}
+
 func TestParseConfigMapWithUnknownKeyDoesNotFail(t *testing.T) {
 	prev := CreateDefaultConfig()
 	conf, errs := parseConfig(map[string]string{"key": "value"}, prev)
@@ -213,6 +264,452 @@ func TestParseConfigMapWithInvalidDuration(t *testing.T) {
 	assert.ErrorContains(t, errs[0], "invalid duration", "wrong error type")
 }
 
+func TestParseConfigMapQueueMaxRuntime(t *testing.T) {
+	prev := CreateDefaultConfig()
+	conf, errs := parseConfig(map[string]string{
+		CMSvcQueueMaxRuntimePrefix + "root":         "48h",
+		CMSvcQueueMaxRuntimePrefix + "root.batch":   "2h",
+		CMSvcQueueMaxRuntimePrefix + "root.batch.x": "30m",
+		CMSvcQueueMaxRuntimePrefix:                  "1h",
+	}, prev)
+	assert.Assert(t, conf != nil, "conf was nil")
+	assert.Assert(t, errs == nil, errs)
+	assert.Equal(t, 3, len(conf.QueueMaxRuntime))
+	assert.Equal(t, 30*time.Minute, conf.GetQueueMaxRuntime("root.batch.x"))
+	assert.Equal(t, 2*time.Hour, conf.GetQueueMaxRuntime("root.batch.y"))
+	assert.Equal(t, 48*time.Hour, conf.GetQueueMaxRuntime("root.default"))
+	assert.Equal(t, time.Duration(0), conf.GetQueueMaxRuntime("other"))
+	assert.Equal(t, time.Duration(0), conf.GetQueueMaxRuntime(""))
+
+	// clone must not share the map
+	clone := conf.Clone()
+	clone.QueueMaxRuntime["root"] = time.Hour
+	assert.Equal(t, 48*time.Hour, conf.GetQueueMaxRuntime("root"))
+
+	// removed keys do not keep the previous value, for all prefixed maps
+	conf.PartitionNamespaces = map[string]string{"gpu": "ml"}
+	conf.TaskGroupInference = map[string]string{"Job.batch": "{.spec}"}
+	conf.NamespacePlaceholders = map[string]*v1.PodTemplateSpec{"ns": {}}
+	conf.NodeCapacityAdjustments = map[string]*NodeCapacityAdjustment{"dev": {}}
+	conf.PartitionNodeSelectors = map[string]string{"gpu": "pool=gpu"}
+	removed, errs := parseConfig(map[string]string{CMSvcPolicyGroup: "test"}, conf)
+	assert.Assert(t, errs == nil, errs)
+	assert.Assert(t, removed.QueueMaxRuntime == nil, "queue max runtime not removed")
+	assert.Assert(t, removed.PartitionNamespaces == nil, "string map not removed")
+	assert.Assert(t, removed.TaskGroupInference == nil, "JSON path map not removed")
+	assert.Assert(t, removed.NamespacePlaceholders == nil, "pod template map not removed")
+	assert.Assert(t, removed.NodeCapacityAdjustments == nil, "capacity adjustment map not removed")
+	assert.Assert(t, removed.PartitionNodeSelectors == nil, "label selector map not removed")
+
+	conf, errs = parseConfig(map[string]string{CMSvcQueueMaxRuntimePrefix + "root": "x"}, prev)
+	assert.Assert(t, conf == nil, "conf exists")
+	assert.Equal(t, 1, len(errs), "wrong error count")
+	assert.ErrorContains(t, errs[0], "invalid duration", "wrong error type")
+}
+
+func TestParseConfigMapTaskGroupInference(t *testing.T) {
+	prev := CreateDefaultConfig()
+	assert.Equal(t, "", prev.GetTaskGroupInferencePath("MPIJob", "kubeflow.org"))
+
+	conf, errs := parseConfig(map[string]string{
+		CMSvcTaskGroupInferencePrefix + "MPIJob.kubeflow.org": "{.spec.mpiReplicaSpecs.Worker.replicas}",
+		CMSvcTaskGroupInferencePrefix + "TFJob.kubeflow.org":  "{.spec.tfReplicaSpecs.Worker.replicas}",
+		CMSvcTaskGroupInferencePrefix:                         "{.spec.replicas}",
+	}, prev)
+	assert.Assert(t, conf != nil, "conf was nil")
+	assert.Assert(t, errs == nil, errs)
+	assert.Equal(t, 2, len(conf.TaskGroupInference))
+	assert.Equal(t, "{.spec.mpiReplicaSpecs.Worker.replicas}", conf.GetTaskGroupInferencePath("MPIJob", "kubeflow.org"))
+	assert.Equal(t, "{.spec.tfReplicaSpecs.Worker.replicas}", conf.GetTaskGroupInferencePath("TFJob", "kubeflow.org"))
+
+	// clone must not share the map
+	clone := conf.Clone()
+	clone.TaskGroupInference["TFJob.kubeflow.org"] = "{.spec.replicas}"
+	assert.Equal(t, "{.spec.tfReplicaSpecs.Worker.replicas}", conf.GetTaskGroupInferencePath("TFJob", "kubeflow.org"))
+
+	conf, errs = parseConfig(map[string]string{CMSvcTaskGroupInferencePrefix + "TFJob.kubeflow.org": "{.spec.replicas"}, prev)
+	assert.Assert(t, conf == nil, "conf exists")
+	assert.Equal(t, 1, len(errs), "wrong error count")
+	assert.ErrorContains(t, errs[0], CMSvcTaskGroupInferencePrefix+"TFJob.kubeflow.org", "wrong error type")
+}
+
+func TestParseConfigMapNodeAttributeLabels(t *testing.T) {
+	nodeLabels := map[string]string{
+		v1.LabelTopologyZone:                          "zone-a",
+		v1.LabelTopologyRegion:                        "region-1",
+		constants.DefaultNodeInstanceTypeNodeLabelKey: "m5.large",
+		"example.com/rack":                            "rack-1",
+		"example.com/pool":                            "spot",
+	}
+	prev := CreateDefaultConfig()
+	assert.DeepEqual(t, prev.GetNodeAttributes(nodeLabels), map[string]string{
+		constants.NodeAttributeZoneKey:         "zone-a",
+		constants.NodeAttributeRegionKey:       "region-1",
+		constants.NodeAttributeInstanceTypeKey: "m5.large",
+	})
+
+	conf, errs := parseConfig(map[string]string{
+		CMSvcNodeAttributeLabelPrefix + NodeAttributeRack:   "example.com/rack",
+		CMSvcNodeAttributeLabelPrefix + NodeAttributeRegion: "",
+		CMSvcNodeAttributeLabelPrefix + "pool":              "example.com/pool",
+	}, prev)
+	assert.Assert(t, conf != nil, "conf was nil")
+	assert.Assert(t, errs == nil, errs)
+	assert.DeepEqual(t, conf.GetNodeAttributes(nodeLabels), map[string]string{
+		constants.DefaultNodeAttributeRackNameKey: "rack-1",
+		constants.NodeAttributeZoneKey:            "zone-a",
+		constants.NodeAttributeInstanceTypeKey:    "m5.large",
+		"pool":                                    "spot",
+	})
+	assert.Equal(t, len(conf.GetNodeAttributes(nil)), 0)
+}
+
+func TestGetNodeDrainTaintKeys(t *testing.T) {
+	prev := CreateDefaultConfig()
+	assert.Assert(t, prev.GetNodeDrainTaintKeys() == nil)
+	conf, errs := parseConfig(map[string]string{CMSvcNodeDrainTaintKeys: " dedicated, ,maintenance"}, prev)
+	assert.Assert(t, errs == nil, errs)
+	assert.DeepEqual(t, conf.GetNodeDrainTaintKeys(), []string{"dedicated", "maintenance"})
+	conf, errs = parseConfig(map[string]string{CMSvcNodeDrainTaintKeys: ""}, prev)
+	assert.Assert(t, errs == nil, errs)
+	assert.Assert(t, conf.GetNodeDrainTaintKeys() == nil)
+}
+
+func TestParseConfigMapNodeCapacityAdjustments(t *testing.T) {
+	prev := CreateDefaultConfig()
+	assert.Assert(t, prev.GetNodeCapacityAdjustment(map[string]string{"pool": "dev"}) == nil)
+
+	conf, errs := parseConfig(map[string]string{
+		CMSvcNodeCapacityAdjustmentPrefix + "dev": `{"nodeSelector": "pool=dev", "overcommit": {"cpu": 0.5}}`,
+		CMSvcNodeCapacityAdjustmentPrefix + "all": "reserved:\n  memory: 1Gi\n",
+	}, prev)
+	assert.Assert(t, conf != nil, "conf was nil")
+	assert.Assert(t, errs == nil, errs)
+	// adjustments are matched in name order, an empty selector matches all nodes
+	adjustment := conf.GetNodeCapacityAdjustment(map[string]string{"pool": "dev"})
+	assert.Assert(t, adjustment != nil)
+	assert.Equal(t, adjustment.Reserved.Memory().String(), "1Gi")
+	conf.NodeCapacityAdjustments["all"].NodeSelector = "pool=batch"
+	adjustment = conf.GetNodeCapacityAdjustment(map[string]string{"pool": "dev"})
+	assert.Assert(t, adjustment != nil)
+	assert.DeepEqual(t, adjustment.Overcommit, map[string]float64{"cpu": 0.5})
+	adjustment.Overcommit["cpu"] = 1
+	assert.Equal(t, conf.NodeCapacityAdjustments["dev"].Overcommit["cpu"], 0.5, "adjustment should be a copy")
+
+	conf, errs = parseConfig(map[string]string{
+		CMSvcNodeCapacityAdjustmentPrefix + "dev": `{"nodeSelector": "pool in dev", "reserved": {"cpu": "-1"}, "overcommit": {"cpu": 0, "memory": 1.5}}`,
+	}, prev)
+	assert.Assert(t, conf == nil, "conf exists")
+	assert.Equal(t, 1, len(errs), "wrong error count")
+	assert.ErrorContains(t, errs[0], "invalid node selector")
+	assert.ErrorContains(t, errs[0], "reservation of cpu must not be negative")
+	assert.ErrorContains(t, errs[0], "overcommit ratio of cpu must be above 0 and at most 1, got 0")
+	assert.ErrorContains(t, errs[0], "overcommit ratio of memory must be above 0 and at most 1, got 1.5")
+}
+
+func TestParseConfigMapResourceAccounting(t *testing.T) {
+	prev := CreateDefaultConfig()
+	assert.Equal(t, prev.GetResourceAccountingPolicy("root.a"), ResourceAccountingRequests)
+	assert.Assert(t, prev.GetResourceAccountingFloor() == nil)
+
+	conf, errs := parseConfig(map[string]string{
+		CMSvcResourceAccountingPolicy:                 ResourceAccountingMax,
+		CMSvcQueueResourceAccountingPrefix + "root.a": ResourceAccountingLimits,
+		CMSvcResourceAccountingFloor:                  `{"cpu": "100m", "memory": "128Mi"}`,
+	}, prev)
+	assert.Assert(t, conf != nil, "conf was nil")
+	assert.Assert(t, errs == nil, errs)
+	assert.Equal(t, conf.GetResourceAccountingPolicy("root.a.b"), ResourceAccountingLimits)
+	assert.Equal(t, conf.GetResourceAccountingPolicy("root.b"), ResourceAccountingMax)
+	assert.Equal(t, conf.GetResourceAccountingPolicy(""), ResourceAccountingMax)
+	floor := conf.GetResourceAccountingFloor()
+	assert.Equal(t, floor.Cpu().MilliValue(), int64(100))
+	assert.Equal(t, floor.Memory().String(), "128Mi")
+
+	conf, errs = parseConfig(map[string]string{
+		CMSvcResourceAccountingPolicy:                 "all",
+		CMSvcQueueResourceAccountingPrefix + "root.a": "none",
+	}, prev)
+	assert.Assert(t, conf == nil, "conf exists")
+	assert.Equal(t, 2, len(errs), "wrong error count")
+
+	conf, errs = parseConfig(map[string]string{CMSvcResourceAccountingFloor: `{"cpu": "x"}`}, prev)
+	assert.Assert(t, conf == nil, "conf exists")
+	assert.Equal(t, 1, len(errs), "wrong error count")
+	assert.ErrorContains(t, errs[0], CMSvcResourceAccountingFloor, "wrong error type")
+}
+
+func TestParseConfigMapResourceMappings(t *testing.T) {
+	prev := CreateDefaultConfig()
+	_, ok := prev.GetResourceMapping("nvidia.com/gpu")
+	assert.Assert(t, !ok, "no mapping expected by default")
+
+	conf, errs := parseConfig(map[string]string{
+		CMSvcResourceMappings: "example.com/*:\n  name: device\nexample.com/fpga-*:\n  drop: true\nexample.com/fpga-x:\n  scale: 2\n",
+	}, prev)
+	assert.Assert(t, conf != nil, "conf was nil")
+	assert.Assert(t, errs == nil, errs)
+	mapping, ok := conf.GetResourceMapping("example.com/gpu")
+	assert.Assert(t, ok)
+	assert.Equal(t, mapping, ResourceMapping{Name: "device"})
+	// the longest prefix wins, an exact name before any prefix
+	mapping, ok = conf.GetResourceMapping("example.com/fpga-y")
+	assert.Assert(t, ok)
+	assert.Equal(t, mapping, ResourceMapping{Drop: true})
+	mapping, ok = conf.GetResourceMapping("example.com/fpga-x")
+	assert.Assert(t, ok)
+	assert.Equal(t, mapping, ResourceMapping{Scale: 2})
+	_, ok = conf.GetResourceMapping("memory")
+	assert.Assert(t, !ok)
+
+	// an empty value removes the mappings
+	conf, errs = parseConfig(map[string]string{CMSvcResourceMappings: ""}, conf)
+	assert.Assert(t, errs == nil, errs)
+	assert.Assert(t, conf.ResourceMappings == nil)
+
+	conf, errs = parseConfig(map[string]string{
+		CMSvcResourceMappings: `{"*": {"drop": true}, "memory": {"drop": true, "scale": 2}, "cpu": {"scale": -1}}`,
+	}, prev)
+	assert.Assert(t, conf == nil, "conf exists")
+	assert.Equal(t, 1, len(errs), "wrong error count")
+	assert.ErrorContains(t, errs[0], "invalid resource name")
+	assert.ErrorContains(t, errs[0], "resource memory is dropped and cannot be renamed or scaled")
+	assert.ErrorContains(t, errs[0], "scale of resource cpu must not be negative")
+}
+
+func TestParseConfigMapPreemptionPDBPolicy(t *testing.T) {
+	prev := CreateDefaultConfig()
+	assert.Equal(t, prev.GetPreemptionPDBPolicy(), PreemptionPDBPolicyIgnore)
+
+	conf, errs := parseConfig(map[string]string{CMSvcPreemptionPDBPolicy: PreemptionPDBPolicyPrefer}, prev)
+	assert.Assert(t, conf != nil, "conf was nil")
+	assert.Assert(t, errs == nil, errs)
+	assert.Equal(t, conf.GetPreemptionPDBPolicy(), PreemptionPDBPolicyPrefer)
+
+	conf, errs = parseConfig(map[string]string{CMSvcPreemptionPDBPolicy: "never"}, prev)
+	assert.Assert(t, conf == nil, "conf exists")
+	assert.Equal(t, 1, len(errs), "wrong error count")
+	assert.ErrorContains(t, errs[0], CMSvcPreemptionPDBPolicy, "wrong error type")
+}
+
+func TestParseConfigMapPredicates(t *testing.T) {
+	prev := CreateDefaultConfig()
+	predConf := prev.GetPredicatesConf()
+	assert.Equal(t, predConf.Reservation, DefaultPredicatesReservation)
+	assert.Equal(t, predConf.Allocation, DefaultPredicatesAllocation)
+	assert.Equal(t, predConf.Plugins, "")
+	assert.Equal(t, predConf.CacheSize, DefaultPredicatesCacheSize)
+	assert.Assert(t, predConf.PluginArgs == nil)
+
+	conf, errs := parseConfig(map[string]string{
+		CMSvcPredicatesReservation:                           "NodeName",
+		CMSvcPredicatesAllocation:                            "*,-VolumeZone",
+		CMSvcPredicatesPlugins:                               "Custom",
+		CMSvcPredicatesCacheSize:                             "0",
+		CMSvcPredicatesPluginArgsPrefix + "NodeResourcesFit": "ignoredResources: [example.com/foo]",
+	}, prev)
+	assert.Assert(t, conf != nil, "conf was nil")
+	assert.Assert(t, errs == nil, errs)
+	predConf = conf.GetPredicatesConf()
+	assert.Equal(t, predConf.Reservation, "NodeName")
+	assert.Equal(t, predConf.Allocation, "*,-VolumeZone")
+	assert.Equal(t, predConf.Plugins, "Custom")
+	assert.Equal(t, predConf.CacheSize, 0)
+	assert.DeepEqual(t, predConf.PluginArgs, map[string]string{"NodeResourcesFit": "ignoredResources: [example.com/foo]"})
+	assert.Assert(t, !predConf.Equal(prev.GetPredicatesConf()))
+	assert.Assert(t, predConf.Equal(conf.Clone().GetPredicatesConf()))
+
+	// the returned conf is a copy
+	predConf.PluginArgs["NodeResourcesFit"] = "changed"
+	assert.Equal(t, conf.GetPredicatesConf().PluginArgs["NodeResourcesFit"], "ignoredResources: [example.com/foo]")
+
+	conf, errs = parseConfig(map[string]string{
+		CMSvcPredicatesReservation: "NodeName,-",
+		CMSvcPredicatesAllocation:  "-*",
+		CMSvcPredicatesPlugins:     "*",
+		CMSvcPredicatesCacheSize:   "-1",
+	}, prev)
+	assert.Assert(t, conf == nil, "conf exists")
+	assert.Equal(t, 4, len(errs), "wrong error count")
+	assert.ErrorContains(t, errs[0], CMSvcPredicatesReservation)
+	assert.ErrorContains(t, errs[1], CMSvcPredicatesAllocation)
+	assert.ErrorContains(t, errs[2], CMSvcPredicatesPlugins)
+	assert.ErrorContains(t, errs[3], CMSvcPredicatesCacheSize)
+}
+
+func TestParsePredicatePluginList(t *testing.T) {
+	testCases := []struct {
+		name     string
+		list     string
+		expected map[string]bool
+		err      string
+	}{
+		{"empty", "", map[string]bool{}, ""},
+		{"wildcard", "*", map[string]bool{"*": true}, ""},
+		{"names", " NodeName, TaintToleration ,", map[string]bool{"NodeName": true, "TaintToleration": true}, ""},
+		{"disabled", "*,-VolumeZone", map[string]bool{"*": true, "VolumeZone": false}, ""},
+		{"missing name", "NodeName,-", nil, "invalid plugin \"-\""},
+		{"disabled wildcard", "-*", nil, "invalid plugin \"-*\""},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			result, err := ParsePredicatePluginList(tc.list)
+			if tc.err != "" {
+				assert.ErrorContains(t, err, tc.err)
+				return
+			}
+			assert.NilError(t, err)
+			assert.DeepEqual(t, result, tc.expected)
+		})
+	}
+}
+
+func TestParseConfigMapPartitions(t *testing.T) {
+	prev := CreateDefaultConfig()
+	assert.Equal(t, constants.DefaultPartition, prev.GetNodePartition(map[string]string{"pool": "gpu"}))
+	assert.Equal(t, constants.DefaultPartition, prev.GetAppPartition("ml", nil))
+
+	conf, errs := parseConfig(map[string]string{
+		CMSvcPartitionNodeSelectorPrefix + "gpu":   "pool=gpu",
+		CMSvcPartitionNodeSelectorPrefix + "batch": "pool in (batch,spot)",
+		CMSvcPartitionNamespacesPrefix + "gpu":     "ml, training",
+		CMSvcPartitionAppSelectorPrefix + "batch":  "tier=batch",
+	}, prev)
+	assert.Assert(t, conf != nil, "conf was nil")
+	assert.Assert(t, errs == nil, errs)
+	assert.Equal(t, "gpu", conf.GetNodePartition(map[string]string{"pool": "gpu"}))
+	assert.Equal(t, "batch", conf.GetNodePartition(map[string]string{"pool": "spot"}))
+	assert.Equal(t, constants.DefaultPartition, conf.GetNodePartition(map[string]string{"pool": "cpu"}))
+	assert.Equal(t, constants.DefaultPartition, conf.GetNodePartition(nil))
+	// namespace rules are checked before the label selectors
+	assert.Equal(t, "gpu", conf.GetAppPartition("training", map[string]string{"tier": "batch"}))
+	assert.Equal(t, "batch", conf.GetAppPartition("default", map[string]string{"tier": "batch"}))
+	assert.Equal(t, constants.DefaultPartition, conf.GetAppPartition("default", nil))
+
+	conf, errs = parseConfig(map[string]string{CMSvcPartitionNodeSelectorPrefix + "gpu": "pool in gpu"}, prev)
+	assert.Assert(t, conf == nil, "conf exists")
+	assert.Equal(t, 1, len(errs), "wrong error count")
+	assert.ErrorContains(t, errs[0], CMSvcPartitionNodeSelectorPrefix+"gpu", "wrong error type")
+}
+
+func TestParseConfigMapPlaceholderTemplate(t *testing.T) {
+	prev := CreateDefaultConfig()
+	conf, errs := parseConfig(map[string]string{
+		CMSvcPlaceholderTemplate: `
+metadata:
+  labels:
+    cost-center: analytics
+spec:
+  runtimeClassName: gvisor
+  containers:
+  - name: placeholder
+`,
+		CMSvcPlaceholderTemplatePrefix + "json": `{"spec": {"priorityClassName": "low"}}`,
+		CMSvcPlaceholderTemplatePrefix + "none": "",
+	}, prev)
+	assert.Assert(t, conf != nil, "conf was nil")
+	assert.Assert(t, errs == nil, errs)
+	tmpl := conf.GetPlaceholderTemplate("default")
+	assert.Equal(t, "analytics", tmpl.Labels["cost-center"])
+	assert.Equal(t, "gvisor", *tmpl.Spec.RuntimeClassName)
+	assert.Equal(t, "placeholder", tmpl.Spec.Containers[0].Name)
+	tmpl = conf.GetPlaceholderTemplate("json")
+	assert.Equal(t, "low", tmpl.Spec.PriorityClassName)
+	assert.Assert(t, tmpl.Spec.RuntimeClassName == nil, "namespace template must not be merged with the global one")
+	assert.Assert(t, conf.GetPlaceholderTemplate("none") == nil, "empty namespace template should disable the global one")
+
+	// returned templates and clones must not share state
+	conf.GetPlaceholderTemplate("default").Labels["cost-center"] = "changed"
+	clone := conf.Clone()
+	clone.PlaceholderTemplate.Labels["cost-center"] = "changed"
+	clone.NamespacePlaceholders["json"].Spec.PriorityClassName = "changed"
+	assert.Equal(t, "analytics", conf.GetPlaceholderTemplate("default").Labels["cost-center"])
+	assert.Equal(t, "low", conf.GetPlaceholderTemplate("json").Spec.PriorityClassName)
+}
+
+func TestParseConfigMapInvalidPlaceholderTemplate(t *testing.T) {
+	testCases := []struct {
+		name     string
+		template string
+		errMsg   string
+	}{
+		{"syntax", "spec: [", "yaml"},
+		{"restartPolicy", "spec:\n  restartPolicy: Always", "restartPolicy must be Never"},
+		{"schedulerName", "spec:\n  schedulerName: default-scheduler", "schedulerName must be yunikorn"},
+		{"root user", "spec:\n  securityContext:\n    runAsUser: 0", "must not run as root"},
+		{"root container", "spec:\n  containers:\n  - securityContext:\n      runAsUser: 0", "must not run as root"},
+		{"containers", "spec:\n  containers:\n  - name: a\n  - name: b", "at most one container"},
+		{"init containers", "spec:\n  initContainers:\n  - name: a", "init and ephemeral containers"},
+		{"container name", "spec:\n  containers:\n  - name: Bad_Name", "invalid container name"},
+		{"resources", "spec:\n  containers:\n  - resources:\n      requests:\n        cpu: 1", "derived from the task group"},
+		{"grace period", "spec:\n  terminationGracePeriodSeconds: -1", "must not be negative"},
+		{"runtimeClassName", "spec:\n  runtimeClassName: Bad_Name", "invalid runtimeClassName"},
+		{"label key", "metadata:\n  labels:\n    bad key: x", "invalid label key"},
+		{"label value", "metadata:\n  labels:\n    key: bad value", "invalid label value"},
+		{"reserved label", "metadata:\n  labels:\n    " + constants.CanonicalLabelQueueName + ": root", "is reserved"},
+		{"reserved annotation", "metadata:\n  annotations:\n    " + constants.AnnotationTaskGroupName + ": tg", "is reserved"},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			prev := CreateDefaultConfig()
+			conf, errs := parseConfig(map[string]string{CMSvcPlaceholderTemplate: tc.template}, prev)
+			assert.Assert(t, conf == nil, "conf exists")
+			assert.Equal(t, 1, len(errs), "wrong error count")
+			assert.ErrorContains(t, errs[0], tc.errMsg, "wrong error type")
+			assert.ErrorContains(t, errs[0], CMSvcPlaceholderTemplate, "key missing from error")
+
+			conf, errs = parseConfig(map[string]string{CMSvcPlaceholderTemplatePrefix + "ns": tc.template}, prev)
+			assert.Assert(t, conf == nil, "conf exists")
+			assert.Equal(t, 1, len(errs), "wrong error count")
+			assert.ErrorContains(t, errs[0], tc.errMsg, "wrong error type")
+		})
+	}
+}
+
+func TestParseConfigMapPlaceholderCreate(t *testing.T) {
+	prev := CreateDefaultConfig()
+	conf, errs := parseConfig(map[string]string{
+		CMSvcPlaceholderCreateParallelism: "20",
+		CMSvcPlaceholderCreateQPS:         "5",
+		CMSvcPlaceholderCreateBurst:       "10",
+		CMSvcPlaceholderCreateRetries:     "0",
+		CMSvcPlaceholderCreateFailPolicy:  PlaceholderCreatePolicyRetry,
+		CMSvcPlaceholderCreateRetryDelay:  "1m",
+		CMSvcPlaceholderCreateMaxAttempts: "5",
+	}, prev)
+	assert.Assert(t, conf != nil, "conf was nil")
+	assert.Assert(t, errs == nil, errs)
+	assert.DeepEqual(t, conf.PlaceholderCreate, PlaceholderCreateConf{
+		Parallelism:   20,
+		QPS:           5,
+		Burst:         10,
+		Retries:       0,
+		FailurePolicy: PlaceholderCreatePolicyRetry,
+		RetryInterval: time.Minute,
+		MaxAttempts:   5,
+	})
+
+	testCases := []struct {
+		name  string
+		value string
+	}{
+		{CMSvcPlaceholderCreateParallelism, "0"},
+		{CMSvcPlaceholderCreateQPS, "0"},
+		{CMSvcPlaceholderCreateBurst, "-1"},
+		{CMSvcPlaceholderCreateRetries, "-1"},
+		{CMSvcPlaceholderCreateMaxAttempts, "0"},
+		{CMSvcPlaceholderCreateFailPolicy, "Ignore"},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			conf, errs := parseConfig(map[string]string{tc.name: tc.value}, prev)
+			assert.Assert(t, conf == nil, "conf exists")
+			assert.Equal(t, 1, len(errs), "wrong error count")
+			assert.ErrorContains(t, errs[0], tc.name, "wrong error type")
+		})
+	}
+}
+
 // get a configuration value by field name
 func getConfValue(t *testing.T, conf *SchedulerConf, name string) interface{} {
 	val := reflect.ValueOf(conf).Elem().FieldByName(name)
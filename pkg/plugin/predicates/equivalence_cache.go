@@ -0,0 +1,252 @@
+/*
+ Licensed to the Apache Software Foundation (ASF) under one
+ or more contributor license agreements.  See the NOTICE file
+ distributed with this work for additional information
+ regarding copyright ownership.  The ASF licenses this file
+ to you under the Apache License, Version 2.0 (the
+ "License"); you may not use this file except in compliance
+ with the License.  You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package predicates
+
+import (
+	"encoding/json"
+	"hash/fnv"
+
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/kubernetes/pkg/scheduler/framework"
+
+	"github.com/apache/yunikorn-k8shim/pkg/locking"
+)
+
+// topologyGenerationLister is implemented by shared listers that track the changes which can affect the predicate
+// results of more than one node, see SchedulerCache.TopologyGeneration.
+type topologyGenerationLister interface {
+	TopologyGeneration() int64
+}
+
+// equivalenceCache caches failed predicate results for pods with the same scheduling relevant spec, an equivalence
+// class. A result is only valid for the generation of the node it was calculated for and the topology generation
+// of the cluster: any change to the node or to the topology invalidates it.
+// Only failures are cached: a pod that fits is checked again before it is allocated.
+type equivalenceCache struct {
+	nodes      map[string]*nodeEquivalenceCache
+	hashes     map[*v1.Pod]podHash // hashes of recently checked pods, cached pods are never modified
+	generation int64               // topology generation the cached results are valid for
+	size       int                 // maximum number of results cached per node
+	lister     topologyGenerationLister
+	locking.Mutex
+}
+
+type nodeEquivalenceCache struct {
+	generation int64 // node generation the cached results are valid for
+	results    map[equivalenceKey]equivalenceResult
+}
+
+// maximum number of pod hashes remembered, a pod is normally checked against many nodes before the next pod
+const maxPodHashes = 1024
+
+type podHash struct {
+	hash      [16]byte
+	cacheable bool
+}
+
+type equivalenceKey struct {
+	hash     [16]byte
+	allocate bool
+}
+
+type equivalenceResult struct {
+	plugin  string
+	message string
+}
+
+// newEquivalenceCache creates a cache storing up to size results per node. Returns nil, which disables caching, if
+// the size is not positive or the shared lister does not track the topology generation.
+func newEquivalenceCache(handle framework.Handle, size int) *equivalenceCache {
+	if size <= 0 || handle == nil {
+		return nil
+	}
+	lister, ok := handle.SnapshotSharedLister().(topologyGenerationLister)
+	if !ok {
+		return nil
+	}
+	return &equivalenceCache{
+		nodes:      make(map[string]*nodeEquivalenceCache),
+		hashes:     make(map[*v1.Pod]podHash),
+		generation: lister.TopologyGeneration(),
+		size:       size,
+		lister:     lister,
+	}
+}
+
+// key returns the key of the equivalence class of the pod, false if the results for the pod cannot be cached
+func (c *equivalenceCache) key(pod *v1.Pod, allocate bool) (equivalenceKey, bool) {
+	if c == nil || pod == nil {
+		return equivalenceKey{}, false
+	}
+	c.Lock()
+	ph, ok := c.hashes[pod]
+	c.Unlock()
+	if !ok {
+		ph.hash, ph.cacheable = podEquivalenceHash(pod)
+		c.Lock()
+		if len(c.hashes) >= maxPodHashes {
+			clear(c.hashes)
+		}
+		c.hashes[pod] = ph
+		c.Unlock()
+	}
+	return equivalenceKey{hash: ph.hash, allocate: allocate}, ph.cacheable
+}
+
+// get returns the cached failure for the key on the node, and the topology generation the lookup was made for
+func (c *equivalenceCache) get(key equivalenceKey, node *framework.NodeInfo) (equivalenceResult, int64, bool) {
+	c.Lock()
+	defer c.Unlock()
+	topology := c.lister.TopologyGeneration()
+	results := c.nodeResults(node.Node().Name, node.Generation, topology)
+	result, ok := results[key]
+	return result, topology, ok
+}
+
+// add stores the failure for the key on the node, using the generations from before the predicates were run
+func (c *equivalenceCache) add(key equivalenceKey, nodeName string, nodeGeneration, topology int64, result equivalenceResult) {
+	c.Lock()
+	defer c.Unlock()
+	results := c.nodeResults(nodeName, nodeGeneration, topology)
+	if results == nil {
+		// the node or topology changed while the predicates ran
+		return
+	}
+	if len(results) >= c.size {
+		clear(results)
+	}
+	results[key] = result
+}
+
+// nodeResults returns the results for the node, dropping all results that are older than the given generations.
+// Returns nil if the cache holds results for newer generations.
+// Must be called while holding the cache lock.
+func (c *equivalenceCache) nodeResults(nodeName string, nodeGeneration, topology int64) map[equivalenceKey]equivalenceResult {
+	if topology != c.generation {
+		if topology < c.generation {
+			return nil
+		}
+		c.nodes = make(map[string]*nodeEquivalenceCache)
+		c.generation = topology
+	}
+	entry, ok := c.nodes[nodeName]
+	if !ok || entry.generation < nodeGeneration {
+		entry = &nodeEquivalenceCache{
+			generation: nodeGeneration,
+			results:    make(map[equivalenceKey]equivalenceResult),
+		}
+		c.nodes[nodeName] = entry
+	}
+	if entry.generation > nodeGeneration {
+		return nil
+	}
+	return entry.results
+}
+
+// podShape contains the parts of a pod that the predicates use when checking a single node
+type podShape struct {
+	Namespace      string                   `json:"ns,omitempty"`
+	Labels         map[string]string        `json:"labels,omitempty"`
+	NodeName       string                   `json:"nodeName,omitempty"`
+	NodeSelector   map[string]string        `json:"nodeSelector,omitempty"`
+	NodeAffinity   *v1.NodeAffinity         `json:"nodeAffinity,omitempty"`
+	Tolerations    []v1.Toleration          `json:"tolerations,omitempty"`
+	Containers     []containerShape         `json:"containers,omitempty"`
+	InitContainers []containerShape         `json:"initContainers,omitempty"`
+	Overhead       v1.ResourceList          `json:"overhead,omitempty"`
+	Resources      *v1.ResourceRequirements `json:"resources,omitempty"`
+	Volumes        []v1.VolumeSource        `json:"volumes,omitempty"`
+}
+
+type containerShape struct {
+	Resources     v1.ResourceRequirements    `json:"resources"`
+	Ports         []v1.ContainerPort         `json:"ports,omitempty"`
+	RestartPolicy *v1.ContainerRestartPolicy `json:"restartPolicy,omitempty"`
+}
+
+// podEquivalenceHash returns the hash of the scheduling relevant parts of the pod. Returns false for pods that have
+// predicate results which depend on the pods on other nodes, like pods with pod (anti-)affinity or hard topology
+// spread constraints, or on objects outside the scheduler cache, like persistent volumes.
+func podEquivalenceHash(pod *v1.Pod) ([16]byte, bool) {
+	var hash [16]byte
+	if !isPodEquivalenceCacheable(pod) {
+		return hash, false
+	}
+	shape := podShape{
+		Namespace:      pod.Namespace,
+		Labels:         pod.Labels,
+		NodeName:       pod.Spec.NodeName,
+		NodeSelector:   pod.Spec.NodeSelector,
+		Tolerations:    pod.Spec.Tolerations,
+		Containers:     containerShapes(pod.Spec.Containers),
+		InitContainers: containerShapes(pod.Spec.InitContainers),
+		Overhead:       pod.Spec.Overhead,
+		Resources:      pod.Spec.Resources,
+	}
+	if pod.Spec.Affinity != nil {
+		shape.NodeAffinity = pod.Spec.Affinity.NodeAffinity
+	}
+	for _, volume := range pod.Spec.Volumes {
+		shape.Volumes = append(shape.Volumes, volume.VolumeSource)
+	}
+	data, err := json.Marshal(shape)
+	if err != nil {
+		return hash, false
+	}
+	h := fnv.New128a()
+	h.Write(data) //nolint:errcheck // writing to a hash never fails
+	h.Sum(hash[:0])
+	return hash, true
+}
+
+func isPodEquivalenceCacheable(pod *v1.Pod) bool {
+	if pod == nil {
+		return false
+	}
+	if affinity := pod.Spec.Affinity; affinity != nil && (affinity.PodAffinity != nil || affinity.PodAntiAffinity != nil) {
+		return false
+	}
+	for _, constraint := range pod.Spec.TopologySpreadConstraints {
+		if constraint.WhenUnsatisfiable == v1.DoNotSchedule {
+			return false
+		}
+	}
+	for _, volume := range pod.Spec.Volumes {
+		if volume.PersistentVolumeClaim != nil || volume.Ephemeral != nil || volume.CSI != nil {
+			return false
+		}
+	}
+	// dynamic resources are tracked outside the cache, allocated resources of a resized pod are part of the status
+	return len(pod.Spec.ResourceClaims) == 0 && len(pod.Status.ContainerStatuses) == 0
+}
+
+func containerShapes(containers []v1.Container) []containerShape {
+	if len(containers) == 0 {
+		return nil
+	}
+	shapes := make([]containerShape, len(containers))
+	for i, container := range containers {
+		shapes[i] = containerShape{
+			Resources:     container.Resources,
+			Ports:         container.Ports,
+			RestartPolicy: container.RestartPolicy,
+		}
+	}
+	return shapes
+}
@@ -0,0 +1,294 @@
+/*
+ Licensed to the Apache Software Foundation (ASF) under one
+ or more contributor license agreements.  See the NOTICE file
+ distributed with this work for additional information
+ regarding copyright ownership.  The ASF licenses this file
+ to you under the Apache License, Version 2.0 (the
+ "License"); you may not use this file except in compliance
+ with the License.  You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package predicates
+
+import (
+	"testing"
+
+	"gotest.tools/v3/assert"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/kubernetes/pkg/scheduler/framework"
+	"k8s.io/kubernetes/pkg/scheduler/framework/plugins/names"
+
+	"github.com/apache/yunikorn-k8shim/pkg/plugin/support"
+)
+
+type topologyListerMock struct {
+	*sharedListerMock
+	generation int64
+}
+
+func (s *topologyListerMock) TopologyGeneration() int64 {
+	return s.generation
+}
+
+func newCachePod(name string, milliCPU int64) *v1.Pod {
+	pod := newResourcePod(framework.Resource{MilliCPU: milliCPU})
+	pod.Name = name
+	pod.UID = types.UID("uid-" + name)
+	pod.Namespace = "default"
+	pod.Labels = map[string]string{"app": "batch"}
+	pod.Spec.Containers[0].Env = []v1.EnvVar{{Name: "POD", Value: name}}
+	return pod
+}
+
+func newCacheNode(name string, milliCPU int64) *framework.NodeInfo {
+	node := framework.NewNodeInfo()
+	node.SetNode(&v1.Node{
+		ObjectMeta: metav1.ObjectMeta{Name: name},
+		Status: v1.NodeStatus{
+			Allocatable: v1.ResourceList{
+				v1.ResourceCPU:  *resource.NewMilliQuantity(milliCPU, resource.DecimalSI),
+				v1.ResourcePods: resource.MustParse("10"),
+			},
+		},
+	})
+	return node
+}
+
+func TestPodEquivalenceHash(t *testing.T) {
+	pod := newCachePod("pod-1", 500)
+	hash, ok := podEquivalenceHash(pod)
+	assert.Assert(t, ok, "pod should be cacheable")
+
+	// name, UID and fields not used by the predicates do not change the hash
+	other, ok := podEquivalenceHash(newCachePod("pod-2", 500))
+	assert.Assert(t, ok, "pod should be cacheable")
+	assert.Equal(t, hash, other)
+
+	changes := map[string]func(pod *v1.Pod){
+		"resources":     func(pod *v1.Pod) { pod.Spec.Containers[0].Resources.Requests[v1.ResourceCPU] = resource.MustParse("1") },
+		"labels":        func(pod *v1.Pod) { pod.Labels["app"] = "service" },
+		"namespace":     func(pod *v1.Pod) { pod.Namespace = "other" },
+		"node selector": func(pod *v1.Pod) { pod.Spec.NodeSelector = map[string]string{"zone": "a"} },
+		"tolerations": func(pod *v1.Pod) {
+			pod.Spec.Tolerations = []v1.Toleration{{Key: "gpu", Operator: v1.TolerationOpExists}}
+		},
+		"ports":     func(pod *v1.Pod) { pod.Spec.Containers[0].Ports = []v1.ContainerPort{{HostPort: 8080}} },
+		"node name": func(pod *v1.Pod) { pod.Spec.NodeName = "node-1" },
+	}
+	for name, change := range changes {
+		t.Run(name, func(t *testing.T) {
+			pod := newCachePod("pod", 500)
+			change(pod)
+			changed, ok := podEquivalenceHash(pod)
+			assert.Assert(t, ok, "pod should be cacheable")
+			assert.Assert(t, changed != hash, "hash should have changed")
+		})
+	}
+
+	notCacheable := map[string]func(pod *v1.Pod){
+		"pod affinity": func(pod *v1.Pod) { pod.Spec.Affinity = &v1.Affinity{PodAffinity: &v1.PodAffinity{}} },
+		"pod anti-affinity": func(pod *v1.Pod) {
+			pod.Spec.Affinity = &v1.Affinity{PodAntiAffinity: &v1.PodAntiAffinity{}}
+		},
+		"hard topology spread": func(pod *v1.Pod) {
+			pod.Spec.TopologySpreadConstraints = []v1.TopologySpreadConstraint{{WhenUnsatisfiable: v1.DoNotSchedule}}
+		},
+		"persistent volume": func(pod *v1.Pod) {
+			pod.Spec.Volumes = []v1.Volume{{VolumeSource: v1.VolumeSource{PersistentVolumeClaim: &v1.PersistentVolumeClaimVolumeSource{}}}}
+		},
+		"resource claims": func(pod *v1.Pod) { pod.Spec.ResourceClaims = []v1.PodResourceClaim{{Name: "claim"}} },
+		"container status": func(pod *v1.Pod) {
+			pod.Status.ContainerStatuses = []v1.ContainerStatus{{Name: "container"}}
+		},
+	}
+	for name, change := range notCacheable {
+		t.Run(name, func(t *testing.T) {
+			pod := newCachePod("pod", 500)
+			change(pod)
+			_, ok := podEquivalenceHash(pod)
+			assert.Assert(t, !ok, "pod should not be cacheable")
+		})
+	}
+	_, ok = podEquivalenceHash(nil)
+	assert.Assert(t, !ok, "nil pod should not be cacheable")
+
+	// soft topology spread constraints are not used by the filters
+	pod = newCachePod("pod", 500)
+	pod.Spec.TopologySpreadConstraints = []v1.TopologySpreadConstraint{{WhenUnsatisfiable: v1.ScheduleAnyway}}
+	_, ok = podEquivalenceHash(pod)
+	assert.Assert(t, ok, "pod should be cacheable")
+}
+
+func TestNewEquivalenceCache(t *testing.T) {
+	clientSet := clientSet()
+	handle := support.NewFrameworkHandle(lister(), informerFactory(clientSet), clientSet)
+	assert.Assert(t, newEquivalenceCache(handle, 10) == nil, "lister without topology generation should disable the cache")
+
+	topologyHandle := support.NewFrameworkHandle(&topologyListerMock{sharedListerMock: lister()}, informerFactory(clientSet), clientSet)
+	assert.Assert(t, newEquivalenceCache(topologyHandle, 0) == nil, "zero size should disable the cache")
+	assert.Assert(t, newEquivalenceCache(topologyHandle, 10) != nil, "cache should have been created")
+
+	// cache is not used with out-of-tree plugins or hard default constraints
+	assert.NilError(t, RegisterPlugin(rejectingPluginName, newRejectingPlugin))
+	t.Cleanup(func() {
+		unregisterPlugin(rejectingPluginName)
+	})
+	pc := defaultPredicatesConf()
+	pm, err := NewPredicateManagerFromConfig(topologyHandle, pc)
+	assert.NilError(t, err)
+	assert.Assert(t, pm.(*predicateManagerImpl).equivalence != nil, "cache should have been created")
+	pc.Plugins = rejectingPluginName
+	pm, err = NewPredicateManagerFromConfig(topologyHandle, pc)
+	assert.NilError(t, err)
+	assert.Assert(t, pm.(*predicateManagerImpl).equivalence == nil, "cache should be disabled for out-of-tree plugins")
+	pc = defaultPredicatesConf()
+	pc.PluginArgs = map[string]string{
+		names.PodTopologySpread: "defaultingType: List\ndefaultConstraints: [{maxSkew: 1, topologyKey: zone, whenUnsatisfiable: DoNotSchedule}]",
+	}
+	pm, err = NewPredicateManagerFromConfig(topologyHandle, pc)
+	assert.NilError(t, err)
+	assert.Assert(t, pm.(*predicateManagerImpl).equivalence == nil, "cache should be disabled for hard default constraints")
+}
+
+func TestPredicatesEquivalenceCache(t *testing.T) {
+	clientSet := clientSet()
+	topologyLister := &topologyListerMock{sharedListerMock: lister()}
+	handle := support.NewFrameworkHandle(topologyLister, informerFactory(clientSet), clientSet)
+	pm, err := NewPredicateManagerFromConfig(handle, defaultPredicatesConf())
+	assert.NilError(t, err)
+	impl, ok := pm.(*predicateManagerImpl)
+	assert.Assert(t, ok)
+	assert.Assert(t, impl.equivalence != nil, "cache should have been created")
+
+	node := newCacheNode("node-1", 1000)
+	plugin, err := pm.Predicates(newCachePod("pod-1", 2000), node, true)
+	assert.ErrorContains(t, err, "Insufficient cpu")
+	assert.Equal(t, plugin, names.NodeResourcesFit)
+	assert.Equal(t, len(impl.equivalence.nodes["node-1"].results), 1)
+
+	// change the node without updating the generation: the cached failure is returned for an identical pod
+	node.Allocatable.MilliCPU = 4000
+	plugin, err = pm.Predicates(newCachePod("pod-2", 2000), node, true)
+	assert.ErrorContains(t, err, "Insufficient cpu")
+	assert.Equal(t, plugin, names.NodeResourcesFit)
+	// reservation results are cached separately
+	_, err = pm.Predicates(newCachePod("pod-2", 2000), node, false)
+	assert.NilError(t, err)
+	// a different pod shape is not affected
+	_, err = pm.Predicates(newCachePod("pod-3", 3000), node, true)
+	assert.NilError(t, err)
+
+	// node update changes the generation
+	setNodeCPU(node, 4000)
+	_, err = pm.Predicates(newCachePod("pod-2", 2000), node, true)
+	assert.NilError(t, err)
+	// success is not cached
+	assert.Equal(t, len(impl.equivalence.nodes["node-1"].results), 0)
+
+	// topology change removes all results
+	setNodeCPU(node, 1000)
+	_, err = pm.Predicates(newCachePod("pod-1", 2000), node, true)
+	assert.ErrorContains(t, err, "Insufficient cpu")
+	assert.Equal(t, len(impl.equivalence.nodes["node-1"].results), 1)
+	topologyLister.generation++
+	node.Allocatable.MilliCPU = 4000
+	_, err = pm.Predicates(newCachePod("pod-1", 2000), node, true)
+	assert.NilError(t, err)
+
+	// pods that cannot be cached always run the predicates
+	setNodeCPU(node, 1000)
+	pod := newCachePod("pod-4", 2000)
+	pod.Spec.Affinity = &v1.Affinity{PodAffinity: &v1.PodAffinity{}}
+	_, err = pm.Predicates(pod, node, true)
+	assert.ErrorContains(t, err, "Insufficient cpu")
+	node.Allocatable.MilliCPU = 4000
+	_, err = pm.Predicates(pod, node, true)
+	assert.NilError(t, err)
+}
+
+func setNodeCPU(nodeInfo *framework.NodeInfo, milliCPU int64) {
+	node := nodeInfo.Node().DeepCopy()
+	node.Status.Allocatable[v1.ResourceCPU] = *resource.NewMilliQuantity(milliCPU, resource.DecimalSI)
+	nodeInfo.SetNode(node)
+}
+
+func TestEquivalenceCacheGenerations(t *testing.T) {
+	topologyLister := &topologyListerMock{sharedListerMock: lister(), generation: 5}
+	clientSet := clientSet()
+	cache := newEquivalenceCache(support.NewFrameworkHandle(topologyLister, informerFactory(clientSet), clientSet), 2)
+	assert.Assert(t, cache != nil)
+	node := newCacheNode("node-1", 1000)
+	result := equivalenceResult{plugin: "plugin", message: "failed"}
+	key := func(i byte) equivalenceKey {
+		return equivalenceKey{hash: [16]byte{i}}
+	}
+
+	// stale generations are not stored
+	cache.add(key(1), "node-1", node.Generation, 4, result)
+	assert.Equal(t, len(cache.nodes), 0)
+	cache.add(key(1), "node-1", node.Generation, 5, result)
+	cache.add(key(2), "node-1", node.Generation-1, 5, result)
+	assert.Equal(t, len(cache.nodes["node-1"].results), 1)
+
+	cached, topology, ok := cache.get(key(1), node)
+	assert.Assert(t, ok, "result should have been cached")
+	assert.Equal(t, topology, int64(5))
+	assert.Equal(t, cached, result)
+	_, _, ok = cache.get(key(2), node)
+	assert.Assert(t, !ok, "result should not have been cached")
+
+	// size limit clears the results of the node
+	cache.add(key(2), "node-1", node.Generation, 5, result)
+	assert.Equal(t, len(cache.nodes["node-1"].results), 2)
+	cache.add(key(3), "node-1", node.Generation, 5, result)
+	assert.Equal(t, len(cache.nodes["node-1"].results), 1)
+	_, _, ok = cache.get(key(3), node)
+	assert.Assert(t, ok, "result should have been cached")
+}
+
+func TestEquivalenceCacheKey(t *testing.T) {
+	var nilCache *equivalenceCache
+	_, ok := nilCache.key(newCachePod("pod-1", 500), true)
+	assert.Assert(t, !ok, "disabled cache should not return a key")
+
+	topologyLister := &topologyListerMock{sharedListerMock: lister()}
+	clientSet := clientSet()
+	cache := newEquivalenceCache(support.NewFrameworkHandle(topologyLister, informerFactory(clientSet), clientSet), 2)
+	assert.Assert(t, cache != nil)
+	_, ok = cache.key(nil, true)
+	assert.Assert(t, !ok, "nil pod should not return a key")
+
+	pod := newCachePod("pod-1", 500)
+	allocate, ok := cache.key(pod, true)
+	assert.Assert(t, ok, "pod should be cacheable")
+	reserve, ok := cache.key(pod, false)
+	assert.Assert(t, ok, "pod should be cacheable")
+	assert.Equal(t, allocate.hash, reserve.hash)
+	assert.Assert(t, allocate != reserve, "phases should use different keys")
+	assert.Equal(t, len(cache.hashes), 1)
+
+	// the hash of the pod object is remembered
+	pod.Spec.Containers[0].Resources.Requests[v1.ResourceCPU] = resource.MustParse("1")
+	key, _ := cache.key(pod, true)
+	assert.Equal(t, key, allocate)
+	key, _ = cache.key(pod.DeepCopy(), true)
+	assert.Assert(t, key != allocate, "updated pod should have a different key")
+	assert.Equal(t, len(cache.hashes), 2)
+
+	// remembered hashes are bounded
+	for i := 0; i < maxPodHashes; i++ {
+		cache.key(newCachePod("pod", 500), true)
+	}
+	assert.Assert(t, len(cache.hashes) < maxPodHashes, "remembered hashes should have been cleared")
+}
@@ -0,0 +1,135 @@
+/*
+ Licensed to the Apache Software Foundation (ASF) under one
+ or more contributor license agreements.  See the NOTICE file
+ distributed with this work for additional information
+ regarding copyright ownership.  The ASF licenses this file
+ to you under the Apache License, Version 2.0 (the
+ "License"); you may not use this file except in compliance
+ with the License.  You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package predicates
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	schedConfig "k8s.io/kube-scheduler/config/v1"
+	apiConfig "k8s.io/kubernetes/pkg/scheduler/apis/config"
+	"k8s.io/kubernetes/pkg/scheduler/apis/config/validation"
+	"k8s.io/kubernetes/pkg/scheduler/framework/plugins"
+	fwruntime "k8s.io/kubernetes/pkg/scheduler/framework/runtime"
+	"sigs.k8s.io/yaml"
+
+	"github.com/apache/yunikorn-k8shim/pkg/locking"
+)
+
+var outOfTree = &outOfTreeRegistry{
+	registry: make(fwruntime.Registry),
+}
+
+type outOfTreeRegistry struct {
+	registry fwruntime.Registry
+	locking.RWMutex
+}
+
+// RegisterPlugin makes an out-of-tree filter plugin, compiled into the binary, available to the predicate manager.
+// The plugin is only used when it is enabled in the predicates configuration. Plugins must be registered before the
+// scheduler is started, normally from an init function.
+func RegisterPlugin(name string, factory fwruntime.PluginFactory) error {
+	if factory == nil {
+		return fmt.Errorf("plugin %q has no factory", name)
+	}
+	if _, ok := plugins.NewInTreeRegistry()[name]; ok {
+		return fmt.Errorf("plugin %q conflicts with an in-tree plugin", name)
+	}
+	outOfTree.Lock()
+	defer outOfTree.Unlock()
+	return outOfTree.registry.Register(name, factory)
+}
+
+// unregisterPlugin removes an out-of-tree plugin, only used in tests
+func unregisterPlugin(name string) {
+	outOfTree.Lock()
+	defer outOfTree.Unlock()
+	delete(outOfTree.registry, name)
+}
+
+// getOutOfTreePlugin returns the factory of a registered out-of-tree plugin
+func getOutOfTreePlugin(name string) (fwruntime.PluginFactory, bool) {
+	outOfTree.RLock()
+	defer outOfTree.RUnlock()
+	factory, ok := outOfTree.registry[name]
+	return factory, ok
+}
+
+// decodePluginArgs converts the YAML or JSON args of a plugin into the object passed to the plugin factory.
+// In-tree plugins get their typed, defaulted and validated args. Out-of-tree plugins get the raw JSON which they
+// can decode using frameworkruntime.DecodeInto.
+func decodePluginArgs(name, args string, inTree bool) (runtime.Object, error) {
+	raw, err := yaml.YAMLToJSON([]byte(args))
+	if err != nil {
+		return nil, fmt.Errorf("invalid args for plugin %s: %w", name, err)
+	}
+	if !inTree {
+		return &runtime.Unknown{Raw: raw, ContentType: runtime.ContentTypeJSON}, nil
+	}
+	var fields map[string]interface{}
+	if err = json.Unmarshal(raw, &fields); err != nil {
+		return nil, fmt.Errorf("invalid args for plugin %s: %w", name, err)
+	}
+	if fields == nil {
+		fields = make(map[string]interface{})
+	}
+	gvk := schedConfig.SchemeGroupVersion.WithKind(name + "Args")
+	fields["apiVersion"] = gvk.GroupVersion().String()
+	fields["kind"] = gvk.Kind
+	if raw, err = json.Marshal(fields); err != nil {
+		return nil, fmt.Errorf("invalid args for plugin %s: %w", name, err)
+	}
+	obj, _, err := configDecoder.Decode(raw, &gvk, nil)
+	if runtime.IsNotRegisteredError(err) {
+		return nil, fmt.Errorf("plugin %s does not take args", name)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("invalid args for plugin %s: %w", name, err)
+	}
+	if err = validatePluginArgs(name, obj); err != nil {
+		return nil, err
+	}
+	return obj, nil
+}
+
+// validatePluginArgs runs the upstream validation for the in-tree plugin args
+func validatePluginArgs(name string, obj runtime.Object) error {
+	path := field.NewPath("pluginArgs", name)
+	var err error
+	switch args := obj.(type) {
+	case *apiConfig.NodeResourcesFitArgs:
+		err = validation.ValidateNodeResourcesFitArgs(path, args)
+	case *apiConfig.PodTopologySpreadArgs:
+		err = validation.ValidatePodTopologySpreadArgs(path, args)
+	case *apiConfig.InterPodAffinityArgs:
+		err = validation.ValidateInterPodAffinityArgs(path, args)
+	case *apiConfig.NodeAffinityArgs:
+		err = validation.ValidateNodeAffinityArgs(path, args)
+	case *apiConfig.VolumeBindingArgs:
+		err = validation.ValidateVolumeBindingArgs(path, args)
+	case *apiConfig.NodeResourcesBalancedAllocationArgs:
+		err = validation.ValidateNodeResourcesBalancedAllocationArgs(path, args)
+	}
+	if err != nil {
+		return fmt.Errorf("invalid args for plugin %s: %w", name, err)
+	}
+	return nil
+}
@@ -0,0 +1,246 @@
+/*
+ Licensed to the Apache Software Foundation (ASF) under one
+ or more contributor license agreements.  See the NOTICE file
+ distributed with this work for additional information
+ regarding copyright ownership.  The ASF licenses this file
+ to you under the Apache License, Version 2.0 (the
+ "License"); you may not use this file except in compliance
+ with the License.  You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package predicates
+
+import (
+	"context"
+	"testing"
+
+	"gotest.tools/v3/assert"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/kubernetes/pkg/scheduler/framework"
+	"k8s.io/kubernetes/pkg/scheduler/framework/plugins/names"
+	fwruntime "k8s.io/kubernetes/pkg/scheduler/framework/runtime"
+
+	"github.com/apache/yunikorn-k8shim/pkg/conf"
+	"github.com/apache/yunikorn-k8shim/pkg/plugin/support"
+)
+
+const rejectingPluginName = "RejectingPlugin"
+
+// rejectingPlugin is an out-of-tree filter plugin that rejects nodes with the label given in its args
+type rejectingPlugin struct {
+	label string
+}
+
+type rejectingPluginArgs struct {
+	Label string `json:"label"`
+}
+
+func (p *rejectingPlugin) Name() string {
+	return rejectingPluginName
+}
+
+func (p *rejectingPlugin) Filter(_ context.Context, _ *framework.CycleState, _ *v1.Pod, nodeInfo *framework.NodeInfo) *framework.Status {
+	if _, ok := nodeInfo.Node().Labels[p.label]; ok {
+		return framework.NewStatus(framework.Unschedulable, "node rejected")
+	}
+	return nil
+}
+
+func newRejectingPlugin(_ context.Context, obj runtime.Object, _ framework.Handle) (framework.Plugin, error) {
+	args := rejectingPluginArgs{Label: "reject"}
+	if obj != nil {
+		if err := fwruntime.DecodeInto(obj, &args); err != nil {
+			return nil, err
+		}
+	}
+	return &rejectingPlugin{label: args.Label}, nil
+}
+
+func defaultPredicatesConf() conf.PredicatesConf {
+	return conf.CreateDefaultConfig().GetPredicatesConf()
+}
+
+func pluginNames(pm PredicateManager) (reservation []string, allocation []string) {
+	impl, ok := pm.(*predicateManagerImpl)
+	if !ok {
+		return nil, nil
+	}
+	for _, plugin := range *impl.reservationFilters {
+		reservation = append(reservation, plugin.Name())
+	}
+	for _, plugin := range *impl.allocationFilters {
+		allocation = append(allocation, plugin.Name())
+	}
+	return reservation, allocation
+}
+
+func TestNewPredicateManagerFromConfig(t *testing.T) {
+	clientSet := clientSet()
+	handle := support.NewFrameworkHandle(lister(), informerFactory(clientSet), clientSet)
+
+	pm, err := NewPredicateManagerFromConfig(handle, defaultPredicatesConf())
+	assert.NilError(t, err)
+	reservation, allocation := pluginNames(pm)
+	assert.DeepEqual(t, reservation, []string{names.NodeUnschedulable, names.NodeName, names.TaintToleration, names.NodeAffinity,
+		names.NodePorts, names.PodTopologySpread, names.InterPodAffinity})
+	assert.Assert(t, len(allocation) > len(reservation))
+	assert.Equal(t, len(*pm.(*predicateManagerImpl).reservationPreFilters), 4)
+
+	pc := defaultPredicatesConf()
+	pc.Reservation = "NodeName"
+	pc.Allocation = "*,-NodeResourcesFit,-VolumeZone"
+	pm, err = NewPredicateManagerFromConfig(handle, pc)
+	assert.NilError(t, err)
+	reservation, allocation = pluginNames(pm)
+	assert.DeepEqual(t, reservation, []string{names.NodeName})
+	for _, name := range allocation {
+		assert.Assert(t, name != names.NodeResourcesFit && name != names.VolumeZone, "disabled plugin %s found", name)
+	}
+	assert.Equal(t, len(*pm.(*predicateManagerImpl).reservationPreFilters), 0)
+}
+
+func TestNewPredicateManagerFromConfigErrors(t *testing.T) {
+	clientSet := clientSet()
+	handle := support.NewFrameworkHandle(lister(), informerFactory(clientSet), clientSet)
+
+	testCases := []struct {
+		name   string
+		update func(pc *conf.PredicatesConf)
+		err    string
+	}{
+		{"invalid list", func(pc *conf.PredicatesConf) { pc.Reservation = "-" }, "reservation predicates: invalid plugin"},
+		{"unknown plugin", func(pc *conf.PredicatesConf) { pc.Allocation = "*,-Unknown" }, "unknown predicate plugin Unknown"},
+		{"removed plugin", func(pc *conf.PredicatesConf) { pc.Reservation = names.DefaultPreemption }, "unknown predicate plugin DefaultPreemption"},
+		{"unregistered out-of-tree", func(pc *conf.PredicatesConf) { pc.Plugins = "Unknown" }, "out-of-tree plugin Unknown is not registered"},
+		{"args unknown plugin", func(pc *conf.PredicatesConf) { pc.PluginArgs = map[string]string{"Unknown": "{}"} }, "args provided for unknown predicate plugin Unknown"},
+		{"args not supported", func(pc *conf.PredicatesConf) { pc.PluginArgs = map[string]string{names.NodeName: "{}"} }, "plugin NodeName does not take args"},
+		{"args invalid yaml", func(pc *conf.PredicatesConf) {
+			pc.PluginArgs = map[string]string{names.NodeResourcesFit: "ignoredResources: ["}
+		}, "invalid args for plugin NodeResourcesFit"},
+		{"args invalid field", func(pc *conf.PredicatesConf) {
+			pc.PluginArgs = map[string]string{names.NodeResourcesFit: "ignoredResources: true"}
+		}, "invalid args for plugin NodeResourcesFit"},
+		{"args failed validation", func(pc *conf.PredicatesConf) {
+			pc.PluginArgs = map[string]string{names.NodeResourcesFit: "ignoredResources: [\"invalid name!\"]"}
+		}, "pluginArgs.NodeResourcesFit.ignoredResources"},
+		{"topology args failed validation", func(pc *conf.PredicatesConf) {
+			pc.PluginArgs = map[string]string{names.PodTopologySpread: "defaultConstraints: [{maxSkew: 0, topologyKey: zone, whenUnsatisfiable: DoNotSchedule}]"}
+		}, "pluginArgs.PodTopologySpread.defaultConstraints[0].maxSkew"},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			pc := defaultPredicatesConf()
+			tc.update(&pc)
+			pm, err := NewPredicateManagerFromConfig(handle, pc)
+			assert.ErrorContains(t, err, tc.err)
+			assert.Assert(t, pm == nil, "predicate manager should not be created")
+			assert.ErrorContains(t, ValidatePredicatesConf(pc), tc.err)
+		})
+	}
+	assert.NilError(t, ValidatePredicatesConf(defaultPredicatesConf()))
+}
+
+func TestUpdateConfigMapsPredicates(t *testing.T) {
+	t.Cleanup(func() {
+		err := conf.UpdateConfigMaps([]*v1.ConfigMap{nil, nil}, true)
+		assert.NilError(t, err, "failed to reset configmap")
+	})
+	err := conf.UpdateConfigMaps([]*v1.ConfigMap{nil, {Data: map[string]string{conf.CMSvcPredicatesPluginArgsPrefix + names.NodeName: "{}"}}}, false)
+	assert.ErrorContains(t, err, "failed to load configmap")
+	err = conf.UpdateConfigMaps([]*v1.ConfigMap{nil, {Data: map[string]string{conf.CMSvcPredicatesAllocation: "*,-Unknown"}}}, false)
+	assert.ErrorContains(t, err, "failed to load configmap")
+	assert.Equal(t, conf.GetSchedulerConf().GetPredicatesConf().Allocation, conf.DefaultPredicatesAllocation)
+	err = conf.UpdateConfigMaps([]*v1.ConfigMap{nil, {Data: map[string]string{conf.CMSvcPredicatesAllocation: "*,-NodePorts"}}}, false)
+	assert.NilError(t, err)
+	assert.Equal(t, conf.GetSchedulerConf().GetPredicatesConf().Allocation, "*,-NodePorts")
+}
+
+func TestPredicatesPluginArgs(t *testing.T) {
+	clientSet := clientSet()
+	handle := support.NewFrameworkHandle(lister(), informerFactory(clientSet), clientSet)
+
+	pod := newResourcePod(framework.Resource{MilliCPU: 100, ScalarResources: map[v1.ResourceName]int64{extendedResourceA: 1}})
+	node := framework.NewNodeInfo()
+	node.SetNode(&v1.Node{
+		ObjectMeta: metav1.ObjectMeta{Name: "node0"},
+		Status: v1.NodeStatus{
+			Allocatable: v1.ResourceList{
+				v1.ResourceCPU:  resource.MustParse("1"),
+				v1.ResourcePods: resource.MustParse("10"),
+			},
+		},
+	})
+
+	pm, err := NewPredicateManagerFromConfig(handle, defaultPredicatesConf())
+	assert.NilError(t, err)
+	plugin, err := pm.Predicates(pod, node, true)
+	assert.ErrorContains(t, err, "Insufficient "+string(extendedResourceA))
+	assert.Equal(t, plugin, names.NodeResourcesFit)
+
+	pc := defaultPredicatesConf()
+	pc.PluginArgs = map[string]string{names.NodeResourcesFit: "ignoredResources: [" + string(extendedResourceA) + "]"}
+	pm, err = NewPredicateManagerFromConfig(handle, pc)
+	assert.NilError(t, err)
+	_, err = pm.Predicates(pod, node, true)
+	assert.NilError(t, err, "ignored resource should not be checked")
+}
+
+func TestRegisterPlugin(t *testing.T) {
+	assert.NilError(t, RegisterPlugin(rejectingPluginName, newRejectingPlugin))
+	t.Cleanup(func() {
+		unregisterPlugin(rejectingPluginName)
+	})
+	assert.ErrorContains(t, RegisterPlugin(rejectingPluginName, newRejectingPlugin), "already exists")
+	assert.ErrorContains(t, RegisterPlugin(names.NodeName, newRejectingPlugin), "conflicts with an in-tree plugin")
+	assert.ErrorContains(t, RegisterPlugin("NoFactory", nil), "has no factory")
+
+	clientSet := clientSet()
+	handle := support.NewFrameworkHandle(lister(), informerFactory(clientSet), clientSet)
+	pod := &v1.Pod{}
+	node := framework.NewNodeInfo()
+	node.SetNode(&v1.Node{
+		ObjectMeta: metav1.ObjectMeta{Name: "node0", Labels: map[string]string{"reject": ""}},
+		Status:     v1.NodeStatus{Allocatable: v1.ResourceList{v1.ResourcePods: resource.MustParse("10")}},
+	})
+
+	// registered but not enabled
+	pm, err := NewPredicateManagerFromConfig(handle, defaultPredicatesConf())
+	assert.NilError(t, err)
+	_, err = pm.Predicates(pod, node, true)
+	assert.NilError(t, err)
+
+	// enabled: only part of the reservation phase when listed
+	pc := defaultPredicatesConf()
+	pc.Plugins = rejectingPluginName
+	pm, err = NewPredicateManagerFromConfig(handle, pc)
+	assert.NilError(t, err)
+	_, err = pm.Predicates(pod, node, false)
+	assert.NilError(t, err)
+	plugin, err := pm.Predicates(pod, node, true)
+	assert.ErrorContains(t, err, "node rejected")
+	assert.Equal(t, plugin, rejectingPluginName)
+
+	pc.Reservation = rejectingPluginName
+	pm, err = NewPredicateManagerFromConfig(handle, pc)
+	assert.NilError(t, err)
+	_, err = pm.Predicates(pod, node, false)
+	assert.ErrorContains(t, err, "node rejected")
+
+	// args are passed to the out-of-tree plugin
+	pc.PluginArgs = map[string]string{rejectingPluginName: "label: other"}
+	pm, err = NewPredicateManagerFromConfig(handle, pc)
+	assert.NilError(t, err)
+	_, err = pm.Predicates(pod, node, true)
+	assert.NilError(t, err)
+}
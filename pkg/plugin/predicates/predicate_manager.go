@@ -40,6 +40,7 @@ import (
 	fwruntime "k8s.io/kubernetes/pkg/scheduler/framework/runtime"
 	"k8s.io/kubernetes/pkg/scheduler/metrics"
 
+	"github.com/apache/yunikorn-k8shim/pkg/conf"
 	"github.com/apache/yunikorn-k8shim/pkg/log"
 )
 
@@ -53,11 +54,17 @@ var _ PredicateManager = &predicateManagerImpl{}
 
 var configDecoder = scheme.Codecs.UniversalDecoder()
 
+func init() {
+	// reject a configuration update with unknown plugins or invalid plugin args as a whole
+	conf.SetPredicatesValidator(ValidatePredicatesConf)
+}
+
 type predicateManagerImpl struct {
 	reservationPreFilters *[]framework.PreFilterPlugin
 	allocationPreFilters  *[]framework.PreFilterPlugin
 	reservationFilters    *[]framework.FilterPlugin
 	allocationFilters     *[]framework.FilterPlugin
+	equivalence           *equivalenceCache // cached failures for identical pods, nil if disabled
 	klogger               klog.Logger
 }
 
@@ -126,12 +133,38 @@ func buildClusterEvents(actionMap map[framework.EventResource]framework.ActionTy
 }
 
 func (p *predicateManagerImpl) Predicates(pod *v1.Pod, node *framework.NodeInfo, allocate bool) (plugin string, error error) {
+	key, cacheable := p.equivalence.key(pod, allocate)
+	if !cacheable {
+		status, plugin := p.runPredicates(pod, node, allocate)
+		return plugin, statusError(status)
+	}
+	result, topology, ok := p.equivalence.get(key, node)
+	if ok {
+		return result.plugin, errors.New(result.message)
+	}
+	nodeGeneration := node.Generation
+	status, plugin := p.runPredicates(pod, node, allocate)
+	// only cache rejections: errors are not caused by the pod or node and might not occur again
+	if !status.IsSuccess() && status.IsRejected() {
+		p.equivalence.add(key, node.Node().Name, nodeGeneration, topology, equivalenceResult{plugin: plugin, message: status.Message()})
+	}
+	return plugin, statusError(status)
+}
+
+func (p *predicateManagerImpl) runPredicates(pod *v1.Pod, node *framework.NodeInfo, allocate bool) (*framework.Status, string) {
 	if allocate {
 		return p.predicatesAllocate(pod, node)
 	}
 	return p.predicatesReserve(pod, node)
 }
 
+func statusError(status *framework.Status) error {
+	if status.IsSuccess() {
+		return nil
+	}
+	return errors.New(status.Message())
+}
+
 func (p *predicateManagerImpl) PreemptionPredicates(pod *v1.Pod, node *framework.NodeInfo, victims []*v1.Pod, startIndex int) int {
 	ctx := context.Background()
 	state := framework.NewCycleState()
@@ -185,31 +218,31 @@ func (p *predicateManagerImpl) removePodFromNodeNoFail(node *framework.NodeInfo,
 	}
 }
 
-func (p *predicateManagerImpl) predicatesReserve(pod *v1.Pod, node *framework.NodeInfo) (string, error) {
+func (p *predicateManagerImpl) predicatesReserve(pod *v1.Pod, node *framework.NodeInfo) (*framework.Status, string) {
 	ctx := context.Background()
 	state := framework.NewCycleState()
 	return p.podFitsNode(ctx, state, *p.reservationPreFilters, *p.reservationFilters, pod, node)
 }
 
-func (p *predicateManagerImpl) predicatesAllocate(pod *v1.Pod, node *framework.NodeInfo) (string, error) {
+func (p *predicateManagerImpl) predicatesAllocate(pod *v1.Pod, node *framework.NodeInfo) (*framework.Status, string) {
 	ctx := context.Background()
 	state := framework.NewCycleState()
 	return p.podFitsNode(ctx, state, *p.allocationPreFilters, *p.allocationFilters, pod, node)
 }
 
-func (p *predicateManagerImpl) podFitsNode(ctx context.Context, state *framework.CycleState, preFilters []framework.PreFilterPlugin, filters []framework.FilterPlugin, pod *v1.Pod, node *framework.NodeInfo) (string, error) {
+func (p *predicateManagerImpl) podFitsNode(ctx context.Context, state *framework.CycleState, preFilters []framework.PreFilterPlugin, filters []framework.FilterPlugin, pod *v1.Pod, node *framework.NodeInfo) (*framework.Status, string) {
 	// Run "prefilter" plugins.
 	status, plugin, skip := p.runPreFilterPlugins(ctx, state, preFilters, pod, node)
 	if !status.IsSuccess() && !status.IsSkip() {
-		return plugin, errors.New(status.Message())
+		return status, plugin
 	}
 
 	// Run "filter" plugins on node
 	status, plugin = p.runFilterPlugins(ctx, filters, state, pod, node, skip)
 	if !status.IsSuccess() {
-		return plugin, errors.New(status.Message())
+		return status, plugin
 	}
-	return "", nil
+	return nil, ""
 }
 
 func (p *predicateManagerImpl) runPreFilterPlugins(ctx context.Context, state *framework.CycleState, plugins []framework.PreFilterPlugin, pod *v1.Pod, node *framework.NodeInfo) (*framework.Status, string, map[string]bool) {
@@ -295,76 +328,62 @@ func EnableOptionalKubernetesFeatureGates() {
 	}
 }
 
+// NewPredicateManager creates the predicate manager from the current scheduler configuration. An invalid predicate
+// configuration is fatal at startup.
 func NewPredicateManager(handle framework.Handle) PredicateManager {
-	/*
-		Default K8S plugins as of 1.32 that implement PreFilter:
-			NodeAffinity
-			NodePorts
-			NodeResourcesFit
-			VolumeRestrictions
-			NodeVolumeLimits
-			VolumeBinding
-			VolumeZone
-			PodTopologySpread
-			InterPodAffinity
-	*/
-
-	// run only the simpler PreFilter plugins during reservation phase
-	reservationPreFilters := map[string]bool{
-		names.NodeAffinity:      true,
-		names.NodePorts:         true,
-		names.PodTopologySpread: true,
-		names.InterPodAffinity:  true,
-		// NodeResourcesFit : skip because during reservation, node resources are not enough
-		// NodeVolumeLimits
-		// VolumeRestrictions
-		// VolumeBinding
-		// VolumeZone
-	}
-
-	// run all PreFilter plugins during allocation phase
-	allocationPreFilters := map[string]bool{
-		"*": true,
-	}
-
-	/*
-		Default K8S plugins as of 1.32 that implement Filter:
-		    NodeUnschedulable
-			NodeName
-			TaintToleration
-			NodeAffinity
-			NodePorts
-			NodeResourcesFit
-			VolumeRestrictions
-			NodeVolumeLimits
-			VolumeBinding
-			VolumeZone
-			PodTopologySpread
-			InterPodAffinity
-	*/
-
-	// run only the simpler Filter plugins during reservation phase
-	reservationFilters := map[string]bool{
-		names.NodeUnschedulable: true,
-		names.NodeName:          true,
-		names.TaintToleration:   true,
-		names.NodeAffinity:      true,
-		names.NodePorts:         true,
-		names.PodTopologySpread: true,
-		names.InterPodAffinity:  true,
-		// NodeResourcesFit : skip because during reservation, node resources are not enough
-		// VolumeRestrictions
-		// NodeVolumeLimits
-		// VolumeBinding
-		// VolumeZone
-	}
-
-	// run all Filter plugins during allocation phase
-	allocationFilters := map[string]bool{
-		"*": true,
-	}
-
-	return newPredicateManagerInternal(handle, reservationPreFilters, allocationPreFilters, reservationFilters, allocationFilters)
+	pm, err := NewPredicateManagerFromConfig(handle, conf.GetSchedulerConf().GetPredicatesConf())
+	if err != nil {
+		log.Log(log.ShimPredicates).Fatal("Unable to create predicate manager", zap.Error(err))
+	}
+	return pm
+}
+
+// NewPredicateManagerFromConfig creates the predicate manager for the given predicate configuration. The same plugin
+// list is used for the PreFilter and Filter plugins of a phase: plugins that do not implement the extension point are
+// ignored.
+func NewPredicateManagerFromConfig(handle framework.Handle, pc conf.PredicatesConf) (PredicateManager, error) {
+	outOfTreePlugins, reservation, allocation, err := parsePredicatesConf(pc)
+	if err != nil {
+		return nil, err
+	}
+	pm, err := buildPredicateManager(handle, outOfTreePlugins, pc.PluginArgs, pc.CacheSize, reservation, allocation, reservation, allocation)
+	if err != nil {
+		return nil, err
+	}
+	return pm, nil
+}
+
+// ValidatePredicatesConf checks the plugin names and the plugin args of the predicate configuration without creating
+// the plugins. It is used to reject a configuration update before it is applied.
+func ValidatePredicatesConf(pc conf.PredicatesConf) error {
+	outOfTreePlugins, reservation, allocation, err := parsePredicatesConf(pc)
+	if err != nil {
+		return err
+	}
+	_, _, _, err = preparePlugins(outOfTreePlugins, pc.PluginArgs, reservation, allocation)
+	return err
+}
+
+// parsePredicatesConf returns the sorted out-of-tree plugin names and the reservation and allocation plugin lists
+func parsePredicatesConf(pc conf.PredicatesConf) ([]string, map[string]bool, map[string]bool, error) {
+	reservation, err := conf.ParsePredicatePluginList(pc.Reservation)
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("reservation predicates: %w", err)
+	}
+	allocation, err := conf.ParsePredicatePluginList(pc.Allocation)
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("allocation predicates: %w", err)
+	}
+	extra, err := conf.ParsePredicatePluginList(pc.Plugins)
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("out-of-tree predicates: %w", err)
+	}
+	outOfTreePlugins := make([]string, 0, len(extra))
+	for name := range extra {
+		outOfTreePlugins = append(outOfTreePlugins, name)
+	}
+	sort.Strings(outOfTreePlugins)
+	return outOfTreePlugins, reservation, allocation, nil
 }
 
 func newPredicateManagerInternal(
@@ -373,25 +392,39 @@ func newPredicateManagerInternal(
 	allocationPreFilters map[string]bool,
 	reservationFilters map[string]bool,
 	allocationFilters map[string]bool) *predicateManagerImpl {
+	pm, err := buildPredicateManager(handle, nil, nil, 0, reservationPreFilters, allocationPreFilters, reservationFilters, allocationFilters)
+	if err != nil {
+		log.Log(log.ShimPredicates).Fatal("Unable to create predicate manager", zap.Error(err))
+	}
+	return pm
+}
+
+func buildPredicateManager(
+	handle framework.Handle,
+	outOfTreePlugins []string,
+	pluginArgs map[string]string,
+	cacheSize int,
+	reservationPreFilters map[string]bool,
+	allocationPreFilters map[string]bool,
+	reservationFilters map[string]bool,
+	allocationFilters map[string]bool) (*predicateManagerImpl, error) {
 	// ensure K8s scheduler metrics have been initialized in YK standalone mode to avoid SIGSEGV
 	if metrics.Goroutines == nil {
 		metrics.InitMetrics()
 	}
 
-	pluginRegistry := plugins.NewInTreeRegistry()
-
-	cfg, err := defaultConfig() // latest.Default()
+	pluginRegistry, registeredPlugins, pluginConfig, err := preparePlugins(outOfTreePlugins, pluginArgs,
+		reservationPreFilters, allocationPreFilters, reservationFilters, allocationFilters)
 	if err != nil {
-		log.Log(log.ShimPredicates).Fatal("Unable to get default predicate config", zap.Error(err))
+		return nil, err
 	}
 
-	profile := cfg.Profiles[0] // first profile is default
-	registeredPlugins := profile.Plugins
-	createdPlugins := make([]framework.Plugin, 0)
-
 	// As of SchedulerConfiguration v1, all plugins implement MultiPoint, therefore we need to instantiate each one and
 	// check to see what interfaces it implements dynamically
-	createPlugins(handle, pluginRegistry, &registeredPlugins.MultiPoint, &createdPlugins)
+	createdPlugins := make([]framework.Plugin, 0)
+	if err = createPlugins(handle, pluginRegistry, &registeredPlugins.MultiPoint, pluginConfig, &createdPlugins); err != nil {
+		return nil, err
+	}
 
 	resPre := make([]framework.Plugin, 0)
 	allocPre := make([]framework.Plugin, 0)
@@ -410,8 +443,74 @@ func newPredicateManagerInternal(
 		allocationFilters:     filterPlugins(allocFilt),
 		klogger:               klog.NewKlogr(),
 	}
+	// out-of-tree plugins and default hard topology spread constraints can depend on state outside the node
+	if len(outOfTreePlugins) == 0 && !hasHardDefaultConstraints(pluginConfig[names.PodTopologySpread]) {
+		pm.equivalence = newEquivalenceCache(handle, cacheSize)
+	}
 
-	return pm
+	return pm, nil
+}
+
+// preparePlugins returns the plugin registry, the plugins of the default profile extended with the out-of-tree
+// plugins and the decoded plugin args. It fails if a filter lists an unknown plugin or if the args are invalid.
+func preparePlugins(outOfTreePlugins []string, pluginArgs map[string]string, filters ...map[string]bool) (fwruntime.Registry, *apiConfig.Plugins, map[string]runtime.Object, error) {
+	pluginRegistry := plugins.NewInTreeRegistry()
+
+	cfg, err := defaultConfig() // latest.Default()
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("unable to get default predicate config: %w", err)
+	}
+
+	profile := cfg.Profiles[0] // first profile is default
+	registeredPlugins := profile.Plugins
+	for _, name := range outOfTreePlugins {
+		factory, ok := getOutOfTreePlugin(name)
+		if !ok {
+			return nil, nil, nil, fmt.Errorf("out-of-tree plugin %s is not registered", name)
+		}
+		if err = pluginRegistry.Register(name, factory); err != nil {
+			return nil, nil, nil, err
+		}
+		registeredPlugins.MultiPoint.Enabled = append(registeredPlugins.MultiPoint.Enabled, apiConfig.Plugin{Name: name})
+	}
+
+	enabled := make(map[string]bool)
+	for _, p := range registeredPlugins.MultiPoint.Enabled {
+		enabled[p.Name] = true
+	}
+	for _, filter := range filters {
+		for name := range filter {
+			if name != "*" && !enabled[name] {
+				return nil, nil, nil, fmt.Errorf("unknown predicate plugin %s", name)
+			}
+		}
+	}
+
+	pluginConfig := make(map[string]runtime.Object)
+	for name, args := range pluginArgs {
+		if !enabled[name] {
+			return nil, nil, nil, fmt.Errorf("args provided for unknown predicate plugin %s", name)
+		}
+		_, inTree := plugins.NewInTreeRegistry()[name]
+		if pluginConfig[name], err = decodePluginArgs(name, args, inTree); err != nil {
+			return nil, nil, nil, err
+		}
+	}
+	return pluginRegistry, registeredPlugins, pluginConfig, nil
+}
+
+// hasHardDefaultConstraints returns true if the topology spread args add constraints that must be satisfied to pods
+func hasHardDefaultConstraints(obj runtime.Object) bool {
+	args, ok := obj.(*apiConfig.PodTopologySpreadArgs)
+	if !ok {
+		return false
+	}
+	for _, constraint := range args.DefaultConstraints {
+		if constraint.WhenUnsatisfiable == v1.DoNotSchedule {
+			return true
+		}
+	}
+	return false
 }
 
 func preFilterPlugins(plugins []framework.Plugin) *[]framework.PreFilterPlugin {
@@ -491,26 +590,23 @@ func addPlugins(phase string, createdPlugins []framework.Plugin, pluginList *[]f
 	}
 }
 
-func createPlugins(handle framework.Handle, registry fwruntime.Registry, plugins *apiConfig.PluginSet, createdPlugins *[]framework.Plugin) {
-	pluginConfig := make(map[string]runtime.Object)
-
+func createPlugins(handle framework.Handle, registry fwruntime.Registry, plugins *apiConfig.PluginSet, pluginConfig map[string]runtime.Object, createdPlugins *[]framework.Plugin) error {
 	for _, p := range plugins.Enabled {
 		cfg, err := getPluginArgsOrDefault(pluginConfig, p.Name)
 		if err != nil {
-			log.Log(log.ShimPredicates).Error("failed to create plugin config", zap.String("pluginName", p.Name), zap.Error(err))
-			continue
+			return fmt.Errorf("failed to create config for plugin %s: %w", p.Name, err)
 		}
 		log.Log(log.ShimPredicates).Debug("plugin config created", zap.String("pluginName", p.Name), zap.Any("cfg", cfg))
 
 		factory := registry[p.Name]
 		plugin, err := factory(context.Background(), cfg, handle)
 		if err != nil {
-			log.Log(log.ShimPredicates).Error("failed to create plugin", zap.String("pluginName", p.Name), zap.Error(err))
-			continue
+			return fmt.Errorf("failed to create plugin %s: %w", p.Name, err)
 		}
 		log.Log(log.ShimPredicates).Debug("plugin created", zap.String("pluginName", p.Name))
 		*createdPlugins = append(*createdPlugins, plugin)
 	}
+	return nil
 }
 
 // getPluginArgsOrDefault returns a configuration provided by the user or builds
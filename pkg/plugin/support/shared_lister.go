@@ -27,6 +27,7 @@ import (
 type sharedListerImpl struct {
 	nodeInfos    framework.NodeInfoLister
 	storageInfos framework.StorageInfoLister
+	cache        *external.SchedulerCache
 }
 
 func (s sharedListerImpl) NodeInfos() framework.NodeInfoLister {
@@ -37,11 +38,17 @@ func (s sharedListerImpl) StorageInfos() framework.StorageInfoLister {
 	return s.storageInfos
 }
 
+// TopologyGeneration returns the topology generation of the scheduler cache
+func (s sharedListerImpl) TopologyGeneration() int64 {
+	return s.cache.TopologyGeneration()
+}
+
 var _ framework.SharedLister = &sharedListerImpl{}
 
 func NewSharedLister(cache *external.SchedulerCache) framework.SharedLister {
 	return &sharedListerImpl{
 		nodeInfos:    NewNodeInfoLister(cache),
 		storageInfos: NewStorageInfoLister(cache),
+		cache:        cache,
 	}
 }
@@ -0,0 +1,133 @@
+/*
+ Licensed to the Apache Software Foundation (ASF) under one
+ or more contributor license agreements.  See the NOTICE file
+ distributed with this work for additional information
+ regarding copyright ownership.  The ASF licenses this file
+ to you under the Apache License, Version 2.0 (the
+ "License"); you may not use this file except in compliance
+ with the License.  You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package shim
+
+import (
+	"strconv"
+	"testing"
+
+	"gotest.tools/v3/assert"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+
+	"github.com/apache/yunikorn-k8shim/pkg/cache"
+	"github.com/apache/yunikorn-k8shim/pkg/client"
+	"github.com/apache/yunikorn-k8shim/pkg/common/constants"
+	"github.com/apache/yunikorn-k8shim/pkg/conf"
+	"github.com/apache/yunikorn-k8shim/pkg/log"
+)
+
+var (
+	predicateNumNodes = 1000
+	predicateNumPods  = 100
+)
+
+// Measures the predicate checks of a batch of identical pods against full nodes, with and without caching of the
+// predicate results. All checks fail, which is the case the predicate cache is meant to speed up.
+func BenchmarkPredicatesIdenticalPods(b *testing.B) {
+	log.UpdateLoggingConfig(map[string]string{
+		"log.level": "WARN",
+	})
+	b.Cleanup(func() {
+		err := conf.UpdateConfigMaps([]*v1.ConfigMap{nil, nil}, true)
+		assert.NilError(b, err, "config reset failed")
+	})
+
+	for _, cacheSize := range []int{0, conf.DefaultPredicatesCacheSize} {
+		b.Run("cacheSize="+strconv.Itoa(cacheSize), func(b *testing.B) {
+			err := conf.UpdateConfigMaps([]*v1.ConfigMap{nil, {Data: map[string]string{
+				conf.CMSvcPredicatesCacheSize: strconv.Itoa(cacheSize),
+			}}}, true)
+			assert.NilError(b, err, "config update failed")
+			ctx := cache.NewContext(client.NewMockedAPIProvider(false))
+			schedulerCache := ctx.GetSchedulerCache()
+
+			nodeNames := make([]string, predicateNumNodes)
+			for i := range nodeNames {
+				nodeNames[i] = "test.host." + strconv.Itoa(i)
+				schedulerCache.UpdateNode(newPredicatePerfNode(nodeNames[i]))
+				// fill the node so that none of the pods fit
+				running := newPredicatePerfPod("running-"+strconv.Itoa(i), "4")
+				running.Spec.NodeName = nodeNames[i]
+				running.Status.Phase = v1.PodRunning
+				schedulerCache.UpdatePod(running)
+			}
+			podUIDs := make([]string, predicateNumPods)
+			for i := range podUIDs {
+				pod := newPredicatePerfPod("batch-"+strconv.Itoa(i), "1")
+				schedulerCache.UpdatePod(pod)
+				podUIDs[i] = string(pod.UID)
+			}
+
+			b.ReportAllocs()
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				podUID := podUIDs[i%predicateNumPods]
+				nodeName := nodeNames[(i/predicateNumPods)%predicateNumNodes]
+				if err := ctx.IsPodFitNode(podUID, nodeName, true); err == nil {
+					b.Fatalf("pod %s should not fit on node %s", podUID, nodeName)
+				}
+			}
+		})
+	}
+}
+
+func newPredicatePerfNode(name string) *v1.Node {
+	return &v1.Node{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:   name,
+			UID:    types.UID("uid-" + name),
+			Labels: map[string]string{"kubernetes.io/hostname": name},
+		},
+		Status: v1.NodeStatus{
+			Allocatable: v1.ResourceList{
+				v1.ResourceCPU:    resource.MustParse("4"),
+				v1.ResourceMemory: resource.MustParse("16Gi"),
+				v1.ResourcePods:   resource.MustParse("110"),
+			},
+		},
+	}
+}
+
+func newPredicatePerfPod(name, cpu string) *v1.Pod {
+	return &v1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: "default",
+			UID:       types.UID("uid-" + name),
+			Labels:    map[string]string{"app": "batch"},
+		},
+		Spec: v1.PodSpec{
+			SchedulerName: constants.SchedulerName,
+			Containers: []v1.Container{{
+				Name:  "container",
+				Image: "busybox",
+				Resources: v1.ResourceRequirements{
+					Requests: v1.ResourceList{
+						v1.ResourceCPU:    resource.MustParse(cpu),
+						v1.ResourceMemory: resource.MustParse("1Gi"),
+					},
+				},
+			}},
+		},
+		Status: v1.PodStatus{Phase: v1.PodPending},
+	}
+}
@@ -47,6 +47,7 @@ type KubernetesShim struct {
 	apiFactory           client.APIProvider
 	context              *cache.Context
 	phManager            *cache.PlaceholderManager
+	phGC                 *cache.PlaceholderGC
 	callback             api.ResourceManagerCallback
 	stopChan             chan struct{}
 	lock                 *locking.RWMutex
@@ -101,6 +102,7 @@ func newShimSchedulerInternal(ctx *cache.Context, apiFactory client.APIProvider,
 		apiFactory:           apiFactory,
 		context:              ctx,
 		phManager:            cache.NewPlaceholderManager(apiFactory.GetAPIs()),
+		phGC:                 cache.NewPlaceholderGC(ctx),
 		callback:             cb,
 		stopChan:             make(chan struct{}),
 		lock:                 &locking.RWMutex{},
@@ -217,6 +219,11 @@ func (ss *KubernetesShim) Run() error {
 		return err
 	}
 
+	// run the orphan placeholder collector
+	// this must be started after the state is recovered: placeholders of applications
+	// that are not recovered yet would otherwise be seen as orphans
+	ss.phGC.Start()
+
 	// start scheduling loop
 	ss.doScheduling()
 
@@ -231,6 +238,8 @@ func (ss *KubernetesShim) Stop() {
 		dispatcher.Stop()
 		// stop the placeholder manager
 		ss.phManager.Stop()
+		// stop the orphan placeholder collector
+		ss.phGC.Stop()
 	default:
 		log.Log(log.ShimScheduler).Info("scheduler is already stopped")
 	}